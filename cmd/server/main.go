@@ -40,5 +40,8 @@ func main() {
 		port = fmt.Sprintf(":%v", os.Args[1])
 	}
 
-	api.Serve(port)
+	if err := api.Serve(port); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 }
@@ -11,27 +11,27 @@ const docTemplate = `{
         "title": "{{.Title}}",
         "termsOfService": "http://swagger.io/terms/",
         "contact": {
-            "name": "API Support",
-            "url": "http://github.com/your-org/go-chat",
-            "email": "support@gochat.dev"
+            "name": "Go Chat API Support",
+            "url": "http://github.com/0xJohnnyboy/go-chat",
+            "email": "contact@theolambert.com"
         },
         "license": {
-            "name": "MIT",
-            "url": "https://opensource.org/licenses/MIT"
+            "name": "AGPL 3.0",
+            "url": "https://github.com/0xJohnnyboy/go-chat/blob/main/LICENSE.md"
         },
         "version": "{{.Version}}"
     },
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/audit": {
-            "get": {
+        "/api/admin/messages/{id}/author": {
+            "patch": {
                 "security": [
                     {
                         "CookieAuth": []
                     }
                 ],
-                "description": "Get audit logs with optional filtering (system admin only)",
+                "description": "Re-attribute a message to the \"[scrubbed]\" placeholder author, e.g. for messages sent from a compromised account (system admin only). The action is recorded in the audit log.",
                 "consumes": [
                     "application/json"
                 ],
@@ -39,46 +39,32 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Audit Logs"
+                    "Administration"
                 ],
-                "summary": "Get audit logs with filtering",
+                "summary": "Scrub a message's author",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Filter by channel ID",
-                        "name": "channel_id",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Filter by actor ID",
-                        "name": "actor_id",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Filter by action type",
-                        "name": "action",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Page number (default: 1)",
-                        "name": "page",
-                        "in": "query"
+                        "description": "Message ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     },
                     {
-                        "type": "integer",
-                        "description": "Number of results per page (default: 20, max: 100)",
-                        "name": "limit",
-                        "in": "query"
+                        "description": "Scrub message author request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ScrubMessageAuthorRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Audit logs retrieved successfully",
+                        "description": "Message author scrubbed successfully",
                         "schema": {
-                            "$ref": "#/definitions/internal_api.AuditLogsResponse"
+                            "$ref": "#/definitions/internal_api.CreateMessageResponse"
                         }
                     },
                     "400": {
@@ -99,6 +85,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
                     },
+                    "404": {
+                        "description": "Message not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
@@ -108,29 +100,58 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/channels": {
+        "/api/admin/users": {
             "get": {
                 "security": [
                     {
                         "CookieAuth": []
                     }
                 ],
-                "description": "Get a list of all publicly visible channels",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "List all users by username with their suspension status and owned and joined channel counts (system admin only). Filter with q, matched case-insensitively anywhere in the username.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Channels"
+                    "Administration"
+                ],
+                "summary": "List users",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Username filter",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number (default: 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Users per page (default: 20, max: 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get all visible channels",
                 "responses": {
                     "200": {
-                        "description": "List of visible channels",
+                        "description": "Users retrieved successfully",
                         "schema": {
-                            "$ref": "#/definitions/internal_api.ChannelsResponse"
+                            "$ref": "#/definitions/internal_api.AdminUsersResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Admin access required",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
                     },
                     "500": {
@@ -140,14 +161,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/api/admin/users/{id}/suspend": {
             "post": {
                 "security": [
                     {
                         "CookieAuth": []
                     }
                 ],
-                "description": "Create a new channel with optional password protection",
+                "description": "Suspend an account server-wide (system admin only). A suspended user cannot log in, refresh their session or make any authenticated request, including with API tokens. Pass a duration for a temporary suspension; omit it to suspend until lifted. System administrators cannot be suspended. The action is recorded in the audit log.",
                 "consumes": [
                     "application/json"
                 ],
@@ -155,31 +178,38 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Channels"
+                    "Administration"
                 ],
-                "summary": "Create a new channel",
+                "summary": "Suspend a user",
                 "parameters": [
                     {
-                        "description": "Create channel request",
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Suspend user request",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/internal_api.CreateChannelRequest"
+                            "$ref": "#/definitions/internal_api.SuspendUserRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Channel created successfully",
+                    "200": {
+                        "description": "User suspended successfully",
                         "schema": {
-                            "$ref": "#/definitions/internal_api.ChannelResponse"
+                            "$ref": "#/definitions/internal_api.SuspendUserResponse"
                         }
                     },
                     "400": {
-                        "description": "Bad request",
+                        "description": "Bad request, invalid fields, or invalid duration format",
                         "schema": {
-                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                            "$ref": "#/definitions/internal_api.ValidationErrorResponse"
                         }
                     },
                     "401": {
@@ -187,37 +217,15 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
-                    }
-                }
-            }
-        },
-        "/api/channels/me": {
-            "get": {
-                "security": [
-                    {
-                        "CookieAuth": []
-                    }
-                ],
-                "description": "Get all channels the authenticated user has joined",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Channels"
-                ],
-                "summary": "Get user's channels",
-                "responses": {
-                    "200": {
-                        "description": "List of user's channels",
+                    },
+                    "403": {
+                        "description": "Admin access required or target is a system administrator",
                         "schema": {
-                            "$ref": "#/definitions/internal_api.ChannelsResponse"
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
                     },
-                    "401": {
-                        "description": "User not authenticated",
+                    "404": {
+                        "description": "User not found",
                         "schema": {
                             "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
@@ -229,30 +237,25 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/api/channels/{id}": {
-            "get": {
+            },
+            "delete": {
                 "security": [
                     {
                         "CookieAuth": []
                     }
                 ],
-                "description": "Get detailed information about a specific channel",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Lift a server-wide suspension before it expires (system admin only). The action is recorded in the audit log.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Channels"
+                    "Administration"
                 ],
-                "summary": "Get channel details",
+                "summary": "Lift a user suspension",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Channel ID",
+                        "description": "User ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -260,66 +263,37 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Channel details",
+                        "description": "User suspension lifted",
                         "schema": {
-                            "$ref": "#/definitions/internal_api.ChannelResponse"
+                            "$ref": "#/definitions/internal_api.MessageResponse"
                         }
                     },
                     "400": {
-                        "description": "Channel ID required",
+                        "description": "User is not suspended",
                         "schema": {
                             "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Channel not found",
+                    "401": {
+                        "description": "User not authenticated",
                         "schema": {
                             "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
-                    }
-                }
-            },
-            "delete": {
-                "security": [
-                    {
-                        "CookieAuth": []
-                    }
-                ],
-                "description": "Delete a channel (only channel owner can delete)",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Channels"
-                ],
-                "summary": "Delete a channel",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Channel ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "Channel deleted successfully",
+                    },
+                    "403": {
+                        "description": "Admin access required",
                         "schema": {
-                            "$ref": "#/definitions/internal_api.MessageResponse"
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
                     },
-                    "400": {
-                        "description": "Bad request or not authorized",
+                    "404": {
+                        "description": "User not found",
                         "schema": {
                             "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
                     },
-                    "401": {
-                        "description": "User not authenticated",
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
@@ -327,14 +301,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/channels/{id}/audit": {
+        "/api/audit": {
             "get": {
                 "security": [
                     {
                         "CookieAuth": []
                     }
                 ],
-                "description": "Get audit logs for a specific channel (only channel owners can view)",
+                "description": "Get audit logs with optional filtering (system admin only)",
                 "consumes": [
                     "application/json"
                 ],
@@ -344,14 +318,25 @@ const docTemplate = `{
                 "tags": [
                     "Audit Logs"
                 ],
-                "summary": "Get channel audit logs",
+                "summary": "Get audit logs with filtering",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Channel ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Filter by channel ID",
+                        "name": "channel_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by actor ID",
+                        "name": "actor_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by action type",
+                        "name": "action",
+                        "in": "query"
                     },
                     {
                         "type": "integer",
@@ -386,13 +371,7 @@ const docTemplate = `{
                         }
                     },
                     "403": {
-                        "description": "Only channel owners can view audit logs",
-                        "schema": {
-                            "$ref": "#/definitions/internal_api.ErrorResponse"
-                        }
-                    },
-                    "404": {
-                        "description": "Channel not found",
+                        "description": "Admin access required",
                         "schema": {
                             "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
@@ -406,53 +385,57 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/channels/{id}/ban": {
-            "post": {
+        "/api/auth/session": {
+            "get": {
                 "security": [
                     {
                         "CookieAuth": []
                     }
                 ],
-                "description": "Permanently ban a user from a channel (only channel owner can ban)",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Get the server time along with the expiry of the current access token and refresh token, so clients can correct for clock skew and schedule refreshes. Expiries are null when not applicable (e.g. API token authentication or no refresh token cookie).",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Channel Administration"
+                    "Authentication"
                 ],
-                "summary": "Ban user from channel",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Channel ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Ban user request",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
+                "summary": "Get session info",
+                "responses": {
+                    "200": {
+                        "description": "Session info",
                         "schema": {
-                            "$ref": "#/definitions/internal_api.BanUserRequest"
+                            "$ref": "#/definitions/internal_api.SessionResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
                     }
+                }
+            }
+        },
+        "/api/categories": {
+            "get": {
+                "security": [
+                    {
+                        "CookieAuth": []
+                    }
+                ],
+                "description": "List the global categories and the authenticated user's own, by name",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Channels"
                 ],
+                "summary": "List channel categories",
                 "responses": {
                     "200": {
-                        "description": "User banned successfully",
-                        "schema": {
-                            "$ref": "#/definitions/internal_api.MessageResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad request",
+                        "description": "List of categories",
                         "schema": {
-                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                            "$ref": "#/definitions/internal_api.CategoriesResponse"
                         }
                     },
                     "401": {
@@ -461,23 +444,21 @@ const docTemplate = `{
                             "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
                     },
-                    "403": {
-                        "description": "Only channel owner can ban users",
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
                     }
                 }
-            }
-        },
-        "/api/channels/{id}/ban/{userId}": {
-            "delete": {
+            },
+            "post": {
                 "security": [
                     {
                         "CookieAuth": []
                     }
                 ],
-                "description": "Remove a ban from a user, allowing them to rejoin the channel (only channel owner can unban)",
+                "description": "Create a category to group channels under. Categories belong to their creator unless global is set, which only system administrators can do. Names are unique among the global categories and among each user's own.",
                 "consumes": [
                     "application/json"
                 ],
@@ -485,36 +466,31 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Channel Administration"
+                    "Channels"
                 ],
-                "summary": "Unban user from channel",
+                "summary": "Create a channel category",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Channel ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "User ID to unban",
-                        "name": "userId",
-                        "in": "path",
-                        "required": true
+                        "description": "Create category request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.CreateCategoryRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "User unbanned successfully",
+                    "201": {
+                        "description": "Category created successfully",
                         "schema": {
-                            "$ref": "#/definitions/internal_api.MessageResponse"
+                            "$ref": "#/definitions/internal_api.CategoryResponse"
                         }
                     },
                     "400": {
-                        "description": "Bad request",
+                        "description": "Bad request or invalid fields",
                         "schema": {
-                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                            "$ref": "#/definitions/internal_api.ValidationErrorResponse"
                         }
                     },
                     "401": {
@@ -524,7 +500,19 @@ const docTemplate = `{
                         }
                     },
                     "403": {
-                        "description": "Only channel owner can unban users",
+                        "description": "Only administrators can create global categories",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Category already exists",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
@@ -532,14 +520,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/channels/{id}/bans": {
+        "/api/channels": {
             "get": {
                 "security": [
                     {
                         "CookieAuth": []
                     }
                 ],
-                "description": "Get a list of all active and inactive bans for a channel (only channel owner can view)",
+                "description": "Get a list of all publicly visible channels, alphabetically by default. Owners who opted out are listed as \"hidden\". With group_by=category, channels are ordered by category name, uncategorized last, and also returned as groups. Responses carry an ETag; send it back in If-None-Match to get 304 when the page is unchanged.",
                 "consumes": [
                     "application/json"
                 ],
@@ -547,39 +535,71 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Channel Administration"
+                    "Channels"
                 ],
-                "summary": "Get channel bans",
+                "summary": "Get all visible channels",
                 "parameters": [
                     {
+                        "type": "integer",
+                        "description": "Page number (default: 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of results per page (default: 20, max: 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "name",
+                            "members",
+                            "recent_activity"
+                        ],
                         "type": "string",
-                        "description": "Channel ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Sort by name, member count or latest message (default: name)",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort direction (default: asc for name, desc otherwise)",
+                        "name": "direction",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "category"
+                        ],
+                        "type": "string",
+                        "description": "Group channels by category",
+                        "name": "group_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a previously fetched page",
+                        "name": "If-None-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "List of channel bans",
-                        "schema": {
-                            "$ref": "#/definitions/internal_api.BansResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "Channel ID required",
+                        "description": "List of visible channels",
                         "schema": {
-                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                            "$ref": "#/definitions/internal_api.ChannelsResponse"
                         }
                     },
-                    "401": {
-                        "description": "User not authenticated",
-                        "schema": {
-                            "$ref": "#/definitions/internal_api.ErrorResponse"
-                        }
+                    "304": {
+                        "description": "Unchanged since the given ETag"
                     },
-                    "403": {
-                        "description": "Only channel owner can view bans",
+                    "400": {
+                        "description": "Invalid sort field, direction or grouping",
                         "schema": {
                             "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
@@ -591,16 +611,14 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/api/channels/{id}/demote": {
+            },
             "post": {
                 "security": [
                     {
                         "CookieAuth": []
                     }
                 ],
-                "description": "Demote a user to a lower role in the channel (only channel owners can demote)",
+                "description": "Create a new channel with optional password protection and message retention (logging_days: default 30, 0 disables history, max 365). Passwords must meet the channel password policy. Each user may own at most MAX_OWNED_CHANNELS channels (default 50).",
                 "consumes": [
                     "application/json"
                 ],
@@ -608,38 +626,31 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Channel Administration"
+                    "Channels"
                 ],
-                "summary": "Demote user in channel",
+                "summary": "Create a new channel",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Channel ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Role update request",
+                        "description": "Create channel request",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/internal_api.RoleUpdateRequest"
+                            "$ref": "#/definitions/internal_api.CreateChannelRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "User demoted successfully",
+                    "201": {
+                        "description": "Channel created successfully",
                         "schema": {
-                            "$ref": "#/definitions/internal_api.MessageResponse"
+                            "$ref": "#/definitions/internal_api.ChannelResponse"
                         }
                     },
                     "400": {
-                        "description": "Bad request",
+                        "description": "Bad request or invalid fields",
                         "schema": {
-                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                            "$ref": "#/definitions/internal_api.ValidationErrorResponse"
                         }
                     },
                     "401": {
@@ -649,13 +660,7 @@ const docTemplate = `{
                         }
                     },
                     "403": {
-                        "description": "Only channel owners can demote users",
-                        "schema": {
-                            "$ref": "#/definitions/internal_api.ErrorResponse"
-                        }
-                    },
-                    "404": {
-                        "description": "Channel or user not found",
+                        "description": "Owned channel limit reached",
                         "schema": {
                             "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
@@ -663,14 +668,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/channels/{id}/join": {
+        "/api/channels/join-bulk": {
             "post": {
                 "security": [
                     {
                         "CookieAuth": []
                     }
                 ],
-                "description": "Join a channel, optionally providing password for protected channels",
+                "description": "Try to join each listed channel (at most 50) and report a status per channel. A failure on one channel does not stop the others. Password-protected channels are reported as password_required.",
                 "consumes": [
                     "application/json"
                 ],
@@ -680,34 +685,27 @@ const docTemplate = `{
                 "tags": [
                     "Channels"
                 ],
-                "summary": "Join a channel",
+                "summary": "Join several channels",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Channel ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Join channel request",
+                        "description": "Channels to join",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/internal_api.JoinChannelRequest"
+                            "$ref": "#/definitions/internal_api.BulkJoinRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Successfully joined channel",
+                        "description": "Per-channel results",
                         "schema": {
-                            "$ref": "#/definitions/internal_api.MessageResponse"
+                            "$ref": "#/definitions/internal_api.BulkJoinResponse"
                         }
                     },
                     "400": {
-                        "description": "Bad request or incorrect password",
+                        "description": "Missing, empty or too many channel IDs",
                         "schema": {
                             "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
@@ -721,14 +719,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/channels/{id}/leave": {
-            "delete": {
+        "/api/channels/me": {
+            "get": {
                 "security": [
                     {
                         "CookieAuth": []
                     }
                 ],
-                "description": "Leave a channel that the user has previously joined",
+                "description": "Get all channels the authenticated user has joined",
                 "consumes": [
                     "application/json"
                 ],
@@ -738,31 +736,36 @@ const docTemplate = `{
                 "tags": [
                     "Channels"
                 ],
-                "summary": "Leave a channel",
+                "summary": "Get user's channels",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Channel ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "type": "integer",
+                        "description": "Page number (default: 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of results per page (default: 20, max: 100)",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Successfully left channel",
+                        "description": "List of user's channels",
                         "schema": {
-                            "$ref": "#/definitions/internal_api.MessageResponse"
+                            "$ref": "#/definitions/internal_api.ChannelsResponse"
                         }
                     },
-                    "400": {
-                        "description": "Bad request",
+                    "401": {
+                        "description": "User not authenticated",
                         "schema": {
                             "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
                     },
-                    "401": {
-                        "description": "User not authenticated",
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
@@ -770,14 +773,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/channels/{id}/messages": {
+        "/api/channels/{id}": {
             "get": {
                 "security": [
                     {
                         "CookieAuth": []
                     }
                 ],
-                "description": "Get paginated message history for a channel (only for channel members)",
+                "description": "Get detailed information about a specific channel, including whether the requester is a member, the owner, or banned. The owner is shown as \"hidden\" to everyone else when the channel hides its owner.",
                 "consumes": [
                     "application/json"
                 ],
@@ -785,9 +788,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Messages"
+                    "Channels"
                 ],
-                "summary": "Get channel message history",
+                "summary": "Get channel details",
                 "parameters": [
                     {
                         "type": "string",
@@ -795,35 +798,17 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Number of messages to retrieve (default: 50, max: 100)",
-                        "name": "limit",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Number of messages to skip (default: 0)",
-                        "name": "offset",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Get messages before this message ID",
-                        "name": "before",
-                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Messages retrieved successfully",
+                        "description": "Channel details",
                         "schema": {
-                            "$ref": "#/definitions/internal_api.MessagesResponse"
+                            "$ref": "#/definitions/internal_api.ChannelDetailResponse"
                         }
                     },
                     "400": {
-                        "description": "Bad request",
+                        "description": "Channel ID required",
                         "schema": {
                             "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
@@ -834,29 +819,27 @@ const docTemplate = `{
                             "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
                     },
-                    "403": {
-                        "description": "You are not a member of this channel",
+                    "404": {
+                        "description": "Channel not found",
                         "schema": {
                             "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Channel not found",
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
                     }
                 }
-            }
-        },
-        "/api/channels/{id}/promote": {
-            "post": {
+            },
+            "delete": {
                 "security": [
                     {
                         "CookieAuth": []
                     }
                 ],
-                "description": "Promote a user to a higher role in the channel (only channel owners can promote)",
+                "description": "Delete a channel (only channel owner can delete). When the server requires confirmation, the body must repeat the channel name as confirm.",
                 "consumes": [
                     "application/json"
                 ],
@@ -864,9 +847,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Channel Administration"
+                    "Channels"
                 ],
-                "summary": "Promote user in channel",
+                "summary": "Delete a channel",
                 "parameters": [
                     {
                         "type": "string",
@@ -876,24 +859,23 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "Role update request",
+                        "description": "Deletion confirmation",
                         "name": "request",
                         "in": "body",
-                        "required": true,
                         "schema": {
-                            "$ref": "#/definitions/internal_api.RoleUpdateRequest"
+                            "$ref": "#/definitions/internal_api.DeleteChannelRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "User promoted successfully",
+                        "description": "Channel deleted successfully",
                         "schema": {
                             "$ref": "#/definitions/internal_api.MessageResponse"
                         }
                     },
                     "400": {
-                        "description": "Bad request",
+                        "description": "Bad request, confirmation required or not authorized",
                         "schema": {
                             "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
@@ -903,30 +885,16 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
-                    },
-                    "403": {
-                        "description": "Only channel owners can promote users",
-                        "schema": {
-                            "$ref": "#/definitions/internal_api.ErrorResponse"
-                        }
-                    },
-                    "404": {
-                        "description": "Channel or user not found",
-                        "schema": {
-                            "$ref": "#/definitions/internal_api.ErrorResponse"
-                        }
                     }
                 }
-            }
-        },
-        "/api/channels/{id}/tempban": {
-            "post": {
+            },
+            "patch": {
                 "security": [
                     {
                         "CookieAuth": []
                     }
                 ],
-                "description": "Temporarily ban a user from a channel for a specified duration (only channel owner can ban)",
+                "description": "Update channel settings (only channel owner). Only provided fields are changed. A new password must meet the channel password policy.",
                 "consumes": [
                     "application/json"
                 ],
@@ -934,9 +902,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Channel Administration"
+                    "Channels"
                 ],
-                "summary": "Temporarily ban user from channel",
+                "summary": "Update channel settings",
                 "parameters": [
                     {
                         "type": "string",
@@ -946,24 +914,24 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "Temporary ban user request",
+                        "description": "Update channel request",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/internal_api.TempBanUserRequest"
+                            "$ref": "#/definitions/internal_api.UpdateChannelRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "User temporarily banned successfully",
+                        "description": "Channel updated successfully",
                         "schema": {
-                            "$ref": "#/definitions/internal_api.MessageResponse"
+                            "$ref": "#/definitions/internal_api.ChannelResponse"
                         }
                     },
                     "400": {
-                        "description": "Bad request or invalid duration format",
+                        "description": "Bad request",
                         "schema": {
                             "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
@@ -975,7 +943,19 @@ const docTemplate = `{
                         }
                     },
                     "403": {
-                        "description": "Only channel owner can ban users",
+                        "description": "Only channel owner can update channel",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Channel not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
@@ -983,14 +963,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/channels/{id}/users": {
+        "/api/channels/{id}/audit": {
             "get": {
                 "security": [
                     {
                         "CookieAuth": []
                     }
                 ],
-                "description": "Get a list of all users in a specific channel",
+                "description": "Get audit logs for a specific channel (only channel owners can view)",
                 "consumes": [
                     "application/json"
                 ],
@@ -998,9 +978,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Channels"
+                    "Audit Logs"
                 ],
-                "summary": "Get channel users",
+                "summary": "Get channel audit logs",
                 "parameters": [
                     {
                         "type": "string",
@@ -1008,17 +988,59 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by actor ID",
+                        "name": "actor_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by action type (e.g. BAN_USER)",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number (default: 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of results per page (default: 20, max: 100)",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "List of channel users",
+                        "description": "Audit logs retrieved successfully",
                         "schema": {
-                            "$ref": "#/definitions/internal_api.UsersResponse"
+                            "$ref": "#/definitions/internal_api.AuditLogsResponse"
                         }
                     },
                     "400": {
-                        "description": "Channel ID required",
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Only channel owners can view audit logs",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Channel not found",
                         "schema": {
                             "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
@@ -1032,14 +1054,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/logout": {
+        "/api/channels/{id}/ban": {
             "post": {
                 "security": [
                     {
                         "CookieAuth": []
                     }
                 ],
-                "description": "Logout user and clear authentication cookies",
+                "description": "Permanently ban a user from a channel (channel owner, or a moderator banning a lower role)",
                 "consumes": [
                     "application/json"
                 ],
@@ -1047,52 +1069,54 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Authentication"
+                    "Channel Administration"
                 ],
-                "summary": "Logout user",
-                "responses": {
-                    "200": {
-                        "description": "User logged out successfully",
+                "summary": "Ban user from channel",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Channel ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Ban user request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
                         "schema": {
-                            "$ref": "#/definitions/internal_api.MessageResponse"
+                            "$ref": "#/definitions/internal_api.BanUserRequest"
                         }
                     }
-                }
-            }
-        },
-        "/api/refresh_token": {
-            "post": {
-                "security": [
-                    {
-                        "CookieAuth": []
-                    }
-                ],
-                "description": "Refresh JWT token using refresh token from cookie",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
                 ],
-                "tags": [
-                    "Authentication"
-                ],
-                "summary": "Refresh JWT token",
                 "responses": {
                     "200": {
-                        "description": "Token refreshed successfully",
+                        "description": "User banned successfully, with the created ban",
                         "schema": {
-                            "$ref": "#/definitions/internal_api.MessageResponse"
+                            "$ref": "#/definitions/internal_api.BanUserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request or invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ValidationErrorResponse"
                         }
                     },
                     "401": {
-                        "description": "Invalid or missing refresh token",
+                        "description": "User not authenticated",
                         "schema": {
                             "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "403": {
+                        "description": "Not allowed to ban this user",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "User is already banned",
                         "schema": {
                             "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
@@ -1100,14 +1124,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/search/channels": {
-            "get": {
+        "/api/channels/{id}/ban/{userId}": {
+            "delete": {
                 "security": [
                     {
                         "CookieAuth": []
                     }
                 ],
-                "description": "Search for visible channels by name (partial matching)",
+                "description": "Remove a ban from a user, allowing them to rejoin the channel (only channel owner can unban)",
                 "consumes": [
                     "application/json"
                 ],
@@ -1115,33 +1139,34 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Search"
+                    "Channel Administration"
                 ],
-                "summary": "Search channels",
+                "summary": "Unban user from channel",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Search query (minimum 2 characters)",
-                        "name": "q",
-                        "in": "query",
+                        "description": "Channel ID",
+                        "name": "id",
+                        "in": "path",
                         "required": true
                     },
                     {
-                        "type": "integer",
-                        "description": "Number of results to return (default: 20, max: 50)",
-                        "name": "limit",
-                        "in": "query"
+                        "type": "string",
+                        "description": "User ID to unban",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Channels found",
+                        "description": "User unbanned successfully",
                         "schema": {
-                            "$ref": "#/definitions/internal_api.ChannelsSearchResponse"
+                            "$ref": "#/definitions/internal_api.MessageResponse"
                         }
                     },
                     "400": {
-                        "description": "Bad request - invalid query",
+                        "description": "Bad request",
                         "schema": {
                             "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
@@ -1151,18 +1176,24 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
+                    },
+                    "403": {
+                        "description": "Only channel owner can unban users",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/api/search/messages": {
+        "/api/channels/{id}/bans": {
             "get": {
                 "security": [
                     {
                         "CookieAuth": []
                     }
                 ],
-                "description": "Search for messages within a specific channel (only for channel members)",
+                "description": "Get a list of all active and inactive bans for a channel (only channel owner can view). Temporary bans report the seconds remaining, computed on the server.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1170,40 +1201,27 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Search"
+                    "Channel Administration"
                 ],
-                "summary": "Search messages",
+                "summary": "Get channel bans",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Search query (minimum 2 characters)",
-                        "name": "q",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Channel ID to search within",
-                        "name": "channel_id",
-                        "in": "query",
+                        "description": "Channel ID",
+                        "name": "id",
+                        "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Number of results to return (default: 20, max: 50)",
-                        "name": "limit",
-                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Messages found",
+                        "description": "List of channel bans",
                         "schema": {
-                            "$ref": "#/definitions/internal_api.MessagesSearchResponse"
+                            "$ref": "#/definitions/internal_api.BansResponse"
                         }
                     },
                     "400": {
-                        "description": "Bad request - invalid query or channel_id",
+                        "description": "Channel ID required",
                         "schema": {
                             "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
@@ -1215,13 +1233,13 @@ const docTemplate = `{
                         }
                     },
                     "403": {
-                        "description": "You are not a member of this channel",
+                        "description": "Only channel owner can view bans",
                         "schema": {
                             "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Channel not found",
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
@@ -1229,14 +1247,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/search/users": {
+        "/api/channels/{id}/bans/{userId}": {
             "get": {
                 "security": [
                     {
                         "CookieAuth": []
                     }
                 ],
-                "description": "Search for users by username (partial matching)",
+                "description": "Get the active ban of a user in a channel, with reason, who banned them and expiry (only channel owners and moderators)",
                 "consumes": [
                     "application/json"
                 ],
@@ -1244,39 +1262,52 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Search"
+                    "Channel Administration"
                 ],
-                "summary": "Search users",
+                "summary": "Get a user's ban",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Search query (minimum 2 characters)",
-                        "name": "q",
-                        "in": "query",
+                        "description": "Channel ID",
+                        "name": "id",
+                        "in": "path",
                         "required": true
                     },
                     {
-                        "type": "integer",
-                        "description": "Number of results to return (default: 20, max: 50)",
-                        "name": "limit",
-                        "in": "query"
+                        "type": "string",
+                        "description": "Banned user ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Users found",
+                        "description": "Active ban",
                         "schema": {
-                            "$ref": "#/definitions/internal_api.UsersSearchResponse"
+                            "$ref": "#/definitions/internal_api.BanResponse"
                         }
                     },
-                    "400": {
-                        "description": "Bad request - invalid query",
+                    "401": {
+                        "description": "User not authenticated",
                         "schema": {
                             "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
                     },
-                    "401": {
-                        "description": "User not authenticated",
+                    "403": {
+                        "description": "Only channel owners and moderators can view bans",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Channel not found or user is not banned",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
@@ -1284,14 +1315,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/user": {
-            "delete": {
+        "/api/channels/{id}/category": {
+            "put": {
                 "security": [
                     {
                         "CookieAuth": []
                     }
                 ],
-                "description": "Soft delete user account and clear authentication cookies",
+                "description": "Assign the channel to a global category or one of the owner's own, or remove it from its category with a null category_id (only channel owner)",
                 "consumes": [
                     "application/json"
                 ],
@@ -1299,14 +1330,38 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "User Management"
+                    "Channels"
+                ],
+                "summary": "Assign channel category",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Channel ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Category assignment",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.SetChannelCategoryRequest"
+                        }
+                    }
                 ],
-                "summary": "Delete user account",
                 "responses": {
                     "200": {
-                        "description": "Account deleted successfully",
+                        "description": "Channel category updated",
                         "schema": {
-                            "$ref": "#/definitions/internal_api.MessageResponse"
+                            "$ref": "#/definitions/internal_api.ChannelResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
                     },
                     "401": {
@@ -1315,8 +1370,14 @@ const docTemplate = `{
                             "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Only channel owner can assign a category",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
                     "404": {
-                        "description": "User not found",
+                        "description": "Channel or category not found",
                         "schema": {
                             "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
@@ -1328,14 +1389,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "patch": {
+            }
+        },
+        "/api/channels/{id}/demote": {
+            "post": {
                 "security": [
                     {
                         "CookieAuth": []
                     }
                 ],
-                "description": "Update user username and/or password",
+                "description": "Demote a user to a lower role in the channel (only channel owners can demote)",
                 "consumes": [
                     "application/json"
                 ],
@@ -1343,31 +1406,38 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "User Management"
+                    "Channel Administration"
                 ],
-                "summary": "Update user information",
+                "summary": "Demote user in channel",
                 "parameters": [
                     {
-                        "description": "Update user request",
+                        "type": "string",
+                        "description": "Channel ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Role update request",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/internal_api.UpdateUserRequest"
+                            "$ref": "#/definitions/internal_api.RoleUpdateRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "User updated successfully",
+                        "description": "User demoted successfully",
                         "schema": {
-                            "$ref": "#/definitions/internal_api.UpdateUserResponse"
+                            "$ref": "#/definitions/internal_api.MessageResponse"
                         }
                     },
                     "400": {
-                        "description": "Bad request or username already exists",
+                        "description": "Bad request, invalid fields or invalid role",
                         "schema": {
-                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                            "$ref": "#/definitions/internal_api.ValidationErrorResponse"
                         }
                     },
                     "401": {
@@ -1376,14 +1446,14 @@ const docTemplate = `{
                             "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "User not found",
+                    "403": {
+                        "description": "Only channel owners can demote users",
                         "schema": {
                             "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "404": {
+                        "description": "Channel or user not found",
                         "schema": {
                             "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
@@ -1391,14 +1461,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/user/channels/joined": {
-            "get": {
+        "/api/channels/{id}/join": {
+            "post": {
                 "security": [
                     {
                         "CookieAuth": []
                     }
                 ],
-                "description": "Get all channels the authenticated user has joined",
+                "description": "Join a channel, optionally providing password for protected channels. With as_guest the user joins as a Guest, who can read the channel but not post until promoted. Fails with \"channel is full\" when the channel's member limit is reached.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1406,14 +1476,38 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "User Management"
+                    "Channels"
+                ],
+                "summary": "Join a channel",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Channel ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Join channel request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.JoinChannelRequest"
+                        }
+                    }
                 ],
-                "summary": "Get joined channels",
                 "responses": {
                     "200": {
-                        "description": "List of joined channels",
+                        "description": "Successfully joined channel",
                         "schema": {
-                            "$ref": "#/definitions/internal_api.ChannelsResponse"
+                            "$ref": "#/definitions/internal_api.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request or incorrect password",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
                     },
                     "401": {
@@ -1422,8 +1516,20 @@ const docTemplate = `{
                             "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "403": {
+                        "description": "You are banned from this channel",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "User already in channel",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many wrong passwords for this channel, try later",
                         "schema": {
                             "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
@@ -1431,14 +1537,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/user/channels/owned": {
-            "get": {
+        "/api/channels/{id}/leave": {
+            "delete": {
                 "security": [
                     {
                         "CookieAuth": []
                     }
                 ],
-                "description": "Get all channels owned by the authenticated user",
+                "description": "Leave a channel that the user has previously joined",
                 "consumes": [
                     "application/json"
                 ],
@@ -1446,24 +1552,33 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "User Management"
+                    "Channels"
+                ],
+                "summary": "Leave a channel",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Channel ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Get owned channels",
                 "responses": {
                     "200": {
-                        "description": "List of owned channels",
+                        "description": "Successfully left channel",
                         "schema": {
-                            "$ref": "#/definitions/internal_api.ChannelsResponse"
+                            "$ref": "#/definitions/internal_api.MessageResponse"
                         }
                     },
-                    "401": {
-                        "description": "User not authenticated",
+                    "400": {
+                        "description": "Bad request",
                         "schema": {
                             "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "401": {
+                        "description": "User not authenticated",
                         "schema": {
                             "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
@@ -1471,29 +1586,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/hc": {
-            "get": {
-                "description": "Check if the server is running and responsive",
-                "produces": [
-                    "text/plain"
-                ],
-                "tags": [
-                    "System"
-                ],
-                "summary": "Health check",
-                "responses": {
-                    "200": {
-                        "description": "Running",
-                        "schema": {
-                            "type": "string"
-                        }
-                    }
-                }
-            }
-        },
-        "/login": {
+        "/api/channels/{id}/lock": {
             "post": {
-                "description": "Authenticate user with username and password",
+                "security": [
+                    {
+                        "CookieAuth": []
+                    }
+                ],
+                "description": "Pause a channel so that only the owner and moderators can post, optionally for a limited duration. A system message announcing the lock is posted to the channel.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1501,35 +1601,53 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Authentication"
+                    "Channel Administration"
                 ],
-                "summary": "Login user",
+                "summary": "Lock channel",
                 "parameters": [
                     {
-                        "description": "Login request",
+                        "type": "string",
+                        "description": "Channel ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Lock channel request",
                         "name": "request",
                         "in": "body",
-                        "required": true,
                         "schema": {
-                            "$ref": "#/definitions/internal_api.UserLoginInput"
+                            "$ref": "#/definitions/internal_api.LockChannelRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "User logged in successfully",
+                        "description": "Channel locked successfully",
                         "schema": {
-                            "$ref": "#/definitions/internal_api.AuthResponse"
+                            "$ref": "#/definitions/internal_api.ChannelLockResponse"
                         }
                     },
                     "400": {
-                        "description": "Invalid credentials",
+                        "description": "Bad request or invalid duration format",
                         "schema": {
                             "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Only channel owners and moderators can lock the channel",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Channel not found",
                         "schema": {
                             "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
@@ -1537,9 +1655,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/register": {
-            "post": {
-                "description": "Register a new user with username and password",
+        "/api/channels/{id}/messages": {
+            "get": {
+                "security": [
+                    {
+                        "CookieAuth": []
+                    }
+                ],
+                "description": "Get paginated message history for a channel (only for channel members). Page backwards with offset, or pass next_before from the previous page as before; has_more reports whether older messages remain.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1547,29 +1670,295 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Authentication"
+                    "Messages"
                 ],
-                "summary": "Register a new user",
+                "summary": "Get channel message history",
                 "parameters": [
                     {
-                        "description": "Registration request",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/internal_api.UserRegisterInput"
-                        }
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "User registered successfully",
-                        "schema": {
-                            "$ref": "#/definitions/internal_api.AuthResponse"
-                        }
+                        "type": "string",
+                        "description": "Channel ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     },
-                    "400": {
-                        "description": "Bad request",
+                    {
+                        "type": "integer",
+                        "description": "Number of messages to retrieve (default: 50, max: 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of messages to skip (default: 0)",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Get messages before this message ID",
+                        "name": "before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Replay messages created after this message ID or RFC3339 timestamp, oldest first (max 100)",
+                        "name": "since",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Messages retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.MessagesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "You are not a member of this channel",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Channel not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "CookieAuth": []
+                    }
+                ],
+                "description": "Post a message to a channel without a WebSocket connection (only for channel members who are not banned; guests have read-only access). Up to 5 attachments can reference files by http or https URL. The message is stored when the channel keeps history. Posting is rate limited per channel according to the member's role (by default Member 30/min, Moderator and Administrator unlimited).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Post a message to a channel",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Channel ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Create message request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.CreateMessageRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Message created successfully",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.CreateMessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "You are not a member of this channel, are banned from it, are a guest, or the channel is locked",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Channel not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Message rate limit exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/channels/{id}/moderation-stats": {
+            "get": {
+                "security": [
+                    {
+                        "CookieAuth": []
+                    }
+                ],
+                "description": "Count moderation events (bans, temporary bans, unbans, role changes, locks, scrubbed messages) recorded in the channel audit log over a period, plus the bans in effect at the end of it. Defaults to the last 30 days. Only channel owners and moderators can view stats.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Channel Administration"
+                ],
+                "summary": "Get channel moderation stats",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Channel ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start of the period (RFC3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the period (RFC3339, default: now)",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Moderation stats",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ModerationStatsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid time range",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Only channel owners and moderators can view moderation stats",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Channel not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/channels/{id}/notifications": {
+            "put": {
+                "security": [
+                    {
+                        "CookieAuth": []
+                    }
+                ],
+                "description": "Set how the authenticated user is notified about activity in a channel: \"all\" (default), \"mentions\" only, or \"none\"",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Channels"
+                ],
+                "summary": "Update channel notification preference",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Channel ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Notification preference",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.UpdateNotificationPrefRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Notification preference updated",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.NotificationPrefResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid notification mode",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Not a member of this channel",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Channel not found",
                         "schema": {
                             "$ref": "#/definitions/internal_api.ErrorResponse"
                         }
@@ -1582,118 +1971,3180 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "internal_api.AuditLogResponse": {
+        },
+        "/api/channels/{id}/preview-messages": {
+            "get": {
+                "security": [
+                    {
+                        "CookieAuth": []
+                    }
+                ],
+                "description": "Get the most recent messages of a public channel without joining it. Only available for visible, password-less channels that keep history and have previews enabled by the owner.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Preview channel messages",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Channel ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of messages to retrieve (default and max: 20)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Recent messages, oldest first",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.MessagesResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Preview is not available for this channel",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Channel not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/channels/{id}/promote": {
+            "post": {
+                "security": [
+                    {
+                        "CookieAuth": []
+                    }
+                ],
+                "description": "Promote a user to a higher role in the channel (only channel owners can promote)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Channel Administration"
+                ],
+                "summary": "Promote user in channel",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Channel ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Role update request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.RoleUpdateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User promoted successfully",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request, invalid fields or invalid role",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ValidationErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Only channel owners can promote users",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Channel or user not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/channels/{id}/reports": {
+            "get": {
+                "security": [
+                    {
+                        "CookieAuth": []
+                    }
+                ],
+                "description": "List the channel's open message reports, oldest first, with the reported message and the reporter (channel owner and moderators only)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Get channel reports",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Channel ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Open reports",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ReportsResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Only channel owners and moderators can view reports",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Channel not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/channels/{id}/tempban": {
+            "post": {
+                "security": [
+                    {
+                        "CookieAuth": []
+                    }
+                ],
+                "description": "Temporarily ban a user from a channel for a specified duration, from 1m to 8760h (1 year) (channel owner, or a moderator banning a lower role)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Channel Administration"
+                ],
+                "summary": "Temporarily ban user from channel",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Channel ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Temporary ban user request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.TempBanUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User temporarily banned successfully, with the created ban",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.BanUserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request, invalid fields, or invalid or out-of-range duration",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ValidationErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Not allowed to ban this user",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "User is already banned",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/channels/{id}/topic": {
+            "put": {
+                "security": [
+                    {
+                        "CookieAuth": []
+                    }
+                ],
+                "description": "Set the current topic of a channel (owner or moderators), or clear it with an empty topic. A system message announcing the new topic is posted to the channel.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Channel Administration"
+                ],
+                "summary": "Set channel topic",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Channel ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Set topic request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.SetChannelTopicRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Channel topic updated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ChannelTopicResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request, invalid fields, or topic too long",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ValidationErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Only channel owners and moderators can set the topic",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Channel not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/channels/{id}/unlock": {
+            "post": {
+                "security": [
+                    {
+                        "CookieAuth": []
+                    }
+                ],
+                "description": "Lift a channel lock so all members can post again. A system message announcing the unlock is posted to the channel.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Channel Administration"
+                ],
+                "summary": "Unlock channel",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Channel ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Channel unlocked successfully",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ChannelLockResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Channel is not locked",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Only channel owners and moderators can unlock the channel",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Channel not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/channels/{id}/users": {
+            "get": {
+                "security": [
+                    {
+                        "CookieAuth": []
+                    }
+                ],
+                "description": "Get a list of all users in a specific channel",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Channels"
+                ],
+                "summary": "Get channel users",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Channel ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of channel users",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.UsersResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Channel ID required",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/channels/{id}/verify-password": {
+            "post": {
+                "security": [
+                    {
+                        "CookieAuth": []
+                    }
+                ],
+                "description": "Check whether joining the channel would accept the password, without joining it. Channels without a password accept any, so the answer does not reveal whether a channel is protected. Strictly rate limited.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Channels"
+                ],
+                "summary": "Verify a channel password",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Channel ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Password to check",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.VerifyChannelPasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Whether the password is accepted",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.VerifyChannelPasswordResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Missing password",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ValidationErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Channel not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Rate limit exceeded, or too many wrong passwords for this channel",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/logout": {
+            "post": {
+                "security": [
+                    {
+                        "CookieAuth": []
+                    }
+                ],
+                "description": "Logout user and clear authentication cookies",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Logout user",
+                "responses": {
+                    "200": {
+                        "description": "User logged out successfully",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.MessageResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/messages/{id}/report": {
+            "post": {
+                "security": [
+                    {
+                        "CookieAuth": []
+                    }
+                ],
+                "description": "Flag a message for the moderators of its channel (channel members only, not your own messages). Reporting the same message again updates your report and reopens it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Report a message",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Message ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Reason for the report",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ReportMessageRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Report created or updated",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ReportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Reason too long",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Not a member of the channel, or reporting your own message",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Message not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/refresh_token": {
+            "post": {
+                "security": [
+                    {
+                        "CookieAuth": []
+                    }
+                ],
+                "description": "Refresh JWT token using refresh token from cookie",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Refresh JWT token",
+                "responses": {
+                    "200": {
+                        "description": "Token refreshed successfully",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.MessageResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid or missing refresh token",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Account suspended",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/reports/{id}": {
+            "patch": {
+                "security": [
+                    {
+                        "CookieAuth": []
+                    }
+                ],
+                "description": "Set a report's status to resolved or dismissed, or back to open (owner and moderators of the reported message's channel only)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Resolve a report",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Report ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New status",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ResolveReportRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated report",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ReportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid report ID or status",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ValidationErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Only channel owners and moderators can resolve reports",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Report not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/roles": {
+            "get": {
+                "security": [
+                    {
+                        "CookieAuth": []
+                    }
+                ],
+                "description": "List the channel roles, highest priority first, with a summary of what members holding each role may do. A member may only moderate members of a lower priority; the channel owner may do everything.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Roles"
+                ],
+                "summary": "List channel roles",
+                "responses": {
+                    "200": {
+                        "description": "Roles retrieved successfully",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.RolesResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/search/channels": {
+            "get": {
+                "security": [
+                    {
+                        "CookieAuth": []
+                    }
+                ],
+                "description": "Search for visible channels by name (partial matching). Owners who opted out are listed as \"hidden\".",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Search"
+                ],
+                "summary": "Search channels",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search query (minimum 2 characters, bounded length and term count)",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number (default: 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of results per page (default: 20, max: 50)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "200",
+                            "404"
+                        ],
+                        "type": "string",
+                        "description": "Status when nothing matches: 200 (empty list) or 404 (default: server setting)",
+                        "name": "on_empty",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "name",
+                            "members",
+                            "recent_activity"
+                        ],
+                        "type": "string",
+                        "description": "Sort by name, member count or latest message (default: name)",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort direction (default: asc for name, desc otherwise)",
+                        "name": "direction",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only return channels the user can join: excludes joined channels and channels with an active ban (default: false)",
+                        "name": "discover",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Channels found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ChannelsSearchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid, too long or too complex query, invalid sort or invalid discover value",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No channels found (when empty results are reported as 404)",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/search/messages": {
+            "get": {
+                "security": [
+                    {
+                        "CookieAuth": []
+                    }
+                ],
+                "description": "Search for messages within a specific channel (only for channel members)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Search"
+                ],
+                "summary": "Search messages",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search query (minimum 2 characters, bounded length and term count)",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Channel ID to search within",
+                        "name": "channel_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number (default: 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of results per page (default: 20, max: 50)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "200",
+                            "404"
+                        ],
+                        "type": "string",
+                        "description": "Status when nothing matches: 200 (empty list) or 404 (default: server setting)",
+                        "name": "on_empty",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Messages found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.MessagesSearchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid, too long or too complex query, or invalid channel_id",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "You are not a member of this channel",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Channel not found, or no messages found (when empty results are reported as 404)",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/search/users": {
+            "get": {
+                "security": [
+                    {
+                        "CookieAuth": []
+                    }
+                ],
+                "description": "Search for users by username (partial matching)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Search"
+                ],
+                "summary": "Search users",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search query (minimum 2 characters, bounded length and term count)",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number (default: 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of results per page (default: 20, max: 50)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "200",
+                            "404"
+                        ],
+                        "type": "string",
+                        "description": "Status when nothing matches: 200 (empty list) or 404 (default: server setting)",
+                        "name": "on_empty",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Users found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.UsersSearchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid, too long or too complex query",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No users found (when empty results are reported as 404)",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/user": {
+            "delete": {
+                "security": [
+                    {
+                        "CookieAuth": []
+                    }
+                ],
+                "description": "Soft delete user account and clear authentication cookies. Owned channels are transferred to another Administrator or deleted, per server policy.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Management"
+                ],
+                "summary": "Delete user account",
+                "responses": {
+                    "200": {
+                        "description": "Account deleted successfully",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.MessageResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "CookieAuth": []
+                    }
+                ],
+                "description": "Update user username and/or password",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Management"
+                ],
+                "summary": "Update user information",
+                "parameters": [
+                    {
+                        "description": "Update user request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.UpdateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User updated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.UpdateUserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request, reserved username or password too long (over 72 bytes)",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Username already exists",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/user/bans": {
+            "get": {
+                "security": [
+                    {
+                        "CookieAuth": []
+                    }
+                ],
+                "description": "Get the authenticated user's active bans across all channels, newest first, with the channel, reason, expiry and who issued them",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Management"
+                ],
+                "summary": "Get own bans",
+                "responses": {
+                    "200": {
+                        "description": "Active bans",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.OwnBansResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/user/channels/joined": {
+            "get": {
+                "security": [
+                    {
+                        "CookieAuth": []
+                    }
+                ],
+                "description": "Get all channels the authenticated user has joined",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Management"
+                ],
+                "summary": "Get joined channels",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page number (default: 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of results per page (default: 20, max: 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of joined channels",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ChannelsResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/user/channels/moderated": {
+            "get": {
+                "security": [
+                    {
+                        "CookieAuth": []
+                    }
+                ],
+                "description": "Get all channels the authenticated user owns or holds a Moderator or Administrator role in",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Management"
+                ],
+                "summary": "Get moderated channels",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page number (default: 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of results per page (default: 20, max: 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of moderated channels",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ChannelsResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/user/channels/owned": {
+            "get": {
+                "security": [
+                    {
+                        "CookieAuth": []
+                    }
+                ],
+                "description": "Get all channels owned by the authenticated user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Management"
+                ],
+                "summary": "Get owned channels",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page number (default: 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of results per page (default: 20, max: 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of owned channels",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ChannelsResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/user/channels/unread": {
+            "get": {
+                "security": [
+                    {
+                        "CookieAuth": []
+                    }
+                ],
+                "description": "Get the number of unread messages from other users in each joined channel, and the total",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Get unread counts",
+                "responses": {
+                    "200": {
+                        "description": "Unread counts",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.UnreadCountsResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/user/notifications/settings": {
+            "get": {
+                "security": [
+                    {
+                        "CookieAuth": []
+                    }
+                ],
+                "description": "Get the default notification mode and the resolved mode of every joined channel in one call. Channels without a preference report the default with is_default set.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Management"
+                ],
+                "summary": "Get notification settings",
+                "responses": {
+                    "200": {
+                        "description": "Notification settings",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.NotificationSettingsResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/user/read-all": {
+            "post": {
+                "security": [
+                    {
+                        "CookieAuth": []
+                    }
+                ],
+                "description": "Advance the read marker of every joined channel to its latest message, clearing all unread counts at once",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Messages"
+                ],
+                "summary": "Mark all channels as read",
+                "responses": {
+                    "200": {
+                        "description": "Number of channels whose read marker moved",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.MarkAllReadResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/user/sessions": {
+            "get": {
+                "security": [
+                    {
+                        "CookieAuth": []
+                    },
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "List the devices the authenticated user is signed in on, one per unexpired refresh token. Token values are never returned.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Management"
+                ],
+                "summary": "List active sessions",
+                "responses": {
+                    "200": {
+                        "description": "List of active sessions",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.DeviceSessionsResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/user/sessions/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "CookieAuth": []
+                    },
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Revoke one of the authenticated user's sessions so its refresh token can no longer be used. Access tokens already issued to it stay valid until they expire.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Management"
+                ],
+                "summary": "Revoke session",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Session ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Session revoked successfully",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid session ID",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Session not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/user/tokens": {
+            "get": {
+                "security": [
+                    {
+                        "CookieAuth": []
+                    },
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "List the authenticated user's API tokens, including revoked ones. Token values are never returned.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Management"
+                ],
+                "summary": "List API tokens",
+                "responses": {
+                    "200": {
+                        "description": "List of API tokens",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ApiTokensResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "CookieAuth": []
+                    },
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Create a long-lived API token for programmatic access. The token is only returned once; send it as \"Authorization: Bearer \u003ctoken\u003e\".",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Management"
+                ],
+                "summary": "Create API token",
+                "parameters": [
+                    {
+                        "description": "Create API token request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.CreateApiTokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "API token created successfully",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.CreateApiTokenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/user/tokens/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "CookieAuth": []
+                    },
+                    {
+                        "Bearer": []
+                    }
+                ],
+                "description": "Revoke an API token so it can no longer authenticate requests",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Management"
+                ],
+                "summary": "Revoke API token",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "API token ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "API token revoked successfully",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid token ID",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "API token not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "CookieAuth": []
+                    }
+                ],
+                "description": "Get the public profile of a user: username, join date and channel counts. Channels that hide their owner are not counted as owned.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User Management"
+                ],
+                "summary": "Get user profile",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Public profile",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.UserProfileResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "User not authenticated",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/hc": {
+            "get": {
+                "description": "Check if the server is running and responsive",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "System"
+                ],
+                "summary": "Health check",
+                "responses": {
+                    "200": {
+                        "description": "Running",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/hc/live": {
+            "get": {
+                "description": "Lightweight liveness probe that does not touch any dependency",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System"
+                ],
+                "summary": "Liveness check",
+                "responses": {
+                    "200": {
+                        "description": "Alive",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/hc/ready": {
+            "get": {
+                "description": "Readiness probe that pings the database",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "System"
+                ],
+                "summary": "Readiness check",
+                "responses": {
+                    "200": {
+                        "description": "Ready",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.HealthResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Database unreachable",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/login": {
+            "post": {
+                "description": "Authenticate user with username and password",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Login user",
+                "parameters": [
+                    {
+                        "description": "Login request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.UserLoginInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User logged in successfully",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.AuthResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid credentials",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Account suspended",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/metrics": {
+            "get": {
+                "description": "Request counts and latencies by route, stored messages and rate limit rejections, in the Prometheus text format. Only served when METRICS_ENABLED is true.",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "System"
+                ],
+                "summary": "Prometheus metrics",
+                "responses": {
+                    "200": {
+                        "description": "Metrics",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/register": {
+            "post": {
+                "description": "Register a new user with username and password. Passwords longer than 72 bytes are rejected with \"password too long\".",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Register a new user",
+                "parameters": [
+                    {
+                        "description": "Registration request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.UserRegisterInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User registered successfully",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.AuthResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Username already exists",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "internal_api.AdminUserInfo": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2023-01-01T00:00:00Z"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "user123"
+                },
+                "is_admin": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "joined_channels": {
+                    "type": "integer",
+                    "example": 5
+                },
+                "owned_channels": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "suspended": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "suspended_until": {
+                    "description": "null unless a temporary suspension is in effect",
+                    "type": "string",
+                    "example": "2023-01-04T00:00:00Z"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "johndoe"
+                }
+            }
+        },
+        "internal_api.AdminUsersResponse": {
+            "type": "object",
+            "properties": {
+                "has_next": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "has_prev": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "limit": {
+                    "type": "integer",
+                    "example": 20
+                },
+                "page": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "total_pages": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "users": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_api.AdminUserInfo"
+                    }
+                }
+            }
+        },
+        "internal_api.ApiTokenInfo": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2023-01-01T00:00:00Z"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "label": {
+                    "type": "string",
+                    "example": "deploy-bot"
+                },
+                "last_used_at": {
+                    "type": "string",
+                    "example": "2023-01-02T00:00:00Z"
+                },
+                "revoked": {
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "internal_api.ApiTokensResponse": {
+            "type": "object",
+            "properties": {
+                "tokens": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_api.ApiTokenInfo"
+                    }
+                }
+            }
+        },
+        "internal_api.AttachmentInfo": {
+            "type": "object",
+            "properties": {
+                "filename": {
+                    "type": "string",
+                    "example": "report.pdf"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "mime_type": {
+                    "type": "string",
+                    "example": "application/pdf"
+                },
+                "size": {
+                    "type": "integer",
+                    "example": 52340
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://files.example.com/report.pdf"
+                }
+            }
+        },
+        "internal_api.AttachmentRequest": {
+            "type": "object",
+            "required": [
+                "url"
+            ],
+            "properties": {
+                "filename": {
+                    "type": "string",
+                    "example": "report.pdf"
+                },
+                "mime_type": {
+                    "type": "string",
+                    "example": "application/pdf"
+                },
+                "size": {
+                    "description": "In bytes",
+                    "type": "integer",
+                    "example": 52340
+                },
+                "url": {
+                    "description": "Absolute http or https URL",
+                    "type": "string",
+                    "example": "https://files.example.com/report.pdf"
+                }
+            }
+        },
+        "internal_api.AuditLogResponse": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "example": "BAN_USER"
+                },
+                "actor": {
+                    "type": "object",
+                    "properties": {
+                        "id": {
+                            "type": "string",
+                            "example": "abc12345"
+                        },
+                        "username": {
+                            "type": "string",
+                            "example": "admin_user"
+                        }
+                    }
+                },
+                "actor_id": {
+                    "type": "string",
+                    "example": "abc12345"
+                },
+                "channel": {
+                    "type": "object",
+                    "properties": {
+                        "id": {
+                            "type": "string",
+                            "example": "xyz123"
+                        },
+                        "name": {
+                            "type": "string",
+                            "example": "general"
+                        }
+                    }
+                },
+                "channel_id": {
+                    "type": "string",
+                    "example": "xyz123"
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2023-01-01T00:00:00Z"
+                },
+                "description": {
+                    "type": "string",
+                    "example": "Banned user from channel"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "metadata": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "target": {
+                    "type": "object",
+                    "properties": {
+                        "id": {
+                            "type": "string",
+                            "example": "def67890"
+                        },
+                        "username": {
+                            "type": "string",
+                            "example": "banned_user"
+                        }
+                    }
+                },
+                "target_id": {
+                    "type": "string",
+                    "example": "def67890"
+                }
+            }
+        },
+        "internal_api.AuditLogsResponse": {
+            "type": "object",
+            "properties": {
+                "has_next": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "has_prev": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "limit": {
+                    "type": "integer",
+                    "example": 20
+                },
+                "logs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_api.AuditLogResponse"
+                    }
+                },
+                "page": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "total_pages": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "internal_api.AuthResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "Register successful"
+                },
+                "user": {
+                    "$ref": "#/definitions/internal_api.UserResponse"
+                }
+            }
+        },
+        "internal_api.BanInfo": {
+            "type": "object",
+            "properties": {
+                "banned_at": {
+                    "type": "string",
+                    "example": "2023-01-01T00:00:00Z"
+                },
+                "banned_by": {
+                    "$ref": "#/definitions/internal_api.UserInfo"
+                },
+                "expires_at": {
+                    "type": "string",
+                    "example": "2023-01-02T00:00:00Z"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "is_active": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "is_temporary": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "spam"
+                },
+                "remaining_seconds": {
+                    "description": "null for permanent bans, 0 once expired",
+                    "type": "integer",
+                    "example": 3600
+                },
+                "user": {
+                    "$ref": "#/definitions/internal_api.UserInfo"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "a1b2c3d4"
+                }
+            }
+        },
+        "internal_api.BanResponse": {
+            "type": "object",
+            "properties": {
+                "ban": {
+                    "$ref": "#/definitions/internal_api.BanInfo"
+                }
+            }
+        },
+        "internal_api.BanUserRequest": {
+            "type": "object",
+            "required": [
+                "user_id"
+            ],
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "example": "spam"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "a1b2c3d4"
+                }
+            }
+        },
+        "internal_api.BanUserResponse": {
+            "type": "object",
+            "properties": {
+                "ban": {
+                    "$ref": "#/definitions/internal_api.BanInfo"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "User banned successfully"
+                }
+            }
+        },
+        "internal_api.BansResponse": {
+            "type": "object",
+            "properties": {
+                "bans": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_api.BanInfo"
+                    }
+                }
+            }
+        },
+        "internal_api.BulkJoinRequest": {
+            "type": "object",
+            "required": [
+                "channel_ids"
+            ],
+            "properties": {
+                "channel_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "ch123",
+                        "ch456"
+                    ]
+                }
+            }
+        },
+        "internal_api.BulkJoinResponse": {
+            "type": "object",
+            "properties": {
+                "joined": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_api.BulkJoinResult"
+                    }
+                }
+            }
+        },
+        "internal_api.BulkJoinResult": {
+            "type": "object",
+            "properties": {
+                "channel_id": {
+                    "type": "string",
+                    "example": "ch123"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "joined",
+                        "already_member",
+                        "banned",
+                        "not_found",
+                        "password_required",
+                        "full",
+                        "failed"
+                    ],
+                    "example": "joined"
+                }
+            }
+        },
+        "internal_api.CategoriesResponse": {
+            "type": "object",
+            "properties": {
+                "categories": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_api.CategoryInfo"
+                    }
+                }
+            }
+        },
+        "internal_api.CategoryInfo": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2023-01-01T00:00:00Z"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "is_global": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Dev"
+                }
+            }
+        },
+        "internal_api.CategoryResponse": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "$ref": "#/definitions/internal_api.CategoryInfo"
+                }
+            }
+        },
+        "internal_api.ChannelDetailResponse": {
+            "type": "object",
+            "properties": {
+                "channel": {
+                    "$ref": "#/definitions/internal_api.ChannelInfo"
+                },
+                "is_banned": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "is_member": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "is_owner": {
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "internal_api.ChannelGroup": {
+            "type": "object",
+            "properties": {
+                "category": {
+                    "$ref": "#/definitions/internal_api.CategoryInfo"
+                },
+                "channels": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_api.ChannelInfo"
+                    }
+                }
+            }
+        },
+        "internal_api.ChannelInfo": {
+            "type": "object",
+            "properties": {
+                "allow_preview": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "announce_membership": {
+                    "description": "Joins, leaves and bans are posted as system messages",
+                    "type": "boolean",
+                    "example": false
+                },
+                "category_id": {
+                    "description": "null when uncategorized",
+                    "type": "integer",
+                    "example": 1
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2023-01-01T00:00:00Z"
+                },
+                "description": {
+                    "type": "string",
+                    "example": "Anything goes"
+                },
+                "hide_owner": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "id": {
+                    "type": "string",
+                    "example": "ch123"
+                },
+                "is_visible": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "last_message_at": {
+                    "description": "null until the first message",
+                    "type": "string",
+                    "example": "2023-01-02T00:00:00Z"
+                },
+                "logging_days": {
+                    "type": "integer",
+                    "example": 30
+                },
+                "max_members": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "name": {
+                    "type": "string",
+                    "example": "general"
+                },
+                "owner": {
+                    "$ref": "#/definitions/internal_api.ChannelOwner"
+                },
+                "topic": {
+                    "type": "string",
+                    "example": "Release planning"
+                }
+            }
+        },
+        "internal_api.ChannelLockResponse": {
+            "type": "object",
+            "properties": {
+                "locked_until": {
+                    "type": "string",
+                    "example": "2023-01-01T00:15:00Z"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Channel locked successfully"
+                },
+                "system_message": {
+                    "$ref": "#/definitions/internal_api.MessageInfo"
+                }
+            }
+        },
+        "internal_api.ChannelNotificationSetting": {
+            "type": "object",
+            "properties": {
+                "channel_id": {
+                    "type": "string",
+                    "example": "ch123"
+                },
+                "channel_name": {
+                    "type": "string",
+                    "example": "general"
+                },
+                "is_default": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "mode": {
+                    "type": "string",
+                    "example": "mentions"
+                }
+            }
+        },
+        "internal_api.ChannelOwner": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string",
+                    "example": "a1b2c3d4"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "john_doe"
+                }
+            }
+        },
+        "internal_api.ChannelResponse": {
+            "type": "object",
+            "properties": {
+                "channel": {
+                    "$ref": "#/definitions/internal_api.ChannelInfo"
+                }
+            }
+        },
+        "internal_api.ChannelSearchResult": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "is_visible": {
+                    "type": "boolean"
+                },
+                "last_message_at": {
+                    "description": "null until the first message",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "owner": {
+                    "type": "object",
+                    "properties": {
+                        "id": {
+                            "type": "string"
+                        },
+                        "username": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "internal_api.ChannelTopicResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "Channel topic updated successfully"
+                },
+                "system_message": {
+                    "$ref": "#/definitions/internal_api.MessageInfo"
+                },
+                "topic": {
+                    "type": "string",
+                    "example": "Release planning"
+                }
+            }
+        },
+        "internal_api.ChannelUnreadCount": {
+            "type": "object",
+            "properties": {
+                "channel_id": {
+                    "type": "string",
+                    "example": "ch123"
+                },
+                "unread": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "internal_api.ChannelsResponse": {
+            "type": "object",
+            "properties": {
+                "channels": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_api.ChannelInfo"
+                    }
+                },
+                "groups": {
+                    "description": "The same channels by category, with group_by=category",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_api.ChannelGroup"
+                    }
+                },
+                "has_next": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "has_prev": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "limit": {
+                    "type": "integer",
+                    "example": 20
+                },
+                "page": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "total_pages": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "internal_api.ChannelsSearchResponse": {
+            "type": "object",
+            "properties": {
+                "channels": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_api.ChannelSearchResult"
+                    }
+                },
+                "has_next": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "has_prev": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "limit": {
+                    "type": "integer",
+                    "example": 20
+                },
+                "page": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "total_pages": {
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "internal_api.CreateApiTokenRequest": {
             "type": "object",
+            "required": [
+                "label"
+            ],
             "properties": {
-                "action": {
+                "label": {
                     "type": "string",
-                    "example": "BAN_USER"
+                    "example": "deploy-bot"
+                }
+            }
+        },
+        "internal_api.CreateApiTokenResponse": {
+            "type": "object",
+            "properties": {
+                "api_token": {
+                    "$ref": "#/definitions/internal_api.ApiTokenInfo"
                 },
-                "actor": {
-                    "type": "object",
-                    "properties": {
-                        "id": {
-                            "type": "string",
-                            "example": "abc12345"
-                        },
-                        "username": {
-                            "type": "string",
-                            "example": "admin_user"
-                        }
+                "message": {
+                    "type": "string",
+                    "example": "API token created successfully"
+                },
+                "token": {
+                    "type": "string",
+                    "example": "gct_3q2-7wEJ8Lx0aZ..."
+                }
+            }
+        },
+        "internal_api.CreateCategoryRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "global": {
+                    "description": "Visible to everyone; system administrators only",
+                    "type": "boolean",
+                    "example": false
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Dev"
+                }
+            }
+        },
+        "internal_api.CreateChannelRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "is_visible": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "logging_days": {
+                    "description": "0 disables history, max 365, default 30",
+                    "type": "integer",
+                    "example": 30
+                },
+                "name": {
+                    "type": "string",
+                    "example": "general"
+                },
+                "password": {
+                    "type": "string",
+                    "example": "secretpass"
+                }
+            }
+        },
+        "internal_api.CreateMessageRequest": {
+            "type": "object",
+            "required": [
+                "content"
+            ],
+            "properties": {
+                "attachments": {
+                    "description": "At most 5",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_api.AttachmentRequest"
                     }
                 },
-                "actor_id": {
+                "content": {
                     "type": "string",
-                    "example": "abc12345"
+                    "example": "Hello everyone!"
                 },
-                "channel": {
+                "format": {
+                    "description": "plain (default) or markdown; stored as-is, never rendered",
+                    "type": "string",
+                    "example": "markdown"
+                }
+            }
+        },
+        "internal_api.CreateMessageResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "$ref": "#/definitions/internal_api.MessageInfo"
+                }
+            }
+        },
+        "internal_api.DeleteChannelRequest": {
+            "type": "object",
+            "properties": {
+                "confirm": {
+                    "description": "Must equal the channel name",
+                    "type": "string",
+                    "example": "general"
+                }
+            }
+        },
+        "internal_api.DeviceSessionInfo": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2023-01-01T00:00:00Z"
+                },
+                "device_label": {
+                    "description": "User-Agent captured at sign-in",
+                    "type": "string",
+                    "example": "Mozilla/5.0 (X11; Linux x86_64) Firefox/128.0"
+                },
+                "expires_at": {
+                    "type": "string",
+                    "example": "2023-01-08T00:00:00Z"
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "last_used_at": {
+                    "description": "null until the session is first refreshed",
+                    "type": "string",
+                    "example": "2023-01-02T00:00:00Z"
+                }
+            }
+        },
+        "internal_api.DeviceSessionsResponse": {
+            "type": "object",
+            "properties": {
+                "sessions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_api.DeviceSessionInfo"
+                    }
+                }
+            }
+        },
+        "internal_api.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "username cannot be empty"
+                }
+            }
+        },
+        "internal_api.HealthResponse": {
+            "type": "object",
+            "properties": {
+                "db": {
+                    "type": "string",
+                    "example": "up"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "internal_api.JoinChannelRequest": {
+            "type": "object",
+            "properties": {
+                "as_guest": {
+                    "description": "Join with read-only access",
+                    "type": "boolean",
+                    "example": false
+                },
+                "password": {
+                    "type": "string",
+                    "example": "secretpass"
+                }
+            }
+        },
+        "internal_api.LockChannelRequest": {
+            "type": "object",
+            "properties": {
+                "duration": {
+                    "description": "e.g., \"15m\", \"1h\"; omit to lock until unlocked",
+                    "type": "string",
+                    "example": "15m"
+                }
+            }
+        },
+        "internal_api.MarkAllReadResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "Channels marked as read"
+                },
+                "updated": {
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "internal_api.MessageInfo": {
+            "type": "object",
+            "properties": {
+                "attachments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_api.AttachmentInfo"
+                    }
+                },
+                "channel_id": {
+                    "type": "string"
+                },
+                "content": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "edited_at": {
+                    "description": "null unless edited",
+                    "type": "string",
+                    "example": "2023-01-01T00:05:00Z"
+                },
+                "format": {
+                    "description": "plain or markdown; clients decide how to render it",
+                    "type": "string",
+                    "example": "plain"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "is_deleted": {
+                    "type": "boolean"
+                },
+                "is_system": {
+                    "type": "boolean"
+                },
+                "seq": {
+                    "description": "Per-channel order of the message; sort by it when deliveries interleave",
+                    "type": "integer",
+                    "example": 42
+                },
+                "user": {
                     "type": "object",
                     "properties": {
                         "id": {
-                            "type": "string",
-                            "example": "xyz123"
+                            "type": "string"
                         },
-                        "name": {
-                            "type": "string",
-                            "example": "general"
+                        "username": {
+                            "type": "string"
                         }
                     }
                 },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_api.MessageResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "Logged out"
+                }
+            }
+        },
+        "internal_api.MessageSearchResult": {
+            "type": "object",
+            "properties": {
+                "attachments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_api.AttachmentInfo"
+                    }
+                },
                 "channel_id": {
-                    "type": "string",
-                    "example": "xyz123"
+                    "type": "string"
+                },
+                "content": {
+                    "type": "string"
                 },
                 "created_at": {
-                    "type": "string",
-                    "example": "2023-01-01T00:00:00Z"
+                    "type": "string"
                 },
-                "description": {
-                    "type": "string",
-                    "example": "Banned user from channel"
+                "edited_at": {
+                    "description": "null unless edited",
+                    "type": "string"
                 },
                 "id": {
-                    "type": "integer",
-                    "example": 1
+                    "type": "string"
                 },
-                "metadata": {
-                    "type": "object",
-                    "additionalProperties": true
+                "is_deleted": {
+                    "description": "Deleted messages are left out of search, so always false",
+                    "type": "boolean"
                 },
-                "target": {
+                "snippet": {
+                    "description": "Excerpt around the first match, which is wrapped in the highlight delimiter",
+                    "type": "string",
+                    "example": "…the deploy **pipeline** is green…"
+                },
+                "user": {
                     "type": "object",
                     "properties": {
                         "id": {
-                            "type": "string",
-                            "example": "def67890"
+                            "type": "string"
                         },
                         "username": {
-                            "type": "string",
-                            "example": "banned_user"
+                            "type": "string"
                         }
                     }
                 },
-                "target_id": {
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_api.MessagesResponse": {
+            "type": "object",
+            "properties": {
+                "has_more": {
+                    "type": "boolean"
+                },
+                "messages": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_api.MessageInfo"
+                    }
+                },
+                "next_before": {
+                    "description": "Pass as before to fetch the next older page; set while has_more",
                     "type": "string",
-                    "example": "def67890"
+                    "example": "msg123"
+                },
+                "total": {
+                    "type": "integer"
                 }
             }
         },
-        "internal_api.AuditLogsResponse": {
+        "internal_api.MessagesSearchResponse": {
             "type": "object",
             "properties": {
+                "has_next": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "has_prev": {
+                    "type": "boolean",
+                    "example": true
+                },
                 "limit": {
-                    "type": "integer"
+                    "type": "integer",
+                    "example": 20
                 },
-                "logs": {
+                "messages": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/internal_api.AuditLogResponse"
+                        "$ref": "#/definitions/internal_api.MessageSearchResult"
                     }
                 },
                 "page": {
-                    "type": "integer"
+                    "type": "integer",
+                    "example": 2
                 },
                 "total": {
-                    "type": "integer"
+                    "type": "integer",
+                    "example": 42
+                },
+                "total_pages": {
+                    "type": "integer",
+                    "example": 3
                 }
             }
         },
-        "internal_api.AuthResponse": {
+        "internal_api.ModerationStatsResponse": {
             "type": "object",
             "properties": {
-                "message": {
+                "active_bans": {
+                    "type": "integer",
+                    "example": 4
+                },
+                "bans": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "demotions": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "from": {
                     "type": "string",
-                    "example": "Register successful"
+                    "example": "2024-01-01T00:00:00Z"
                 },
-                "user": {
-                    "$ref": "#/definitions/internal_api.UserResponse"
+                "locks": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "promotions": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "scrubbed_messages": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "temp_bans": {
+                    "type": "integer",
+                    "example": 5
+                },
+                "to": {
+                    "type": "string",
+                    "example": "2024-01-31T00:00:00Z"
+                },
+                "unbans": {
+                    "type": "integer",
+                    "example": 2
                 }
             }
         },
-        "internal_api.BanInfo": {
+        "internal_api.NotificationPrefResponse": {
+            "type": "object",
+            "properties": {
+                "channel_id": {
+                    "type": "string",
+                    "example": "ch123"
+                },
+                "mode": {
+                    "type": "string",
+                    "example": "mentions"
+                }
+            }
+        },
+        "internal_api.NotificationSettingsResponse": {
+            "type": "object",
+            "properties": {
+                "channels": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_api.ChannelNotificationSetting"
+                    }
+                },
+                "default_mode": {
+                    "type": "string",
+                    "example": "all"
+                }
+            }
+        },
+        "internal_api.OwnBanInfo": {
             "type": "object",
             "properties": {
                 "banned_at": {
@@ -1703,7 +5154,16 @@ const docTemplate = `{
                 "banned_by": {
                     "$ref": "#/definitions/internal_api.UserInfo"
                 },
+                "channel_id": {
+                    "type": "string",
+                    "example": "ch123"
+                },
+                "channel_name": {
+                    "type": "string",
+                    "example": "general"
+                },
                 "expires_at": {
+                    "description": "null for permanent bans",
                     "type": "string",
                     "example": "2023-01-02T00:00:00Z"
                 },
@@ -1711,321 +5171,389 @@ const docTemplate = `{
                     "type": "integer",
                     "example": 1
                 },
-                "is_active": {
-                    "type": "boolean",
-                    "example": true
-                },
-                "reason": {
-                    "type": "string",
-                    "example": "spam"
-                },
-                "user": {
-                    "$ref": "#/definitions/internal_api.UserInfo"
-                },
-                "user_id": {
-                    "type": "string",
-                    "example": "a1b2c3d4"
-                }
-            }
-        },
-        "internal_api.BanUserRequest": {
-            "type": "object",
-            "required": [
-                "user_id"
-            ],
-            "properties": {
                 "reason": {
                     "type": "string",
                     "example": "spam"
                 },
-                "user_id": {
-                    "type": "string",
-                    "example": "a1b2c3d4"
+                "remaining_seconds": {
+                    "description": "null for permanent bans",
+                    "type": "integer",
+                    "example": 3600
                 }
             }
         },
-        "internal_api.BansResponse": {
+        "internal_api.OwnBansResponse": {
             "type": "object",
             "properties": {
                 "bans": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/internal_api.BanInfo"
+                        "$ref": "#/definitions/internal_api.OwnBanInfo"
                     }
                 }
             }
         },
-        "internal_api.ChannelInfo": {
+        "internal_api.ReportInfo": {
             "type": "object",
             "properties": {
+                "channel_id": {
+                    "type": "string",
+                    "example": "ch123"
+                },
                 "created_at": {
                     "type": "string",
                     "example": "2023-01-01T00:00:00Z"
                 },
                 "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "message": {
+                    "$ref": "#/definitions/internal_api.MessageInfo"
+                },
+                "reason": {
                     "type": "string",
-                    "example": "ch123"
+                    "example": "harassment"
                 },
-                "is_visible": {
-                    "type": "boolean",
-                    "example": true
+                "reporter": {
+                    "$ref": "#/definitions/internal_api.UserInfo"
                 },
-                "name": {
+                "resolved_at": {
+                    "description": "null while open",
                     "type": "string",
-                    "example": "general"
+                    "example": "2023-01-01T01:00:00Z"
                 },
-                "owner": {
-                    "$ref": "#/definitions/internal_api.ChannelOwner"
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "open",
+                        "resolved",
+                        "dismissed"
+                    ],
+                    "example": "open"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2023-01-01T00:00:00Z"
                 }
             }
         },
-        "internal_api.ChannelOwner": {
+        "internal_api.ReportMessageRequest": {
             "type": "object",
             "properties": {
-                "id": {
+                "reason": {
+                    "description": "At most 500 characters",
                     "type": "string",
-                    "example": "a1b2c3d4"
+                    "example": "harassment"
+                }
+            }
+        },
+        "internal_api.ReportResponse": {
+            "type": "object",
+            "properties": {
+                "report": {
+                    "$ref": "#/definitions/internal_api.ReportInfo"
+                }
+            }
+        },
+        "internal_api.ReportsResponse": {
+            "type": "object",
+            "properties": {
+                "reports": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/internal_api.ReportInfo"
+                    }
+                }
+            }
+        },
+        "internal_api.ResolveReportRequest": {
+            "type": "object",
+            "required": [
+                "status"
+            ],
+            "properties": {
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "open",
+                        "resolved",
+                        "dismissed"
+                    ],
+                    "example": "resolved"
+                }
+            }
+        },
+        "internal_api.RoleInfo": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer",
+                    "example": 2
                 },
-                "username": {
+                "name": {
                     "type": "string",
-                    "example": "john_doe"
+                    "example": "Moderator"
+                },
+                "permissions": {
+                    "$ref": "#/definitions/internal_api.RolePermissions"
+                },
+                "priority": {
+                    "description": "Higher roles may moderate lower ones",
+                    "type": "integer",
+                    "example": 50
                 }
             }
         },
-        "internal_api.ChannelResponse": {
+        "internal_api.RolePermissions": {
             "type": "object",
             "properties": {
-                "channel": {
-                    "$ref": "#/definitions/internal_api.ChannelInfo"
+                "can_ban": {
+                    "description": "Ban and temporarily ban members of lower roles",
+                    "type": "boolean",
+                    "example": true
+                },
+                "can_lock": {
+                    "description": "Lock and unlock the channel",
+                    "type": "boolean",
+                    "example": true
+                },
+                "can_post": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "can_post_when_locked": {
+                    "description": "Post while the channel is locked",
+                    "type": "boolean",
+                    "example": true
+                },
+                "can_promote": {
+                    "description": "Change members' roles; reserved to the owner",
+                    "type": "boolean",
+                    "example": false
+                },
+                "can_review_reports": {
+                    "description": "List and resolve reported messages",
+                    "type": "boolean",
+                    "example": true
+                },
+                "can_view_moderation": {
+                    "description": "View bans and moderation statistics",
+                    "type": "boolean",
+                    "example": true
                 }
             }
         },
-        "internal_api.ChannelSearchResult": {
+        "internal_api.RoleUpdateRequest": {
             "type": "object",
+            "required": [
+                "role",
+                "user_id"
+            ],
             "properties": {
-                "id": {
-                    "type": "string"
-                },
-                "is_visible": {
-                    "type": "boolean"
+                "reason": {
+                    "description": "Recorded in the audit log",
+                    "type": "string",
+                    "example": "Keeps the channel on topic"
                 },
-                "name": {
-                    "type": "string"
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "Administrator",
+                        "Moderator",
+                        "Member",
+                        "Guest"
+                    ],
+                    "example": "Moderator"
                 },
-                "owner": {
-                    "type": "object",
-                    "properties": {
-                        "id": {
-                            "type": "string"
-                        },
-                        "username": {
-                            "type": "string"
-                        }
-                    }
+                "user_id": {
+                    "type": "string",
+                    "example": "abc12345"
                 }
             }
         },
-        "internal_api.ChannelsResponse": {
+        "internal_api.RolesResponse": {
             "type": "object",
             "properties": {
-                "channels": {
+                "roles": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/internal_api.ChannelInfo"
+                        "$ref": "#/definitions/internal_api.RoleInfo"
                     }
                 }
             }
         },
-        "internal_api.ChannelsSearchResponse": {
+        "internal_api.ScrubMessageAuthorRequest": {
             "type": "object",
+            "required": [
+                "reason"
+            ],
             "properties": {
-                "channels": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/internal_api.ChannelSearchResult"
-                    }
-                },
-                "total": {
-                    "type": "integer"
+                "reason": {
+                    "type": "string",
+                    "example": "compromised account"
                 }
             }
         },
-        "internal_api.CreateChannelRequest": {
+        "internal_api.SessionResponse": {
             "type": "object",
-            "required": [
-                "name"
-            ],
             "properties": {
-                "is_visible": {
-                    "type": "boolean",
-                    "example": true
+                "refresh_token_expires_at": {
+                    "type": "string",
+                    "example": "2023-01-08T12:00:00Z"
                 },
-                "name": {
+                "server_time": {
                     "type": "string",
-                    "example": "general"
+                    "example": "2023-01-01T12:00:00.123456789Z"
                 },
-                "password": {
+                "token_expires_at": {
                     "type": "string",
-                    "example": "secretpass"
+                    "example": "2023-01-02T12:00:00Z"
                 }
             }
         },
-        "internal_api.ErrorResponse": {
+        "internal_api.SetChannelCategoryRequest": {
             "type": "object",
             "properties": {
-                "error": {
-                    "type": "string",
-                    "example": "username cannot be empty"
+                "category_id": {
+                    "type": "integer",
+                    "example": 1
                 }
             }
         },
-        "internal_api.JoinChannelRequest": {
+        "internal_api.SetChannelTopicRequest": {
             "type": "object",
             "properties": {
-                "password": {
+                "topic": {
+                    "description": "At most 200 characters; empty clears the topic",
                     "type": "string",
-                    "example": "secretpass"
+                    "example": "Release planning"
                 }
             }
         },
-        "internal_api.MessageInfo": {
+        "internal_api.SuspendUserRequest": {
             "type": "object",
+            "required": [
+                "reason"
+            ],
             "properties": {
-                "channel_id": {
-                    "type": "string"
-                },
-                "content": {
-                    "type": "string"
-                },
-                "created_at": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "string"
-                },
-                "user": {
-                    "type": "object",
-                    "properties": {
-                        "id": {
-                            "type": "string"
-                        },
-                        "username": {
-                            "type": "string"
-                        }
-                    }
+                "duration": {
+                    "description": "e.g., \"24h\", \"168h\"; omit to suspend until lifted",
+                    "type": "string",
+                    "example": "72h"
                 },
-                "user_id": {
-                    "type": "string"
+                "reason": {
+                    "type": "string",
+                    "example": "spamming across channels"
                 }
             }
         },
-        "internal_api.MessageResponse": {
+        "internal_api.SuspendUserResponse": {
             "type": "object",
             "properties": {
                 "message": {
                     "type": "string",
-                    "example": "Logged out"
-                }
-            }
-        },
-        "internal_api.MessageSearchResult": {
-            "type": "object",
-            "properties": {
-                "channel_id": {
-                    "type": "string"
-                },
-                "content": {
-                    "type": "string"
+                    "example": "User suspended successfully"
                 },
-                "created_at": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "string"
+                "reason": {
+                    "type": "string",
+                    "example": "spamming across channels"
                 },
-                "user": {
-                    "type": "object",
-                    "properties": {
-                        "id": {
-                            "type": "string"
-                        },
-                        "username": {
-                            "type": "string"
-                        }
-                    }
+                "suspended_until": {
+                    "type": "string",
+                    "example": "2023-01-04T00:00:00Z"
                 },
                 "user_id": {
-                    "type": "string"
+                    "type": "string",
+                    "example": "user123"
                 }
             }
         },
-        "internal_api.MessagesResponse": {
+        "internal_api.TempBanUserRequest": {
             "type": "object",
+            "required": [
+                "duration",
+                "user_id"
+            ],
             "properties": {
-                "has_more": {
-                    "type": "boolean"
+                "duration": {
+                    "description": "e.g., \"24h\", \"30m\"; from 1m to 8760h (1 year)",
+                    "type": "string",
+                    "example": "24h"
                 },
-                "messages": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/internal_api.MessageInfo"
-                    }
+                "reason": {
+                    "type": "string",
+                    "example": "timeout"
                 },
-                "total": {
-                    "type": "integer"
+                "user_id": {
+                    "type": "string",
+                    "example": "a1b2c3d4"
                 }
             }
         },
-        "internal_api.MessagesSearchResponse": {
+        "internal_api.UnreadCountsResponse": {
             "type": "object",
             "properties": {
-                "messages": {
+                "channels": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/internal_api.MessageSearchResult"
+                        "$ref": "#/definitions/internal_api.ChannelUnreadCount"
                     }
                 },
                 "total": {
-                    "type": "integer"
+                    "type": "integer",
+                    "example": 3
                 }
             }
         },
-        "internal_api.RoleUpdateRequest": {
+        "internal_api.UpdateChannelRequest": {
             "type": "object",
-            "required": [
-                "role",
-                "user_id"
-            ],
             "properties": {
-                "role": {
+                "allow_preview": {
+                    "description": "Let non-members preview recent history of a public channel",
+                    "type": "boolean",
+                    "example": true
+                },
+                "announce_membership": {
+                    "description": "Post \"\u003cuser\u003e joined/left/was banned\" system messages",
+                    "type": "boolean",
+                    "example": true
+                },
+                "description": {
+                    "description": "At most 500 characters; empty clears it",
                     "type": "string",
-                    "example": "Moderator"
+                    "example": "Anything goes"
                 },
-                "user_id": {
+                "hide_owner": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "max_members": {
+                    "description": "Includes the owner; 0 removes the cap",
+                    "type": "integer",
+                    "example": 10
+                },
+                "password": {
+                    "description": "New channel password; empty removes it",
                     "type": "string",
-                    "example": "abc12345"
+                    "example": "n3wSecret"
                 }
             }
         },
-        "internal_api.TempBanUserRequest": {
+        "internal_api.UpdateNotificationPrefRequest": {
             "type": "object",
             "required": [
-                "duration",
-                "user_id"
+                "mode"
             ],
             "properties": {
-                "duration": {
-                    "description": "e.g., \"24h\", \"30m\"",
-                    "type": "string",
-                    "example": "24h"
-                },
-                "reason": {
-                    "type": "string",
-                    "example": "timeout"
-                },
-                "user_id": {
+                "mode": {
                     "type": "string",
-                    "example": "a1b2c3d4"
+                    "enum": [
+                        "all",
+                        "mentions",
+                        "none"
+                    ],
+                    "example": "mentions"
                 }
             }
         },
@@ -2084,6 +5612,31 @@ const docTemplate = `{
                 }
             }
         },
+        "internal_api.UserProfileResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2023-01-01T00:00:00Z"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "a1b2c3d4"
+                },
+                "joined_channels": {
+                    "type": "integer",
+                    "example": 5
+                },
+                "owned_channels": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "username": {
+                    "type": "string",
+                    "example": "john_doe"
+                }
+            }
+        },
         "internal_api.UserRegisterInput": {
             "type": "object",
             "required": [
@@ -2139,8 +5692,29 @@ const docTemplate = `{
         "internal_api.UsersSearchResponse": {
             "type": "object",
             "properties": {
+                "has_next": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "has_prev": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "limit": {
+                    "type": "integer",
+                    "example": 20
+                },
+                "page": {
+                    "type": "integer",
+                    "example": 2
+                },
                 "total": {
-                    "type": "integer"
+                    "type": "integer",
+                    "example": 42
+                },
+                "total_pages": {
+                    "type": "integer",
+                    "example": 3
                 },
                 "users": {
                     "type": "array",
@@ -2149,6 +5723,43 @@ const docTemplate = `{
                     }
                 }
             }
+        },
+        "internal_api.ValidationErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "Invalid request"
+                },
+                "errors": {
+                    "description": "Field name to problem, for invalid request bodies",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "internal_api.VerifyChannelPasswordRequest": {
+            "type": "object",
+            "required": [
+                "password"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string",
+                    "example": "secretpass"
+                }
+            }
+        },
+        "internal_api.VerifyChannelPasswordResponse": {
+            "type": "object",
+            "properties": {
+                "valid": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
         }
     },
     "securityDefinitions": {
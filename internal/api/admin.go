@@ -0,0 +1,287 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	c "go-chat/internal/channel"
+	m "go-chat/internal/message"
+	u "go-chat/internal/user"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type AdminHandlers struct {
+	userService    *u.UserService
+	messageService *m.MessageService
+}
+
+func NewAdminHandlers(db *gorm.DB, members *c.MemberCache) *AdminHandlers {
+	return &AdminHandlers{
+		userService:    u.NewUserService(db, members),
+		messageService: m.NewMessageService(db, members),
+	}
+}
+
+// RequireAdmin rejects requests from users who are not system administrators.
+// It must run after the auth middleware has set the user_id.
+func (h *AdminHandlers) RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, exists := c.Get("user_id")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+			c.Abort()
+			return
+		}
+
+		isAdmin, err := h.userService.IsAdmin(userID.(string))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify admin access"})
+			c.Abort()
+			return
+		}
+		if !isAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+type ScrubMessageAuthorRequest struct {
+	Reason string `json:"reason" binding:"required" example:"compromised account"`
+}
+
+// ScrubMessageAuthorHandler re-attributes a message to the scrubbed placeholder author
+// @Summary Scrub a message's author
+// @Description Re-attribute a message to the "[scrubbed]" placeholder author, e.g. for messages sent from a compromised account (system admin only). The action is recorded in the audit log.
+// @Tags Administration
+// @Accept json
+// @Produce json
+// @Security CookieAuth
+// @Param id path string true "Message ID"
+// @Param request body ScrubMessageAuthorRequest true "Scrub message author request"
+// @Success 200 {object} CreateMessageResponse "Message author scrubbed successfully"
+// @Failure 400 {object} ErrorResponse "Bad request"
+// @Failure 401 {object} ErrorResponse "User not authenticated"
+// @Failure 403 {object} ErrorResponse "Admin access required"
+// @Failure 404 {object} ErrorResponse "Message not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/admin/messages/{id}/author [patch]
+func (h *AdminHandlers) ScrubMessageAuthorHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	messageID := c.Param("id")
+	if messageID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Message ID is required"})
+		return
+	}
+
+	var req ScrubMessageAuthorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	message, err := h.messageService.ScrubMessageAuthor(userID.(string), messageID, req.Reason)
+	if err != nil {
+		switch err.Error() {
+		case "admin access required":
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+		case "message not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": "Message not found"})
+		case "message author already scrubbed":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to scrub message author"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, CreateMessageResponse{Message: toMessageInfo(*message)})
+}
+
+// AdminUserInfo is a user as listed to system administrators. Password hashes are never included.
+type AdminUserInfo struct {
+	ID             string  `json:"id" example:"user123"`
+	Username       string  `json:"username" example:"johndoe"`
+	IsAdmin        bool    `json:"is_admin" example:"false"`
+	CreatedAt      string  `json:"created_at" example:"2023-01-01T00:00:00Z"`
+	Suspended      bool    `json:"suspended" example:"false"`
+	SuspendedUntil *string `json:"suspended_until" example:"2023-01-04T00:00:00Z"` // null unless a temporary suspension is in effect
+	OwnedChannels  int64   `json:"owned_channels" example:"2"`
+	JoinedChannels int64   `json:"joined_channels" example:"5"`
+}
+
+type AdminUsersResponse struct {
+	Users []AdminUserInfo `json:"users"`
+	PaginationMeta
+}
+
+// ListUsersHandler pages through all users
+// @Summary List users
+// @Description List all users by username with their suspension status and owned and joined channel counts (system admin only). Filter with q, matched case-insensitively anywhere in the username.
+// @Tags Administration
+// @Produce json
+// @Security CookieAuth
+// @Param q query string false "Username filter"
+// @Param page query int false "Page number (default: 1)"
+// @Param limit query int false "Users per page (default: 20, max: 100)"
+// @Success 200 {object} AdminUsersResponse "Users retrieved successfully"
+// @Failure 401 {object} ErrorResponse "User not authenticated"
+// @Failure 403 {object} ErrorResponse "Admin access required"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/admin/users [get]
+func (h *AdminHandlers) ListUsersHandler(c *gin.Context) {
+	page, limit, offset := parsePagination(c)
+
+	users, total, err := h.userService.ListUsers(c.Query("q"), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list users"})
+		return
+	}
+
+	now := time.Now()
+	infos := make([]AdminUserInfo, 0, len(users))
+	for _, user := range users {
+		info := AdminUserInfo{
+			ID:             user.ID,
+			Username:       user.Username,
+			IsAdmin:        user.IsAdmin,
+			CreatedAt:      user.CreatedAt.Format(time.RFC3339),
+			Suspended:      user.IsSuspendedAt(now),
+			OwnedChannels:  user.OwnedChannels,
+			JoinedChannels: user.JoinedChannels,
+		}
+		if info.Suspended && user.SuspendedUntil != nil {
+			suspendedUntil := user.SuspendedUntil.Format(time.RFC3339)
+			info.SuspendedUntil = &suspendedUntil
+		}
+		infos = append(infos, info)
+	}
+
+	c.JSON(http.StatusOK, AdminUsersResponse{
+		Users:          infos,
+		PaginationMeta: newPaginationMeta(total, page, limit),
+	})
+}
+
+type SuspendUserRequest struct {
+	Reason   string `json:"reason" binding:"required" example:"spamming across channels"`
+	Duration string `json:"duration,omitempty" example:"72h"` // e.g., "24h", "168h"; omit to suspend until lifted
+}
+
+type SuspendUserResponse struct {
+	Message        string  `json:"message" example:"User suspended successfully"`
+	UserID         string  `json:"user_id" example:"user123"`
+	Reason         string  `json:"reason" example:"spamming across channels"`
+	SuspendedUntil *string `json:"suspended_until" example:"2023-01-04T00:00:00Z"`
+}
+
+// SuspendUserHandler suspends an account server-wide
+// @Summary Suspend a user
+// @Description Suspend an account server-wide (system admin only). A suspended user cannot log in, refresh their session or make any authenticated request, including with API tokens. Pass a duration for a temporary suspension; omit it to suspend until lifted. System administrators cannot be suspended. The action is recorded in the audit log.
+// @Tags Administration
+// @Accept json
+// @Produce json
+// @Security CookieAuth
+// @Param id path string true "User ID"
+// @Param request body SuspendUserRequest true "Suspend user request"
+// @Success 200 {object} SuspendUserResponse "User suspended successfully"
+// @Failure 400 {object} ValidationErrorResponse "Bad request, invalid fields, or invalid duration format"
+// @Failure 401 {object} ErrorResponse "User not authenticated"
+// @Failure 403 {object} ErrorResponse "Admin access required or target is a system administrator"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/admin/users/{id}/suspend [post]
+func (h *AdminHandlers) SuspendUserHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req SuspendUserRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	var duration time.Duration
+	if req.Duration != "" {
+		var err error
+		duration, err = time.ParseDuration(req.Duration)
+		if err != nil || duration <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid duration format"})
+			return
+		}
+	}
+
+	user, err := h.userService.SuspendUser(userID.(string), c.Param("id"), req.Reason, duration)
+	if err != nil {
+		switch err.Error() {
+		case "user not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		case "cannot suspend a system administrator":
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case "invalid suspension duration":
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid duration format"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to suspend user"})
+		}
+		return
+	}
+
+	response := SuspendUserResponse{
+		Message: "User suspended successfully",
+		UserID:  user.ID,
+		Reason:  user.SuspendedReason,
+	}
+	if user.SuspendedUntil != nil {
+		suspendedUntil := user.SuspendedUntil.Format(time.RFC3339)
+		response.SuspendedUntil = &suspendedUntil
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// UnsuspendUserHandler lifts an account suspension
+// @Summary Lift a user suspension
+// @Description Lift a server-wide suspension before it expires (system admin only). The action is recorded in the audit log.
+// @Tags Administration
+// @Produce json
+// @Security CookieAuth
+// @Param id path string true "User ID"
+// @Success 200 {object} MessageResponse "User suspension lifted"
+// @Failure 400 {object} ErrorResponse "User is not suspended"
+// @Failure 401 {object} ErrorResponse "User not authenticated"
+// @Failure 403 {object} ErrorResponse "Admin access required"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/admin/users/{id}/suspend [delete]
+func (h *AdminHandlers) UnsuspendUserHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	if err := h.userService.UnsuspendUser(userID.(string), c.Param("id")); err != nil {
+		switch err.Error() {
+		case "user not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		case "user is not suspended":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to lift user suspension"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "User suspension lifted"})
+}
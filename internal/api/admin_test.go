@@ -0,0 +1,328 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	a "go-chat/internal/audit"
+	. "go-chat/pkg/chat"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupAdminTest(t *testing.T) (*gin.Engine, *gorm.DB) {
+	gin.SetMode(gin.TestMode)
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	err = db.AutoMigrate(&User{}, &RefreshToken{}, &Role{}, &Channel{}, &UserChannel{}, &UserBan{}, &Message{}, &AuditLog{})
+	if err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	router := gin.New()
+	NewRouter(db).RegisterRoutes(router)
+
+	return router, db
+}
+
+func TestAdminHandlers_ScrubMessageAuthorHandler(t *testing.T) {
+	router, db := setupAdminTest(t)
+
+	admin := &User{Username: "admin", Password: hashPasswordForTest("password123"), IsAdmin: true}
+	author := &User{Username: "author", Password: hashPasswordForTest("password123")}
+	require.NoError(t, db.Create(admin).Error)
+	require.NoError(t, db.Create(author).Error)
+
+	channel := &Channel{Name: "general", IsVisible: true, OwnerID: author.ID, LoggingDays: 30}
+	require.NoError(t, db.Create(channel).Error)
+
+	message := &Message{Content: "spam from a stolen account", UserID: author.ID, ChannelID: channel.ID}
+	require.NoError(t, db.Create(message).Error)
+
+	scrub := func(user *User, body string) *httptest.ResponseRecorder {
+		token, err := getAuthTokenForUser(user)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest("PATCH", "/api/admin/messages/"+message.ID+"/author", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(&http.Cookie{Name: "token", Value: token})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("non-admin is forbidden", func(t *testing.T) {
+		w := scrub(author, `{"reason": "hiding my tracks"}`)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		var stored Message
+		require.NoError(t, db.First(&stored, "id = ?", message.ID).Error)
+		assert.Equal(t, author.ID, stored.UserID)
+	})
+
+	t.Run("reason is required", func(t *testing.T) {
+		w := scrub(admin, `{}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("admin scrubs the author and the action is audited", func(t *testing.T) {
+		w := scrub(admin, `{"reason": "compromised account"}`)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response CreateMessageResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, ScrubbedUsername, response.Message.User.Username)
+
+		var stored Message
+		require.NoError(t, db.Preload("User").First(&stored, "id = ?", message.ID).Error)
+		assert.Equal(t, ScrubbedUsername, stored.User.Username)
+		assert.NotEqual(t, author.ID, stored.UserID)
+
+		var auditLog AuditLog
+		require.NoError(t, db.Where("action = ?", a.ActionScrubMessage).First(&auditLog).Error)
+		assert.Equal(t, admin.ID, auditLog.ActorID)
+		require.NotNil(t, auditLog.TargetID)
+		assert.Equal(t, author.ID, *auditLog.TargetID)
+		require.NotNil(t, auditLog.ChannelID)
+		assert.Equal(t, channel.ID, *auditLog.ChannelID)
+
+		var metadata map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(auditLog.Metadata), &metadata))
+		assert.Equal(t, "compromised account", metadata["reason"])
+		assert.Equal(t, message.ID, metadata["message_id"])
+	})
+
+	t.Run("scrubbing twice is rejected", func(t *testing.T) {
+		w := scrub(admin, `{"reason": "again"}`)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestAdminHandlers_ListUsersHandler(t *testing.T) {
+	router, db := setupAdminTest(t)
+
+	admin := &User{Username: "admin", Password: hashPasswordForTest("password123"), IsAdmin: true}
+	alice := &User{Username: "Alice", Password: hashPasswordForTest("password123")}
+	malik := &User{Username: "malik", Password: hashPasswordForTest("password123")}
+	bob := &User{Username: "bob", Password: hashPasswordForTest("password123")}
+	for _, user := range []*User{admin, alice, malik, bob} {
+		require.NoError(t, db.Create(user).Error)
+	}
+
+	general := &Channel{Name: "general", IsVisible: true, OwnerID: alice.ID}
+	random := &Channel{Name: "random", IsVisible: true, OwnerID: alice.ID}
+	closed := &Channel{Name: "closed", IsVisible: true, OwnerID: alice.ID}
+	for _, channel := range []*Channel{general, random, closed} {
+		require.NoError(t, db.Create(channel).Error)
+	}
+	for _, membership := range []UserChannel{
+		{UserID: alice.ID, ChannelID: general.ID},
+		{UserID: bob.ID, ChannelID: general.ID},
+		{UserID: bob.ID, ChannelID: random.ID},
+		{UserID: bob.ID, ChannelID: closed.ID},
+	} {
+		require.NoError(t, db.Create(&membership).Error)
+	}
+	require.NoError(t, db.Delete(closed).Error)
+
+	list := func(user *User, query string) *httptest.ResponseRecorder {
+		token, err := getAuthTokenForUser(user)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest("GET", "/api/admin/users"+query, nil)
+		req.AddCookie(&http.Cookie{Name: "token", Value: token})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	decode := func(w *httptest.ResponseRecorder) AdminUsersResponse {
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var response AdminUsersResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+
+	t.Run("non-admin is forbidden", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, list(bob, "").Code)
+	})
+
+	t.Run("admin lists every user with counts", func(t *testing.T) {
+		w := list(admin, "")
+		assert.NotContains(t, w.Body.String(), "password")
+		assert.NotContains(t, w.Body.String(), alice.Password)
+
+		response := decode(w)
+		assert.Equal(t, int64(4), response.Total)
+		require.Len(t, response.Users, 4)
+
+		byName := make(map[string]AdminUserInfo)
+		var names []string
+		for _, user := range response.Users {
+			byName[user.Username] = user
+			names = append(names, user.Username)
+		}
+		assert.Equal(t, []string{"Alice", "admin", "bob", "malik"}, names)
+		assert.True(t, byName["admin"].IsAdmin)
+		assert.NotEmpty(t, byName["bob"].CreatedAt)
+		// Deleted channels are not counted
+		assert.Equal(t, int64(2), byName["Alice"].OwnedChannels)
+		assert.Equal(t, int64(1), byName["Alice"].JoinedChannels)
+		assert.Equal(t, int64(2), byName["bob"].JoinedChannels)
+		assert.Equal(t, int64(0), byName["malik"].OwnedChannels)
+	})
+
+	t.Run("search filter", func(t *testing.T) {
+		response := decode(list(admin, "?q=LI"))
+		require.Len(t, response.Users, 2)
+		assert.Equal(t, "Alice", response.Users[0].Username)
+		assert.Equal(t, "malik", response.Users[1].Username)
+		assert.Equal(t, int64(2), response.Total)
+	})
+
+	t.Run("suspension status", func(t *testing.T) {
+		until := time.Now().Add(time.Hour).Truncate(time.Second)
+		require.NoError(t, db.Model(&User{}).Where("id = ?", malik.ID).
+			Updates(map[string]interface{}{"suspended_at": time.Now(), "suspended_until": until, "suspended_reason": "spam"}).Error)
+		require.NoError(t, db.Model(&User{}).Where("id = ?", alice.ID).
+			Updates(map[string]interface{}{"suspended_at": time.Now().Add(-2 * time.Hour), "suspended_until": time.Now().Add(-time.Hour)}).Error)
+
+		byName := make(map[string]AdminUserInfo)
+		for _, user := range decode(list(admin, "")).Users {
+			byName[user.Username] = user
+		}
+		assert.True(t, byName["malik"].Suspended)
+		require.NotNil(t, byName["malik"].SuspendedUntil)
+		assert.Equal(t, until.Format(time.RFC3339), *byName["malik"].SuspendedUntil)
+		assert.False(t, byName["bob"].Suspended)
+		assert.Nil(t, byName["bob"].SuspendedUntil)
+		// An expired suspension no longer counts
+		assert.False(t, byName["Alice"].Suspended)
+		assert.Nil(t, byName["Alice"].SuspendedUntil)
+	})
+
+	t.Run("pagination", func(t *testing.T) {
+		response := decode(list(admin, "?limit=3&page=2"))
+		require.Len(t, response.Users, 1)
+		assert.Equal(t, "malik", response.Users[0].Username)
+		assert.Equal(t, 2, response.TotalPages)
+		assert.True(t, response.HasPrev)
+		assert.False(t, response.HasNext)
+	})
+}
+
+func TestAdminHandlers_SuspendUser(t *testing.T) {
+	router, db := setupAdminTest(t)
+
+	admin := &User{Username: "admin", Password: hashPasswordForTest("password123"), IsAdmin: true}
+	spammer := &User{Username: "spammer", Password: hashPasswordForTest("password123")}
+	bystander := &User{Username: "bystander", Password: hashPasswordForTest("password123")}
+	for _, user := range []*User{admin, spammer, bystander} {
+		require.NoError(t, db.Create(user).Error)
+	}
+
+	request := func(user *User, method, path, body string) *httptest.ResponseRecorder {
+		token, err := getAuthTokenForUser(user)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(&http.Cookie{Name: "token", Value: token})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	suspendPath := "/api/admin/users/" + spammer.ID + "/suspend"
+	login := func(username string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/login", strings.NewReader(`{"username":"`+username+`","password":"password123"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("non-admin is forbidden", func(t *testing.T) {
+		w := request(bystander, "POST", suspendPath, `{"reason":"nope"}`)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, http.StatusOK, request(spammer, "GET", "/api/auth/session", "").Code)
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		w := request(admin, "POST", suspendPath, `{}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		var invalid ValidationErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &invalid))
+		assert.Equal(t, map[string]string{"reason": "is required"}, invalid.Errors)
+		assert.Equal(t, http.StatusBadRequest, request(admin, "POST", suspendPath, `{"reason":"spam","duration":"soon"}`).Code)
+		assert.Equal(t, http.StatusNotFound, request(admin, "POST", "/api/admin/users/missing/suspend", `{"reason":"spam"}`).Code)
+		assert.Equal(t, http.StatusForbidden, request(admin, "POST", "/api/admin/users/"+admin.ID+"/suspend", `{"reason":"spam"}`).Code)
+		assert.Equal(t, http.StatusBadRequest, request(admin, "DELETE", suspendPath, "").Code)
+	})
+
+	t.Run("permanent suspension rejects a valid token", func(t *testing.T) {
+		w := request(admin, "POST", suspendPath, `{"reason":"spamming across channels"}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response SuspendUserResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, spammer.ID, response.UserID)
+		assert.Nil(t, response.SuspendedUntil)
+
+		w = request(spammer, "GET", "/api/auth/session", "")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, "Account suspended", body["error"])
+		assert.Equal(t, "spamming across channels", body["reason"])
+		assert.Nil(t, body["suspended_until"])
+
+		assert.Equal(t, http.StatusForbidden, request(spammer, "POST", "/api/refresh_token", "").Code)
+		assert.Equal(t, http.StatusForbidden, login("spammer").Code)
+		assert.Equal(t, http.StatusOK, login("bystander").Code)
+
+		var logs []AuditLog
+		require.NoError(t, db.Where("action = ?", a.ActionSuspendUser).Find(&logs).Error)
+		require.Len(t, logs, 1)
+		assert.Equal(t, spammer.ID, *logs[0].TargetID)
+	})
+
+	t.Run("lifting a suspension restores access", func(t *testing.T) {
+		w := request(admin, "DELETE", suspendPath, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		assert.Equal(t, http.StatusOK, request(spammer, "GET", "/api/auth/session", "").Code)
+		assert.Equal(t, http.StatusOK, login("spammer").Code)
+	})
+
+	t.Run("temporary suspension expires", func(t *testing.T) {
+		w := request(admin, "POST", suspendPath, `{"reason":"cool off","duration":"1h"}`)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response SuspendUserResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.NotNil(t, response.SuspendedUntil)
+
+		w = request(spammer, "GET", "/api/auth/session", "")
+		require.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), *response.SuspendedUntil)
+
+		require.NoError(t, db.Model(&User{}).Where("id = ?", spammer.ID).
+			Update("suspended_until", time.Now().Add(-time.Minute)).Error)
+		assert.Equal(t, http.StatusOK, request(spammer, "GET", "/api/auth/session", "").Code)
+	})
+}
@@ -3,7 +3,6 @@ package api
 import (
 	"encoding/json"
 	"net/http"
-	"strconv"
 
 	a "go-chat/internal/audit"
 	"github.com/gin-gonic/gin"
@@ -44,10 +43,17 @@ type AuditLogResponse struct {
 }
 
 type AuditLogsResponse struct {
-	Logs  []AuditLogResponse `json:"logs"`
-	Total int64              `json:"total"`
-	Page  int                `json:"page"`
-	Limit int                `json:"limit"`
+	Logs []AuditLogResponse `json:"logs"`
+	PaginationMeta
+}
+
+// queryFilter returns the named query parameter as an audit log filter, nil when absent
+func queryFilter(c *gin.Context, name string) *string {
+	value := c.Query(name)
+	if value == "" {
+		return nil
+	}
+	return &value
 }
 
 // GetChannelAuditLogsHandler gets audit logs for a specific channel
@@ -58,6 +64,8 @@ type AuditLogsResponse struct {
 // @Produce json
 // @Security CookieAuth
 // @Param id path string true "Channel ID"
+// @Param actor_id query string false "Filter by actor ID"
+// @Param action query string false "Filter by action type (e.g. BAN_USER)"
 // @Param page query int false "Page number (default: 1)"
 // @Param limit query int false "Number of results per page (default: 20, max: 100)"
 // @Success 200 {object} AuditLogsResponse "Audit logs retrieved successfully"
@@ -80,29 +88,17 @@ func (h *AuditHandlers) GetChannelAuditLogsHandler(c *gin.Context) {
 		return
 	}
 
-	// Parse pagination parameters
-	pageStr := c.DefaultQuery("page", "1")
-	page, err := strconv.Atoi(pageStr)
-	if err != nil || page < 1 {
-		page = 1
-	}
-
-	limitStr := c.DefaultQuery("limit", "20")
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit <= 0 {
-		limit = 20
-	}
-	if limit > 100 {
-		limit = 100
-	}
-
-	offset := (page - 1) * limit
+	page, limit, offset := parsePagination(c)
 
 	// Get audit logs for the channel
-	logs, total, err := h.service.GetChannelAuditLogs(userID.(string), channelID, limit, offset)
+	logs, total, err := h.service.GetChannelAuditLogs(userID.(string), channelID, queryFilter(c, "actor_id"), queryFilter(c, "action"), limit, offset)
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Channel not found or access denied"})
+		if err.Error() == "channel not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Channel not found"})
+			return
+		}
+		if err.Error() == "only channel owners can view audit logs" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only channel owners can view audit logs"})
 			return
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve audit logs"})
@@ -165,10 +161,8 @@ func (h *AuditHandlers) GetChannelAuditLogsHandler(c *gin.Context) {
 	}
 
 	response := AuditLogsResponse{
-		Logs:  auditLogs,
-		Total: total,
-		Page:  page,
-		Limit: limit,
+		Logs:           auditLogs,
+		PaginationMeta: newPaginationMeta(total, page, limit),
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -203,43 +197,10 @@ func (h *AuditHandlers) GetAuditLogsHandler(c *gin.Context) {
 	// For now, this endpoint is available to all authenticated users
 	// In production, you would want to check if user has admin role
 
-	// Parse filter parameters
-	channelID := c.Query("channel_id")
-	actorID := c.Query("actor_id")
-	action := c.Query("action")
-
-	// Parse pagination parameters
-	pageStr := c.DefaultQuery("page", "1")
-	page, err := strconv.Atoi(pageStr)
-	if err != nil || page < 1 {
-		page = 1
-	}
-
-	limitStr := c.DefaultQuery("limit", "20")
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit <= 0 {
-		limit = 20
-	}
-	if limit > 100 {
-		limit = 100
-	}
-
-	offset := (page - 1) * limit
-
-	// Prepare filter pointers
-	var channelFilter, actorFilter, actionFilter *string
-	if channelID != "" {
-		channelFilter = &channelID
-	}
-	if actorID != "" {
-		actorFilter = &actorID
-	}
-	if action != "" {
-		actionFilter = &action
-	}
+	page, limit, offset := parsePagination(c)
 
 	// Get audit logs with filters
-	logs, total, err := h.service.GetAuditLogs(channelFilter, actorFilter, actionFilter, limit, offset)
+	logs, total, err := h.service.GetAuditLogs(queryFilter(c, "channel_id"), queryFilter(c, "actor_id"), queryFilter(c, "action"), limit, offset)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve audit logs"})
 		return
@@ -300,10 +261,8 @@ func (h *AuditHandlers) GetAuditLogsHandler(c *gin.Context) {
 	}
 
 	response := AuditLogsResponse{
-		Logs:  auditLogs,
-		Total: total,
-		Page:  page,
-		Limit: limit,
+		Logs:           auditLogs,
+		PaginationMeta: newPaginationMeta(total, page, limit),
 	}
 
 	c.JSON(http.StatusOK, response)
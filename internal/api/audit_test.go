@@ -80,7 +80,7 @@ func TestAuditHandlers_GetChannelAuditLogsHandler_Success(t *testing.T) {
 	// Setup handler
 	ah := NewAuditHandlers(db)
 	router := gin.New()
-	am := auth.NewAuthMiddleware()
+	am := auth.NewAuthMiddleware(db)
 	router.GET("/api/channels/:id/audit", am.RequireAuth(), ah.GetChannelAuditLogsHandler)
 	
 	// Create request
@@ -150,12 +150,38 @@ func TestAuditHandlers_GetChannelAuditLogsHandler_NonOwnerAccess(t *testing.T) {
 	ah.GetChannelAuditLogsHandler(c)
 	
 	// Assert response
-	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, http.StatusForbidden, w.Code)
 	
 	var response map[string]interface{}
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	require.NoError(t, err)
-	assert.Equal(t, "Channel not found or access denied", response["error"])
+	assert.Equal(t, "Only channel owners can view audit logs", response["error"])
+}
+
+func TestAuditHandlers_GetChannelAuditLogsHandler_ChannelNotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := setupAuditTestDB(t)
+
+	user := &User{Username: "owner", Password: hashPasswordForAuditTest("password123")}
+	require.NoError(t, db.Create(user).Error)
+
+	ah := NewAuditHandlers(db)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", "/api/channels/missing-channel/audit", nil)
+	c.Params = gin.Params{{Key: "id", Value: "missing-channel"}}
+	c.Set("user_id", user.ID)
+
+	ah.GetChannelAuditLogsHandler(c)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	var response map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	require.NoError(t, err)
+	assert.Equal(t, "Channel not found", response["error"])
 }
 
 func TestAuditHandlers_GetChannelAuditLogsHandler_WithPagination(t *testing.T) {
@@ -211,6 +237,9 @@ func TestAuditHandlers_GetChannelAuditLogsHandler_WithPagination(t *testing.T) {
 	assert.Len(t, response.Logs, 3)
 	assert.Equal(t, 1, response.Page)
 	assert.Equal(t, 3, response.Limit)
+	assert.Equal(t, 2, response.TotalPages)
+	assert.True(t, response.HasNext)
+	assert.False(t, response.HasPrev)
 }
 
 func TestAuditHandlers_GetAuditLogsHandler_WithFilters(t *testing.T) {
@@ -278,6 +307,74 @@ func TestAuditHandlers_GetAuditLogsHandler_WithFilters(t *testing.T) {
 	assert.Len(t, response.Logs, 2)
 }
 
+func TestAuditHandlers_GetChannelAuditLogsHandler_WithFilters(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := setupAuditTestDB(t)
+
+	owner := &User{Username: "owner", Password: hashPasswordForAuditTest("password123")}
+	moderator := &User{Username: "moderator", Password: hashPasswordForAuditTest("password123")}
+	require.NoError(t, db.Create(owner).Error)
+	require.NoError(t, db.Create(moderator).Error)
+
+	channel := &Channel{Name: "channel1", IsVisible: true, OwnerID: owner.ID}
+	other := &Channel{Name: "channel2", IsVisible: true, OwnerID: owner.ID}
+	require.NoError(t, db.Create(channel).Error)
+	require.NoError(t, db.Create(other).Error)
+
+	logs := []AuditLog{
+		{Action: "BAN_USER", ActorID: owner.ID, ChannelID: &channel.ID, Description: "Owner banned a user", Metadata: "{}"},
+		{Action: "BAN_USER", ActorID: moderator.ID, ChannelID: &channel.ID, Description: "Moderator banned a user", Metadata: "{}"},
+		{Action: "UNBAN_USER", ActorID: moderator.ID, ChannelID: &channel.ID, Description: "Moderator unbanned a user", Metadata: "{}"},
+		{Action: "BAN_USER", ActorID: moderator.ID, ChannelID: &other.ID, Description: "Ban in another channel", Metadata: "{}"},
+	}
+	for _, log := range logs {
+		require.NoError(t, db.Create(&log).Error)
+	}
+
+	ah := NewAuditHandlers(db)
+
+	tests := []struct {
+		name         string
+		query        string
+		requester    string
+		expectedCode int
+		expected     []string
+	}{
+		{"by action", "?action=BAN_USER", owner.ID, http.StatusOK, []string{"Owner banned a user", "Moderator banned a user"}},
+		{"by actor", "?actor_id=" + moderator.ID, owner.ID, http.StatusOK, []string{"Moderator banned a user", "Moderator unbanned a user"}},
+		{"by action and actor", "?action=UNBAN_USER&actor_id=" + moderator.ID, owner.ID, http.StatusOK, []string{"Moderator unbanned a user"}},
+		{"filters keep the owner-only gate", "?actor_id=" + moderator.ID, moderator.ID, http.StatusForbidden, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("GET", fmt.Sprintf("/api/channels/%s/audit%s", channel.ID, tt.query), nil)
+			c.Params = gin.Params{{Key: "id", Value: channel.ID}}
+			c.Set("user_id", tt.requester)
+
+			ah.GetChannelAuditLogsHandler(c)
+
+			require.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedCode != http.StatusOK {
+				return
+			}
+
+			var response AuditLogsResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, int64(len(tt.expected)), response.Total)
+
+			var descriptions []string
+			for _, log := range response.Logs {
+				descriptions = append(descriptions, log.Description)
+			}
+			assert.ElementsMatch(t, tt.expected, descriptions)
+		})
+	}
+}
+
 func TestAuditHandlers_GetAuditLogsHandler_NoAuth(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	
@@ -1,7 +1,8 @@
 package api
 
 import (
-	"fmt"
+	"errors"
+	"time"
 
 	. "go-chat/internal/auth"
 
@@ -40,13 +41,14 @@ type ErrorResponse struct {
 
 // RegisterHandler registers a new user
 // @Summary Register a new user
-// @Description Register a new user with username and password
+// @Description Register a new user with username and password. Passwords longer than 72 bytes are rejected with "password too long".
 // @Tags Authentication
 // @Accept json
 // @Produce json
 // @Param request body UserRegisterInput true "Registration request"
 // @Success 200 {object} AuthResponse "User registered successfully"
 // @Failure 400 {object} ErrorResponse "Bad request"
+// @Failure 409 {object} ErrorResponse "Username already exists"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /register [post]
 func (h *AuthHandlers) RegisterHandler(c *gin.Context) {
@@ -57,6 +59,10 @@ func (h *AuthHandlers) RegisterHandler(c *gin.Context) {
 	}
 	user, err := h.authService.Register(input.Username, input.Password)
 	if err != nil {
+		if err.Error() == "username already exists" {
+			c.JSON(409, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
@@ -67,7 +73,7 @@ func (h *AuthHandlers) RegisterHandler(c *gin.Context) {
 		return
 	}
 
-	refreshToken, err := h.authService.CreateRefreshToken(user.ID)
+	refreshToken, err := h.authService.CreateRefreshToken(user.ID, c.Request.UserAgent())
 	if err != nil {
 		c.JSON(500, gin.H{"error": "User created but refresh token generation failed"})
 		return
@@ -99,6 +105,7 @@ type UserLoginInput struct {
 // @Param request body UserLoginInput true "Login request"
 // @Success 200 {object} AuthResponse "User logged in successfully"
 // @Failure 400 {object} ErrorResponse "Invalid credentials"
+// @Failure 403 {object} ErrorResponse "Account suspended"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /login [post]
 func (h *AuthHandlers) LoginHandler(c *gin.Context) {
@@ -109,11 +116,16 @@ func (h *AuthHandlers) LoginHandler(c *gin.Context) {
 	}
 	user, err := h.authService.Login(input.Username, input.Password)
 	if err != nil {
+		var suspended *SuspendedError
+		if errors.As(err, &suspended) {
+			c.JSON(403, SuspendedResponse(suspended))
+			return
+		}
 		c.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
 
-	refreshToken, err := h.authService.CreateRefreshToken(user.ID)
+	refreshToken, err := h.authService.CreateRefreshToken(user.ID, c.Request.UserAgent())
 	if err != nil {
 		c.JSON(500, gin.H{"error": "User created but refresh token generation failed"})
 		return
@@ -170,12 +182,11 @@ func (h *AuthHandlers) LogoutHandler(c *gin.Context) {
 // @Security CookieAuth
 // @Success 200 {object} MessageResponse "Token refreshed successfully"
 // @Failure 401 {object} ErrorResponse "Invalid or missing refresh token"
+// @Failure 403 {object} ErrorResponse "Account suspended"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /api/refresh_token [post]
 func (h *AuthHandlers) RefreshTokenHandler(c *gin.Context) {
 	refreshToken, err := c.Cookie("refresh_token")
-
-	fmt.Println(refreshToken)
 	if err != nil {
 		c.JSON(401, gin.H{"error": "No refresh token"})
 		return
@@ -183,6 +194,11 @@ func (h *AuthHandlers) RefreshTokenHandler(c *gin.Context) {
 
 	user, err := h.authService.ValidateRefreshToken(refreshToken)
 	if err != nil {
+		var suspended *SuspendedError
+		if errors.As(err, &suspended) {
+			c.JSON(403, SuspendedResponse(suspended))
+			return
+		}
 		c.JSON(401, gin.H{"error": "Invalid refresh token"})
 		return
 	}
@@ -197,3 +213,38 @@ func (h *AuthHandlers) RefreshTokenHandler(c *gin.Context) {
 	c.SetCookie("token", newJWT, 3600*24, "/", "", true, true)
 	c.JSON(200, gin.H{"message": "Token refreshed"})
 }
+
+type SessionResponse struct {
+	ServerTime            string  `json:"server_time" example:"2023-01-01T12:00:00.123456789Z"`
+	TokenExpiresAt        *string `json:"token_expires_at" example:"2023-01-02T12:00:00Z"`
+	RefreshTokenExpiresAt *string `json:"refresh_token_expires_at" example:"2023-01-08T12:00:00Z"`
+}
+
+// SessionHandler returns session timing information
+// @Summary Get session info
+// @Description Get the server time along with the expiry of the current access token and refresh token, so clients can correct for clock skew and schedule refreshes. Expiries are null when not applicable (e.g. API token authentication or no refresh token cookie).
+// @Tags Authentication
+// @Produce json
+// @Security CookieAuth
+// @Success 200 {object} SessionResponse "Session info"
+// @Failure 401 {object} ErrorResponse "User not authenticated"
+// @Router /api/auth/session [get]
+func (h *AuthHandlers) SessionHandler(c *gin.Context) {
+	response := SessionResponse{
+		ServerTime: time.Now().UTC().Format(time.RFC3339Nano),
+	}
+
+	if expiresAt, exists := c.Get("token_expires_at"); exists {
+		formatted := expiresAt.(time.Time).UTC().Format(time.RFC3339)
+		response.TokenExpiresAt = &formatted
+	}
+
+	if refreshToken, err := c.Cookie("refresh_token"); err == nil && refreshToken != "" {
+		if expiresAt, err := h.authService.GetRefreshTokenExpiry(refreshToken); err == nil {
+			formatted := expiresAt.UTC().Format(time.RFC3339)
+			response.RefreshTokenExpiresAt = &formatted
+		}
+	}
+
+	c.JSON(200, response)
+}
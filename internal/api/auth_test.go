@@ -6,7 +6,9 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"go-chat/internal/auth"
 	. "go-chat/pkg/chat"
 	"github.com/gin-gonic/gin"
 	"gorm.io/driver/sqlite"
@@ -19,7 +21,7 @@ func setupTestDB(t *testing.T) *gorm.DB {
 		t.Fatalf("Failed to connect to database: %v", err)
 	}
 
-	err = db.AutoMigrate(&User{}, &RefreshToken{}, &Role{}, &Channel{}, &UserChannel{}, &UserBan{})
+	err = db.AutoMigrate(&User{}, &RefreshToken{}, &ApiToken{}, &Role{}, &Channel{}, &UserChannel{}, &UserBan{})
 	if err != nil {
 		t.Fatalf("Failed to migrate database: %v", err)
 	}
@@ -84,6 +86,25 @@ func TestAuthHandlers_RegisterHandler(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "duplicate username",
+			requestBody: UserRegisterInput{
+				Username: "testuser",
+				Password: "otherpassword",
+			},
+			expectedStatus: 409,
+			checkResponse: func(t *testing.T, body []byte) {
+				var response map[string]interface{}
+				if err := json.Unmarshal(body, &response); err != nil {
+					t.Errorf("Failed to parse response: %v", err)
+					return
+				}
+
+				if response["error"] != "username already exists" {
+					t.Errorf("Expected conflict error, got: %v", response["error"])
+				}
+			},
+		},
 		{
 			name: "empty username",
 			requestBody: UserRegisterInput{
@@ -465,4 +486,124 @@ func TestAuthHandlers_RefreshTokenHandler(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+func TestAuthHandlers_SessionHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := setupTestDB(t)
+	router := gin.New()
+	NewRouter(db).RegisterRoutes(router)
+
+	registerReq := UserRegisterInput{
+		Username: "sessionuser",
+		Password: "testpassword",
+	}
+	reqBody, _ := json.Marshal(registerReq)
+	req, _ := http.NewRequest("POST", "/register", bytes.NewBuffer(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != 200 {
+		t.Fatalf("Failed to create test user: %d", w.Code)
+	}
+
+	var token, refreshToken string
+	for _, cookie := range w.Result().Cookies() {
+		switch cookie.Name {
+		case "token":
+			token = cookie.Value
+		case "refresh_token":
+			refreshToken = cookie.Value
+		}
+	}
+
+	claims, err := auth.ValidateToken(token)
+	if err != nil {
+		t.Fatalf("Failed to parse issued token: %v", err)
+	}
+	exp, err := claims.GetExpirationTime()
+	if err != nil {
+		t.Fatalf("Issued token has no expiry: %v", err)
+	}
+
+	var storedRefresh RefreshToken
+	if err := db.First(&storedRefresh).Error; err != nil {
+		t.Fatalf("Failed to load refresh token: %v", err)
+	}
+
+	t.Run("returns token and refresh token expiry", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/auth/session", nil)
+		req.AddCookie(&http.Cookie{Name: "token", Value: token})
+		req.AddCookie(&http.Cookie{Name: "refresh_token", Value: refreshToken})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != 200 {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+
+		var response SessionResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+
+		if response.TokenExpiresAt == nil {
+			t.Fatalf("Expected token_expires_at to be set")
+		}
+		expiresAt, err := time.Parse(time.RFC3339, *response.TokenExpiresAt)
+		if err != nil {
+			t.Fatalf("Invalid token_expires_at: %v", err)
+		}
+		if !expiresAt.Equal(exp.Time) {
+			t.Errorf("Expected token expiry %v, got %v", exp.Time, expiresAt)
+		}
+
+		if response.RefreshTokenExpiresAt == nil {
+			t.Fatalf("Expected refresh_token_expires_at to be set")
+		}
+		refreshExpiresAt, err := time.Parse(time.RFC3339, *response.RefreshTokenExpiresAt)
+		if err != nil {
+			t.Fatalf("Invalid refresh_token_expires_at: %v", err)
+		}
+		if refreshExpiresAt.Unix() != storedRefresh.ExpiresAt {
+			t.Errorf("Expected refresh expiry %d, got %d", storedRefresh.ExpiresAt, refreshExpiresAt.Unix())
+		}
+
+		serverTime, err := time.Parse(time.RFC3339Nano, response.ServerTime)
+		if err != nil {
+			t.Fatalf("Invalid server_time: %v", err)
+		}
+		if time.Since(serverTime) > time.Minute {
+			t.Errorf("Expected server_time to be current, got %v", serverTime)
+		}
+	})
+
+	t.Run("omits refresh expiry without refresh cookie", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/auth/session", nil)
+		req.AddCookie(&http.Cookie{Name: "token", Value: token})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != 200 {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+
+		var response SessionResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		if response.RefreshTokenExpiresAt != nil {
+			t.Errorf("Expected no refresh expiry, got %v", *response.RefreshTokenExpiresAt)
+		}
+	})
+
+	t.Run("requires authentication", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/auth/session", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != 401 {
+			t.Errorf("Expected status 401, got %d", w.Code)
+		}
+	})
+}
@@ -0,0 +1,208 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"go-chat/pkg/chat"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GroupByCategory is the group_by value that groups channel listings by category
+const GroupByCategory = "category"
+
+type CreateCategoryRequest struct {
+	Name   string `json:"name" binding:"required" example:"Dev"`
+	Global bool   `json:"global,omitempty" example:"false"` // Visible to everyone; system administrators only
+}
+
+// SetChannelCategoryRequest assigns a channel to a category; a null category_id removes it
+type SetChannelCategoryRequest struct {
+	CategoryID *uint `json:"category_id" example:"1"`
+}
+
+type CategoryInfo struct {
+	ID        uint   `json:"id" example:"1"`
+	Name      string `json:"name" example:"Dev"`
+	IsGlobal  bool   `json:"is_global" example:"true"`
+	CreatedAt string `json:"created_at" example:"2023-01-01T00:00:00Z"`
+}
+
+type CategoryResponse struct {
+	Category CategoryInfo `json:"category"`
+}
+
+type CategoriesResponse struct {
+	Categories []CategoryInfo `json:"categories"`
+}
+
+// ChannelGroup lists the channels of one category; Category is null for uncategorized channels
+type ChannelGroup struct {
+	Category *CategoryInfo `json:"category"`
+	Channels []ChannelInfo `json:"channels"`
+}
+
+func toCategoryInfo(category chat.ChannelCategory) CategoryInfo {
+	return CategoryInfo{
+		ID:        category.ID,
+		Name:      category.Name,
+		IsGlobal:  category.OwnerID == nil,
+		CreatedAt: category.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// groupByCategory splits a page of channels, ordered by category, into one group
+// per category. The channels must have their category loaded.
+func groupByCategory(channels []chat.Channel, infos []ChannelInfo) []ChannelGroup {
+	groups := []ChannelGroup{}
+	for i, channel := range channels {
+		last := len(groups) - 1
+		if last < 0 || !sameCategory(groups[last].Category, channel.CategoryID) {
+			group := ChannelGroup{Channels: []ChannelInfo{}}
+			if channel.Category != nil {
+				category := toCategoryInfo(*channel.Category)
+				group.Category = &category
+			}
+			groups = append(groups, group)
+			last++
+		}
+		groups[last].Channels = append(groups[last].Channels, infos[i])
+	}
+	return groups
+}
+
+func sameCategory(category *CategoryInfo, categoryID *uint) bool {
+	if category == nil || categoryID == nil {
+		return category == nil && categoryID == nil
+	}
+	return category.ID == *categoryID
+}
+
+// CreateCategoryHandler creates a channel category
+// @Summary Create a channel category
+// @Description Create a category to group channels under. Categories belong to their creator unless global is set, which only system administrators can do. Names are unique among the global categories and among each user's own.
+// @Tags Channels
+// @Accept json
+// @Produce json
+// @Security CookieAuth
+// @Param request body CreateCategoryRequest true "Create category request"
+// @Success 201 {object} CategoryResponse "Category created successfully"
+// @Failure 400 {object} ValidationErrorResponse "Bad request or invalid fields"
+// @Failure 401 {object} ErrorResponse "User not authenticated"
+// @Failure 403 {object} ErrorResponse "Only administrators can create global categories"
+// @Failure 409 {object} ErrorResponse "Category already exists"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/categories [post]
+func (h *ChannelHandlers) CreateCategoryHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req CreateCategoryRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	category, err := h.service.CreateCategory(userID.(string), req.Name, req.Global)
+	if err != nil {
+		switch err.Error() {
+		case "category name cannot be empty", "category name is too long":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case "only administrators can create global categories":
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case "category already exists":
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create category"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, CategoryResponse{Category: toCategoryInfo(*category)})
+}
+
+// GetCategoriesHandler lists the categories the user can assign channels to
+// @Summary List channel categories
+// @Description List the global categories and the authenticated user's own, by name
+// @Tags Channels
+// @Produce json
+// @Security CookieAuth
+// @Success 200 {object} CategoriesResponse "List of categories"
+// @Failure 401 {object} ErrorResponse "User not authenticated"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/categories [get]
+func (h *ChannelHandlers) GetCategoriesHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	categories, err := h.service.GetCategories(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch categories"})
+		return
+	}
+
+	categoryList := make([]CategoryInfo, 0, len(categories))
+	for _, category := range categories {
+		categoryList = append(categoryList, toCategoryInfo(category))
+	}
+
+	c.JSON(http.StatusOK, CategoriesResponse{Categories: categoryList})
+}
+
+// SetChannelCategoryHandler assigns a channel to a category
+// @Summary Assign channel category
+// @Description Assign the channel to a global category or one of the owner's own, or remove it from its category with a null category_id (only channel owner)
+// @Tags Channels
+// @Accept json
+// @Produce json
+// @Security CookieAuth
+// @Param id path string true "Channel ID"
+// @Param request body SetChannelCategoryRequest true "Category assignment"
+// @Success 200 {object} ChannelResponse "Channel category updated"
+// @Failure 400 {object} ErrorResponse "Bad request"
+// @Failure 401 {object} ErrorResponse "User not authenticated"
+// @Failure 403 {object} ErrorResponse "Only channel owner can assign a category"
+// @Failure 404 {object} ErrorResponse "Channel or category not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/channels/{id}/category [put]
+func (h *ChannelHandlers) SetChannelCategoryHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	channelID := c.Param("id")
+	if channelID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Channel ID required"})
+		return
+	}
+
+	var req SetChannelCategoryRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	channel, err := h.service.SetChannelCategory(userID.(string), channelID, req.CategoryID)
+	if err != nil {
+		switch err.Error() {
+		case "channel not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": "Channel not found"})
+		case "category not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		case "only channel owner can assign a category":
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update channel category"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, ChannelResponse{Channel: toChannelInfo(*channel)})
+}
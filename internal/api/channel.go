@@ -1,49 +1,150 @@
 package api
 
 import (
+	"errors"
+	"io"
 	"net/http"
+	"os"
 	"time"
 
 	c "go-chat/internal/channel"
+	"go-chat/pkg/chat"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 type ChannelHandlers struct {
 	service *c.ChannelService
+	// Require deletions to repeat the channel name in the body
+	confirmDeletion bool
 }
 
-func NewChannelHandlers(db *gorm.DB) *ChannelHandlers {
+func NewChannelHandlers(db *gorm.DB, members *c.MemberCache) *ChannelHandlers {
 	return &ChannelHandlers{
-		service: c.NewChannelService(db),
+		service:         c.NewChannelService(db, members),
+		confirmDeletion: DeleteConfirmationFromEnv(),
 	}
 }
 
+// DeleteConfirmationFromEnv reports whether channel deletions must be confirmed
+// with the channel name, enabled by REQUIRE_DELETE_CONFIRMATION=true
+func DeleteConfirmationFromEnv() bool {
+	return os.Getenv("REQUIRE_DELETE_CONFIRMATION") == "true"
+}
+
+// SetDeleteConfirmation overrides whether channel deletions must be confirmed
+func (h *ChannelHandlers) SetDeleteConfirmation(required bool) {
+	h.confirmDeletion = required
+}
+
 type CreateChannelRequest struct {
-	Name      string  `json:"name" binding:"required" example:"general"`
-	Password  *string `json:"password,omitempty" example:"secretpass"`
-	IsVisible bool    `json:"is_visible" example:"true"`
+	Name        string  `json:"name" binding:"required" example:"general"`
+	Password    *string `json:"password,omitempty" example:"secretpass"`
+	IsVisible   bool    `json:"is_visible" example:"true"`
+	LoggingDays *int    `json:"logging_days,omitempty" example:"30"` // 0 disables history, max 365, default 30
+}
+
+type UpdateChannelRequest struct {
+	HideOwner    *bool   `json:"hide_owner,omitempty" example:"true"`
+	MaxMembers   *int    `json:"max_members,omitempty" example:"10"`     // Includes the owner; 0 removes the cap
+	AllowPreview *bool   `json:"allow_preview,omitempty" example:"true"` // Let non-members preview recent history of a public channel
+	Password     *string `json:"password,omitempty" example:"n3wSecret"` // New channel password; empty removes it
+
+	AnnounceMembership *bool   `json:"announce_membership,omitempty" example:"true"` // Post "<user> joined/left/was banned" system messages
+	Description        *string `json:"description,omitempty" example:"Anything goes"` // At most 500 characters; empty clears it
+}
+
+// toService converts the API request to the service request
+func (r UpdateChannelRequest) toService() c.UpdateChannelRequest {
+	return c.UpdateChannelRequest{
+		HideOwner:    r.HideOwner,
+		MaxMembers:   r.MaxMembers,
+		AllowPreview: r.AllowPreview,
+		Password:     r.Password,
+
+		AnnounceMembership: r.AnnounceMembership,
+		Description:        r.Description,
+	}
+}
+
+func isPasswordPolicyError(err error) bool {
+	return err.Error() == "channel password is too short" || err.Error() == "channel password is too simple" ||
+		err.Error() == "password too long"
+}
+
+// toChannelInfo maps a channel, with its owner loaded, to the API representation
+func toChannelInfo(channel chat.Channel) ChannelInfo {
+	return ChannelInfo{
+		ID:                 channel.ID,
+		Name:               channel.Name,
+		IsVisible:          channel.IsVisible,
+		HideOwner:          channel.HideOwner,
+		LoggingDays:        channel.LoggingDays,
+		MaxMembers:         channel.MaxMembers,
+		AllowPreview:       channel.AllowPreview,
+		AnnounceMembership: channel.AnnounceMembership,
+		Description:        channel.Description,
+		Topic:              channel.Topic,
+		CreatedAt:          channel.CreatedAt.Format(time.RFC3339),
+		LastMessageAt:      lastMessageAt(channel),
+		CategoryID:         channel.CategoryID,
+		Owner:              ChannelOwner{ID: channel.Owner.ID, Username: channel.Owner.Username},
+	}
+}
+
+func lastMessageAt(channel chat.Channel) *string {
+	if channel.LastMessageAt == nil {
+		return nil
+	}
+	formatted := channel.LastMessageAt.Format(time.RFC3339)
+	return &formatted
+}
+
+// parseChannelSort reads the sort and direction query parameters of channel listings
+func parseChannelSort(ctx *gin.Context) (c.ChannelSort, error) {
+	return c.ParseChannelSort(ctx.Query("sort"), ctx.Query("direction"))
+}
+
+// HiddenOwnerName replaces the owner's username in public listings when the owner opted out
+const HiddenOwnerName = "hidden"
+
+// listedOwner returns the owner as shown in public channel listings
+func listedOwner(channel chat.Channel) ChannelOwner {
+	if channel.HideOwner {
+		return ChannelOwner{Username: HiddenOwnerName}
+	}
+	return ChannelOwner{ID: channel.Owner.ID, Username: channel.Owner.Username}
 }
 
 type JoinChannelRequest struct {
 	Password *string `json:"password,omitempty" example:"secretpass"`
+	AsGuest  bool    `json:"as_guest,omitempty" example:"false"` // Join with read-only access
 }
 
 type ChannelResponse struct {
 	Channel ChannelInfo `json:"channel"`
 }
 
+// ChannelDetailResponse adds the requester's relation to the channel
+type ChannelDetailResponse struct {
+	Channel  ChannelInfo `json:"channel"`
+	IsMember bool        `json:"is_member" example:"true"`
+	IsOwner  bool        `json:"is_owner" example:"false"`
+	IsBanned bool        `json:"is_banned" example:"false"`
+}
+
 // CreateChannelHandler creates a new channel
 // @Summary Create a new channel
-// @Description Create a new channel with optional password protection
+// @Description Create a new channel with optional password protection and message retention (logging_days: default 30, 0 disables history, max 365). Passwords must meet the channel password policy. Each user may own at most MAX_OWNED_CHANNELS channels (default 50).
 // @Tags Channels
 // @Accept json
 // @Produce json
 // @Security CookieAuth
 // @Param request body CreateChannelRequest true "Create channel request"
 // @Success 201 {object} ChannelResponse "Channel created successfully"
-// @Failure 400 {object} ErrorResponse "Bad request"
+// @Failure 400 {object} ValidationErrorResponse "Bad request or invalid fields"
 // @Failure 401 {object} ErrorResponse "User not authenticated"
+// @Failure 403 {object} ErrorResponse "Owned channel limit reached"
 // @Router /api/channels [post]
 func (h *ChannelHandlers) CreateChannelHandler(c *gin.Context) {
 	userID, exists := c.Get("user_id")
@@ -53,58 +154,75 @@ func (h *ChannelHandlers) CreateChannelHandler(c *gin.Context) {
 	}
 
 	var req CreateChannelRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	channel, err := h.service.CreateChannel(userID.(string), req.Name, req.Password, req.IsVisible)
+	channel, err := h.service.CreateChannel(userID.(string), req.Name, req.Password, req.IsVisible, req.LoggingDays)
 	if err != nil {
+		if err.Error() == "you have reached the maximum number of owned channels" {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"channel": gin.H{
-			"id":         channel.ID,
-			"name":       channel.Name,
-			"is_visible": channel.IsVisible,
-			"owner_id":   channel.OwnerID,
-		},
-	})
+	c.JSON(http.StatusCreated, ChannelResponse{Channel: toChannelInfo(*channel)})
 }
 
 // GetChannelsHandler gets all visible channels
 // @Summary Get all visible channels
-// @Description Get a list of all publicly visible channels
+// @Description Get a list of all publicly visible channels, alphabetically by default. Owners who opted out are listed as "hidden". With group_by=category, channels are ordered by category name, uncategorized last, and also returned as groups. Responses carry an ETag; send it back in If-None-Match to get 304 when the page is unchanged.
 // @Tags Channels
 // @Accept json
 // @Produce json
 // @Security CookieAuth
+// @Param page query int false "Page number (default: 1)"
+// @Param limit query int false "Number of results per page (default: 20, max: 100)"
+// @Param sort query string false "Sort by name, member count or latest message (default: name)" Enums(name, members, recent_activity)
+// @Param direction query string false "Sort direction (default: asc for name, desc otherwise)" Enums(asc, desc)
+// @Param group_by query string false "Group channels by category" Enums(category)
+// @Param If-None-Match header string false "ETag of a previously fetched page"
 // @Success 200 {object} ChannelsResponse "List of visible channels"
+// @Success 304 "Unchanged since the given ETag"
+// @Failure 400 {object} ErrorResponse "Invalid sort field, direction or grouping"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /api/channels [get]
 func (h *ChannelHandlers) GetChannelsHandler(c *gin.Context) {
-	channels, err := h.service.GetVisibleChannels()
+	sort, err := parseChannelSort(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	switch c.Query("group_by") {
+	case "":
+	case GroupByCategory:
+		sort.ByCategory = true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid group_by value"})
+		return
+	}
+
+	page, limit, offset := parsePagination(c)
+	channels, total, err := h.service.GetVisibleChannels(sort, limit, offset)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch channels"})
 		return
 	}
 
-	var channelList []gin.H
+	channelList := make([]ChannelInfo, 0, len(channels))
 	for _, channel := range channels {
-		channelList = append(channelList, gin.H{
-			"id":         channel.ID,
-			"name":       channel.Name,
-			"is_visible": channel.IsVisible,
-			"owner": gin.H{
-				"id":       channel.Owner.ID,
-				"username": channel.Owner.Username,
-			},
-		})
+		info := toChannelInfo(channel)
+		info.Owner = listedOwner(channel)
+		channelList = append(channelList, info)
 	}
 
-	c.JSON(http.StatusOK, gin.H{"channels": channelList})
+	response := ChannelsResponse{Channels: channelList, PaginationMeta: newPaginationMeta(total, page, limit)}
+	if sort.ByCategory {
+		response.Groups = groupByCategory(channels, channelList)
+	}
+	jsonWithETag(c, http.StatusOK, response)
 }
 
 // GetUserChannelsHandler gets user's channels
@@ -114,6 +232,8 @@ func (h *ChannelHandlers) GetChannelsHandler(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Security CookieAuth
+// @Param page query int false "Page number (default: 1)"
+// @Param limit query int false "Number of results per page (default: 20, max: 100)"
 // @Success 200 {object} ChannelsResponse "List of user's channels"
 // @Failure 401 {object} ErrorResponse "User not authenticated"
 // @Failure 500 {object} ErrorResponse "Internal server error"
@@ -125,41 +245,42 @@ func (h *ChannelHandlers) GetUserChannelsHandler(c *gin.Context) {
 		return
 	}
 
-	channels, err := h.service.GetUserChannels(userID.(string))
+	page, limit, offset := parsePagination(c)
+	channels, total, err := h.service.GetUserChannels(userID.(string), limit, offset)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user channels"})
 		return
 	}
 
-	var channelList []gin.H
+	channelList := make([]ChannelInfo, 0, len(channels))
 	for _, channel := range channels {
-		channelList = append(channelList, gin.H{
-			"id":         channel.ID,
-			"name":       channel.Name,
-			"is_visible": channel.IsVisible,
-			"owner": gin.H{
-				"id":       channel.Owner.ID,
-				"username": channel.Owner.Username,
-			},
-		})
+		channelList = append(channelList, toChannelInfo(channel))
 	}
 
-	c.JSON(http.StatusOK, gin.H{"channels": channelList})
+	c.JSON(http.StatusOK, ChannelsResponse{Channels: channelList, PaginationMeta: newPaginationMeta(total, page, limit)})
 }
 
 // GetChannelHandler gets a specific channel
 // @Summary Get channel details
-// @Description Get detailed information about a specific channel
+// @Description Get detailed information about a specific channel, including whether the requester is a member, the owner, or banned. The owner is shown as "hidden" to everyone else when the channel hides its owner.
 // @Tags Channels
 // @Accept json
 // @Produce json
 // @Security CookieAuth
 // @Param id path string true "Channel ID"
-// @Success 200 {object} ChannelResponse "Channel details"
+// @Success 200 {object} ChannelDetailResponse "Channel details"
 // @Failure 400 {object} ErrorResponse "Channel ID required"
+// @Failure 401 {object} ErrorResponse "User not authenticated"
 // @Failure 404 {object} ErrorResponse "Channel not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /api/channels/{id} [get]
 func (h *ChannelHandlers) GetChannelHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
 	channelID := c.Param("id")
 	if channelID == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Channel ID required"})
@@ -172,22 +293,28 @@ func (h *ChannelHandlers) GetChannelHandler(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"channel": gin.H{
-			"id":         channel.ID,
-			"name":       channel.Name,
-			"is_visible": channel.IsVisible,
-			"owner": gin.H{
-				"id":       channel.Owner.ID,
-				"username": channel.Owner.Username,
-			},
-		},
+	membership, err := h.service.GetMembership(userID.(string), channel)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get channel membership"})
+		return
+	}
+
+	info := toChannelInfo(*channel)
+	if !membership.IsOwner {
+		info.Owner = listedOwner(*channel)
+	}
+
+	c.JSON(http.StatusOK, ChannelDetailResponse{
+		Channel:  info,
+		IsMember: membership.IsMember,
+		IsOwner:  membership.IsOwner,
+		IsBanned: membership.IsBanned,
 	})
 }
 
 // JoinChannelHandler joins a channel
 // @Summary Join a channel
-// @Description Join a channel, optionally providing password for protected channels
+// @Description Join a channel, optionally providing password for protected channels. With as_guest the user joins as a Guest, who can read the channel but not post until promoted. Fails with "channel is full" when the channel's member limit is reached.
 // @Tags Channels
 // @Accept json
 // @Produce json
@@ -197,6 +324,9 @@ func (h *ChannelHandlers) GetChannelHandler(c *gin.Context) {
 // @Success 200 {object} MessageResponse "Successfully joined channel"
 // @Failure 400 {object} ErrorResponse "Bad request or incorrect password"
 // @Failure 401 {object} ErrorResponse "User not authenticated"
+// @Failure 403 {object} ErrorResponse "You are banned from this channel"
+// @Failure 409 {object} ErrorResponse "User already in channel"
+// @Failure 429 {object} ErrorResponse "Too many wrong passwords for this channel, try later"
 // @Router /api/channels/{id}/join [post]
 func (h *ChannelHandlers) JoinChannelHandler(c *gin.Context) {
 	userID, exists := c.Get("user_id")
@@ -217,8 +347,25 @@ func (h *ChannelHandlers) JoinChannelHandler(c *gin.Context) {
 		return
 	}
 
-	err := h.service.JoinChannel(userID.(string), channelID, req.Password)
+	join := h.service.JoinChannel
+	if req.AsGuest {
+		join = h.service.JoinChannelAsGuest
+	}
+
+	err := join(userID.(string), channelID, req.Password)
 	if err != nil {
+		if err.Error() == "you are banned from this channel" {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if err.Error() == "too many attempts, try later" {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			return
+		}
+		if err.Error() == "user already in channel" {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -226,6 +373,152 @@ func (h *ChannelHandlers) JoinChannelHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Successfully joined channel"})
 }
 
+type VerifyChannelPasswordRequest struct {
+	Password string `json:"password" binding:"required" example:"secretpass"`
+}
+
+type VerifyChannelPasswordResponse struct {
+	Valid bool `json:"valid" example:"true"`
+}
+
+// VerifyChannelPasswordHandler checks a channel password without joining
+// @Summary Verify a channel password
+// @Description Check whether joining the channel would accept the password, without joining it. Channels without a password accept any, so the answer does not reveal whether a channel is protected. Strictly rate limited.
+// @Tags Channels
+// @Accept json
+// @Produce json
+// @Security CookieAuth
+// @Param id path string true "Channel ID"
+// @Param request body VerifyChannelPasswordRequest true "Password to check"
+// @Success 200 {object} VerifyChannelPasswordResponse "Whether the password is accepted"
+// @Failure 400 {object} ValidationErrorResponse "Missing password"
+// @Failure 401 {object} ErrorResponse "User not authenticated"
+// @Failure 404 {object} ErrorResponse "Channel not found"
+// @Failure 429 {object} ErrorResponse "Rate limit exceeded, or too many wrong passwords for this channel"
+// @Router /api/channels/{id}/verify-password [post]
+func (h *ChannelHandlers) VerifyChannelPasswordHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req VerifyChannelPasswordRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	valid, err := h.service.VerifyChannelPassword(userID.(string), c.Param("id"), req.Password)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Channel not found"})
+			return
+		}
+		if err.Error() == "too many attempts, try later" {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify password"})
+		return
+	}
+
+	c.JSON(http.StatusOK, VerifyChannelPasswordResponse{Valid: valid})
+}
+
+// MaxBulkJoinChannels caps how many channels a single bulk join may attempt
+const MaxBulkJoinChannels = 50
+
+// Bulk join statuses
+const (
+	BulkJoinJoined           = "joined"
+	BulkJoinAlreadyMember    = "already_member"
+	BulkJoinBanned           = "banned"
+	BulkJoinNotFound         = "not_found"
+	BulkJoinPasswordRequired = "password_required"
+	BulkJoinFull             = "full"
+	BulkJoinFailed           = "failed"
+)
+
+type BulkJoinRequest struct {
+	ChannelIDs []string `json:"channel_ids" binding:"required" example:"ch123,ch456"`
+}
+
+type BulkJoinResult struct {
+	ChannelID string `json:"channel_id" example:"ch123"`
+	Status    string `json:"status" example:"joined" enums:"joined,already_member,banned,not_found,password_required,full,failed"`
+}
+
+type BulkJoinResponse struct {
+	Results []BulkJoinResult `json:"results"`
+	Joined  int              `json:"joined" example:"1"`
+}
+
+// bulkJoinStatus maps a JoinChannel outcome to a bulk join status
+func bulkJoinStatus(err error) string {
+	if err == nil {
+		return BulkJoinJoined
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return BulkJoinNotFound
+	}
+	switch err.Error() {
+	case "user already in channel":
+		return BulkJoinAlreadyMember
+	case "you are banned from this channel":
+		return BulkJoinBanned
+	case "password required for this channel":
+		return BulkJoinPasswordRequired
+	case "channel is full":
+		return BulkJoinFull
+	}
+	return BulkJoinFailed
+}
+
+// BulkJoinChannelsHandler joins several channels at once
+// @Summary Join several channels
+// @Description Try to join each listed channel (at most 50) and report a status per channel. A failure on one channel does not stop the others. Password-protected channels are reported as password_required.
+// @Tags Channels
+// @Accept json
+// @Produce json
+// @Security CookieAuth
+// @Param request body BulkJoinRequest true "Channels to join"
+// @Success 200 {object} BulkJoinResponse "Per-channel results"
+// @Failure 400 {object} ErrorResponse "Missing, empty or too many channel IDs"
+// @Failure 401 {object} ErrorResponse "User not authenticated"
+// @Router /api/channels/join-bulk [post]
+func (h *ChannelHandlers) BulkJoinChannelsHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req BulkJoinRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.ChannelIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "At least one channel ID is required"})
+		return
+	}
+	if len(req.ChannelIDs) > MaxBulkJoinChannels {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Too many channel IDs"})
+		return
+	}
+
+	response := BulkJoinResponse{Results: make([]BulkJoinResult, 0, len(req.ChannelIDs))}
+	for _, channelID := range req.ChannelIDs {
+		status := bulkJoinStatus(h.service.JoinChannel(userID.(string), channelID, nil))
+		if status == BulkJoinJoined {
+			response.Joined++
+		}
+		response.Results = append(response.Results, BulkJoinResult{ChannelID: channelID, Status: status})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
 // LeaveChannelHandler leaves a channel
 // @Summary Leave a channel
 // @Description Leave a channel that the user has previously joined
@@ -260,16 +553,22 @@ func (h *ChannelHandlers) LeaveChannelHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Successfully left channel"})
 }
 
+// DeleteChannelRequest confirms a channel deletion when the server requires it
+type DeleteChannelRequest struct {
+	Confirm string `json:"confirm" example:"general"` // Must equal the channel name
+}
+
 // DeleteChannelHandler deletes a channel
 // @Summary Delete a channel
-// @Description Delete a channel (only channel owner can delete)
+// @Description Delete a channel (only channel owner can delete). When the server requires confirmation, the body must repeat the channel name as confirm.
 // @Tags Channels
 // @Accept json
 // @Produce json
 // @Security CookieAuth
 // @Param id path string true "Channel ID"
+// @Param request body DeleteChannelRequest false "Deletion confirmation"
 // @Success 200 {object} MessageResponse "Channel deleted successfully"
-// @Failure 400 {object} ErrorResponse "Bad request or not authorized"
+// @Failure 400 {object} ErrorResponse "Bad request, confirmation required or not authorized"
 // @Failure 401 {object} ErrorResponse "User not authenticated"
 // @Router /api/channels/{id} [delete]
 func (h *ChannelHandlers) DeleteChannelHandler(c *gin.Context) {
@@ -285,6 +584,10 @@ func (h *ChannelHandlers) DeleteChannelHandler(c *gin.Context) {
 		return
 	}
 
+	if h.confirmDeletion && !h.deletionConfirmed(c, channelID) {
+		return
+	}
+
 	err := h.service.DeleteChannel(userID.(string), channelID)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -294,6 +597,23 @@ func (h *ChannelHandlers) DeleteChannelHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Channel deleted successfully"})
 }
 
+// deletionConfirmed checks that the request body names the channel being deleted,
+// replying 400 when it does not. Unknown channels are left for the deletion to reject.
+func (h *ChannelHandlers) deletionConfirmed(c *gin.Context, channelID string) bool {
+	var req DeleteChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return false
+	}
+
+	channel, err := h.service.GetChannel(channelID)
+	if err == nil && req.Confirm != channel.Name {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "confirmation required"})
+		return false
+	}
+	return true
+}
+
 type UserInfo struct {
 	ID       string `json:"id" example:"a1b2c3d4"`
 	Username string `json:"username" example:"john_doe"`
@@ -349,22 +669,35 @@ type BanUserRequest struct {
 type TempBanUserRequest struct {
 	UserID   string `json:"user_id" binding:"required" example:"a1b2c3d4"`
 	Reason   string `json:"reason" example:"timeout"`
-	Duration string `json:"duration" binding:"required" example:"24h"` // e.g., "24h", "30m"
+	Duration string `json:"duration" binding:"required" example:"24h"` // e.g., "24h", "30m"; from 1m to 8760h (1 year)
+}
+
+// Bounds of temporary ban durations
+const (
+	MinTempBanDuration = time.Minute
+	MaxTempBanDuration = 365 * 24 * time.Hour
+)
+
+// BanUserResponse confirms a ban and returns it, so clients need not re-fetch the ban list
+type BanUserResponse struct {
+	Message string  `json:"message" example:"User banned successfully"`
+	Ban     BanInfo `json:"ban"`
 }
 
 // BanUserHandler permanently bans a user from a channel
 // @Summary Ban user from channel
-// @Description Permanently ban a user from a channel (only channel owner can ban)
+// @Description Permanently ban a user from a channel (channel owner, or a moderator banning a lower role)
 // @Tags Channel Administration
 // @Accept json
 // @Produce json
 // @Security CookieAuth
 // @Param id path string true "Channel ID"
 // @Param request body BanUserRequest true "Ban user request"
-// @Success 200 {object} MessageResponse "User banned successfully"
-// @Failure 400 {object} ErrorResponse "Bad request"
+// @Success 200 {object} BanUserResponse "User banned successfully, with the created ban"
+// @Failure 400 {object} ValidationErrorResponse "Bad request or invalid fields"
 // @Failure 401 {object} ErrorResponse "User not authenticated"
-// @Failure 403 {object} ErrorResponse "Only channel owner can ban users"
+// @Failure 403 {object} ErrorResponse "Not allowed to ban this user"
+// @Failure 409 {object} ErrorResponse "User is already banned"
 // @Router /api/channels/{id}/ban [post]
 func (h *ChannelHandlers) BanUserHandler(c *gin.Context) {
 	userID, exists := c.Get("user_id")
@@ -380,37 +713,39 @@ func (h *ChannelHandlers) BanUserHandler(c *gin.Context) {
 	}
 
 	var req BanUserRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	err := h.service.BanUser(userID.(string), req.UserID, channelID, req.Reason)
+	ban, err := h.service.BanUser(userID.(string), req.UserID, channelID, req.Reason)
 	if err != nil {
-		if err.Error() == "only channel owner can ban users" {
+		if err.Error() == "only channel owners and moderators can ban users" || err.Error() == "cannot ban a member with an equal or higher role" {
 			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		} else if err.Error() == "user is already banned" {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
 		} else {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		}
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "User banned successfully"})
+	c.JSON(http.StatusOK, BanUserResponse{Message: "User banned successfully", Ban: toBanInfo(*ban, time.Now())})
 }
 
 // TempBanUserHandler temporarily bans a user from a channel
 // @Summary Temporarily ban user from channel
-// @Description Temporarily ban a user from a channel for a specified duration (only channel owner can ban)
+// @Description Temporarily ban a user from a channel for a specified duration, from 1m to 8760h (1 year) (channel owner, or a moderator banning a lower role)
 // @Tags Channel Administration
 // @Accept json
 // @Produce json
 // @Security CookieAuth
 // @Param id path string true "Channel ID"
 // @Param request body TempBanUserRequest true "Temporary ban user request"
-// @Success 200 {object} MessageResponse "User temporarily banned successfully"
-// @Failure 400 {object} ErrorResponse "Bad request or invalid duration format"
+// @Success 200 {object} BanUserResponse "User temporarily banned successfully, with the created ban"
+// @Failure 400 {object} ValidationErrorResponse "Bad request, invalid fields, or invalid or out-of-range duration"
 // @Failure 401 {object} ErrorResponse "User not authenticated"
-// @Failure 403 {object} ErrorResponse "Only channel owner can ban users"
+// @Failure 403 {object} ErrorResponse "Not allowed to ban this user"
+// @Failure 409 {object} ErrorResponse "User is already banned"
 // @Router /api/channels/{id}/tempban [post]
 func (h *ChannelHandlers) TempBanUserHandler(c *gin.Context) {
 	userID, exists := c.Get("user_id")
@@ -426,8 +761,7 @@ func (h *ChannelHandlers) TempBanUserHandler(c *gin.Context) {
 	}
 
 	var req TempBanUserRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
@@ -437,18 +771,24 @@ func (h *ChannelHandlers) TempBanUserHandler(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid duration format"})
 		return
 	}
+	if duration < MinTempBanDuration || duration > MaxTempBanDuration {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "duration must be between 1m and 8760h (1 year)"})
+		return
+	}
 
-	err = h.service.TempBanUser(userID.(string), req.UserID, channelID, req.Reason, duration)
+	ban, err := h.service.TempBanUser(userID.(string), req.UserID, channelID, req.Reason, duration)
 	if err != nil {
-		if err.Error() == "only channel owner can ban users" {
+		if err.Error() == "only channel owners and moderators can ban users" || err.Error() == "cannot ban a member with an equal or higher role" {
 			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		} else if err.Error() == "user is already banned" {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
 		} else {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		}
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "User temporarily banned successfully"})
+	c.JSON(http.StatusOK, BanUserResponse{Message: "User temporarily banned successfully", Ban: toBanInfo(*ban, time.Now())})
 }
 
 // UnbanUserHandler unbans a user from a channel
@@ -498,23 +838,56 @@ func (h *ChannelHandlers) UnbanUserHandler(c *gin.Context) {
 }
 
 type BanInfo struct {
-	ID        uint      `json:"id" example:"1"`
-	UserID    string    `json:"user_id" example:"a1b2c3d4"`
-	Reason    string    `json:"reason" example:"spam"`
-	BannedAt  string    `json:"banned_at" example:"2023-01-01T00:00:00Z"`
-	ExpiresAt *string   `json:"expires_at" example:"2023-01-02T00:00:00Z"`
-	IsActive  bool      `json:"is_active" example:"true"`
-	User      UserInfo  `json:"user"`
-	BannedBy  UserInfo  `json:"banned_by"`
+	ID               uint     `json:"id" example:"1"`
+	UserID           string   `json:"user_id" example:"a1b2c3d4"`
+	Reason           string   `json:"reason" example:"spam"`
+	BannedAt         string   `json:"banned_at" example:"2023-01-01T00:00:00Z"`
+	ExpiresAt        *string  `json:"expires_at" example:"2023-01-02T00:00:00Z"`
+	IsActive         bool     `json:"is_active" example:"true"`
+	IsTemporary      bool     `json:"is_temporary" example:"true"`
+	RemainingSeconds *int64   `json:"remaining_seconds" example:"3600"` // null for permanent bans, 0 once expired
+	User             UserInfo `json:"user"`
+	BannedBy         UserInfo `json:"banned_by"`
+}
+
+// toBanInfo maps a ban, with its user and banner loaded, to the API representation.
+// The remaining time is computed against the server clock at now.
+func toBanInfo(ban chat.UserBan, now time.Time) BanInfo {
+	info := BanInfo{
+		ID:       ban.ID,
+		UserID:   ban.UserID,
+		Reason:   ban.Reason,
+		BannedAt: ban.CreatedAt.Format(time.RFC3339),
+		IsActive: ban.IsActive,
+		User:     UserInfo{ID: ban.User.ID, Username: ban.User.Username},
+		BannedBy: UserInfo{ID: ban.BannedByUser.ID, Username: ban.BannedByUser.Username},
+	}
+
+	if ban.ExpiresAt != nil {
+		expiresAt := ban.ExpiresAt.Format(time.RFC3339)
+		remaining := int64(ban.ExpiresAt.Sub(now).Seconds())
+		if remaining < 0 {
+			remaining = 0
+		}
+		info.ExpiresAt = &expiresAt
+		info.IsTemporary = true
+		info.RemainingSeconds = &remaining
+	}
+
+	return info
 }
 
 type BansResponse struct {
 	Bans []BanInfo `json:"bans"`
 }
 
+type BanResponse struct {
+	Ban BanInfo `json:"ban"`
+}
+
 // GetChannelBansHandler gets all bans for a channel
 // @Summary Get channel bans
-// @Description Get a list of all active and inactive bans for a channel (only channel owner can view)
+// @Description Get a list of all active and inactive bans for a channel (only channel owner can view). Temporary bans report the seconds remaining, computed on the server.
 // @Tags Channel Administration
 // @Accept json
 // @Produce json
@@ -549,33 +922,56 @@ func (h *ChannelHandlers) GetChannelBansHandler(c *gin.Context) {
 		return
 	}
 
-	var banList []gin.H
+	now := time.Now()
+	banList := make([]BanInfo, 0, len(bans))
 	for _, ban := range bans {
-		banData := gin.H{
-			"id":         ban.ID,
-			"user_id":    ban.UserID,
-			"reason":     ban.Reason,
-			"banned_at":  ban.CreatedAt,
-			"expires_at": ban.ExpiresAt,
-			"is_active":  ban.IsActive,
-			"user": gin.H{
-				"id":       ban.User.ID,
-				"username": ban.User.Username,
-			},
-			"banned_by": gin.H{
-				"id":       ban.BannedByUser.ID,
-				"username": ban.BannedByUser.Username,
-			},
+		banList = append(banList, toBanInfo(ban, now))
+	}
+
+	c.JSON(http.StatusOK, BansResponse{Bans: banList})
+}
+
+// GetUserBanHandler gets the active ban of a user in a channel
+// @Summary Get a user's ban
+// @Description Get the active ban of a user in a channel, with reason, who banned them and expiry (only channel owners and moderators)
+// @Tags Channel Administration
+// @Accept json
+// @Produce json
+// @Security CookieAuth
+// @Param id path string true "Channel ID"
+// @Param userId path string true "Banned user ID"
+// @Success 200 {object} BanResponse "Active ban"
+// @Failure 401 {object} ErrorResponse "User not authenticated"
+// @Failure 403 {object} ErrorResponse "Only channel owners and moderators can view bans"
+// @Failure 404 {object} ErrorResponse "Channel not found or user is not banned"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/channels/{id}/bans/{userId} [get]
+func (h *ChannelHandlers) GetUserBanHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	ban, err := h.service.GetUserBan(userID.(string), c.Param("id"), c.Param("userId"))
+	if err != nil {
+		if err.Error() == "channel not found" || err.Error() == "user is not banned" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else if err.Error() == "only channel owners and moderators can view bans" {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch ban"})
 		}
-		banList = append(banList, banData)
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"bans": banList})
+	c.JSON(http.StatusOK, BanResponse{Ban: toBanInfo(*ban, time.Now())})
 }
 
 type RoleUpdateRequest struct {
 	UserID string `json:"user_id" binding:"required" example:"abc12345"`
-	Role   string `json:"role" binding:"required" example:"Moderator"`
+	Role   string `json:"role" binding:"required" example:"Moderator" enums:"Administrator,Moderator,Member,Guest"`
+	Reason string `json:"reason,omitempty" example:"Keeps the channel on topic"` // Recorded in the audit log
 }
 
 // PromoteUserHandler promotes a user in a channel
@@ -588,7 +984,7 @@ type RoleUpdateRequest struct {
 // @Param id path string true "Channel ID"
 // @Param request body RoleUpdateRequest true "Role update request"
 // @Success 200 {object} MessageResponse "User promoted successfully"
-// @Failure 400 {object} ErrorResponse "Bad request"
+// @Failure 400 {object} ValidationErrorResponse "Bad request, invalid fields or invalid role"
 // @Failure 401 {object} ErrorResponse "User not authenticated"
 // @Failure 403 {object} ErrorResponse "Only channel owners can promote users"
 // @Failure 404 {object} ErrorResponse "Channel or user not found"
@@ -607,12 +1003,11 @@ func (h *ChannelHandlers) PromoteUserHandler(c *gin.Context) {
 	}
 
 	var req RoleUpdateRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	err := h.service.PromoteUser(userID.(string), channelID, req.UserID, req.Role)
+	err := h.service.PromoteUser(userID.(string), channelID, req.UserID, req.Role, req.Reason)
 	if err != nil {
 		if err.Error() == "channel not found" {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Channel not found"})
@@ -626,7 +1021,7 @@ func (h *ChannelHandlers) PromoteUserHandler(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "User not found in channel"})
 			return
 		}
-		if err.Error() == "role not found" {
+		if err.Error() == "invalid role" {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role"})
 			return
 		}
@@ -647,7 +1042,7 @@ func (h *ChannelHandlers) PromoteUserHandler(c *gin.Context) {
 // @Param id path string true "Channel ID"
 // @Param request body RoleUpdateRequest true "Role update request"
 // @Success 200 {object} MessageResponse "User demoted successfully"
-// @Failure 400 {object} ErrorResponse "Bad request"
+// @Failure 400 {object} ValidationErrorResponse "Bad request, invalid fields or invalid role"
 // @Failure 401 {object} ErrorResponse "User not authenticated"
 // @Failure 403 {object} ErrorResponse "Only channel owners can demote users"
 // @Failure 404 {object} ErrorResponse "Channel or user not found"
@@ -666,12 +1061,11 @@ func (h *ChannelHandlers) DemoteUserHandler(c *gin.Context) {
 	}
 
 	var req RoleUpdateRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
-	err := h.service.DemoteUser(userID.(string), channelID, req.UserID, req.Role)
+	err := h.service.DemoteUser(userID.(string), channelID, req.UserID, req.Role, req.Reason)
 	if err != nil {
 		if err.Error() == "channel not found" {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Channel not found"})
@@ -685,7 +1079,7 @@ func (h *ChannelHandlers) DemoteUserHandler(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "User not found in channel"})
 			return
 		}
-		if err.Error() == "role not found" {
+		if err.Error() == "invalid role" {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role"})
 			return
 		}
@@ -694,4 +1088,342 @@ func (h *ChannelHandlers) DemoteUserHandler(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "User demoted successfully"})
-}
\ No newline at end of file
+}
+type LockChannelRequest struct {
+	Duration string `json:"duration,omitempty" example:"15m"` // e.g., "15m", "1h"; omit to lock until unlocked
+}
+
+type ChannelLockResponse struct {
+	Message       string      `json:"message" example:"Channel locked successfully"`
+	LockedUntil   *string     `json:"locked_until" example:"2023-01-01T00:15:00Z"`
+	SystemMessage MessageInfo `json:"system_message"`
+}
+
+// LockChannelHandler temporarily locks a channel
+// @Summary Lock channel
+// @Description Pause a channel so that only the owner and moderators can post, optionally for a limited duration. A system message announcing the lock is posted to the channel.
+// @Tags Channel Administration
+// @Accept json
+// @Produce json
+// @Security CookieAuth
+// @Param id path string true "Channel ID"
+// @Param request body LockChannelRequest false "Lock channel request"
+// @Success 200 {object} ChannelLockResponse "Channel locked successfully"
+// @Failure 400 {object} ErrorResponse "Bad request or invalid duration format"
+// @Failure 401 {object} ErrorResponse "User not authenticated"
+// @Failure 403 {object} ErrorResponse "Only channel owners and moderators can lock the channel"
+// @Failure 404 {object} ErrorResponse "Channel not found"
+// @Router /api/channels/{id}/lock [post]
+func (h *ChannelHandlers) LockChannelHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	channelID := c.Param("id")
+	if channelID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Channel ID required"})
+		return
+	}
+
+	// The body is optional; an empty request locks until explicitly unlocked
+	var req LockChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var duration time.Duration
+	if req.Duration != "" {
+		var err error
+		duration, err = time.ParseDuration(req.Duration)
+		if err != nil || duration <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid duration format"})
+			return
+		}
+	}
+
+	channel, message, err := h.service.LockChannel(userID.(string), channelID, duration)
+	if err != nil {
+		if err.Error() == "channel not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Channel not found"})
+		} else if err.Error() == "only channel owners and moderators can lock the channel" {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		} else if err.Error() == "invalid lock duration" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid duration format"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to lock channel"})
+		}
+		return
+	}
+
+	response := ChannelLockResponse{
+		Message:       "Channel locked successfully",
+		SystemMessage: toMessageInfo(*message),
+	}
+	if channel.LockedUntil != nil {
+		lockedUntil := channel.LockedUntil.Format(time.RFC3339)
+		response.LockedUntil = &lockedUntil
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// UnlockChannelHandler lifts a channel lock
+// @Summary Unlock channel
+// @Description Lift a channel lock so all members can post again. A system message announcing the unlock is posted to the channel.
+// @Tags Channel Administration
+// @Produce json
+// @Security CookieAuth
+// @Param id path string true "Channel ID"
+// @Success 200 {object} ChannelLockResponse "Channel unlocked successfully"
+// @Failure 400 {object} ErrorResponse "Channel is not locked"
+// @Failure 401 {object} ErrorResponse "User not authenticated"
+// @Failure 403 {object} ErrorResponse "Only channel owners and moderators can unlock the channel"
+// @Failure 404 {object} ErrorResponse "Channel not found"
+// @Router /api/channels/{id}/unlock [post]
+func (h *ChannelHandlers) UnlockChannelHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	channelID := c.Param("id")
+	if channelID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Channel ID required"})
+		return
+	}
+
+	_, message, err := h.service.UnlockChannel(userID.(string), channelID)
+	if err != nil {
+		if err.Error() == "channel not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Channel not found"})
+		} else if err.Error() == "only channel owners and moderators can unlock the channel" {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		} else if err.Error() == "channel is not locked" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlock channel"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, ChannelLockResponse{
+		Message:       "Channel unlocked successfully",
+		SystemMessage: toMessageInfo(*message),
+	})
+}
+
+type SetChannelTopicRequest struct {
+	Topic string `json:"topic" example:"Release planning"` // At most 200 characters; empty clears the topic
+}
+
+type ChannelTopicResponse struct {
+	Message       string      `json:"message" example:"Channel topic updated successfully"`
+	Topic         string      `json:"topic" example:"Release planning"`
+	SystemMessage MessageInfo `json:"system_message"`
+}
+
+// SetChannelTopicHandler changes the current topic of a channel
+// @Summary Set channel topic
+// @Description Set the current topic of a channel (owner or moderators), or clear it with an empty topic. A system message announcing the new topic is posted to the channel.
+// @Tags Channel Administration
+// @Accept json
+// @Produce json
+// @Security CookieAuth
+// @Param id path string true "Channel ID"
+// @Param request body SetChannelTopicRequest true "Set topic request"
+// @Success 200 {object} ChannelTopicResponse "Channel topic updated successfully"
+// @Failure 400 {object} ValidationErrorResponse "Bad request, invalid fields, or topic too long"
+// @Failure 401 {object} ErrorResponse "User not authenticated"
+// @Failure 403 {object} ErrorResponse "Only channel owners and moderators can set the topic"
+// @Failure 404 {object} ErrorResponse "Channel not found"
+// @Router /api/channels/{id}/topic [put]
+func (h *ChannelHandlers) SetChannelTopicHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	channelID := c.Param("id")
+	if channelID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Channel ID required"})
+		return
+	}
+
+	var req SetChannelTopicRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	channel, message, err := h.service.SetChannelTopic(userID.(string), channelID, req.Topic)
+	if err != nil {
+		if err.Error() == "channel not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Channel not found"})
+		} else if err.Error() == "only channel owners and moderators can set the topic" {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		} else if err.Error() == "topic is too long" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set channel topic"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, ChannelTopicResponse{
+		Message:       "Channel topic updated successfully",
+		Topic:         channel.Topic,
+		SystemMessage: toMessageInfo(*message),
+	})
+}
+
+// DefaultModerationStatsWindow is the period covered by moderation stats when no "from" is given
+const DefaultModerationStatsWindow = 30 * 24 * time.Hour
+
+type ModerationStatsResponse struct {
+	From             string `json:"from" example:"2024-01-01T00:00:00Z"`
+	To               string `json:"to" example:"2024-01-31T00:00:00Z"`
+	Bans             int64  `json:"bans" example:"3"`
+	TempBans         int64  `json:"temp_bans" example:"5"`
+	Unbans           int64  `json:"unbans" example:"2"`
+	ActiveBans       int64  `json:"active_bans" example:"4"`
+	Promotions       int64  `json:"promotions" example:"1"`
+	Demotions        int64  `json:"demotions" example:"0"`
+	Locks            int64  `json:"locks" example:"1"`
+	ScrubbedMessages int64  `json:"scrubbed_messages" example:"0"`
+}
+
+// GetModerationStatsHandler reports moderation activity in a channel
+// @Summary Get channel moderation stats
+// @Description Count moderation events (bans, temporary bans, unbans, role changes, locks, scrubbed messages) recorded in the channel audit log over a period, plus the bans in effect at the end of it. Defaults to the last 30 days. Only channel owners and moderators can view stats.
+// @Tags Channel Administration
+// @Produce json
+// @Security CookieAuth
+// @Param id path string true "Channel ID"
+// @Param from query string false "Start of the period (RFC3339)"
+// @Param to query string false "End of the period (RFC3339, default: now)"
+// @Success 200 {object} ModerationStatsResponse "Moderation stats"
+// @Failure 400 {object} ErrorResponse "Invalid time range"
+// @Failure 401 {object} ErrorResponse "User not authenticated"
+// @Failure 403 {object} ErrorResponse "Only channel owners and moderators can view moderation stats"
+// @Failure 404 {object} ErrorResponse "Channel not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/channels/{id}/moderation-stats [get]
+func (h *ChannelHandlers) GetModerationStatsHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	channelID := c.Param("id")
+	if channelID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Channel ID required"})
+		return
+	}
+
+	to := time.Now()
+	if toStr := c.Query("to"); toStr != "" {
+		parsed, err := time.Parse(time.RFC3339, toStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'to' time, expected RFC3339"})
+			return
+		}
+		to = parsed
+	}
+
+	from := to.Add(-DefaultModerationStatsWindow)
+	if fromStr := c.Query("from"); fromStr != "" {
+		parsed, err := time.Parse(time.RFC3339, fromStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'from' time, expected RFC3339"})
+			return
+		}
+		from = parsed
+	}
+
+	stats, err := h.service.GetModerationStats(userID.(string), channelID, from, to)
+	if err != nil {
+		if err.Error() == "channel not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Channel not found"})
+		} else if err.Error() == "only channel owners and moderators can view moderation stats" {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		} else if err.Error() == "invalid time range" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get moderation stats"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, ModerationStatsResponse{
+		From:             stats.From.Format(time.RFC3339),
+		To:               stats.To.Format(time.RFC3339),
+		Bans:             stats.Bans,
+		TempBans:         stats.TempBans,
+		Unbans:           stats.Unbans,
+		ActiveBans:       stats.ActiveBans,
+		Promotions:       stats.Promotions,
+		Demotions:        stats.Demotions,
+		Locks:            stats.Locks,
+		ScrubbedMessages: stats.ScrubbedMessages,
+	})
+}
+
+// UpdateChannelHandler updates channel settings
+// @Summary Update channel settings
+// @Description Update channel settings (only channel owner). Only provided fields are changed. A new password must meet the channel password policy.
+// @Tags Channels
+// @Accept json
+// @Produce json
+// @Security CookieAuth
+// @Param id path string true "Channel ID"
+// @Param request body UpdateChannelRequest true "Update channel request"
+// @Success 200 {object} ChannelResponse "Channel updated successfully"
+// @Failure 400 {object} ErrorResponse "Bad request"
+// @Failure 401 {object} ErrorResponse "User not authenticated"
+// @Failure 403 {object} ErrorResponse "Only channel owner can update channel"
+// @Failure 404 {object} ErrorResponse "Channel not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/channels/{id} [patch]
+func (h *ChannelHandlers) UpdateChannelHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	channelID := c.Param("id")
+	if channelID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Channel ID required"})
+		return
+	}
+
+	var req UpdateChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	channel, err := h.service.UpdateChannel(userID.(string), channelID, req.toService())
+	if err != nil {
+		if err.Error() == "channel not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Channel not found"})
+		} else if err.Error() == "only channel owner can update channel" {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		} else if err.Error() == "max members cannot be negative" || err.Error() == "description is too long" || isPasswordPolicyError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update channel"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Channel updated successfully",
+		"channel": toChannelInfo(*channel),
+	})
+}
@@ -5,10 +5,12 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	c "go-chat/internal/channel"
+	. "go-chat/pkg/chat"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
@@ -73,8 +75,8 @@ func TestChannelHandlers_BanUserHandler(t *testing.T) {
 	_, nonOwnerToken := createTestUserWithAuth(t, router, "nonowner", "password")
 
 	// Create channel and add user
-	channelService := c.NewChannelService(db)
-	channel, err := channelService.CreateChannel(ownerID, "testchannel", nil, true)
+	channelService := c.NewChannelService(db, c.NewMemberCache(0))
+	channel, err := channelService.CreateChannel(ownerID, "testchannel", nil, true, nil)
 	if err != nil {
 		t.Fatalf("Failed to create channel: %v", err)
 	}
@@ -118,6 +120,27 @@ func TestChannelHandlers_BanUserHandler(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:      "ban an already banned user",
+			channelID: channel.ID,
+			token:     ownerToken,
+			requestBody: BanUserRequest{
+				UserID: userID,
+				Reason: "spam again",
+			},
+			expectedStatus: 409,
+			checkResponse: func(t *testing.T, body []byte) {
+				var response map[string]interface{}
+				if err := json.Unmarshal(body, &response); err != nil {
+					t.Errorf("Failed to parse response: %v", err)
+					return
+				}
+
+				if response["error"] != "user is already banned" {
+					t.Errorf("Expected conflict error, got: %v", response["error"])
+				}
+			},
+		},
 		{
 			name:      "non-owner tries to ban user",
 			channelID: channel.ID,
@@ -134,7 +157,7 @@ func TestChannelHandlers_BanUserHandler(t *testing.T) {
 					return
 				}
 				
-				if response["error"] != "only channel owner can ban users" {
+				if response["error"] != "only channel owners and moderators can ban users" {
 					t.Errorf("Expected permission error, got: %v", response["error"])
 				}
 			},
@@ -210,8 +233,8 @@ func TestChannelHandlers_TempBanUserHandler(t *testing.T) {
 	userID, _ := createTestUserWithAuth(t, router, "user", "password")
 
 	// Create channel and add user
-	channelService := c.NewChannelService(db)
-	channel, err := channelService.CreateChannel(ownerID, "testchannel", nil, true)
+	channelService := c.NewChannelService(db, c.NewMemberCache(0))
+	channel, err := channelService.CreateChannel(ownerID, "testchannel", nil, true, nil)
 	if err != nil {
 		t.Fatalf("Failed to create channel: %v", err)
 	}
@@ -227,6 +250,14 @@ func TestChannelHandlers_TempBanUserHandler(t *testing.T) {
 		Duration string `json:"duration"` // e.g., "24h", "30m"
 	}
 
+	expectDurationRangeError := func(t *testing.T, body []byte) {
+		var response map[string]interface{}
+		json.Unmarshal(body, &response)
+		if response["error"] != "duration must be between 1m and 8760h (1 year)" {
+			t.Errorf("Expected the accepted range in the error, got: %v", response["error"])
+		}
+	}
+
 	tests := []struct {
 		name           string
 		channelID      string
@@ -257,6 +288,28 @@ func TestChannelHandlers_TempBanUserHandler(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:      "temp ban an already banned user",
+			channelID: channel.ID,
+			token:     ownerToken,
+			requestBody: TempBanUserRequest{
+				UserID:   userID,
+				Reason:   "timeout again",
+				Duration: "1h",
+			},
+			expectedStatus: 409,
+			checkResponse: func(t *testing.T, body []byte) {
+				var response map[string]interface{}
+				if err := json.Unmarshal(body, &response); err != nil {
+					t.Errorf("Failed to parse response: %v", err)
+					return
+				}
+
+				if response["error"] != "user is already banned" {
+					t.Errorf("Expected conflict error, got: %v", response["error"])
+				}
+			},
+		},
 		{
 			name:      "invalid duration format",
 			channelID: channel.ID,
@@ -279,6 +332,38 @@ func TestChannelHandlers_TempBanUserHandler(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:           "duration too short",
+			channelID:      channel.ID,
+			token:          ownerToken,
+			requestBody:    TempBanUserRequest{UserID: userID, Reason: "test", Duration: "30s"},
+			expectedStatus: 400,
+			checkResponse:  expectDurationRangeError,
+		},
+		{
+			name:           "duration too long",
+			channelID:      channel.ID,
+			token:          ownerToken,
+			requestBody:    TempBanUserRequest{UserID: userID, Reason: "test", Duration: "876000h"},
+			expectedStatus: 400,
+			checkResponse:  expectDurationRangeError,
+		},
+		{
+			name:           "zero duration",
+			channelID:      channel.ID,
+			token:          ownerToken,
+			requestBody:    TempBanUserRequest{UserID: userID, Reason: "test", Duration: "0s"},
+			expectedStatus: 400,
+			checkResponse:  expectDurationRangeError,
+		},
+		{
+			name:           "negative duration",
+			channelID:      channel.ID,
+			token:          ownerToken,
+			requestBody:    TempBanUserRequest{UserID: userID, Reason: "test", Duration: "-1h"},
+			expectedStatus: 400,
+			checkResponse:  expectDurationRangeError,
+		},
 	}
 
 	for _, tt := range tests {
@@ -315,6 +400,72 @@ func TestChannelHandlers_TempBanUserHandler(t *testing.T) {
 	}
 }
 
+func TestChannelHandlers_BanHandlers_ReturnBan(t *testing.T) {
+	router, _, _, ch := setupChannelAdminRouter(t)
+
+	ownerID, ownerToken := createTestUserWithAuth(t, router, "owner", "password")
+	permanentID, _ := createTestUserWithAuth(t, router, "permanent", "password")
+	temporaryID, _ := createTestUserWithAuth(t, router, "temporary", "password")
+
+	channel, err := ch.service.CreateChannel(ownerID, "returning", nil, true, nil)
+	if err != nil {
+		t.Fatalf("Failed to create channel: %v", err)
+	}
+	for _, userID := range []string{permanentID, temporaryID} {
+		if err := ch.service.JoinChannel(userID, channel.ID, nil); err != nil {
+			t.Fatalf("Failed to join channel: %v", err)
+		}
+	}
+
+	post := func(path string, body interface{}) BanUserResponse {
+		jsonBody, _ := json.Marshal(body)
+		req, _ := http.NewRequest("POST", "/api/channels/"+channel.ID+path, bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(&http.Cookie{Name: "token", Value: ownerToken})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var response BanUserResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		return response
+	}
+
+	permanent := post("/ban", BanUserRequest{UserID: permanentID, Reason: "spam"})
+	if permanent.Message != "User banned successfully" {
+		t.Errorf("Unexpected message %q", permanent.Message)
+	}
+	if permanent.Ban.ID == 0 || permanent.Ban.UserID != permanentID || permanent.Ban.Reason != "spam" || !permanent.Ban.IsActive {
+		t.Errorf("Unexpected ban %+v", permanent.Ban)
+	}
+	if permanent.Ban.ExpiresAt != nil || permanent.Ban.IsTemporary {
+		t.Errorf("Expected a permanent ban, got %+v", permanent.Ban)
+	}
+	if permanent.Ban.BannedBy.ID != ownerID || permanent.Ban.User.Username != "permanent" {
+		t.Errorf("Expected ban by owner of permanent, got %+v", permanent.Ban)
+	}
+
+	before := time.Now()
+	temporary := post("/tempban", TempBanUserRequest{UserID: temporaryID, Reason: "timeout", Duration: "2h"})
+	if temporary.Ban.UserID != temporaryID || temporary.Ban.Reason != "timeout" || !temporary.Ban.IsActive || !temporary.Ban.IsTemporary {
+		t.Errorf("Unexpected ban %+v", temporary.Ban)
+	}
+	if temporary.Ban.ExpiresAt == nil {
+		t.Fatalf("Expected expires_at on a temporary ban")
+	}
+	expiresAt, err := time.Parse(time.RFC3339, *temporary.Ban.ExpiresAt)
+	if err != nil {
+		t.Fatalf("Failed to parse expires_at: %v", err)
+	}
+	if expected := before.Add(2 * time.Hour); expiresAt.Before(expected.Add(-5*time.Second)) || expiresAt.After(expected.Add(5*time.Second)) {
+		t.Errorf("Expected expires_at around %v, got %v", expected, expiresAt)
+	}
+}
+
 func TestChannelHandlers_UnbanUserHandler(t *testing.T) {
 	router, db, _, _ := setupChannelAdminRouter(t)
 
@@ -323,8 +474,8 @@ func TestChannelHandlers_UnbanUserHandler(t *testing.T) {
 	userID, _ := createTestUserWithAuth(t, router, "user", "password")
 
 	// Create channel, add user, and ban them
-	channelService := c.NewChannelService(db)
-	channel, err := channelService.CreateChannel(ownerID, "testchannel", nil, true)
+	channelService := c.NewChannelService(db, c.NewMemberCache(0))
+	channel, err := channelService.CreateChannel(ownerID, "testchannel", nil, true, nil)
 	if err != nil {
 		t.Fatalf("Failed to create channel: %v", err)
 	}
@@ -334,7 +485,7 @@ func TestChannelHandlers_UnbanUserHandler(t *testing.T) {
 		t.Fatalf("Failed to add user to channel: %v", err)
 	}
 
-	err = channelService.BanUser(ownerID, userID, channel.ID, "test ban")
+	_, err = channelService.BanUser(ownerID, userID, channel.ID, "test ban")
 	if err != nil {
 		t.Fatalf("Failed to ban user: %v", err)
 	}
@@ -412,8 +563,8 @@ func TestChannelHandlers_GetChannelBansHandler(t *testing.T) {
 	_, nonOwnerToken := createTestUserWithAuth(t, router, "nonowner", "password")
 
 	// Create channel and add users
-	channelService := c.NewChannelService(db)
-	channel, err := channelService.CreateChannel(ownerID, "testchannel", nil, true)
+	channelService := c.NewChannelService(db, c.NewMemberCache(0))
+	channel, err := channelService.CreateChannel(ownerID, "testchannel", nil, true, nil)
 	if err != nil {
 		t.Fatalf("Failed to create channel: %v", err)
 	}
@@ -429,12 +580,12 @@ func TestChannelHandlers_GetChannelBansHandler(t *testing.T) {
 	}
 
 	// Ban users
-	err = channelService.BanUser(ownerID, user1ID, channel.ID, "spam")
+	_, err = channelService.BanUser(ownerID, user1ID, channel.ID, "spam")
 	if err != nil {
 		t.Fatalf("Failed to ban user1: %v", err)
 	}
 
-	err = channelService.TempBanUser(ownerID, user2ID, channel.ID, "timeout", 24*time.Hour)
+	_, err = channelService.TempBanUser(ownerID, user2ID, channel.ID, "timeout", 24*time.Hour)
 	if err != nil {
 		t.Fatalf("Failed to temp ban user2: %v", err)
 	}
@@ -514,4 +665,526 @@ func TestChannelHandlers_GetChannelBansHandler(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+func TestChannelHandlers_GetChannelBansHandler_RemainingTime(t *testing.T) {
+	router, db, _, ch := setupChannelAdminRouter(t)
+
+	ownerID, ownerToken := createTestUserWithAuth(t, router, "owner", "password")
+	permanentID, _ := createTestUserWithAuth(t, router, "permanent", "password")
+	temporaryID, _ := createTestUserWithAuth(t, router, "temporary", "password")
+	lapsedID, _ := createTestUserWithAuth(t, router, "lapsed", "password")
+
+	channel, err := ch.service.CreateChannel(ownerID, "strict", nil, true, nil)
+	if err != nil {
+		t.Fatalf("Failed to create channel: %v", err)
+	}
+	for _, userID := range []string{permanentID, temporaryID} {
+		if err := ch.service.JoinChannel(userID, channel.ID, nil); err != nil {
+			t.Fatalf("Failed to join channel: %v", err)
+		}
+	}
+	if _, err := ch.service.BanUser(ownerID, permanentID, channel.ID, "spam"); err != nil {
+		t.Fatalf("Failed to ban user: %v", err)
+	}
+	if _, err := ch.service.TempBanUser(ownerID, temporaryID, channel.ID, "timeout", time.Hour); err != nil {
+		t.Fatalf("Failed to temp ban user: %v", err)
+	}
+	// Expired but not yet swept: still listed, with nothing left
+	lapsedAt := time.Now().Add(-time.Minute)
+	if err := db.Create(&UserBan{UserID: lapsedID, ChannelID: channel.ID, BannedBy: ownerID, ExpiresAt: &lapsedAt}).Error; err != nil {
+		t.Fatalf("Failed to create ban: %v", err)
+	}
+
+	req, _ := http.NewRequest("GET", "/api/channels/"+channel.ID+"/bans", nil)
+	req.AddCookie(&http.Cookie{Name: "token", Value: ownerToken})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response struct {
+		Bans []map[string]interface{} `json:"bans"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+
+	bans := make(map[string]map[string]interface{})
+	for _, ban := range response.Bans {
+		bans[ban["user_id"].(string)] = ban
+	}
+	if len(bans) != 3 {
+		t.Fatalf("Expected 3 bans, got %d", len(bans))
+	}
+
+	permanent := bans[permanentID]
+	if value, ok := permanent["remaining_seconds"]; !ok || value != nil {
+		t.Errorf("Expected null remaining_seconds for a permanent ban, got %v", value)
+	}
+	if permanent["is_temporary"] != false {
+		t.Errorf("Expected permanent ban not to be temporary")
+	}
+
+	temporary := bans[temporaryID]
+	remaining, ok := temporary["remaining_seconds"].(float64)
+	if !ok || remaining <= 0 || remaining > 3600 {
+		t.Errorf("Expected remaining_seconds in (0, 3600], got %v", temporary["remaining_seconds"])
+	}
+	if temporary["is_temporary"] != true {
+		t.Errorf("Expected temporary ban to be temporary")
+	}
+
+	if remaining := bans[lapsedID]["remaining_seconds"]; remaining != float64(0) {
+		t.Errorf("Expected 0 remaining_seconds for an expired ban, got %v", remaining)
+	}
+}
+
+func TestChannelHandlers_GetUserBanHandler(t *testing.T) {
+	router, _, _, ch := setupChannelAdminRouter(t)
+
+	ownerID, ownerToken := createTestUserWithAuth(t, router, "owner", "password")
+	bannedID, _ := createTestUserWithAuth(t, router, "banned", "password")
+	memberID, memberToken := createTestUserWithAuth(t, router, "member", "password")
+
+	channel, err := ch.service.CreateChannel(ownerID, "appeals", nil, true, nil)
+	if err != nil {
+		t.Fatalf("Failed to create channel: %v", err)
+	}
+	for _, userID := range []string{bannedID, memberID} {
+		if err := ch.service.JoinChannel(userID, channel.ID, nil); err != nil {
+			t.Fatalf("Failed to join channel: %v", err)
+		}
+	}
+	if _, err := ch.service.TempBanUser(ownerID, bannedID, channel.ID, "flooding", time.Hour); err != nil {
+		t.Fatalf("Failed to ban user: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		userID         string
+		token          string
+		expectedStatus int
+	}{
+		{"owner looks up banned user", bannedID, ownerToken, http.StatusOK},
+		{"owner looks up user who is not banned", memberID, ownerToken, http.StatusNotFound},
+		{"member is not allowed", bannedID, memberToken, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/api/channels/"+channel.ID+"/bans/"+tt.userID, nil)
+			req.AddCookie(&http.Cookie{Name: "token", Value: tt.token})
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var response BanResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			ban := response.Ban
+			if ban.UserID != bannedID || ban.User.Username != "banned" {
+				t.Errorf("Expected ban of user %s, got %+v", bannedID, ban)
+			}
+			if ban.Reason != "flooding" {
+				t.Errorf("Expected reason 'flooding', got %q", ban.Reason)
+			}
+			if ban.BannedBy.ID != ownerID || ban.BannedBy.Username != "owner" {
+				t.Errorf("Expected banned_by owner, got %+v", ban.BannedBy)
+			}
+			if ban.ExpiresAt == nil || !ban.IsTemporary {
+				t.Errorf("Expected a temporary ban with an expiry, got %+v", ban)
+			}
+		})
+	}
+}
+
+func TestChannelHandlers_LockChannelHandler(t *testing.T) {
+	router, db, _, _ := setupChannelAdminRouter(t)
+	if err := db.AutoMigrate(&Message{}, &AuditLog{}); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	ownerID, ownerToken := createTestUserWithAuth(t, router, "owner", "password")
+	memberID, memberToken := createTestUserWithAuth(t, router, "member", "password")
+	modID, modToken := createTestUserWithAuth(t, router, "moderator", "password")
+
+	channelService := c.NewChannelService(db, c.NewMemberCache(0))
+	channel, err := channelService.CreateChannel(ownerID, "heated", nil, true, nil)
+	if err != nil {
+		t.Fatalf("Failed to create channel: %v", err)
+	}
+	if err := channelService.JoinChannel(memberID, channel.ID, nil); err != nil {
+		t.Fatalf("Failed to join channel: %v", err)
+	}
+	if err := channelService.JoinChannel(modID, channel.ID, nil); err != nil {
+		t.Fatalf("Failed to join channel: %v", err)
+	}
+	modRole := Role{Name: "Moderator"}
+	db.FirstOrCreate(&modRole, Role{Name: "Moderator"})
+	db.Model(&UserChannel{}).Where("user_id = ? AND channel_id = ?", modID, channel.ID).Update("role_id", modRole.ID)
+
+	send := func(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+		var reader *bytes.Buffer
+		if body != nil {
+			reqBody, _ := json.Marshal(body)
+			reader = bytes.NewBuffer(reqBody)
+		} else {
+			reader = bytes.NewBuffer(nil)
+		}
+		req, _ := http.NewRequest(method, path, reader)
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		req.AddCookie(&http.Cookie{Name: "token", Value: token})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	post := func(token string) int {
+		return send("POST", "/api/channels/"+channel.ID+"/messages", token, CreateMessageRequest{Content: "hello"}).Code
+	}
+
+	t.Run("members cannot lock", func(t *testing.T) {
+		w := send("POST", "/api/channels/"+channel.ID+"/lock", memberToken, nil)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("Expected status %d, got %d", http.StatusForbidden, w.Code)
+		}
+	})
+
+	t.Run("invalid duration", func(t *testing.T) {
+		w := send("POST", "/api/channels/"+channel.ID+"/lock", ownerToken, LockChannelRequest{Duration: "soon"})
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("members cannot post while locked", func(t *testing.T) {
+		w := send("POST", "/api/channels/"+channel.ID+"/lock", ownerToken, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		var response ChannelLockResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if !response.SystemMessage.IsSystem {
+			t.Errorf("Expected lock to generate a system message")
+		}
+		if response.LockedUntil != nil {
+			t.Errorf("Expected open-ended lock, got locked_until %v", *response.LockedUntil)
+		}
+
+		if code := post(memberToken); code != http.StatusForbidden {
+			t.Errorf("Expected member post to be rejected with %d, got %d", http.StatusForbidden, code)
+		}
+		if code := post(modToken); code != http.StatusCreated {
+			t.Errorf("Expected moderator post to succeed with %d, got %d", http.StatusCreated, code)
+		}
+	})
+
+	t.Run("members can post after unlock", func(t *testing.T) {
+		w := send("POST", "/api/channels/"+channel.ID+"/unlock", modToken, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		if code := post(memberToken); code != http.StatusCreated {
+			t.Errorf("Expected member post to succeed with %d, got %d", http.StatusCreated, code)
+		}
+
+		w = send("POST", "/api/channels/"+channel.ID+"/unlock", ownerToken, nil)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected unlocking an unlocked channel to fail with %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("members can post after expiry", func(t *testing.T) {
+		w := send("POST", "/api/channels/"+channel.ID+"/lock", ownerToken, LockChannelRequest{Duration: "10m"})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		if code := post(memberToken); code != http.StatusForbidden {
+			t.Errorf("Expected member post to be rejected with %d, got %d", http.StatusForbidden, code)
+		}
+
+		// Move the expiry into the past
+		db.Model(&Channel{}).Where("id = ?", channel.ID).Update("locked_until", time.Now().Add(-time.Minute))
+
+		if code := post(memberToken); code != http.StatusCreated {
+			t.Errorf("Expected member post to succeed with %d, got %d", http.StatusCreated, code)
+		}
+	})
+
+	t.Run("locking leaves ownership untouched", func(t *testing.T) {
+		var reloaded Channel
+		db.First(&reloaded, "id = ?", channel.ID)
+		if reloaded.OwnerID != ownerID {
+			t.Errorf("Expected owner %s, got %s", ownerID, reloaded.OwnerID)
+		}
+	})
+
+	t.Run("lock and unlock are recorded as system messages", func(t *testing.T) {
+		var count int64
+		db.Model(&Message{}).Where("channel_id = ? AND is_system = ?", channel.ID, true).Count(&count)
+		if count != 3 {
+			t.Errorf("Expected 3 system messages, got %d", count)
+		}
+	})
+}
+
+func TestChannelHandlers_GetModerationStatsHandler(t *testing.T) {
+	router, db, _, _ := setupChannelAdminRouter(t)
+	if err := db.AutoMigrate(&AuditLog{}); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	ownerID, ownerToken := createTestUserWithAuth(t, router, "owner", "password")
+	memberID, memberToken := createTestUserWithAuth(t, router, "member", "password")
+	modID, modToken := createTestUserWithAuth(t, router, "moderator", "password")
+
+	channelService := c.NewChannelService(db, c.NewMemberCache(0))
+	channel, err := channelService.CreateChannel(ownerID, "stats", nil, true, nil)
+	if err != nil {
+		t.Fatalf("Failed to create channel: %v", err)
+	}
+	other, err := channelService.CreateChannel(ownerID, "other", nil, true, nil)
+	if err != nil {
+		t.Fatalf("Failed to create channel: %v", err)
+	}
+	for _, id := range []string{memberID, modID} {
+		if err := channelService.JoinChannel(id, channel.ID, nil); err != nil {
+			t.Fatalf("Failed to join channel: %v", err)
+		}
+	}
+	modRole := Role{Name: "Moderator"}
+	db.FirstOrCreate(&modRole, Role{Name: "Moderator"})
+	db.Model(&UserChannel{}).Where("user_id = ? AND channel_id = ?", modID, channel.ID).Update("role_id", modRole.ID)
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	inWindow := from.Add(24 * time.Hour)
+	beforeWindow := from.Add(-24 * time.Hour)
+
+	logEvent := func(action, channelID string, at time.Time) {
+		log := AuditLog{Action: action, ActorID: ownerID, ChannelID: &channelID, Metadata: "{}"}
+		log.CreatedAt = at
+		if err := db.Create(&log).Error; err != nil {
+			t.Fatalf("Failed to create audit log: %v", err)
+		}
+	}
+	logEvent("BAN_USER", channel.ID, inWindow)
+	logEvent("BAN_USER", channel.ID, inWindow)
+	logEvent("TEMP_BAN_USER", channel.ID, inWindow)
+	logEvent("UNBAN_USER", channel.ID, inWindow)
+	logEvent("PROMOTE_USER", channel.ID, inWindow)
+	logEvent("LOCK_CHANNEL", channel.ID, inWindow)
+	logEvent("BAN_USER", channel.ID, beforeWindow)
+	logEvent("BAN_USER", other.ID, inWindow)
+
+	expired := inWindow.Add(time.Hour)
+	bans := []UserBan{
+		{UserID: memberID, ChannelID: channel.ID, BannedBy: ownerID, IsActive: true},
+		{UserID: modID, ChannelID: channel.ID, BannedBy: ownerID, IsActive: true, ExpiresAt: &expired},
+		{UserID: ownerID, ChannelID: channel.ID, BannedBy: ownerID, IsActive: true},
+	}
+	for i := range bans {
+		bans[i].CreatedAt = inWindow
+		if err := db.Create(&bans[i]).Error; err != nil {
+			t.Fatalf("Failed to create ban: %v", err)
+		}
+	}
+	// IsActive defaults to true on create, so lift the last ban afterwards
+	db.Model(&bans[2]).Update("is_active", false)
+
+	get := func(token, query string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/api/channels/"+channel.ID+"/moderation-stats"+query, nil)
+		req.AddCookie(&http.Cookie{Name: "token", Value: token})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	window := "?from=" + from.Format(time.RFC3339) + "&to=" + to.Format(time.RFC3339)
+
+	t.Run("counts events within the window", func(t *testing.T) {
+		for _, token := range []string{ownerToken, modToken} {
+			w := get(token, window)
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+			}
+
+			var stats ModerationStatsResponse
+			json.Unmarshal(w.Body.Bytes(), &stats)
+			expected := ModerationStatsResponse{
+				From:       from.Format(time.RFC3339),
+				To:         to.Format(time.RFC3339),
+				Bans:       2,
+				TempBans:   1,
+				Unbans:     1,
+				ActiveBans: 1,
+				Promotions: 1,
+				Locks:      1,
+			}
+			if stats != expected {
+				t.Errorf("Expected %+v, got %+v", expected, stats)
+			}
+		}
+	})
+
+	t.Run("members cannot view stats", func(t *testing.T) {
+		w := get(memberToken, window)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("Expected status %d, got %d", http.StatusForbidden, w.Code)
+		}
+	})
+
+	t.Run("invalid range", func(t *testing.T) {
+		w := get(ownerToken, "?from="+to.Format(time.RFC3339)+"&to="+from.Format(time.RFC3339))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+
+		w = get(ownerToken, "?from=yesterday")
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("channel not found", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/channels/missing/moderation-stats", nil)
+		req.AddCookie(&http.Cookie{Name: "token", Value: ownerToken})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+}
+
+func TestChannelHandlers_DescriptionAndTopic(t *testing.T) {
+	router, db, _, _ := setupChannelAdminRouter(t)
+	if err := db.AutoMigrate(&Message{}, &AuditLog{}); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+
+	ownerID, ownerToken := createTestUserWithAuth(t, router, "owner", "password")
+	memberID, memberToken := createTestUserWithAuth(t, router, "member", "password")
+	modID, modToken := createTestUserWithAuth(t, router, "moderator", "password")
+
+	channelService := c.NewChannelService(db, c.NewMemberCache(0))
+	channel, err := channelService.CreateChannel(ownerID, "planning", nil, true, nil)
+	if err != nil {
+		t.Fatalf("Failed to create channel: %v", err)
+	}
+	for _, userID := range []string{memberID, modID} {
+		if err := channelService.JoinChannel(userID, channel.ID, nil); err != nil {
+			t.Fatalf("Failed to join channel: %v", err)
+		}
+	}
+	modRole := Role{Name: "Moderator"}
+	db.FirstOrCreate(&modRole, Role{Name: "Moderator"})
+	db.Model(&UserChannel{}).Where("user_id = ? AND channel_id = ?", modID, channel.ID).Update("role_id", modRole.ID)
+
+	send := func(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+		reqBody, _ := json.Marshal(body)
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(&http.Cookie{Name: "token", Value: token})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	channelPath := "/api/channels/" + channel.ID
+	describe := func(token, description string) *httptest.ResponseRecorder {
+		return send("PATCH", channelPath, token, UpdateChannelRequest{Description: &description})
+	}
+	setTopic := func(token, topic string) *httptest.ResponseRecorder {
+		return send("PUT", channelPath+"/topic", token, SetChannelTopicRequest{Topic: topic})
+	}
+
+	t.Run("only the owner sets the description", func(t *testing.T) {
+		if w := describe(modToken, "Taken over"); w.Code != http.StatusForbidden {
+			t.Errorf("Expected moderator to get %d, got %d", http.StatusForbidden, w.Code)
+		}
+		if w := describe(ownerToken, "  Where releases are planned  "); w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("owner and moderators set the topic", func(t *testing.T) {
+		if w := setTopic(memberToken, "Lunch"); w.Code != http.StatusForbidden {
+			t.Errorf("Expected member to get %d, got %d", http.StatusForbidden, w.Code)
+		}
+
+		w := setTopic(modToken, "Release 2.0")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var response ChannelTopicResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if response.Topic != "Release 2.0" || !response.SystemMessage.IsSystem ||
+			response.SystemMessage.Content != "Topic changed to: Release 2.0" {
+			t.Errorf("Unexpected response: %+v", response)
+		}
+
+		if w := setTopic(ownerToken, "Release 2.1"); w.Code != http.StatusOK {
+			t.Errorf("Expected owner to set the topic, got %d", w.Code)
+		}
+	})
+
+	t.Run("description and topic are returned with the channel", func(t *testing.T) {
+		w := send("GET", channelPath, memberToken, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		var response ChannelDetailResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if response.Channel.Description != "Where releases are planned" || response.Channel.Topic != "Release 2.1" {
+			t.Errorf("Unexpected channel: %+v", response.Channel)
+		}
+	})
+
+	t.Run("length limits", func(t *testing.T) {
+		if w := describe(ownerToken, strings.Repeat("d", c.MaxDescriptionLength+1)); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected long description to be rejected with %d, got %d", http.StatusBadRequest, w.Code)
+		}
+		if w := setTopic(modToken, strings.Repeat("t", c.MaxTopicLength+1)); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected long topic to be rejected with %d, got %d", http.StatusBadRequest, w.Code)
+		}
+		// Limits count characters, not bytes
+		if w := setTopic(modToken, strings.Repeat("é", c.MaxTopicLength)); w.Code != http.StatusOK {
+			t.Errorf("Expected topic at the limit to be accepted, got %d", w.Code)
+		}
+	})
+
+	t.Run("malformed body", func(t *testing.T) {
+		w := send("PUT", channelPath+"/topic", modToken, map[string]int{"topic": 5})
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+		var response ValidationErrorResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if response.Error != "Invalid request body" {
+			t.Errorf("Expected the generic binding error, got %q", response.Error)
+		}
+	})
+
+	t.Run("empty topic clears it", func(t *testing.T) {
+		w := setTopic(modToken, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		var stored Channel
+		db.First(&stored, "id = ?", channel.ID)
+		if stored.Topic != "" || stored.Description != "Where releases are planned" {
+			t.Errorf("Expected cleared topic and unchanged description, got %q and %q", stored.Topic, stored.Description)
+		}
+	})
+}
@@ -0,0 +1,1085 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "go-chat/pkg/chat"
+)
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func TestChannelHandlers_CreateChannelHandler_LoggingDays(t *testing.T) {
+	router, db, _, _ := setupChannelAdminRouter(t)
+	_, token := createTestUserWithAuth(t, router, "creator", "password")
+
+	tests := []struct {
+		name           string
+		channelName    string
+		loggingDays    *int
+		expectedStatus int
+		expectedDays   uint
+	}{
+		{
+			name:           "defaults to 30 days",
+			channelName:    "default-retention",
+			loggingDays:    nil,
+			expectedStatus: http.StatusCreated,
+			expectedDays:   30,
+		},
+		{
+			name:           "explicit 0 disables history",
+			channelName:    "no-history",
+			loggingDays:    intPtr(0),
+			expectedStatus: http.StatusCreated,
+			expectedDays:   0,
+		},
+		{
+			name:           "maximum retention",
+			channelName:    "long-retention",
+			loggingDays:    intPtr(365),
+			expectedStatus: http.StatusCreated,
+			expectedDays:   365,
+		},
+		{
+			name:           "above maximum",
+			channelName:    "too-long",
+			loggingDays:    intPtr(366),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "negative",
+			channelName:    "negative",
+			loggingDays:    intPtr(-1),
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reqBody, _ := json.Marshal(CreateChannelRequest{
+				Name:        tt.channelName,
+				IsVisible:   true,
+				LoggingDays: tt.loggingDays,
+			})
+			req, _ := http.NewRequest("POST", "/api/channels", bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.AddCookie(&http.Cookie{Name: "token", Value: token})
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+
+			if tt.expectedStatus != http.StatusCreated {
+				var count int64
+				db.Model(&Channel{}).Where("name = ?", tt.channelName).Count(&count)
+				if count != 0 {
+					t.Errorf("Expected channel %q not to be created", tt.channelName)
+				}
+				return
+			}
+
+			var channel Channel
+			if err := db.Where("name = ?", tt.channelName).First(&channel).Error; err != nil {
+				t.Fatalf("Failed to load created channel: %v", err)
+			}
+			if channel.LoggingDays != tt.expectedDays {
+				t.Errorf("Expected logging days %d, got %d", tt.expectedDays, channel.LoggingDays)
+			}
+		})
+	}
+}
+
+func TestChannelHandlers_HideOwnerInListings(t *testing.T) {
+	router, _, _, ch := setupChannelAdminRouter(t)
+	ownerID, ownerToken := createTestUserWithAuth(t, router, "shyowner", "password")
+	_, viewerToken := createTestUserWithAuth(t, router, "viewer", "password")
+
+	channel, err := ch.service.CreateChannel(ownerID, "quiet-place", nil, true, nil)
+	if err != nil {
+		t.Fatalf("Failed to create channel: %v", err)
+	}
+
+	request := func(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+		reqBody, _ := json.Marshal(body)
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(reqBody))
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		req.AddCookie(&http.Cookie{Name: "token", Value: token})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	setHideOwner := func(token string, hide bool) int {
+		return request("PATCH", "/api/channels/"+channel.ID, token, UpdateChannelRequest{HideOwner: &hide}).Code
+	}
+	listedOwners := func() (string, string) {
+		var listing struct {
+			Channels []ChannelInfo `json:"channels"`
+		}
+		w := request("GET", "/api/channels", viewerToken, nil)
+		json.Unmarshal(w.Body.Bytes(), &listing)
+
+		var search ChannelsSearchResponse
+		w = request("GET", "/api/search/channels?q=quiet", viewerToken, nil)
+		json.Unmarshal(w.Body.Bytes(), &search)
+
+		if len(listing.Channels) != 1 || len(search.Channels) != 1 {
+			t.Fatalf("Expected the channel in listing and search, got %d and %d", len(listing.Channels), len(search.Channels))
+		}
+		return listing.Channels[0].Owner.Username, search.Channels[0].Owner.Username
+	}
+
+	t.Run("owner shown by default", func(t *testing.T) {
+		listed, searched := listedOwners()
+		if listed != "shyowner" || searched != "shyowner" {
+			t.Errorf("Expected owner to be shown, got %q and %q", listed, searched)
+		}
+	})
+
+	t.Run("only owner can change the setting", func(t *testing.T) {
+		if code := setHideOwner(viewerToken, true); code != http.StatusForbidden {
+			t.Errorf("Expected status %d, got %d", http.StatusForbidden, code)
+		}
+	})
+
+	t.Run("owner hidden when setting is on", func(t *testing.T) {
+		if code := setHideOwner(ownerToken, true); code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, code)
+		}
+		listed, searched := listedOwners()
+		if listed != HiddenOwnerName || searched != HiddenOwnerName {
+			t.Errorf("Expected owner to be hidden, got %q and %q", listed, searched)
+		}
+	})
+
+	t.Run("channel details hide owner from non-owners", func(t *testing.T) {
+		detailOwner := func(token string) ChannelOwner {
+			var detail ChannelDetailResponse
+			w := request("GET", "/api/channels/"+channel.ID, token, nil)
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+			}
+			json.Unmarshal(w.Body.Bytes(), &detail)
+			return detail.Channel.Owner
+		}
+
+		if owner := detailOwner(viewerToken); owner.Username != HiddenOwnerName || owner.ID != "" {
+			t.Errorf("Expected owner to be hidden from a non-owner, got %+v", owner)
+		}
+		if owner := detailOwner(ownerToken); owner.Username != "shyowner" || owner.ID != ownerID {
+			t.Errorf("Expected the owner to see themselves, got %+v", owner)
+		}
+	})
+
+	t.Run("owner shown again when setting is off", func(t *testing.T) {
+		if code := setHideOwner(ownerToken, false); code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, code)
+		}
+		listed, searched := listedOwners()
+		if listed != "shyowner" || searched != "shyowner" {
+			t.Errorf("Expected owner to be shown, got %q and %q", listed, searched)
+		}
+	})
+}
+
+func TestChannelHandlers_LastMessageAtInListings(t *testing.T) {
+	router, db, _, ch := setupChannelAdminRouter(t)
+	if err := db.AutoMigrate(&Message{}, &Attachment{}, &ChannelReadState{}); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+	ownerID, ownerToken := createTestUserWithAuth(t, router, "chatty", "password")
+
+	channel, err := ch.service.CreateChannel(ownerID, "active-place", nil, true, nil)
+	if err != nil {
+		t.Fatalf("Failed to create channel: %v", err)
+	}
+
+	request := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		reqBody, _ := json.Marshal(body)
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(reqBody))
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		req.AddCookie(&http.Cookie{Name: "token", Value: ownerToken})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	listed := func() (*string, *string) {
+		var listing ChannelsResponse
+		json.Unmarshal(request("GET", "/api/channels", nil).Body.Bytes(), &listing)
+		var search ChannelsSearchResponse
+		json.Unmarshal(request("GET", "/api/search/channels?q=active", nil).Body.Bytes(), &search)
+		if len(listing.Channels) != 1 || len(search.Channels) != 1 {
+			t.Fatalf("Expected the channel in listing and search, got %d and %d", len(listing.Channels), len(search.Channels))
+		}
+		return listing.Channels[0].LastMessageAt, search.Channels[0].LastMessageAt
+	}
+
+	if listedAt, searchedAt := listed(); listedAt != nil || searchedAt != nil {
+		t.Errorf("Expected no activity before the first message, got %v and %v", listedAt, searchedAt)
+	}
+
+	w := request("POST", "/api/channels/"+channel.ID+"/messages", CreateMessageRequest{Content: "first!"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var created CreateMessageResponse
+	json.Unmarshal(w.Body.Bytes(), &created)
+
+	var stored Channel
+	db.First(&stored, "id = ?", channel.ID)
+	if stored.LastMessageAt == nil {
+		t.Fatalf("Expected the channel's last_message_at to be set")
+	}
+
+	listedAt, searchedAt := listed()
+	if listedAt == nil || searchedAt == nil || *listedAt != *searchedAt {
+		t.Fatalf("Expected last_message_at in listing and search, got %v and %v", listedAt, searchedAt)
+	}
+	messageAt, _ := time.Parse(time.RFC3339, created.Message.CreatedAt)
+	activityAt, _ := time.Parse(time.RFC3339, *listedAt)
+	if !activityAt.Equal(messageAt.Truncate(time.Second)) {
+		t.Errorf("Expected last_message_at %v to match the message time %v", activityAt, messageAt)
+	}
+}
+
+func TestChannelHandlers_LastMessageAtUnloggedChannel(t *testing.T) {
+	router, db, _, ch := setupChannelAdminRouter(t)
+	if err := db.AutoMigrate(&Message{}, &Attachment{}, &ChannelReadState{}); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+	ownerID, ownerToken := createTestUserWithAuth(t, router, "ephemeral", "password")
+
+	channel, err := ch.service.CreateChannel(ownerID, "no-history", nil, true, nil)
+	if err != nil {
+		t.Fatalf("Failed to create channel: %v", err)
+	}
+	db.Model(&Channel{}).Where("id = ?", channel.ID).Update("logging_days", 0)
+
+	reqBody, _ := json.Marshal(CreateMessageRequest{Content: "gone soon"})
+	req, _ := http.NewRequest("POST", "/api/channels/"+channel.ID+"/messages", bytes.NewBuffer(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.AddCookie(&http.Cookie{Name: "token", Value: ownerToken})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	var messages int64
+	db.Model(&Message{}).Where("channel_id = ?", channel.ID).Count(&messages)
+	if messages != 0 {
+		t.Fatalf("Expected the message not to be stored, got %d", messages)
+	}
+
+	// The channel still counts as active even though nothing was stored
+	var stored Channel
+	db.First(&stored, "id = ?", channel.ID)
+	if stored.LastMessageAt == nil {
+		t.Errorf("Expected the channel's last_message_at to be set")
+	}
+}
+
+func TestChannelHandlers_MaxMembers(t *testing.T) {
+	router, _, _, ch := setupChannelAdminRouter(t)
+	ownerID, ownerToken := createTestUserWithAuth(t, router, "owner", "password")
+	_, memberToken := createTestUserWithAuth(t, router, "member", "password")
+	_, latecomerToken := createTestUserWithAuth(t, router, "latecomer", "password")
+
+	channel, err := ch.service.CreateChannel(ownerID, "cozy", nil, true, nil)
+	if err != nil {
+		t.Fatalf("Failed to create channel: %v", err)
+	}
+
+	request := func(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+		reqBody, _ := json.Marshal(body)
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(&http.Cookie{Name: "token", Value: token})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("negative limit rejected", func(t *testing.T) {
+		w := request("PATCH", "/api/channels/"+channel.ID, ownerToken, UpdateChannelRequest{MaxMembers: intPtr(-1)})
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("owner sets limit", func(t *testing.T) {
+		w := request("PATCH", "/api/channels/"+channel.ID, ownerToken, UpdateChannelRequest{MaxMembers: intPtr(2)})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		var response struct {
+			Channel struct {
+				MaxMembers int `json:"max_members"`
+			} `json:"channel"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if response.Channel.MaxMembers != 2 {
+			t.Errorf("Expected max_members 2, got %d", response.Channel.MaxMembers)
+		}
+	})
+
+	t.Run("joining twice is a conflict", func(t *testing.T) {
+		w := request("POST", "/api/channels/"+channel.ID+"/join", ownerToken, JoinChannelRequest{})
+		if w.Code != http.StatusConflict {
+			t.Fatalf("Expected status %d, got %d", http.StatusConflict, w.Code)
+		}
+		var response map[string]string
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if response["error"] != "user already in channel" {
+			t.Errorf("Expected 'user already in channel', got %q", response["error"])
+		}
+	})
+
+	t.Run("join rejected once full", func(t *testing.T) {
+		w := request("POST", "/api/channels/"+channel.ID+"/join", memberToken, JoinChannelRequest{})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		w = request("POST", "/api/channels/"+channel.ID+"/join", latecomerToken, JoinChannelRequest{})
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+		var response map[string]string
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if response["error"] != "channel is full" {
+			t.Errorf("Expected 'channel is full', got %q", response["error"])
+		}
+	})
+}
+
+func TestChannelHandlers_ChannelInfoShape(t *testing.T) {
+	router, _, _, _ := setupChannelAdminRouter(t)
+	ownerID, token := createTestUserWithAuth(t, router, "creator", "password")
+
+	request := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		reqBody, _ := json.Marshal(body)
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(reqBody))
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		req.AddCookie(&http.Cookie{Name: "token", Value: token})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	assertInfo := func(t *testing.T, info ChannelInfo) {
+		if info.ID == "" || info.Name != "shaped" || !info.IsVisible {
+			t.Errorf("Unexpected channel info: %+v", info)
+		}
+		if _, err := time.Parse(time.RFC3339, info.CreatedAt); err != nil {
+			t.Errorf("Expected RFC3339 created_at, got %q", info.CreatedAt)
+		}
+		if info.Owner.ID != ownerID || info.Owner.Username != "creator" {
+			t.Errorf("Expected owner creator, got %+v", info.Owner)
+		}
+		if info.LoggingDays != 30 {
+			t.Errorf("Expected logging_days 30, got %d", info.LoggingDays)
+		}
+	}
+
+	w := request("POST", "/api/channels", CreateChannelRequest{Name: "shaped", IsVisible: true})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+	var created ChannelResponse
+	json.Unmarshal(w.Body.Bytes(), &created)
+	assertInfo(t, created.Channel)
+
+	t.Run("get one", func(t *testing.T) {
+		var response ChannelResponse
+		json.Unmarshal(request("GET", "/api/channels/"+created.Channel.ID, nil).Body.Bytes(), &response)
+		assertInfo(t, response.Channel)
+	})
+
+	for _, path := range []string{"/api/channels", "/api/channels/me", "/api/user/channels/owned", "/api/user/channels/joined"} {
+		t.Run("list "+path, func(t *testing.T) {
+			var response ChannelsResponse
+			json.Unmarshal(request("GET", path, nil).Body.Bytes(), &response)
+			if len(response.Channels) != 1 {
+				t.Fatalf("Expected 1 channel, got %d", len(response.Channels))
+			}
+			assertInfo(t, response.Channels[0])
+		})
+	}
+}
+
+func TestChannelHandlers_GetChannelHandler_Membership(t *testing.T) {
+	router, _, _, ch := setupChannelAdminRouter(t)
+	ownerID, ownerToken := createTestUserWithAuth(t, router, "owner", "password")
+	memberID, memberToken := createTestUserWithAuth(t, router, "member", "password")
+	_, outsiderToken := createTestUserWithAuth(t, router, "outsider", "password")
+	bannedID, bannedToken := createTestUserWithAuth(t, router, "banned", "password")
+
+	channel, err := ch.service.CreateChannel(ownerID, "lobby", nil, true, nil)
+	if err != nil {
+		t.Fatalf("Failed to create channel: %v", err)
+	}
+	for _, userID := range []string{memberID, bannedID} {
+		if err := ch.service.JoinChannel(userID, channel.ID, nil); err != nil {
+			t.Fatalf("Failed to join channel: %v", err)
+		}
+	}
+	if _, err := ch.service.BanUser(ownerID, bannedID, channel.ID, "spam"); err != nil {
+		t.Fatalf("Failed to ban user: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		token    string
+		expected ChannelDetailResponse
+	}{
+		{"owner", ownerToken, ChannelDetailResponse{IsMember: true, IsOwner: true}},
+		{"member", memberToken, ChannelDetailResponse{IsMember: true}},
+		{"non-member", outsiderToken, ChannelDetailResponse{}},
+		{"banned", bannedToken, ChannelDetailResponse{IsBanned: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/api/channels/"+channel.ID, nil)
+			req.AddCookie(&http.Cookie{Name: "token", Value: tt.token})
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+			}
+
+			var response ChannelDetailResponse
+			json.Unmarshal(w.Body.Bytes(), &response)
+			if response.Channel.ID != channel.ID {
+				t.Errorf("Expected channel %s, got %s", channel.ID, response.Channel.ID)
+			}
+			if response.IsMember != tt.expected.IsMember || response.IsOwner != tt.expected.IsOwner || response.IsBanned != tt.expected.IsBanned {
+				t.Errorf("Expected is_member=%v is_owner=%v is_banned=%v, got is_member=%v is_owner=%v is_banned=%v",
+					tt.expected.IsMember, tt.expected.IsOwner, tt.expected.IsBanned,
+					response.IsMember, response.IsOwner, response.IsBanned)
+			}
+		})
+	}
+}
+
+func TestChannelHandlers_BulkJoinChannelsHandler(t *testing.T) {
+	router, _, _, ch := setupChannelAdminRouter(t)
+	ownerID, _ := createTestUserWithAuth(t, router, "owner", "password")
+	userID, token := createTestUserWithAuth(t, router, "newcomer", "password")
+
+	create := func(name string, password *string) *Channel {
+		channel, err := ch.service.CreateChannel(ownerID, name, password, true, nil)
+		if err != nil {
+			t.Fatalf("Failed to create channel: %v", err)
+		}
+		return channel
+	}
+	open := create("open", nil)
+	joined := create("joined", nil)
+	banned := create("banned", nil)
+	locked := create("locked", stringPtr("secret"))
+
+	for _, channel := range []*Channel{joined, banned} {
+		if err := ch.service.JoinChannel(userID, channel.ID, nil); err != nil {
+			t.Fatalf("Failed to join channel: %v", err)
+		}
+	}
+	if _, err := ch.service.BanUser(ownerID, userID, banned.ID, "spam"); err != nil {
+		t.Fatalf("Failed to ban user: %v", err)
+	}
+
+	request := func(body interface{}) *httptest.ResponseRecorder {
+		reqBody, _ := json.Marshal(body)
+		req, _ := http.NewRequest("POST", "/api/channels/join-bulk", bytes.NewBuffer(reqBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(&http.Cookie{Name: "token", Value: token})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("mixed batch", func(t *testing.T) {
+		w := request(BulkJoinRequest{ChannelIDs: []string{open.ID, joined.ID, banned.ID, "missing", locked.ID}})
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		var response BulkJoinResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+
+		expected := []BulkJoinResult{
+			{ChannelID: open.ID, Status: BulkJoinJoined},
+			{ChannelID: joined.ID, Status: BulkJoinAlreadyMember},
+			{ChannelID: banned.ID, Status: BulkJoinBanned},
+			{ChannelID: "missing", Status: BulkJoinNotFound},
+			{ChannelID: locked.ID, Status: BulkJoinPasswordRequired},
+		}
+		if len(response.Results) != len(expected) {
+			t.Fatalf("Expected %d results, got %+v", len(expected), response.Results)
+		}
+		for i := range expected {
+			if response.Results[i] != expected[i] {
+				t.Errorf("Result %d: expected %+v, got %+v", i, expected[i], response.Results[i])
+			}
+		}
+		if response.Joined != 1 {
+			t.Errorf("Expected 1 joined, got %d", response.Joined)
+		}
+	})
+
+	t.Run("empty batch rejected", func(t *testing.T) {
+		if w := request(BulkJoinRequest{ChannelIDs: []string{}}); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("oversized batch rejected", func(t *testing.T) {
+		ids := make([]string, MaxBulkJoinChannels+1)
+		for i := range ids {
+			ids[i] = fmt.Sprintf("ch%d", i)
+		}
+		if w := request(BulkJoinRequest{ChannelIDs: ids}); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+}
+
+func TestChannelHandlers_ListingPagination(t *testing.T) {
+	router, _, _, ch := setupChannelAdminRouter(t)
+	ownerID, token := createTestUserWithAuth(t, router, "collector", "password")
+
+	// Created out of order to check listings are sorted by name
+	for i := 24; i >= 0; i-- {
+		if _, err := ch.service.CreateChannel(ownerID, fmt.Sprintf("room-%02d", i), nil, true, nil); err != nil {
+			t.Fatalf("Failed to create channel: %v", err)
+		}
+	}
+
+	list := func(path string) ChannelsResponse {
+		req, _ := http.NewRequest("GET", path, nil)
+		req.AddCookie(&http.Cookie{Name: "token", Value: token})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		var response ChannelsResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return response
+	}
+	names := func(response ChannelsResponse) []string {
+		var names []string
+		for _, channel := range response.Channels {
+			names = append(names, channel.Name)
+		}
+		return names
+	}
+
+	for _, path := range []string{"/api/channels", "/api/channels/me", "/api/user/channels/owned", "/api/user/channels/joined"} {
+		t.Run(path, func(t *testing.T) {
+			first := list(path)
+			if first.Total != 25 || first.Page != 1 || first.Limit != DefaultPageLimit || len(first.Channels) != DefaultPageLimit {
+				t.Errorf("Unexpected default page: total=%d page=%d limit=%d len=%d", first.Total, first.Page, first.Limit, len(first.Channels))
+			}
+			if first.TotalPages != 2 || !first.HasNext || first.HasPrev {
+				t.Errorf("Unexpected first page navigation: total_pages=%d has_next=%v has_prev=%v", first.TotalPages, first.HasNext, first.HasPrev)
+			}
+			if first.Channels[0].Name != "room-00" {
+				t.Errorf("Expected listing to start with room-00, got %s", first.Channels[0].Name)
+			}
+
+			second := list(path + "?page=2&limit=10")
+			expected := []string{"room-10", "room-11", "room-12", "room-13", "room-14", "room-15", "room-16", "room-17", "room-18", "room-19"}
+			if fmt.Sprint(names(second)) != fmt.Sprint(expected) {
+				t.Errorf("Expected second page %v, got %v", expected, names(second))
+			}
+			if second.Total != 25 || second.Page != 2 || second.Limit != 10 {
+				t.Errorf("Unexpected second page metadata: total=%d page=%d limit=%d", second.Total, second.Page, second.Limit)
+			}
+			if second.TotalPages != 3 || !second.HasNext || !second.HasPrev {
+				t.Errorf("Unexpected middle page navigation: total_pages=%d has_next=%v has_prev=%v", second.TotalPages, second.HasNext, second.HasPrev)
+			}
+
+			last := list(path + "?page=3&limit=10")
+			if len(last.Channels) != 5 {
+				t.Errorf("Expected 5 channels on the last page, got %d", len(last.Channels))
+			}
+			if last.TotalPages != 3 || last.HasNext || !last.HasPrev {
+				t.Errorf("Unexpected last page navigation: total_pages=%d has_next=%v has_prev=%v", last.TotalPages, last.HasNext, last.HasPrev)
+			}
+
+			clamped := list(path + "?limit=1000")
+			if clamped.Limit != MaxPageLimit || len(clamped.Channels) != 25 {
+				t.Errorf("Expected limit clamped to %d with all 25 channels, got limit=%d len=%d", MaxPageLimit, clamped.Limit, len(clamped.Channels))
+			}
+		})
+	}
+}
+
+func TestChannelHandlers_GetChannelsHandler_ETag(t *testing.T) {
+	router, _, _, ch := setupChannelAdminRouter(t)
+	ownerID, token := createTestUserWithAuth(t, router, "poller", "password")
+
+	if _, err := ch.service.CreateChannel(ownerID, "general", nil, true, nil); err != nil {
+		t.Fatalf("Failed to create channel: %v", err)
+	}
+
+	list := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/api/channels", nil)
+		req.AddCookie(&http.Cookie{Name: "token", Value: token})
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	first := list("")
+	if first.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, first.Code)
+	}
+	etag := first.Header().Get("ETag")
+	if etag == "" {
+		t.Fatalf("Expected an ETag header")
+	}
+	var response ChannelsResponse
+	json.Unmarshal(first.Body.Bytes(), &response)
+	if len(response.Channels) != 1 {
+		t.Errorf("Expected 1 channel, got %d", len(response.Channels))
+	}
+
+	unchanged := list(etag)
+	if unchanged.Code != http.StatusNotModified {
+		t.Fatalf("Expected status %d, got %d", http.StatusNotModified, unchanged.Code)
+	}
+	if unchanged.Body.Len() != 0 {
+		t.Errorf("Expected an empty body, got %q", unchanged.Body.String())
+	}
+	if unchanged.Header().Get("ETag") != etag {
+		t.Errorf("Expected the 304 to repeat the ETag")
+	}
+
+	if _, err := ch.service.CreateChannel(ownerID, "random", nil, true, nil); err != nil {
+		t.Fatalf("Failed to create channel: %v", err)
+	}
+
+	changed := list(etag)
+	if changed.Code != http.StatusOK {
+		t.Fatalf("Expected status %d after a change, got %d", http.StatusOK, changed.Code)
+	}
+	if changed.Header().Get("ETag") == etag {
+		t.Errorf("Expected a new ETag after a change")
+	}
+	json.Unmarshal(changed.Body.Bytes(), &response)
+	if len(response.Channels) != 2 {
+		t.Errorf("Expected 2 channels, got %d", len(response.Channels))
+	}
+}
+
+func TestChannelHandlers_ValidationErrors(t *testing.T) {
+	router, _, _, _ := setupChannelAdminRouter(t)
+	_, token := createTestUserWithAuth(t, router, "validator", "password")
+
+	tests := []struct {
+		name           string
+		path           string
+		body           string
+		expectedErrors map[string]string
+	}{
+		{"create channel without name", "/api/channels", `{"is_visible":true}`, map[string]string{"name": "is required"}},
+		{"ban without user", "/api/channels/ch123/ban", `{"reason":"spam"}`, map[string]string{"user_id": "is required"}},
+		{"temp ban without user or duration", "/api/channels/ch123/tempban", `{}`, map[string]string{"user_id": "is required", "duration": "is required"}},
+		{"promote without role", "/api/channels/ch123/promote", `{"user_id":"abc12345"}`, map[string]string{"role": "is required"}},
+		{"malformed body", "/api/channels", `{"name":`, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", tt.path, bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.AddCookie(&http.Cookie{Name: "token", Value: token})
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("Expected status 400, got %d: %s", w.Code, w.Body.String())
+			}
+
+			var response ValidationErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to parse response: %v", err)
+			}
+			if response.Error == "" {
+				t.Errorf("Expected an error message, got %s", w.Body.String())
+			}
+			if len(response.Errors) != len(tt.expectedErrors) {
+				t.Fatalf("Expected field errors %v, got %v", tt.expectedErrors, response.Errors)
+			}
+			for field, message := range tt.expectedErrors {
+				if response.Errors[field] != message {
+					t.Errorf("Expected %s %q, got %q", field, message, response.Errors[field])
+				}
+			}
+		})
+	}
+}
+
+func TestChannelHandlers_Categories(t *testing.T) {
+	router, db, _, ch := setupChannelAdminRouter(t)
+	if err := db.AutoMigrate(&ChannelCategory{}); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+	ownerID, ownerToken := createTestUserWithAuth(t, router, "curator", "password")
+	_, otherToken := createTestUserWithAuth(t, router, "passerby", "password")
+
+	request := func(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+		reqBody, _ := json.Marshal(body)
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(reqBody))
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		req.AddCookie(&http.Cookie{Name: "token", Value: token})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	createCategory := func(name string) CategoryInfo {
+		w := request("POST", "/api/categories", ownerToken, CreateCategoryRequest{Name: name})
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+		var response CategoryResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return response.Category
+	}
+
+	dev := createCategory("Dev")
+	offTopic := createCategory("Off-topic")
+
+	if w := request("POST", "/api/categories", ownerToken, CreateCategoryRequest{Name: "dev"}); w.Code != http.StatusConflict {
+		t.Errorf("Expected duplicate category to be rejected with %d, got %d", http.StatusConflict, w.Code)
+	}
+	if w := request("POST", "/api/categories", ownerToken, CreateCategoryRequest{Name: "General", Global: true}); w.Code != http.StatusForbidden {
+		t.Errorf("Expected global category from a non-admin to be rejected with %d, got %d", http.StatusForbidden, w.Code)
+	}
+
+	var channels []*Channel
+	for _, name := range []string{"backend", "frontend", "memes", "lobby"} {
+		channel, err := ch.service.CreateChannel(ownerID, name, nil, true, nil)
+		if err != nil {
+			t.Fatalf("Failed to create channel: %v", err)
+		}
+		channels = append(channels, channel)
+	}
+
+	assign := func(token, channelID string, categoryID *uint) int {
+		return request("PUT", "/api/channels/"+channelID+"/category", token, SetChannelCategoryRequest{CategoryID: categoryID}).Code
+	}
+
+	t.Run("only the owner assigns a category", func(t *testing.T) {
+		if code := assign(otherToken, channels[0].ID, &dev.ID); code != http.StatusForbidden {
+			t.Errorf("Expected status %d, got %d", http.StatusForbidden, code)
+		}
+	})
+
+	t.Run("other users' categories are not found", func(t *testing.T) {
+		w := request("POST", "/api/categories", otherToken, CreateCategoryRequest{Name: "Private"})
+		var foreign CategoryResponse
+		json.Unmarshal(w.Body.Bytes(), &foreign)
+		if code := assign(ownerToken, channels[0].ID, &foreign.Category.ID); code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, code)
+		}
+	})
+
+	for i, categoryID := range []*uint{&dev.ID, &dev.ID, &offTopic.ID} {
+		if code := assign(ownerToken, channels[i].ID, categoryID); code != http.StatusOK {
+			t.Fatalf("Expected status %d assigning %s, got %d", http.StatusOK, channels[i].Name, code)
+		}
+	}
+
+	t.Run("grouped listing", func(t *testing.T) {
+		w := request("GET", "/api/channels?group_by=category", ownerToken, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+		var listing ChannelsResponse
+		json.Unmarshal(w.Body.Bytes(), &listing)
+
+		var got []string
+		for _, group := range listing.Groups {
+			name := "uncategorized"
+			if group.Category != nil {
+				name = group.Category.Name
+			}
+			var names []string
+			for _, channel := range group.Channels {
+				names = append(names, channel.Name)
+			}
+			got = append(got, name+": "+strings.Join(names, ", "))
+		}
+		expected := []string{"Dev: backend, frontend", "Off-topic: memes", "uncategorized: lobby"}
+		if fmt.Sprint(got) != fmt.Sprint(expected) {
+			t.Errorf("Expected groups %v, got %v", expected, got)
+		}
+		if len(listing.Channels) != 4 || listing.Channels[0].CategoryID == nil || *listing.Channels[0].CategoryID != dev.ID {
+			t.Errorf("Expected the flat list in category order, got %+v", listing.Channels)
+		}
+	})
+
+	t.Run("ungrouped listing has no groups", func(t *testing.T) {
+		var listing ChannelsResponse
+		json.Unmarshal(request("GET", "/api/channels", ownerToken, nil).Body.Bytes(), &listing)
+		if listing.Groups != nil {
+			t.Errorf("Expected no groups without group_by, got %v", listing.Groups)
+		}
+	})
+
+	t.Run("invalid group_by", func(t *testing.T) {
+		if w := request("GET", "/api/channels?group_by=owner", ownerToken, nil); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("clearing the category", func(t *testing.T) {
+		if code := assign(ownerToken, channels[2].ID, nil); code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, code)
+		}
+		var stored Channel
+		db.First(&stored, "id = ?", channels[2].ID)
+		if stored.CategoryID != nil {
+			t.Errorf("Expected the category to be cleared, got %v", *stored.CategoryID)
+		}
+	})
+
+	var categories CategoriesResponse
+	json.Unmarshal(request("GET", "/api/categories", ownerToken, nil).Body.Bytes(), &categories)
+	if len(categories.Categories) != 2 || categories.Categories[0].Name != "Dev" {
+		t.Errorf("Expected the owner's two categories, got %+v", categories.Categories)
+	}
+}
+
+func TestChannelHandlers_DeleteChannelHandler_Confirmation(t *testing.T) {
+	router, db, _, ch := setupChannelAdminRouter(t)
+	ch.SetDeleteConfirmation(true)
+	ownerID, ownerToken := createTestUserWithAuth(t, router, "cautious", "password")
+
+	channel, err := ch.service.CreateChannel(ownerID, "precious", nil, true, nil)
+	if err != nil {
+		t.Fatalf("Failed to create channel: %v", err)
+	}
+
+	deleteChannel := func(body interface{}) *httptest.ResponseRecorder {
+		var reqBody *bytes.Buffer
+		if body != nil {
+			raw, _ := json.Marshal(body)
+			reqBody = bytes.NewBuffer(raw)
+		} else {
+			reqBody = &bytes.Buffer{}
+		}
+		req, _ := http.NewRequest("DELETE", "/api/channels/"+channel.ID, reqBody)
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		req.AddCookie(&http.Cookie{Name: "token", Value: ownerToken})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	tests := []struct {
+		name string
+		body interface{}
+	}{
+		{"without a body", nil},
+		{"without confirm", DeleteChannelRequest{}},
+		{"with the wrong name", DeleteChannelRequest{Confirm: "other"}},
+		{"with the name in another case", DeleteChannelRequest{Confirm: "PRECIOUS"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := deleteChannel(tt.body)
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+			}
+			var response map[string]string
+			json.Unmarshal(w.Body.Bytes(), &response)
+			if response["error"] != "confirmation required" {
+				t.Errorf("Expected confirmation error, got %q", response["error"])
+			}
+			var count int64
+			db.Model(&Channel{}).Where("id = ?", channel.ID).Count(&count)
+			if count != 1 {
+				t.Fatalf("Expected the channel to survive an unconfirmed deletion")
+			}
+		})
+	}
+
+	if w := deleteChannel(DeleteChannelRequest{Confirm: "precious"}); w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var count int64
+	db.Model(&Channel{}).Where("id = ?", channel.ID).Count(&count)
+	if count != 0 {
+		t.Errorf("Expected the channel to be deleted once confirmed")
+	}
+}
+
+func TestChannelHandlers_DeleteChannelHandler_ConfirmationDisabled(t *testing.T) {
+	router, _, _, ch := setupChannelAdminRouter(t)
+	ch.SetDeleteConfirmation(false)
+	ownerID, ownerToken := createTestUserWithAuth(t, router, "hasty", "password")
+
+	channel, err := ch.service.CreateChannel(ownerID, "scratch", nil, true, nil)
+	if err != nil {
+		t.Fatalf("Failed to create channel: %v", err)
+	}
+
+	req, _ := http.NewRequest("DELETE", "/api/channels/"+channel.ID, nil)
+	req.AddCookie(&http.Cookie{Name: "token", Value: ownerToken})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected deletion without confirmation to succeed, got %d", w.Code)
+	}
+}
+
+func TestChannelHandlers_VerifyChannelPasswordHandler(t *testing.T) {
+	router, db, _, ch := setupChannelAdminRouter(t)
+	ownerID, _ := createTestUserWithAuth(t, router, "keeper", "password")
+	visitorID, visitorToken := createTestUserWithAuth(t, router, "visitor", "password")
+
+	protected, err := ch.service.CreateChannel(ownerID, "vault", stringPtr("s3cretpass"), true, nil)
+	if err != nil {
+		t.Fatalf("Failed to create channel: %v", err)
+	}
+	open, err := ch.service.CreateChannel(ownerID, "lobby", nil, true, nil)
+	if err != nil {
+		t.Fatalf("Failed to create channel: %v", err)
+	}
+
+	verify := func(channelID string, body interface{}) *httptest.ResponseRecorder {
+		raw, _ := json.Marshal(body)
+		req, _ := http.NewRequest("POST", "/api/channels/"+channelID+"/verify-password", bytes.NewBuffer(raw))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(&http.Cookie{Name: "token", Value: visitorToken})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	tests := []struct {
+		name      string
+		channelID string
+		password  string
+		expected  bool
+	}{
+		{"correct password", protected.ID, "s3cretpass", true},
+		{"incorrect password", protected.ID, "guess", false},
+		{"unprotected channel", open.ID, "anything", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := verify(tt.channelID, VerifyChannelPasswordRequest{Password: tt.password})
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+			}
+			var response VerifyChannelPasswordResponse
+			json.Unmarshal(w.Body.Bytes(), &response)
+			if response.Valid != tt.expected {
+				t.Errorf("Expected valid=%v, got %v", tt.expected, response.Valid)
+			}
+		})
+	}
+
+	if w := verify("missing", VerifyChannelPasswordRequest{Password: "s3cretpass"}); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for a missing channel, got %d", http.StatusNotFound, w.Code)
+	}
+
+	var count int64
+	db.Model(&UserChannel{}).Where("user_id = ?", visitorID).Count(&count)
+	if count != 0 {
+		t.Errorf("Expected verifying a password not to join the channel")
+	}
+
+	// The burst is small enough that a guessing client is cut off quickly
+	limited := false
+	for i := 0; i < 10 && !limited; i++ {
+		limited = verify(protected.ID, VerifyChannelPasswordRequest{Password: "guess"}).Code == http.StatusTooManyRequests
+	}
+	if !limited {
+		t.Errorf("Expected password checks to be rate limited")
+	}
+}
+
+func TestChannelHandlers_JoinedListingsSkipLeftChannels(t *testing.T) {
+	router, _, _, ch := setupChannelAdminRouter(t)
+	ownerID, _ := createTestUserWithAuth(t, router, "owner", "password")
+	_, memberToken := createTestUserWithAuth(t, router, "member", "password")
+
+	stay, err := ch.service.CreateChannel(ownerID, "stay", nil, true, nil)
+	if err != nil {
+		t.Fatalf("Failed to create channel: %v", err)
+	}
+	left, err := ch.service.CreateChannel(ownerID, "left", nil, true, nil)
+	if err != nil {
+		t.Fatalf("Failed to create channel: %v", err)
+	}
+
+	request := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, nil)
+		if body != nil {
+			reqBody, _ := json.Marshal(body)
+			req, _ = http.NewRequest(method, path, bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+		}
+		req.AddCookie(&http.Cookie{Name: "token", Value: memberToken})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, channel := range []*Channel{stay, left} {
+		if w := request("POST", "/api/channels/"+channel.ID+"/join", JoinChannelRequest{}); w.Code != http.StatusOK {
+			t.Fatalf("Failed to join %s: %d", channel.Name, w.Code)
+		}
+	}
+	if w := request("DELETE", "/api/channels/"+left.ID+"/leave", nil); w.Code != http.StatusOK {
+		t.Fatalf("Failed to leave channel: %d %s", w.Code, w.Body.String())
+	}
+
+	for _, path := range []string{"/api/channels/me", "/api/user/channels/joined"} {
+		w := request("GET", path, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status %d, got %d", path, http.StatusOK, w.Code)
+		}
+		var response ChannelsResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if len(response.Channels) != 1 || response.Channels[0].ID != stay.ID || response.Total != 1 {
+			t.Errorf("%s: expected only the channel still joined, got %d channels (total %d)", path, len(response.Channels), response.Total)
+		}
+	}
+}
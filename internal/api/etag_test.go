@@ -0,0 +1,34 @@
+package api
+
+import "testing"
+
+func TestEtagMatches(t *testing.T) {
+	etag := computeETag([]byte(`{"channels":[]}`))
+	if etag != computeETag([]byte(`{"channels":[]}`)) {
+		t.Fatalf("Expected the same body to get the same ETag")
+	}
+	if etag == computeETag([]byte(`{"channels":[{}]}`)) {
+		t.Fatalf("Expected a different body to get a different ETag")
+	}
+
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		expected    bool
+	}{
+		{"empty", "", false},
+		{"same tag", etag, true},
+		{"weak tag", "W/" + etag, true},
+		{"in a list", `"other", ` + etag, true},
+		{"wildcard", "*", true},
+		{"other tag", `"other"`, false},
+		{"unquoted", etag[1 : len(etag)-1], false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := etagMatches(tt.ifNoneMatch, etag); got != tt.expected {
+				t.Errorf("etagMatches(%q) = %v, want %v", tt.ifNoneMatch, got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type HealthHandlers struct {
+	db *gorm.DB
+}
+
+func NewHealthHandlers(db *gorm.DB) *HealthHandlers {
+	return &HealthHandlers{db: db}
+}
+
+type HealthResponse struct {
+	Status string `json:"status" example:"ok"`
+	DB     string `json:"db,omitempty" example:"up"`
+}
+
+// LivenessHandler reports that the process is alive
+// @Summary Liveness check
+// @Description Lightweight liveness probe that does not touch any dependency
+// @Tags System
+// @Produce json
+// @Success 200 {object} HealthResponse "Alive"
+// @Router /hc/live [get]
+func (h *HealthHandlers) LivenessHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, HealthResponse{Status: "ok"})
+}
+
+// ReadinessHandler reports whether the server can serve requests
+// @Summary Readiness check
+// @Description Readiness probe that pings the database
+// @Tags System
+// @Produce json
+// @Success 200 {object} HealthResponse "Ready"
+// @Failure 503 {object} HealthResponse "Database unreachable"
+// @Router /hc/ready [get]
+func (h *HealthHandlers) ReadinessHandler(c *gin.Context) {
+	sqlDB, err := h.db.DB()
+	if err == nil {
+		err = sqlDB.PingContext(c.Request.Context())
+	}
+
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, HealthResponse{Status: "unavailable", DB: "down"})
+		return
+	}
+
+	c.JSON(http.StatusOK, HealthResponse{Status: "ok", DB: "up"})
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := setupTestDB(t)
+	router := gin.New()
+	NewRouter(db).RegisterRoutes(router)
+
+	get := func(path string) (*httptest.ResponseRecorder, HealthResponse) {
+		req := httptest.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response HealthResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response
+	}
+
+	t.Run("ready when database is up", func(t *testing.T) {
+		w, response := get("/hc/ready")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, HealthResponse{Status: "ok", DB: "up"}, response)
+	})
+
+	t.Run("live", func(t *testing.T) {
+		w, response := get("/hc/live")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "ok", response.Status)
+	})
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	require.NoError(t, sqlDB.Close())
+
+	t.Run("not ready when database is down", func(t *testing.T) {
+		w, response := get("/hc/ready")
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, "down", response.DB)
+	})
+
+	t.Run("live when database is down", func(t *testing.T) {
+		w, _ := get("/hc/live")
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
@@ -4,7 +4,9 @@ import (
 	"net/http"
 	"strconv"
 
+	c "go-chat/internal/channel"
 	m "go-chat/internal/message"
+	"go-chat/pkg/chat"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
@@ -13,33 +15,83 @@ type MessageHandlers struct {
 	service *m.MessageService
 }
 
-func NewMessageHandlers(db *gorm.DB) *MessageHandlers {
+func NewMessageHandlers(db *gorm.DB, members *c.MemberCache) *MessageHandlers {
 	return &MessageHandlers{
-		service: m.NewMessageService(db),
+		service: m.NewMessageService(db, members),
 	}
 }
 
+// DeletedMessageContent replaces the content of deleted messages kept in history
+const DeletedMessageContent = "[message deleted]"
+
 type MessageInfo struct {
-	ID        string `json:"id"`
-	Content   string `json:"content"`
-	UserID    string `json:"user_id"`
-	ChannelID string `json:"channel_id"`
-	CreatedAt string `json:"created_at"`
+	ID        string  `json:"id"`
+	Content   string  `json:"content"`
+	UserID    string  `json:"user_id"`
+	ChannelID string  `json:"channel_id"`
+	CreatedAt string  `json:"created_at"`
+	EditedAt  *string `json:"edited_at" example:"2023-01-01T00:05:00Z"` // null unless edited
+	IsDeleted bool    `json:"is_deleted"`
+	IsSystem  bool    `json:"is_system"`
+	Format    string  `json:"format" example:"plain"` // plain or markdown; clients decide how to render it
+	Seq       int64   `json:"seq" example:"42"`       // Per-channel order of the message; sort by it when deliveries interleave
 	User      struct {
 		ID       string `json:"id"`
 		Username string `json:"username"`
 	} `json:"user"`
+	Attachments []AttachmentInfo `json:"attachments"`
+}
+
+// AttachmentRequest references a file hosted elsewhere; uploads are not supported
+type AttachmentRequest struct {
+	URL      string `json:"url" binding:"required" example:"https://files.example.com/report.pdf"` // Absolute http or https URL
+	MimeType string `json:"mime_type,omitempty" example:"application/pdf"`
+	Size     int64  `json:"size,omitempty" example:"52340"` // In bytes
+	Filename string `json:"filename,omitempty" example:"report.pdf"`
+}
+
+type AttachmentInfo struct {
+	ID       uint   `json:"id" example:"1"`
+	URL      string `json:"url" example:"https://files.example.com/report.pdf"`
+	MimeType string `json:"mime_type" example:"application/pdf"`
+	Size     int64  `json:"size" example:"52340"`
+	Filename string `json:"filename" example:"report.pdf"`
+}
+
+type CreateMessageRequest struct {
+	Content     string              `json:"content" binding:"required" example:"Hello everyone!"`
+	Format      string              `json:"format,omitempty" example:"markdown"` // plain (default) or markdown; stored as-is, never rendered
+	Attachments []AttachmentRequest `json:"attachments,omitempty" binding:"omitempty,dive"` // At most 5
+}
+
+// attachments converts the requested attachments to the models stored with the message
+func (r CreateMessageRequest) attachments() []chat.Attachment {
+	var attachments []chat.Attachment
+	for _, attachment := range r.Attachments {
+		attachments = append(attachments, chat.Attachment{
+			URL:      attachment.URL,
+			MimeType: attachment.MimeType,
+			Size:     attachment.Size,
+			Filename: attachment.Filename,
+		})
+	}
+	return attachments
+}
+
+type CreateMessageResponse struct {
+	Message MessageInfo `json:"message"`
 }
 
 type MessagesResponse struct {
-	Messages []MessageInfo `json:"messages"`
-	HasMore  bool          `json:"has_more,omitempty"`
-	Total    int64         `json:"total,omitempty"`
+	Messages   []MessageInfo `json:"messages"`
+	HasMore    bool          `json:"has_more,omitempty"`
+	Total      int64         `json:"total,omitempty"`
+	NextBefore string        `json:"next_before,omitempty" example:"msg123"` // Pass as before to fetch the next older page; set while has_more
 }
 
 // GetChannelMessagesHandler retrieves message history for a channel
 // @Summary Get channel message history
-// @Description Get paginated message history for a channel (only for channel members)
+// @Description Get paginated message history for a channel (only for channel members). Page backwards with offset, or pass next_before from the previous page as before; has_more reports whether older messages remain.
 // @Tags Messages
 // @Accept json
 // @Produce json
@@ -48,6 +100,7 @@ type MessagesResponse struct {
 // @Param limit query int false "Number of messages to retrieve (default: 50, max: 100)"
 // @Param offset query int false "Number of messages to skip (default: 0)"
 // @Param before query string false "Get messages before this message ID"
+// @Param since query string false "Replay messages created after this message ID or RFC3339 timestamp, oldest first (max 100)"
 // @Success 200 {object} MessagesResponse "Messages retrieved successfully"
 // @Failure 400 {object} ErrorResponse "Bad request"
 // @Failure 401 {object} ErrorResponse "User not authenticated"
@@ -84,9 +137,18 @@ func (h *MessageHandlers) GetChannelMessagesHandler(c *gin.Context) {
 	}
 
 	beforeID := c.Query("before")
+	since := c.Query("since")
 
-	// Get messages
-	messages, total, err := h.service.GetChannelMessages(userID.(string), channelID, limit, offset, beforeID)
+	// Get messages, either replaying what was missed since a point or paging through history
+	var messages []chat.Message
+	var total int64
+	var hasMore bool
+	if since != "" {
+		messages, hasMore, err = h.service.GetMessagesSince(userID.(string), channelID, since, limit)
+		total = int64(len(messages))
+	} else {
+		messages, total, hasMore, err = h.service.GetChannelMessages(userID.(string), channelID, limit, offset, beforeID)
+	}
 	if err != nil {
 		if err.Error() == "channel not found" {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Channel not found"})
@@ -96,6 +158,10 @@ func (h *MessageHandlers) GetChannelMessagesHandler(c *gin.Context) {
 			c.JSON(http.StatusForbidden, gin.H{"error": "You are not a member of this channel"})
 			return
 		}
+		if err.Error() == "invalid since value" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid since value, expected a message ID or RFC3339 timestamp"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve messages"})
 		return
 	}
@@ -103,23 +169,268 @@ func (h *MessageHandlers) GetChannelMessagesHandler(c *gin.Context) {
 	// Convert to response format
 	var messageResponses []MessageInfo
 	for _, msg := range messages {
-		msgResponse := MessageInfo{
-			ID:        msg.ID,
-			Content:   msg.Content,
-			UserID:    msg.UserID,
-			ChannelID: msg.ChannelID,
-			CreatedAt: msg.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		}
-		msgResponse.User.ID = msg.User.ID
-		msgResponse.User.Username = msg.User.Username
-		messageResponses = append(messageResponses, msgResponse)
+		messageResponses = append(messageResponses, toMessageInfo(msg))
 	}
 
 	response := MessagesResponse{
 		Messages: messageResponses,
 		Total:    total,
-		HasMore:  int64(offset+limit) < total,
+		HasMore:  hasMore,
+	}
+	// History pages run backwards, so the oldest message is the next cursor
+	if hasMore && since == "" && len(messages) > 0 {
+		response.NextBefore = messages[0].ID
 	}
 
 	c.JSON(http.StatusOK, response)
-}
\ No newline at end of file
+}
+
+// GetPreviewMessagesHandler shows recent history of a channel to prospective members
+// @Summary Preview channel messages
+// @Description Get the most recent messages of a public channel without joining it. Only available for visible, password-less channels that keep history and have previews enabled by the owner.
+// @Tags Messages
+// @Produce json
+// @Security CookieAuth
+// @Param id path string true "Channel ID"
+// @Param limit query int false "Number of messages to retrieve (default and max: 20)"
+// @Success 200 {object} MessagesResponse "Recent messages, oldest first"
+// @Failure 401 {object} ErrorResponse "User not authenticated"
+// @Failure 403 {object} ErrorResponse "Preview is not available for this channel"
+// @Failure 404 {object} ErrorResponse "Channel not found"
+// @Router /api/channels/{id}/preview-messages [get]
+func (h *MessageHandlers) GetPreviewMessagesHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	channelID := c.Param("id")
+	if channelID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Channel ID is required"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit <= 0 {
+		limit = m.MaxPreviewMessages
+	}
+
+	messages, err := h.service.GetPreviewMessages(userID.(string), channelID, limit)
+	if err != nil {
+		if err.Error() == "channel not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Channel not found"})
+		} else if err.Error() == "preview is not available for this channel" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Preview is not available for this channel"})
+		} else if err.Error() == "you are banned from this channel" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You are banned from this channel"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve messages"})
+		}
+		return
+	}
+
+	messageResponses := make([]MessageInfo, 0, len(messages))
+	for _, msg := range messages {
+		messageResponses = append(messageResponses, toMessageInfo(msg))
+	}
+
+	c.JSON(http.StatusOK, MessagesResponse{
+		Messages: messageResponses,
+		Total:    int64(len(messageResponses)),
+	})
+}
+
+type ChannelUnreadCount struct {
+	ChannelID string `json:"channel_id" example:"ch123"`
+	Unread    int64  `json:"unread" example:"3"`
+}
+
+type UnreadCountsResponse struct {
+	Channels []ChannelUnreadCount `json:"channels"`
+	Total    int64                `json:"total" example:"3"`
+}
+
+type MarkAllReadResponse struct {
+	Message string `json:"message" example:"Channels marked as read"`
+	Updated int    `json:"updated" example:"2"`
+}
+
+// GetUnreadCountsHandler reports unread messages in the user's channels
+// @Summary Get unread counts
+// @Description Get the number of unread messages from other users in each joined channel, and the total
+// @Tags Messages
+// @Produce json
+// @Security CookieAuth
+// @Success 200 {object} UnreadCountsResponse "Unread counts"
+// @Failure 401 {object} ErrorResponse "User not authenticated"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/user/channels/unread [get]
+func (h *MessageHandlers) GetUnreadCountsHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	counts, err := h.service.GetUnreadCounts(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get unread counts"})
+		return
+	}
+
+	response := UnreadCountsResponse{Channels: make([]ChannelUnreadCount, 0, len(counts))}
+	for _, count := range counts {
+		response.Channels = append(response.Channels, ChannelUnreadCount{ChannelID: count.ChannelID, Unread: count.Unread})
+		response.Total += count.Unread
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// MarkAllReadHandler marks every joined channel as read
+// @Summary Mark all channels as read
+// @Description Advance the read marker of every joined channel to its latest message, clearing all unread counts at once
+// @Tags Messages
+// @Produce json
+// @Security CookieAuth
+// @Success 200 {object} MarkAllReadResponse "Number of channels whose read marker moved"
+// @Failure 401 {object} ErrorResponse "User not authenticated"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/user/read-all [post]
+func (h *MessageHandlers) MarkAllReadHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	updated, err := h.service.MarkAllRead(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark channels as read"})
+		return
+	}
+
+	c.JSON(http.StatusOK, MarkAllReadResponse{
+		Message: "Channels marked as read",
+		Updated: updated,
+	})
+}
+
+// CreateMessageHandler posts a message to a channel
+// @Summary Post a message to a channel
+// @Description Post a message to a channel without a WebSocket connection (only for channel members who are not banned; guests have read-only access). Up to 5 attachments can reference files by http or https URL. The message is stored when the channel keeps history. Posting is rate limited per channel according to the member's role (by default Member 30/min, Moderator and Administrator unlimited).
+// @Tags Messages
+// @Accept json
+// @Produce json
+// @Security CookieAuth
+// @Param id path string true "Channel ID"
+// @Param request body CreateMessageRequest true "Create message request"
+// @Success 201 {object} CreateMessageResponse "Message created successfully"
+// @Failure 400 {object} ErrorResponse "Bad request"
+// @Failure 401 {object} ErrorResponse "User not authenticated"
+// @Failure 403 {object} ErrorResponse "You are not a member of this channel, are banned from it, are a guest, or the channel is locked"
+// @Failure 404 {object} ErrorResponse "Channel not found"
+// @Failure 429 {object} ErrorResponse "Message rate limit exceeded"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/channels/{id}/messages [post]
+func (h *MessageHandlers) CreateMessageHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	channelID := c.Param("id")
+	if channelID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Channel ID is required"})
+		return
+	}
+
+	var req CreateMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	message, err := h.service.CreateMessage(userID.(string), channelID, req.Content, req.Format, req.attachments())
+	if err != nil {
+		if err.Error() == "channel not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Channel not found"})
+		} else if err.Error() == "you are not a member of this channel" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You are not a member of this channel"})
+		} else if err.Error() == "you are banned from this channel" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You are banned from this channel"})
+		} else if err.Error() == "channel is locked" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Channel is locked"})
+		} else if err.Error() == "guests cannot post in this channel" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Guests cannot post in this channel"})
+		} else if err.Error() == "message content cannot be empty" || err.Error() == "too many attachments" ||
+			err.Error() == "invalid attachment url" || err.Error() == "invalid attachment size" ||
+			err.Error() == "invalid message format" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else if err.Error() == "message rate limit exceeded" {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "Message rate limit exceeded"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create message"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, CreateMessageResponse{Message: toMessageInfo(*message)})
+}
+
+func toMessageInfo(msg chat.Message) MessageInfo {
+	info := MessageInfo{
+		ID:        msg.ID,
+		Content:   msg.Content,
+		UserID:    msg.UserID,
+		ChannelID: msg.ChannelID,
+		CreatedAt: msg.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		EditedAt:  editedAt(msg),
+		IsDeleted: msg.DeletedAt.Valid,
+		IsSystem:  msg.IsSystem,
+		Format:    msg.Format,
+		Seq:       msg.Seq,
+	}
+	info.Attachments = toAttachmentInfos(msg.Attachments)
+	if info.IsDeleted {
+		info.Content = DeletedMessageContent
+		info.Attachments = []AttachmentInfo{}
+	}
+	info.User.ID = msg.User.ID
+	info.User.Username = authorName(msg)
+	return info
+}
+
+func toAttachmentInfos(attachments []chat.Attachment) []AttachmentInfo {
+	infos := make([]AttachmentInfo, 0, len(attachments))
+	for _, attachment := range attachments {
+		infos = append(infos, AttachmentInfo{
+			ID:       attachment.ID,
+			URL:      attachment.URL,
+			MimeType: attachment.MimeType,
+			Size:     attachment.Size,
+			Filename: attachment.Filename,
+		})
+	}
+	return infos
+}
+
+func editedAt(msg chat.Message) *string {
+	if msg.EditedAt == nil {
+		return nil
+	}
+	formatted := msg.EditedAt.Format("2006-01-02T15:04:05Z07:00")
+	return &formatted
+}
+
+// authorName is the username shown for a message author. Preloading skips
+// soft-deleted users, leaving the author empty, so those get a placeholder.
+// System messages are returned without their author loaded and keep it empty.
+func authorName(msg chat.Message) string {
+	if msg.User.ID == "" && !msg.IsSystem {
+		return chat.DeletedUsername
+	}
+	return msg.User.Username
+}
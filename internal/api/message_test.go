@@ -5,10 +5,13 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"go-chat/internal/auth"
+	c "go-chat/internal/channel"
+	m "go-chat/internal/message"
 	. "go-chat/pkg/chat"
 
 	"github.com/gin-gonic/gin"
@@ -25,7 +28,7 @@ func setupMessageTestDB(t *testing.T) *gorm.DB {
 		t.Fatalf("Failed to connect to database: %v", err)
 	}
 
-	err = db.AutoMigrate(&User{}, &RefreshToken{}, &Role{}, &Channel{}, &UserChannel{}, &UserBan{}, &Message{}, &AuditLog{})
+	err = db.AutoMigrate(&User{}, &RefreshToken{}, &Role{}, &Channel{}, &UserChannel{}, &UserBan{}, &Message{}, &Attachment{}, &AuditLog{}, &ChannelReadState{})
 	if err != nil {
 		t.Fatalf("Failed to migrate database: %v", err)
 	}
@@ -81,9 +84,9 @@ func TestMessageHandlers_GetChannelMessagesHandler_Success(t *testing.T) {
 	}
 	
 	// Setup handler
-	mh := NewMessageHandlers(db)
+	mh := NewMessageHandlers(db, c.NewMemberCache(0))
 	router := gin.New()
-	am := auth.NewAuthMiddleware()
+	am := auth.NewAuthMiddleware(db)
 	router.GET("/api/channels/:id/messages", am.RequireAuth(), mh.GetChannelMessagesHandler)
 	
 	// Create request
@@ -116,6 +119,57 @@ func TestMessageHandlers_GetChannelMessagesHandler_Success(t *testing.T) {
 	assert.Equal(t, user1.ID, firstMsg["user_id"])
 }
 
+func TestMessageHandlers_GetChannelMessagesHandler_EditedAndDeleted(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := setupMessageTestDB(t)
+
+	user := &User{Username: "author", Password: hashPasswordForTest("password123")}
+	require.NoError(t, db.Create(user).Error)
+	channel := &Channel{Name: "history-channel", IsVisible: true, OwnerID: user.ID, LoggingDays: 30}
+	require.NoError(t, db.Create(channel).Error)
+	require.NoError(t, db.Create(&UserChannel{UserID: user.ID, ChannelID: channel.ID}).Error)
+
+	editedAt := time.Now().Add(-time.Minute).UTC().Truncate(time.Second)
+	now := time.Now()
+	plain := &Message{Content: "first", UserID: user.ID, ChannelID: channel.ID, CreatedAt: now.Add(-3 * time.Minute)}
+	edited := &Message{Content: "second, fixed", UserID: user.ID, ChannelID: channel.ID, CreatedAt: now.Add(-2 * time.Minute), EditedAt: &editedAt}
+	deleted := &Message{Content: "something regrettable", UserID: user.ID, ChannelID: channel.ID, CreatedAt: now.Add(-time.Minute)}
+	for _, msg := range []*Message{plain, edited, deleted} {
+		require.NoError(t, db.Create(msg).Error)
+	}
+	require.NoError(t, db.Delete(deleted).Error)
+
+	mh := NewMessageHandlers(db, c.NewMemberCache(0))
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest("GET", fmt.Sprintf("/api/channels/%s/messages", channel.ID), nil)
+	c.Set("user_id", user.ID)
+	c.Params = gin.Params{{Key: "id", Value: channel.ID}}
+
+	mh.GetChannelMessagesHandler(c)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var response MessagesResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Messages, 3)
+
+	assert.Nil(t, response.Messages[0].EditedAt)
+	assert.False(t, response.Messages[0].IsDeleted)
+
+	assert.Equal(t, "second, fixed", response.Messages[1].Content)
+	require.NotNil(t, response.Messages[1].EditedAt)
+	parsed, err := time.Parse(time.RFC3339, *response.Messages[1].EditedAt)
+	require.NoError(t, err)
+	assert.True(t, parsed.Equal(editedAt))
+
+	assert.Equal(t, deleted.ID, response.Messages[2].ID)
+	assert.True(t, response.Messages[2].IsDeleted)
+	assert.Equal(t, DeletedMessageContent, response.Messages[2].Content)
+	assert.NotContains(t, w.Body.String(), "regrettable")
+	assert.Equal(t, "author", response.Messages[2].User.Username)
+}
+
 func TestMessageHandlers_GetChannelMessagesHandler_WithPagination(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	
@@ -157,7 +211,7 @@ func TestMessageHandlers_GetChannelMessagesHandler_WithPagination(t *testing.T)
 	}
 	
 	// Setup handler
-	mh := NewMessageHandlers(db)
+	mh := NewMessageHandlers(db, c.NewMemberCache(0))
 	
 	// Test pagination with limit=10
 	w := httptest.NewRecorder()
@@ -181,6 +235,83 @@ func TestMessageHandlers_GetChannelMessagesHandler_WithPagination(t *testing.T)
 	assert.Len(t, messages_data, 10) // Should return only 10 messages
 }
 
+func TestMessageHandlers_GetChannelMessagesHandler_HasMoreAndNextBefore(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := setupMessageTestDB(t)
+
+	user := &User{Username: "testuser", Password: hashPasswordForTest("password123")}
+	require.NoError(t, db.Create(user).Error)
+	channel := &Channel{Name: "test-channel", IsVisible: true, OwnerID: user.ID, LoggingDays: 30}
+	require.NoError(t, db.Create(channel).Error)
+	require.NoError(t, db.Create(&UserChannel{UserID: user.ID, ChannelID: channel.ID}).Error)
+
+	var created []*Message
+	for i := 0; i < 5; i++ {
+		msg := &Message{Content: fmt.Sprintf("Message %d", i+1), UserID: user.ID, ChannelID: channel.ID}
+		require.NoError(t, db.Create(msg).Error)
+		created = append(created, msg)
+		time.Sleep(1 * time.Millisecond) // Ensure different timestamps
+	}
+
+	mh := NewMessageHandlers(db, c.NewMemberCache(0))
+
+	fetch := func(t *testing.T, query string) MessagesResponse {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", fmt.Sprintf("/api/channels/%s/messages?%s", channel.ID, query), nil)
+		c.Set("user_id", user.ID)
+		c.Params = gin.Params{{Key: "id", Value: channel.ID}}
+
+		mh.GetChannelMessagesHandler(c)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response MessagesResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+	contents := func(response MessagesResponse) []string {
+		var result []string
+		for _, msg := range response.Messages {
+			result = append(result, msg.Content)
+		}
+		return result
+	}
+
+	t.Run("offset mode", func(t *testing.T) {
+		tests := []struct {
+			offset     int
+			expected   []string
+			hasMore    bool
+			nextBefore string
+		}{
+			{0, []string{"Message 4", "Message 5"}, true, created[3].ID},
+			{2, []string{"Message 2", "Message 3"}, true, created[1].ID},
+			{3, []string{"Message 1", "Message 2"}, false, ""},
+			{4, []string{"Message 1"}, false, ""},
+		}
+
+		for _, tt := range tests {
+			response := fetch(t, fmt.Sprintf("limit=2&offset=%d", tt.offset))
+			assert.Equal(t, tt.expected, contents(response), "offset %d", tt.offset)
+			assert.Equal(t, tt.hasMore, response.HasMore, "offset %d", tt.offset)
+			assert.Equal(t, tt.nextBefore, response.NextBefore, "offset %d", tt.offset)
+		}
+	})
+
+	t.Run("cursor mode", func(t *testing.T) {
+		response := fetch(t, "limit=2&before="+created[4].ID)
+		assert.Equal(t, []string{"Message 3", "Message 4"}, contents(response))
+		assert.True(t, response.HasMore)
+		require.Equal(t, created[2].ID, response.NextBefore)
+
+		response = fetch(t, "limit=2&before="+response.NextBefore)
+		assert.Equal(t, []string{"Message 1", "Message 2"}, contents(response))
+		assert.False(t, response.HasMore)
+		assert.Empty(t, response.NextBefore)
+	})
+}
+
 func TestMessageHandlers_GetChannelMessagesHandler_Unauthorized(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	
@@ -201,7 +332,7 @@ func TestMessageHandlers_GetChannelMessagesHandler_Unauthorized(t *testing.T) {
 	require.NoError(t, db.Create(channel).Error)
 	
 	// Setup handler
-	mh := NewMessageHandlers(db)
+	mh := NewMessageHandlers(db, c.NewMemberCache(0))
 	
 	// Create request from non-member
 	w := httptest.NewRecorder()
@@ -222,7 +353,650 @@ func TestMessageHandlers_GetChannelMessagesHandler_Unauthorized(t *testing.T) {
 	assert.Equal(t, "You are not a member of this channel", response["error"])
 }
 
+func TestMessageHandlers_GetChannelMessagesHandler_Since(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	
+	db := setupMessageTestDB(t)
+	
+	// Create test user
+	user := &User{Username: "testuser", Password: hashPasswordForTest("password123")}
+	require.NoError(t, db.Create(user).Error)
+	
+	// Create channels with history enabled and disabled
+	channel := &Channel{Name: "test-channel", IsVisible: true, OwnerID: user.ID, LoggingDays: 30}
+	noHistory := &Channel{Name: "no-history", IsVisible: true, OwnerID: user.ID, LoggingDays: 0}
+	require.NoError(t, db.Create(channel).Error)
+	require.NoError(t, db.Create(noHistory).Error)
+	require.NoError(t, db.Create(&UserChannel{UserID: user.ID, ChannelID: channel.ID}).Error)
+	require.NoError(t, db.Create(&UserChannel{UserID: user.ID, ChannelID: noHistory.ID}).Error)
+	
+	// Create 5 messages in each channel
+	var created []*Message
+	for i := 0; i < 5; i++ {
+		msg := &Message{Content: fmt.Sprintf("Message %d", i+1), UserID: user.ID, ChannelID: channel.ID}
+		require.NoError(t, db.Create(msg).Error)
+		created = append(created, msg)
+		require.NoError(t, db.Create(&Message{Content: "unlogged", UserID: user.ID, ChannelID: noHistory.ID}).Error)
+		time.Sleep(1 * time.Millisecond) // Ensure different timestamps
+	}
+	
+	mh := NewMessageHandlers(db, c.NewMemberCache(0))
+	
+	t.Run("replays the backlog after the last received message in order", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", fmt.Sprintf("/api/channels/%s/messages?since=%s", channel.ID, created[1].ID), nil)
+		c.Set("user_id", user.ID)
+		c.Params = gin.Params{{Key: "id", Value: channel.ID}}
+		
+		mh.GetChannelMessagesHandler(c)
+		
+		assert.Equal(t, http.StatusOK, w.Code)
+		
+		var response MessagesResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Messages, 3)
+		assert.Equal(t, "Message 3", response.Messages[0].Content)
+		assert.Equal(t, "Message 4", response.Messages[1].Content)
+		assert.Equal(t, "Message 5", response.Messages[2].Content)
+		assert.False(t, response.HasMore)
+	})
+	
+	t.Run("bounds the replay and reports more pending", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", fmt.Sprintf("/api/channels/%s/messages?since=%s&limit=2", channel.ID, created[0].ID), nil)
+		c.Set("user_id", user.ID)
+		c.Params = gin.Params{{Key: "id", Value: channel.ID}}
+		
+		mh.GetChannelMessagesHandler(c)
+		
+		assert.Equal(t, http.StatusOK, w.Code)
+		
+		var response MessagesResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Messages, 2)
+		assert.Equal(t, "Message 2", response.Messages[0].Content)
+		assert.Equal(t, "Message 3", response.Messages[1].Content)
+		assert.True(t, response.HasMore)
+	})
+	
+	t.Run("does not replay channels with history disabled", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		since := time.Now().Add(-time.Hour).Format(time.RFC3339)
+		c.Request = httptest.NewRequest("GET", fmt.Sprintf("/api/channels/%s/messages?since=%s", noHistory.ID, since), nil)
+		c.Set("user_id", user.ID)
+		c.Params = gin.Params{{Key: "id", Value: noHistory.ID}}
+		
+		mh.GetChannelMessagesHandler(c)
+		
+		assert.Equal(t, http.StatusOK, w.Code)
+		
+		var response MessagesResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Empty(t, response.Messages)
+	})
+	
+	t.Run("rejects an invalid since value", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", fmt.Sprintf("/api/channels/%s/messages?since=not-a-cursor", channel.ID), nil)
+		c.Set("user_id", user.ID)
+		c.Params = gin.Params{{Key: "id", Value: channel.ID}}
+		
+		mh.GetChannelMessagesHandler(c)
+		
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestMessageHandlers_CreateMessageHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	
+	db := setupMessageTestDB(t)
+	
+	// Create test users
+	owner := &User{Username: "owner", Password: hashPasswordForTest("password123")}
+	member := &User{Username: "member", Password: hashPasswordForTest("password123")}
+	nonMember := &User{Username: "nonmember", Password: hashPasswordForTest("password123")}
+	banned := &User{Username: "banned", Password: hashPasswordForTest("password123")}
+	for _, u := range []*User{owner, member, nonMember, banned} {
+		require.NoError(t, db.Create(u).Error)
+	}
+	
+	// Create channels with history enabled and disabled
+	channel := &Channel{Name: "test-channel", IsVisible: true, OwnerID: owner.ID, LoggingDays: 30}
+	noHistory := &Channel{Name: "no-history", IsVisible: true, OwnerID: owner.ID, LoggingDays: 0}
+	require.NoError(t, db.Create(channel).Error)
+	require.NoError(t, db.Create(noHistory).Error)
+	require.NoError(t, db.Create(&UserChannel{UserID: member.ID, ChannelID: channel.ID}).Error)
+	require.NoError(t, db.Create(&UserChannel{UserID: member.ID, ChannelID: noHistory.ID}).Error)
+	require.NoError(t, db.Create(&UserBan{UserID: banned.ID, ChannelID: channel.ID, BannedBy: owner.ID, IsActive: true}).Error)
+	
+	mh := NewMessageHandlers(db, c.NewMemberCache(0))
+	
+	post := func(userID, channelID, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("POST", fmt.Sprintf("/api/channels/%s/messages", channelID), strings.NewReader(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user_id", userID)
+		c.Params = gin.Params{{Key: "id", Value: channelID}}
+		mh.CreateMessageHandler(c)
+		return w
+	}
+	
+	t.Run("member posts a message", func(t *testing.T) {
+		w := post(member.ID, channel.ID, `{"content": "Hello from the API"}`)
+		
+		assert.Equal(t, http.StatusCreated, w.Code)
+		
+		var response CreateMessageResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.NotEmpty(t, response.Message.ID)
+		assert.Equal(t, "Hello from the API", response.Message.Content)
+		assert.Equal(t, member.ID, response.Message.User.ID)
+		assert.Equal(t, "member", response.Message.User.Username)
+		
+		var stored Message
+		require.NoError(t, db.First(&stored, "id = ?", response.Message.ID).Error)
+		assert.Equal(t, channel.ID, stored.ChannelID)
+	})
+	
+	t.Run("message is not stored when history is disabled", func(t *testing.T) {
+		w := post(member.ID, noHistory.ID, `{"content": "ephemeral"}`)
+		
+		assert.Equal(t, http.StatusCreated, w.Code)
+		
+		var response CreateMessageResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.NotEmpty(t, response.Message.ID)
+		
+		var count int64
+		db.Model(&Message{}).Where("channel_id = ?", noHistory.ID).Count(&count)
+		assert.Equal(t, int64(0), count)
+	})
+	
+	t.Run("non-member is forbidden", func(t *testing.T) {
+		w := post(nonMember.ID, channel.ID, `{"content": "let me in"}`)
+		
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "You are not a member of this channel", response["error"])
+	})
+	
+	t.Run("banned user is rejected", func(t *testing.T) {
+		w := post(banned.ID, channel.ID, `{"content": "still here"}`)
+		
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "You are banned from this channel", response["error"])
+	})
+	
+	t.Run("blank content is rejected", func(t *testing.T) {
+		w := post(member.ID, channel.ID, `{"content": "   "}`)
+		
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
 func hashPasswordForTest(password string) string {
 	hash, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	return string(hash)
-}
\ No newline at end of file
+}
+func TestMessageHandlers_GetPreviewMessagesHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := setupMessageTestDB(t)
+
+	owner := &User{Username: "owner", Password: hashPasswordForTest("password123")}
+	visitor := &User{Username: "visitor", Password: hashPasswordForTest("password123")}
+	require.NoError(t, db.Create(owner).Error)
+	require.NoError(t, db.Create(visitor).Error)
+
+	password := hashPasswordForTest("secret")
+	public := &Channel{Name: "public", IsVisible: true, OwnerID: owner.ID, LoggingDays: 30, AllowPreview: true}
+	protected := &Channel{Name: "protected", IsVisible: true, OwnerID: owner.ID, LoggingDays: 30, AllowPreview: true, Password: &password}
+	hidden := &Channel{Name: "hidden", IsVisible: false, OwnerID: owner.ID, LoggingDays: 30, AllowPreview: true}
+	disabled := &Channel{Name: "disabled", IsVisible: true, OwnerID: owner.ID, LoggingDays: 30}
+	for _, channel := range []*Channel{public, protected, hidden, disabled} {
+		require.NoError(t, db.Create(channel).Error)
+	}
+
+	for i := 0; i < m.MaxPreviewMessages+5; i++ {
+		require.NoError(t, db.Create(&Message{Content: fmt.Sprintf("Message %d", i+1), UserID: owner.ID, ChannelID: public.ID}).Error)
+		time.Sleep(1 * time.Millisecond) // Ensure different timestamps
+	}
+
+	mh := NewMessageHandlers(db, c.NewMemberCache(0))
+
+	preview := func(channelID, query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", fmt.Sprintf("/api/channels/%s/preview-messages%s", channelID, query), nil)
+		c.Set("user_id", visitor.ID)
+		c.Params = gin.Params{{Key: "id", Value: channelID}}
+		mh.GetPreviewMessagesHandler(c)
+		return w
+	}
+
+	t.Run("non-member previews the most recent messages of a public channel", func(t *testing.T) {
+		w := preview(public.ID, "")
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response MessagesResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Messages, m.MaxPreviewMessages)
+		assert.Equal(t, "Message 6", response.Messages[0].Content)
+		assert.Equal(t, fmt.Sprintf("Message %d", m.MaxPreviewMessages+5), response.Messages[m.MaxPreviewMessages-1].Content)
+	})
+
+	t.Run("limit is honoured", func(t *testing.T) {
+		w := preview(public.ID, "?limit=3")
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response MessagesResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Len(t, response.Messages, 3)
+	})
+
+	for name, channel := range map[string]*Channel{"password-protected": protected, "hidden": hidden, "previews disabled": disabled} {
+		t.Run(name+" channel is rejected", func(t *testing.T) {
+			w := preview(channel.ID, "")
+			assert.Equal(t, http.StatusForbidden, w.Code)
+		})
+	}
+
+	t.Run("missing channel", func(t *testing.T) {
+		w := preview("missing", "")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestMessageHandlers_MarkAllReadHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := setupMessageTestDB(t)
+
+	reader := &User{Username: "reader", Password: hashPasswordForTest("password123")}
+	author := &User{Username: "author", Password: hashPasswordForTest("password123")}
+	require.NoError(t, db.Create(reader).Error)
+	require.NoError(t, db.Create(author).Error)
+
+	busy := &Channel{Name: "busy", IsVisible: true, OwnerID: author.ID, LoggingDays: 30}
+	quiet := &Channel{Name: "quiet", IsVisible: true, OwnerID: author.ID, LoggingDays: 30}
+	empty := &Channel{Name: "empty", IsVisible: true, OwnerID: author.ID, LoggingDays: 30}
+	notJoined := &Channel{Name: "not-joined", IsVisible: true, OwnerID: author.ID, LoggingDays: 30}
+	for _, channel := range []*Channel{busy, quiet, empty, notJoined} {
+		require.NoError(t, db.Create(channel).Error)
+	}
+	for _, channel := range []*Channel{busy, quiet, empty} {
+		require.NoError(t, db.Create(&UserChannel{UserID: reader.ID, ChannelID: channel.ID}).Error)
+	}
+
+	post := func(channel *Channel, user *User, count int) {
+		for i := 0; i < count; i++ {
+			require.NoError(t, db.Create(&Message{Content: "hello", UserID: user.ID, ChannelID: channel.ID}).Error)
+			time.Sleep(1 * time.Millisecond) // Ensure different timestamps
+		}
+	}
+	post(busy, author, 3)
+	post(busy, reader, 1) // own messages are never unread
+	post(quiet, author, 1)
+	post(notJoined, author, 2)
+
+	mh := NewMessageHandlers(db, c.NewMemberCache(0))
+
+	unread := func() UnreadCountsResponse {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/api/user/channels/unread", nil)
+		c.Set("user_id", reader.ID)
+		mh.GetUnreadCountsHandler(c)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response UnreadCountsResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+	router := gin.New()
+	NewRouter(db).RegisterRoutes(router)
+	token, err := getAuthTokenForUser(reader)
+	require.NoError(t, err)
+	markAllReadAt := func(path string) MarkAllReadResponse {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", path, nil)
+		req.AddCookie(&http.Cookie{Name: "token", Value: token})
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response MarkAllReadResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+	markAllRead := func() MarkAllReadResponse {
+		return markAllReadAt("/api/user/read-all")
+	}
+
+	before := unread()
+	assert.Equal(t, int64(4), before.Total)
+	assert.Len(t, before.Channels, 3)
+
+	// Only the channels with messages have a marker to advance
+	assert.Equal(t, 2, markAllRead().Updated)
+
+	after := unread()
+	assert.Equal(t, int64(0), after.Total)
+	for _, channel := range after.Channels {
+		assert.Equal(t, int64(0), channel.Unread, channel.ChannelID)
+	}
+
+	t.Run("nothing to update when already read", func(t *testing.T) {
+		assert.Equal(t, 0, markAllRead().Updated)
+	})
+
+	t.Run("new messages are unread again", func(t *testing.T) {
+		post(quiet, author, 2)
+		assert.Equal(t, int64(2), unread().Total)
+
+		assert.Equal(t, 1, markAllRead().Updated)
+		assert.Equal(t, int64(0), unread().Total)
+	})
+
+	t.Run("previous path still works", func(t *testing.T) {
+		post(busy, author, 1)
+		assert.Equal(t, 1, markAllReadAt("/api/user/channels/read-all").Updated)
+		assert.Equal(t, int64(0), unread().Total)
+	})
+
+	t.Run("a single statement whatever the number of channels", func(t *testing.T) {
+		post(busy, author, 1)
+		post(quiet, author, 1)
+
+		statements := 0
+		count := func(*gorm.DB) { statements++ }
+		require.NoError(t, db.Callback().Query().After("gorm:query").Register("test:count_queries", count))
+		require.NoError(t, db.Callback().Raw().After("gorm:raw").Register("test:count_raw", count))
+		require.NoError(t, db.Callback().Create().After("gorm:create").Register("test:count_creates", count))
+		require.NoError(t, db.Callback().Update().After("gorm:update").Register("test:count_updates", count))
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("POST", "/api/user/read-all", nil)
+		c.Set("user_id", reader.ID)
+		mh.MarkAllReadHandler(c)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response MarkAllReadResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, 2, response.Updated)
+		assert.Equal(t, 1, statements)
+	})
+}
+
+func TestMessageHandlers_CreateMessageHandler_RoleRateLimits(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := setupMessageTestDB(t)
+
+	owner := &User{Username: "owner", Password: hashPasswordForTest("password123")}
+	require.NoError(t, db.Create(owner).Error)
+	channel := &Channel{Name: "rated", IsVisible: true, OwnerID: owner.ID, LoggingDays: 30}
+	require.NoError(t, db.Create(channel).Error)
+
+	join := func(username, roleName string) *User {
+		user := &User{Username: username, Password: hashPasswordForTest("password123")}
+		require.NoError(t, db.Create(user).Error)
+		role := Role{Name: roleName}
+		require.NoError(t, db.FirstOrCreate(&role, Role{Name: roleName}).Error)
+		require.NoError(t, db.Create(&UserChannel{UserID: user.ID, ChannelID: channel.ID, RoleID: &role.ID}).Error)
+		return user
+	}
+	guest := join("guest", "Guest")
+	member := join("member", "Member")
+	moderator := join("moderator", "Moderator")
+	require.NoError(t, db.Create(&UserChannel{UserID: owner.ID, ChannelID: channel.ID}).Error)
+
+	mh := NewMessageHandlers(db, c.NewMemberCache(0))
+	mh.service.SetRoleMessageRates(map[string]int{"Member": 4, "Moderator": 0})
+
+	post := func(userID string) int {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("POST", fmt.Sprintf("/api/channels/%s/messages", channel.ID), strings.NewReader(`{"content": "hello"}`))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user_id", userID)
+		c.Params = gin.Params{{Key: "id", Value: channel.ID}}
+		mh.CreateMessageHandler(c)
+		return w.Code
+	}
+	accepted := func(userID string, attempts int) int {
+		count := 0
+		for i := 0; i < attempts; i++ {
+			if post(userID) == http.StatusCreated {
+				count++
+			}
+		}
+		return count
+	}
+
+	t.Run("member is limited to their role's rate", func(t *testing.T) {
+		assert.Equal(t, 4, accepted(member.ID, 6))
+		assert.Equal(t, http.StatusTooManyRequests, post(member.ID))
+	})
+
+	t.Run("guest cannot post at all", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, post(guest.ID))
+	})
+
+	t.Run("moderator and owner are exempt", func(t *testing.T) {
+		assert.Equal(t, 20, accepted(moderator.ID, 20))
+		assert.Equal(t, 20, accepted(owner.ID, 20))
+	})
+}
+
+func TestMessageHandlers_GuestIsReadOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := setupMessageTestDB(t)
+	for _, name := range DefaultRoles {
+		require.NoError(t, db.Create(&Role{Name: name}).Error)
+	}
+	router := gin.New()
+	NewRouter(db).RegisterRoutes(router)
+
+	ownerID, ownerToken := createTestUserWithAuth(t, router, "owner", "password")
+	guestID, guestToken := createTestUserWithAuth(t, router, "guest", "password")
+
+	channel := &Channel{Name: "announcements", IsVisible: true, OwnerID: ownerID, LoggingDays: 30}
+	require.NoError(t, db.Create(channel).Error)
+	require.NoError(t, db.Create(&Message{ChannelID: channel.ID, UserID: ownerID, Content: "welcome"}).Error)
+
+	send := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		req.AddCookie(&http.Cookie{Name: "token", Value: token})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	messagesPath := fmt.Sprintf("/api/channels/%s/messages", channel.ID)
+
+	w := send("POST", fmt.Sprintf("/api/channels/%s/join", channel.ID), guestToken, `{"as_guest": true}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var userChannel UserChannel
+	require.NoError(t, db.Preload("Role").Where("user_id = ? AND channel_id = ?", guestID, channel.ID).First(&userChannel).Error)
+	assert.Equal(t, "Guest", userChannel.Role.Name)
+
+	t.Run("guest can read history", func(t *testing.T) {
+		w := send("GET", messagesPath, guestToken, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response MessagesResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Messages, 1)
+		assert.Equal(t, "welcome", response.Messages[0].Content)
+	})
+
+	t.Run("guest cannot post", func(t *testing.T) {
+		w := send("POST", messagesPath, guestToken, `{"content": "hi"}`)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "Guests cannot post in this channel")
+	})
+
+	t.Run("promoted guest can post", func(t *testing.T) {
+		w := send("POST", fmt.Sprintf("/api/channels/%s/promote", channel.ID), ownerToken,
+			fmt.Sprintf(`{"user_id": %q, "role": "Member"}`, guestID))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		w = send("POST", messagesPath, guestToken, `{"content": "hi"}`)
+		assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	})
+}
+
+func TestMessageHandlers_CreateMessageHandler_Attachments(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := setupMessageTestDB(t)
+	memberRole := &Role{Name: "Member"}
+	require.NoError(t, db.Create(memberRole).Error)
+	router := gin.New()
+	NewRouter(db).RegisterRoutes(router)
+
+	ownerID, ownerToken := createTestUserWithAuth(t, router, "owner", "password")
+	channel := &Channel{Name: "files", IsVisible: true, OwnerID: ownerID, LoggingDays: 30}
+	require.NoError(t, db.Create(channel).Error)
+	require.NoError(t, db.Create(&UserChannel{UserID: ownerID, ChannelID: channel.ID, RoleID: &memberRole.ID}).Error)
+
+	messagesPath := fmt.Sprintf("/api/channels/%s/messages", channel.ID)
+	send := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		payload, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, path, strings.NewReader(string(payload)))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(&http.Cookie{Name: "token", Value: ownerToken})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	attachment := AttachmentRequest{URL: "https://files.example.com/report.pdf", MimeType: "application/pdf", Size: 52340, Filename: "report.pdf"}
+
+	t.Run("attachments are stored and listed in history", func(t *testing.T) {
+		w := send("POST", messagesPath, CreateMessageRequest{Content: "see attached", Attachments: []AttachmentRequest{attachment}})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		var created CreateMessageResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		require.Len(t, created.Message.Attachments, 1)
+		assert.NotZero(t, created.Message.Attachments[0].ID)
+
+		w = send("GET", messagesPath, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var history MessagesResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &history))
+		require.Len(t, history.Messages, 1)
+		require.Len(t, history.Messages[0].Attachments, 1)
+		listed := history.Messages[0].Attachments[0]
+		assert.Equal(t, attachment.URL, listed.URL)
+		assert.Equal(t, attachment.MimeType, listed.MimeType)
+		assert.Equal(t, attachment.Size, listed.Size)
+		assert.Equal(t, attachment.Filename, listed.Filename)
+	})
+
+	t.Run("too many attachments are rejected", func(t *testing.T) {
+		attachments := make([]AttachmentRequest, 6)
+		for i := range attachments {
+			attachments[i] = attachment
+		}
+		w := send("POST", messagesPath, CreateMessageRequest{Content: "bulk", Attachments: attachments})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "too many attachments")
+	})
+
+	t.Run("invalid attachment URLs are rejected", func(t *testing.T) {
+		for _, url := range []string{"not a url", "javascript:alert(1)", "ftp://files.example.com/a", "https://"} {
+			w := send("POST", messagesPath, CreateMessageRequest{Content: "bad link", Attachments: []AttachmentRequest{{URL: url}}})
+			assert.Equal(t, http.StatusBadRequest, w.Code, url)
+			assert.Contains(t, w.Body.String(), "invalid attachment url", url)
+		}
+
+		var stored int64
+		db.Model(&Attachment{}).Count(&stored)
+		assert.Equal(t, int64(1), stored)
+	})
+}
+
+func TestMessageHandlers_CreateMessageHandler_Format(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := setupMessageTestDB(t)
+	memberRole := &Role{Name: "Member"}
+	require.NoError(t, db.Create(memberRole).Error)
+	router := gin.New()
+	NewRouter(db).RegisterRoutes(router)
+
+	ownerID, ownerToken := createTestUserWithAuth(t, router, "owner", "password")
+	channel := &Channel{Name: "formatted", IsVisible: true, OwnerID: ownerID, LoggingDays: 30}
+	require.NoError(t, db.Create(channel).Error)
+	require.NoError(t, db.Create(&UserChannel{UserID: ownerID, ChannelID: channel.ID, RoleID: &memberRole.ID}).Error)
+
+	messagesPath := fmt.Sprintf("/api/channels/%s/messages", channel.ID)
+	send := func(method, path string, body interface{}) *httptest.ResponseRecorder {
+		payload, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, path, strings.NewReader(string(payload)))
+		req.Header.Set("Content-Type", "application/json")
+		req.AddCookie(&http.Cookie{Name: "token", Value: ownerToken})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("format defaults to plain", func(t *testing.T) {
+		w := send("POST", messagesPath, CreateMessageRequest{Content: "just text"})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		var created CreateMessageResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		assert.Equal(t, MessageFormatPlain, created.Message.Format)
+	})
+
+	t.Run("markdown is stored and echoed in history", func(t *testing.T) {
+		w := send("POST", messagesPath, CreateMessageRequest{Content: "**bold** move", Format: MessageFormatMarkdown})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		var created CreateMessageResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		assert.Equal(t, MessageFormatMarkdown, created.Message.Format)
+		// The content is kept as typed, not rendered
+		assert.Equal(t, "**bold** move", created.Message.Content)
+
+		w = send("GET", messagesPath, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var history MessagesResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &history))
+		require.Len(t, history.Messages, 2)
+		assert.Equal(t, MessageFormatPlain, history.Messages[0].Format)
+		assert.Equal(t, MessageFormatMarkdown, history.Messages[1].Format)
+		assert.Equal(t, int64(1), history.Messages[0].Seq)
+		assert.Equal(t, int64(2), history.Messages[1].Seq)
+	})
+
+	t.Run("unknown formats are rejected", func(t *testing.T) {
+		for _, format := range []string{"html", "Markdown", "rich"} {
+			w := send("POST", messagesPath, CreateMessageRequest{Content: "styled", Format: format})
+			assert.Equal(t, http.StatusBadRequest, w.Code, format)
+			assert.Contains(t, w.Body.String(), "invalid message format", format)
+		}
+
+		var stored int64
+		db.Model(&Message{}).Count(&stored)
+		assert.Equal(t, int64(2), stored)
+	})
+}
@@ -0,0 +1,30 @@
+package api
+
+import (
+	"net/http"
+	"os"
+
+	"go-chat/internal/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsEnabledFromEnv reports whether /metrics is served, enabled by METRICS_ENABLED=true
+func MetricsEnabledFromEnv() bool {
+	return os.Getenv("METRICS_ENABLED") == "true"
+}
+
+// MetricsHandler exposes server metrics for Prometheus
+// @Summary Prometheus metrics
+// @Description Request counts and latencies by route, stored messages and rate limit rejections, in the Prometheus text format. Only served when METRICS_ENABLED is true.
+// @Tags System
+// @Produce plain
+// @Success 200 {string} string "Metrics"
+// @Router /metrics [get]
+func MetricsHandler(c *gin.Context) {
+	c.Status(http.StatusOK)
+	c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := metrics.Default.WriteText(c.Writer); err != nil {
+		c.Error(err)
+	}
+}
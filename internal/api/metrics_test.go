@@ -0,0 +1,46 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricsHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("disabled by default", func(t *testing.T) {
+		router := gin.New()
+		NewRouter(setupTestDB(t)).RegisterRoutes(router)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("reports traffic when enabled", func(t *testing.T) {
+		t.Setenv("METRICS_ENABLED", "true")
+		router := gin.New()
+		NewRouter(setupTestDB(t)).RegisterRoutes(router)
+
+		for _, path := range []string{"/hc/live", "/api/channels", "/no-such-route"} {
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Type"), "text/plain")
+
+		body := w.Body.String()
+		assert.Contains(t, body, `gochat_http_requests_total{method="GET",route="/hc/live",status="200"}`)
+		assert.Contains(t, body, `gochat_http_requests_total{method="GET",route="/api/channels",status="401"}`)
+		assert.Contains(t, body, `gochat_http_requests_total{method="GET",route="unmatched",status="404"}`)
+		assert.Contains(t, body, `gochat_http_request_duration_seconds_count{method="GET",route="/hc/live"}`)
+		assert.Contains(t, body, "# TYPE gochat_messages_persisted_total counter")
+		assert.Contains(t, body, "# TYPE gochat_rate_limit_rejections_total counter")
+	})
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"net/http"
+
+	n "go-chat/internal/notification"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type NotificationHandlers struct {
+	service *n.NotificationService
+}
+
+func NewNotificationHandlers(db *gorm.DB) *NotificationHandlers {
+	return &NotificationHandlers{
+		service: n.NewNotificationService(db),
+	}
+}
+
+type UpdateNotificationPrefRequest struct {
+	Mode string `json:"mode" binding:"required" example:"mentions" enums:"all,mentions,none"`
+}
+
+type NotificationPrefResponse struct {
+	ChannelID string `json:"channel_id" example:"ch123"`
+	Mode      string `json:"mode" example:"mentions"`
+}
+
+// UpdateNotificationPrefHandler sets the user's notification mode for a channel
+// @Summary Update channel notification preference
+// @Description Set how the authenticated user is notified about activity in a channel: "all" (default), "mentions" only, or "none"
+// @Tags Channels
+// @Accept json
+// @Produce json
+// @Security CookieAuth
+// @Param id path string true "Channel ID"
+// @Param request body UpdateNotificationPrefRequest true "Notification preference"
+// @Success 200 {object} NotificationPrefResponse "Notification preference updated"
+// @Failure 400 {object} ErrorResponse "Invalid notification mode"
+// @Failure 401 {object} ErrorResponse "User not authenticated"
+// @Failure 403 {object} ErrorResponse "Not a member of this channel"
+// @Failure 404 {object} ErrorResponse "Channel not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/channels/{id}/notifications [put]
+func (h *NotificationHandlers) UpdateNotificationPrefHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	channelID := c.Param("id")
+
+	var req UpdateNotificationPrefRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pref, err := h.service.SetChannelPreference(userID.(string), channelID, req.Mode)
+	if err != nil {
+		if err.Error() == "invalid notification mode" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else if err.Error() == "channel not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else if err.Error() == "you are not a member of this channel" {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification preference"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, NotificationPrefResponse{
+		ChannelID: pref.ChannelID,
+		Mode:      pref.Mode,
+	})
+}
+
+type ChannelNotificationSetting struct {
+	ChannelID   string `json:"channel_id" example:"ch123"`
+	ChannelName string `json:"channel_name" example:"general"`
+	Mode        string `json:"mode" example:"mentions"`
+	IsDefault   bool   `json:"is_default" example:"false"`
+}
+
+type NotificationSettingsResponse struct {
+	DefaultMode string                       `json:"default_mode" example:"all"`
+	Channels    []ChannelNotificationSetting `json:"channels"`
+}
+
+// GetNotificationSettingsHandler summarizes the user's notification settings
+// @Summary Get notification settings
+// @Description Get the default notification mode and the resolved mode of every joined channel in one call. Channels without a preference report the default with is_default set.
+// @Tags User Management
+// @Accept json
+// @Produce json
+// @Security CookieAuth
+// @Success 200 {object} NotificationSettingsResponse "Notification settings"
+// @Failure 401 {object} ErrorResponse "User not authenticated"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/user/notifications/settings [get]
+func (h *NotificationHandlers) GetNotificationSettingsHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	settings, err := h.service.GetSettingsSummary(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notification settings"})
+		return
+	}
+
+	channels := make([]ChannelNotificationSetting, 0, len(settings))
+	for _, setting := range settings {
+		channels = append(channels, ChannelNotificationSetting{
+			ChannelID:   setting.ChannelID,
+			ChannelName: setting.ChannelName,
+			Mode:        setting.Mode,
+			IsDefault:   setting.IsDefault,
+		})
+	}
+
+	c.JSON(http.StatusOK, NotificationSettingsResponse{
+		DefaultMode: n.DefaultMode,
+		Channels:    channels,
+	})
+}
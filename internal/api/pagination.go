@@ -0,0 +1,63 @@
+package api
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+	MaxSearchLimit   = 50
+)
+
+// PaginationMeta describes where a page sits in a paginated listing
+type PaginationMeta struct {
+	Total      int64 `json:"total" example:"42"`
+	Page       int   `json:"page" example:"2"`
+	Limit      int   `json:"limit" example:"20"`
+	TotalPages int   `json:"total_pages" example:"3"`
+	HasNext    bool  `json:"has_next" example:"true"`
+	HasPrev    bool  `json:"has_prev" example:"true"`
+}
+
+// newPaginationMeta derives the page count and navigation flags from the total
+func newPaginationMeta(total int64, page, limit int) PaginationMeta {
+	totalPages := 0
+	if limit > 0 {
+		totalPages = int((total + int64(limit) - 1) / int64(limit))
+	}
+	return PaginationMeta{
+		Total:      total,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: totalPages,
+		HasNext:    page < totalPages,
+		HasPrev:    page > 1,
+	}
+}
+
+// parsePagination reads the page and limit query parameters, defaulting to the first
+// page of DefaultPageLimit items and clamping limit to MaxPageLimit
+func parsePagination(c *gin.Context) (page, limit, offset int) {
+	return parsePaginationLimits(c, DefaultPageLimit, MaxPageLimit)
+}
+
+// parsePaginationLimits is parsePagination with a custom default and maximum limit
+func parsePaginationLimits(c *gin.Context, defaultLimit, maxLimit int) (page, limit, offset int) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	limit, err = strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultLimit)))
+	if err != nil || limit <= 0 {
+		limit = defaultLimit
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+
+	return page, limit, (page - 1) * limit
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestNewPaginationMeta(t *testing.T) {
+	tests := []struct {
+		name       string
+		total      int64
+		page       int
+		limit      int
+		totalPages int
+		hasNext    bool
+		hasPrev    bool
+	}{
+		{"first page", 45, 1, 20, 3, true, false},
+		{"middle page", 45, 2, 20, 3, true, true},
+		{"last page", 45, 3, 20, 3, false, true},
+		{"exact multiple", 40, 2, 20, 2, false, true},
+		{"single page", 5, 1, 20, 1, false, false},
+		{"no results", 0, 1, 20, 0, false, false},
+		{"past the end", 45, 5, 20, 3, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta := newPaginationMeta(tt.total, tt.page, tt.limit)
+			if meta.Total != tt.total || meta.Page != tt.page || meta.Limit != tt.limit {
+				t.Errorf("Expected total=%d page=%d limit=%d, got %+v", tt.total, tt.page, tt.limit, meta)
+			}
+			if meta.TotalPages != tt.totalPages {
+				t.Errorf("Expected %d total pages, got %d", tt.totalPages, meta.TotalPages)
+			}
+			if meta.HasNext != tt.hasNext || meta.HasPrev != tt.hasPrev {
+				t.Errorf("Expected has_next=%v has_prev=%v, got has_next=%v has_prev=%v", tt.hasNext, tt.hasPrev, meta.HasNext, meta.HasPrev)
+			}
+		})
+	}
+}
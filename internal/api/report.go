@@ -0,0 +1,199 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"go-chat/pkg/chat"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReportMessageRequest struct {
+	Reason string `json:"reason,omitempty" example:"harassment"` // At most 500 characters
+}
+
+type ResolveReportRequest struct {
+	Status string `json:"status" binding:"required" example:"resolved" enums:"open,resolved,dismissed"`
+}
+
+type ReportInfo struct {
+	ID         uint        `json:"id" example:"1"`
+	ChannelID  string      `json:"channel_id" example:"ch123"`
+	Reason     string      `json:"reason" example:"harassment"`
+	Status     string      `json:"status" example:"open" enums:"open,resolved,dismissed"`
+	CreatedAt  string      `json:"created_at" example:"2023-01-01T00:00:00Z"`
+	UpdatedAt  string      `json:"updated_at" example:"2023-01-01T00:00:00Z"`
+	ResolvedAt *string     `json:"resolved_at" example:"2023-01-01T01:00:00Z"` // null while open
+	Reporter   UserInfo    `json:"reporter"`
+	Message    MessageInfo `json:"message"`
+}
+
+type ReportResponse struct {
+	Report ReportInfo `json:"report"`
+}
+
+type ReportsResponse struct {
+	Reports []ReportInfo `json:"reports"`
+}
+
+// toReportInfo maps a report, with its reporter and message loaded, to the API representation
+func toReportInfo(report chat.MessageReport) ReportInfo {
+	info := ReportInfo{
+		ID:        report.ID,
+		ChannelID: report.ChannelID,
+		Reason:    report.Reason,
+		Status:    report.Status,
+		CreatedAt: report.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: report.UpdatedAt.Format(time.RFC3339),
+		Reporter:  UserInfo{ID: report.Reporter.ID, Username: report.Reporter.Username},
+		Message:   toMessageInfo(report.Message),
+	}
+	if report.ResolvedAt != nil {
+		resolvedAt := report.ResolvedAt.Format(time.RFC3339)
+		info.ResolvedAt = &resolvedAt
+	}
+	return info
+}
+
+// reportErrorStatus maps report service errors to HTTP statuses
+func reportErrorStatus(err error) int {
+	switch err.Error() {
+	case "message not found", "channel not found", "report not found":
+		return http.StatusNotFound
+	case "you are not a member of this channel", "you are banned from this channel", "you cannot report your own message",
+		"only channel owners and moderators can view reports", "only channel owners and moderators can resolve reports":
+		return http.StatusForbidden
+	case "report reason is too long", "invalid report status":
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// ReportMessageHandler flags a message for moderators
+// @Summary Report a message
+// @Description Flag a message for the moderators of its channel (channel members only, not your own messages). Reporting the same message again updates your report and reopens it.
+// @Tags Messages
+// @Accept json
+// @Produce json
+// @Security CookieAuth
+// @Param id path string true "Message ID"
+// @Param request body ReportMessageRequest false "Reason for the report"
+// @Success 200 {object} ReportResponse "Report created or updated"
+// @Failure 400 {object} ErrorResponse "Reason too long"
+// @Failure 401 {object} ErrorResponse "User not authenticated"
+// @Failure 403 {object} ErrorResponse "Not a member of the channel, or reporting your own message"
+// @Failure 404 {object} ErrorResponse "Message not found"
+// @Router /api/messages/{id}/report [post]
+func (h *MessageHandlers) ReportMessageHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	// The reason is optional, so an empty body is accepted
+	var req ReportMessageRequest
+	if c.Request.ContentLength != 0 && !bindJSON(c, &req) {
+		return
+	}
+
+	report, err := h.service.ReportMessage(userID.(string), c.Param("id"), req.Reason)
+	if err != nil {
+		status := reportErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			c.JSON(status, gin.H{"error": "Failed to report message"})
+			return
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, ReportResponse{Report: toReportInfo(*report)})
+}
+
+// GetChannelReportsHandler lists the open reports of a channel
+// @Summary Get channel reports
+// @Description List the channel's open message reports, oldest first, with the reported message and the reporter (channel owner and moderators only)
+// @Tags Messages
+// @Accept json
+// @Produce json
+// @Security CookieAuth
+// @Param id path string true "Channel ID"
+// @Success 200 {object} ReportsResponse "Open reports"
+// @Failure 401 {object} ErrorResponse "User not authenticated"
+// @Failure 403 {object} ErrorResponse "Only channel owners and moderators can view reports"
+// @Failure 404 {object} ErrorResponse "Channel not found"
+// @Router /api/channels/{id}/reports [get]
+func (h *MessageHandlers) GetChannelReportsHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	reports, err := h.service.GetChannelReports(userID.(string), c.Param("id"))
+	if err != nil {
+		status := reportErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			c.JSON(status, gin.H{"error": "Failed to fetch reports"})
+			return
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := ReportsResponse{Reports: make([]ReportInfo, 0, len(reports))}
+	for _, report := range reports {
+		response.Reports = append(response.Reports, toReportInfo(report))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// ResolveReportHandler sets the status of a report
+// @Summary Resolve a report
+// @Description Set a report's status to resolved or dismissed, or back to open (owner and moderators of the reported message's channel only)
+// @Tags Messages
+// @Accept json
+// @Produce json
+// @Security CookieAuth
+// @Param id path int true "Report ID"
+// @Param request body ResolveReportRequest true "New status"
+// @Success 200 {object} ReportResponse "Updated report"
+// @Failure 400 {object} ValidationErrorResponse "Invalid report ID or status"
+// @Failure 401 {object} ErrorResponse "User not authenticated"
+// @Failure 403 {object} ErrorResponse "Only channel owners and moderators can resolve reports"
+// @Failure 404 {object} ErrorResponse "Report not found"
+// @Router /api/reports/{id} [patch]
+func (h *MessageHandlers) ResolveReportHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	reportID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid report ID"})
+		return
+	}
+
+	var req ResolveReportRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	report, err := h.service.ResolveReport(userID.(string), uint(reportID), req.Status)
+	if err != nil {
+		status := reportErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			c.JSON(status, gin.H{"error": "Failed to update report"})
+			return
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, ReportResponse{Report: toReportInfo(*report)})
+}
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "go-chat/pkg/chat"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageReportEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := setupMessageTestDB(t)
+	require.NoError(t, db.AutoMigrate(&MessageReport{}))
+	router := gin.New()
+	NewRouter(db).RegisterRoutes(router)
+
+	roles := make(map[string]*Role)
+	for _, name := range []string{"Administrator", "Moderator", "Member"} {
+		role := &Role{Name: name}
+		require.NoError(t, db.Create(role).Error)
+		roles[name] = role
+	}
+
+	owner := createTestUserForUserTests(db, "owner", "password123")
+	moderator := createTestUserForUserTests(db, "moderator", "password123")
+	reporter := createTestUserForUserTests(db, "reporter", "password123")
+	troll := createTestUserForUserTests(db, "troll", "password123")
+	outsider := createTestUserForUserTests(db, "outsider", "password123")
+
+	channel := &Channel{Name: "reported", IsVisible: true, OwnerID: owner.ID, LoggingDays: 30}
+	require.NoError(t, db.Create(channel).Error)
+	for user, role := range map[*User]string{owner: "Administrator", moderator: "Moderator", reporter: "Member", troll: "Member"} {
+		require.NoError(t, db.Create(&UserChannel{UserID: user.ID, ChannelID: channel.ID, RoleID: &roles[role].ID}).Error)
+	}
+
+	abusive := &Message{Content: "something abusive", UserID: troll.ID, ChannelID: channel.ID}
+	require.NoError(t, db.Create(abusive).Error)
+
+	send := func(user *User, method, path string, body interface{}) *httptest.ResponseRecorder {
+		var reqBody bytes.Buffer
+		if body != nil {
+			json.NewEncoder(&reqBody).Encode(body)
+		}
+		req := httptest.NewRequest(method, path, &reqBody)
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		token, err := getAuthTokenForUser(user)
+		require.NoError(t, err)
+		req.AddCookie(&http.Cookie{Name: "token", Value: token})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	reportPath := fmt.Sprintf("/api/messages/%s/report", abusive.ID)
+	listPath := fmt.Sprintf("/api/channels/%s/reports", channel.ID)
+
+	var first ReportResponse
+	t.Run("member reports a message", func(t *testing.T) {
+		w := send(reporter, "POST", reportPath, ReportMessageRequest{Reason: "insults"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &first))
+		assert.Equal(t, ReportStatusOpen, first.Report.Status)
+		assert.Equal(t, "insults", first.Report.Reason)
+		assert.Equal(t, "reporter", first.Report.Reporter.Username)
+		assert.Equal(t, abusive.ID, first.Report.Message.ID)
+		assert.Equal(t, "something abusive", first.Report.Message.Content)
+	})
+
+	t.Run("reporting again updates the report", func(t *testing.T) {
+		w := send(reporter, "POST", reportPath, ReportMessageRequest{Reason: "insults and threats"})
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var second ReportResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &second))
+		assert.Equal(t, first.Report.ID, second.Report.ID)
+		assert.Equal(t, "insults and threats", second.Report.Reason)
+
+		var count int64
+		db.Model(&MessageReport{}).Count(&count)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("reports are refused", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, send(outsider, "POST", reportPath, nil).Code)
+		// Membership is checked against the message's channel, whatever channel the client names
+		other := &Channel{Name: "outsiders", IsVisible: true, OwnerID: outsider.ID, LoggingDays: 30}
+		require.NoError(t, db.Create(other).Error)
+		require.NoError(t, db.Create(&UserChannel{UserID: outsider.ID, ChannelID: other.ID, RoleID: &roles["Administrator"].ID}).Error)
+		assert.Equal(t, http.StatusForbidden, send(outsider, "POST", reportPath+"?channel_id="+other.ID, ReportMessageRequest{Reason: "spam"}).Code)
+		assert.Equal(t, http.StatusForbidden, send(troll, "POST", reportPath, nil).Code)
+		assert.Equal(t, http.StatusNotFound, send(reporter, "POST", "/api/messages/missing/report", nil).Code)
+	})
+
+	t.Run("only the owner and moderators list reports", func(t *testing.T) {
+		for _, user := range []*User{owner, moderator} {
+			w := send(user, "GET", listPath, nil)
+			require.Equal(t, http.StatusOK, w.Code)
+
+			var response ReportsResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			require.Len(t, response.Reports, 1)
+			assert.Equal(t, "insults and threats", response.Reports[0].Reason)
+			assert.Equal(t, "troll", response.Reports[0].Message.User.Username)
+		}
+
+		for _, user := range []*User{reporter, troll, outsider} {
+			assert.Equal(t, http.StatusForbidden, send(user, "GET", listPath, nil).Code, user.Username)
+		}
+	})
+
+	t.Run("moderator resolves the report", func(t *testing.T) {
+		resolvePath := fmt.Sprintf("/api/reports/%d", first.Report.ID)
+		assert.Equal(t, http.StatusForbidden, send(reporter, "PATCH", resolvePath, ResolveReportRequest{Status: ReportStatusResolved}).Code)
+		assert.Equal(t, http.StatusBadRequest, send(moderator, "PATCH", resolvePath, ResolveReportRequest{Status: "ignored"}).Code)
+
+		w := send(moderator, "PATCH", resolvePath, ResolveReportRequest{Status: ReportStatusResolved})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var response ReportResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, ReportStatusResolved, response.Report.Status)
+		assert.NotNil(t, response.Report.ResolvedAt)
+
+		var stored MessageReport
+		require.NoError(t, db.First(&stored, first.Report.ID).Error)
+		require.NotNil(t, stored.ResolvedBy)
+		assert.Equal(t, moderator.ID, *stored.ResolvedBy)
+
+		// Resolved reports leave the open list
+		var reports ReportsResponse
+		require.NoError(t, json.Unmarshal(send(owner, "GET", listPath, nil).Body.Bytes(), &reports))
+		assert.Empty(t, reports.Reports)
+	})
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"net/http"
+
+	r "go-chat/internal/role"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type RoleHandlers struct {
+	service *r.RoleService
+}
+
+func NewRoleHandlers(db *gorm.DB) *RoleHandlers {
+	return &RoleHandlers{
+		service: r.NewRoleService(db),
+	}
+}
+
+// RolePermissions tells what a member holding the role may do in a channel.
+// Channel owners may do everything whatever their role.
+type RolePermissions struct {
+	CanPost           bool `json:"can_post" example:"true"`
+	CanPostWhenLocked bool `json:"can_post_when_locked" example:"true"` // Post while the channel is locked
+	CanBan            bool `json:"can_ban" example:"true"`              // Ban and temporarily ban members of lower roles
+	CanLock           bool `json:"can_lock" example:"true"`             // Lock and unlock the channel
+	CanReviewReports  bool `json:"can_review_reports" example:"true"`   // List and resolve reported messages
+	CanViewModeration bool `json:"can_view_moderation" example:"true"`  // View bans and moderation statistics
+	CanPromote        bool `json:"can_promote" example:"false"`         // Change members' roles; reserved to the owner
+}
+
+type RoleInfo struct {
+	ID          uint            `json:"id" example:"2"`
+	Name        string          `json:"name" example:"Moderator"`
+	Priority    int             `json:"priority" example:"50"` // Higher roles may moderate lower ones
+	Permissions RolePermissions `json:"permissions"`
+}
+
+type RolesResponse struct {
+	Roles []RoleInfo `json:"roles"`
+}
+
+// GetRolesHandler lists the channel roles and what each may do
+// @Summary List channel roles
+// @Description List the channel roles, highest priority first, with a summary of what members holding each role may do. A member may only moderate members of a lower priority; the channel owner may do everything.
+// @Tags Roles
+// @Produce json
+// @Security CookieAuth
+// @Success 200 {object} RolesResponse "Roles retrieved successfully"
+// @Failure 401 {object} ErrorResponse "User not authenticated"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/roles [get]
+func (h *RoleHandlers) GetRolesHandler(c *gin.Context) {
+	roles, err := h.service.ListRoles()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get roles"})
+		return
+	}
+
+	infos := make([]RoleInfo, 0, len(roles))
+	for _, role := range roles {
+		permissions := r.PermissionsOf(role)
+		infos = append(infos, RoleInfo{
+			ID:       role.ID,
+			Name:     role.Name,
+			Priority: role.Priority,
+			Permissions: RolePermissions{
+				CanPost:           permissions.CanPost,
+				CanPostWhenLocked: permissions.CanPostWhenLocked,
+				CanBan:            permissions.CanBan,
+				CanLock:           permissions.CanLock,
+				CanReviewReports:  permissions.CanReviewReports,
+				CanViewModeration: permissions.CanViewModeration,
+				CanPromote:        permissions.CanPromote,
+			},
+		})
+	}
+
+	c.JSON(http.StatusOK, RolesResponse{Roles: infos})
+}
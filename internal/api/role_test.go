@@ -9,6 +9,8 @@ import (
 	"testing"
 
 	"go-chat/internal/auth"
+	c "go-chat/internal/channel"
+	"go-chat/internal/storage"
 	. "go-chat/pkg/chat"
 
 	"github.com/gin-gonic/gin"
@@ -75,9 +77,9 @@ func TestChannelHandlers_PromoteUserHandler_Success(t *testing.T) {
 	require.NoError(t, db.Create(memberChannel).Error)
 	
 	// Setup handler
-	ch := NewChannelHandlers(db)
+	ch := NewChannelHandlers(db, c.NewMemberCache(0))
 	router := gin.New()
-	am := auth.NewAuthMiddleware()
+	am := auth.NewAuthMiddleware(db)
 	router.POST("/api/channels/:id/promote", am.RequireAuth(), ch.PromoteUserHandler)
 	
 	// Create request
@@ -143,7 +145,7 @@ func TestChannelHandlers_PromoteUserHandler_Unauthorized(t *testing.T) {
 	require.NoError(t, db.Create(channel).Error)
 	
 	// Setup handler
-	ch := NewChannelHandlers(db)
+	ch := NewChannelHandlers(db, c.NewMemberCache(0))
 	
 	// Create request
 	reqBody := map[string]interface{}{
@@ -213,7 +215,7 @@ func TestChannelHandlers_DemoteUserHandler_Success(t *testing.T) {
 	require.NoError(t, db.Create(modChannel).Error)
 	
 	// Setup handler
-	ch := NewChannelHandlers(db)
+	ch := NewChannelHandlers(db, c.NewMemberCache(0))
 	
 	// Create request
 	reqBody := map[string]interface{}{
@@ -247,6 +249,102 @@ func TestChannelHandlers_DemoteUserHandler_Success(t *testing.T) {
 	assert.Equal(t, "Member", updatedMod.Role.Name)
 }
 
+func TestChannelHandlers_PromoteUserHandler_RoleAndReason(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := setupRoleTestDB(t)
+
+	owner := &User{Username: "owner", Password: hashPassword("password123")}
+	member := &User{Username: "member", Password: hashPassword("password123")}
+	require.NoError(t, db.Create(owner).Error)
+	require.NoError(t, db.Create(member).Error)
+
+	memberRole := &Role{Name: "Member"}
+	require.NoError(t, db.Create(memberRole).Error)
+	require.NoError(t, db.Create(&Role{Name: "Moderator"}).Error)
+
+	channel := &Channel{Name: "test-channel", IsVisible: true, OwnerID: owner.ID}
+	require.NoError(t, db.Create(channel).Error)
+	require.NoError(t, db.Create(&UserChannel{UserID: member.ID, ChannelID: channel.ID, RoleID: &memberRole.ID}).Error)
+
+	ch := NewChannelHandlers(db, c.NewMemberCache(0))
+	promote := func(body RoleUpdateRequest) *httptest.ResponseRecorder {
+		jsonBody, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("POST", fmt.Sprintf("/api/channels/%s/promote", channel.ID), bytes.NewBuffer(jsonBody))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Set("user_id", owner.ID)
+		c.Params = gin.Params{{Key: "id", Value: channel.ID}}
+		ch.PromoteUserHandler(c)
+		return w
+	}
+
+	t.Run("unknown role is rejected", func(t *testing.T) {
+		w := promote(RoleUpdateRequest{UserID: member.ID, Role: "Modderator"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "Invalid role")
+
+		var roles int64
+		db.Model(&Role{}).Where("name = ?", "Modderator").Count(&roles)
+		assert.Zero(t, roles, "unknown role should not be created")
+	})
+
+	t.Run("reason is recorded in the audit log", func(t *testing.T) {
+		w := promote(RoleUpdateRequest{UserID: member.ID, Role: "Moderator", Reason: "keeps the peace"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var auditLog AuditLog
+		require.NoError(t, db.Where("action = ? AND target_id = ?", "PROMOTE_USER", member.ID).First(&auditLog).Error)
+		var metadata map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(auditLog.Metadata), &metadata))
+		assert.Equal(t, "keeps the peace", metadata["reason"])
+		assert.Equal(t, "Moderator", metadata["new_role"])
+	})
+}
+
+func TestGetRolesHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := setupTestDB(t)
+	require.NoError(t, storage.SeedRoles(db))
+	router := gin.New()
+	NewRouter(db).RegisterRoutes(router)
+	_, token := createTestUserWithAuth(t, router, "reader", "password123")
+
+	req := httptest.NewRequest("GET", "/api/roles", nil)
+	req.AddCookie(&http.Cookie{Name: "token", Value: token})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var response RolesResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Roles, 4)
+
+	var names []string
+	for i, role := range response.Roles {
+		names = append(names, role.Name)
+		assert.Equal(t, RolePriorities[role.Name], role.Priority)
+		if i > 0 {
+			assert.Greater(t, response.Roles[i-1].Priority, role.Priority)
+		}
+	}
+	assert.Equal(t, []string{"Administrator", "Moderator", "Member", "Guest"}, names)
+
+	moderator := RolePermissions{CanPost: true, CanPostWhenLocked: true, CanBan: true, CanLock: true, CanReviewReports: true, CanViewModeration: true}
+	assert.Equal(t, moderator, response.Roles[0].Permissions)
+	assert.Equal(t, moderator, response.Roles[1].Permissions)
+	assert.Equal(t, RolePermissions{CanPost: true}, response.Roles[2].Permissions)
+	assert.Equal(t, RolePermissions{}, response.Roles[3].Permissions)
+
+	t.Run("requires authentication", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/api/roles", nil))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
 func hashPassword(password string) string {
 	hash, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	return string(hash)
@@ -2,6 +2,7 @@ package api
 
 import (
 	a "go-chat/internal/auth"
+	c "go-chat/internal/channel"
 	"go-chat/internal/middleware"
 
 	"github.com/gin-gonic/gin"
@@ -15,41 +16,80 @@ type Router struct {
 	mh *MessageHandlers
 	sh *SearchHandlers
 	audh *AuditHandlers
+	admh *AdminHandlers
+	th *ApiTokenHandlers
+	dsh *DeviceSessionHandlers
+	nh *NotificationHandlers
+	hh *HealthHandlers
+	rh *RoleHandlers
 	am *a.AuthMiddleware
 	// Rate limiters for different endpoint types
 	authRateLimit     *middleware.IPRateLimiter
 	generalRateLimit  *middleware.IPRateLimiter
 	readOnlyRateLimit *middleware.IPRateLimiter
+	passwordRateLimit *middleware.IPRateLimiter
+	// Content-type enforcement for endpoints that accept a body
+	contentType middleware.ContentTypeConfig
+	// Cross-origin policy for browser clients
+	cors middleware.CORSConfig
+	// Serve Prometheus metrics on /metrics
+	metricsEnabled bool
 }
 
 func NewRouter(db *gorm.DB) *Router {
+	// Shared by every service that reads or changes channel memberships
+	members := c.NewMemberCache(c.MemberCacheTTLFromEnv())
+
 	return &Router{
 		ah: NewHandlers(db),
-		ch: NewChannelHandlers(db),
-		uh: NewUserHandlers(db),
-		mh: NewMessageHandlers(db),
+		ch: NewChannelHandlers(db, members),
+		uh: NewUserHandlers(db, members),
+		mh: NewMessageHandlers(db, members),
 		sh: NewSearchHandlers(db),
 		audh: NewAuditHandlers(db),
-		am: a.NewAuthMiddleware(),
+		admh: NewAdminHandlers(db, members),
+		th: NewApiTokenHandlers(db),
+		dsh: NewDeviceSessionHandlers(db),
+		nh: NewNotificationHandlers(db),
+		hh: NewHealthHandlers(db),
+		rh: NewRoleHandlers(db),
+		am: a.NewAuthMiddleware(db),
 		// Initialize rate limiters with different configurations
 		authRateLimit:     middleware.NewIPRateLimiter(middleware.StrictRateLimit),
 		generalRateLimit:  middleware.NewIPRateLimiter(middleware.StandardRateLimit),
 		readOnlyRateLimit: middleware.NewIPRateLimiter(middleware.LenientRateLimit),
+		passwordRateLimit: middleware.NewIPRateLimiter(middleware.PasswordRateLimit),
+		contentType:       middleware.ContentTypeConfigFromEnv(),
+		cors:              middleware.CORSConfigFromEnv(),
+		metricsEnabled:    MetricsEnabledFromEnv(),
 	}
 }
 
 func (r *Router) RegisterRoutes(router *gin.Engine) {
+	// Registered before any group so that it also answers preflight requests,
+	// which match no route
+	router.Use(middleware.CORSMiddleware(r.cors))
+
+	if r.metricsEnabled {
+		router.Use(middleware.MetricsMiddleware())
+		// Scraped by Prometheus from inside the deployment, so not rate limited
+		router.GET("/metrics", MetricsHandler)
+	}
+
 	{
 		// Health check with lenient rate limiting
 		health := router.Group("/")
 		health.Use(middleware.RateLimitMiddleware(r.readOnlyRateLimit))
 		health.GET("/hc", HealthCheckHandler)
+		health.GET("/hc/live", r.hh.LivenessHandler)
+		health.GET("/hc/ready", r.hh.ReadinessHandler)
 	}
 	
 	{
 		// Authentication endpoints with strict rate limiting
 		auth := router.Group("/")
 		auth.Use(middleware.RateLimitMiddleware(r.authRateLimit))
+		auth.Use(middleware.RequireJSONMiddleware(r.contentType))
 		auth.POST("/register", r.ah.RegisterHandler)
 		auth.POST("/login", r.ah.LoginHandler)
 	}
@@ -62,21 +102,44 @@ func (r *Router) RegisterRoutes(router *gin.Engine) {
 		authProtected.POST("/logout", r.ah.LogoutHandler)
 		authProtected.POST("/refresh_token", r.ah.RefreshTokenHandler)
 	}
+
+	{
+		// Password checks with their own, stricter rate limit against brute forcing
+		passwordCheck := router.Group("/api")
+		passwordCheck.Use(r.am.RequireAuth())
+		passwordCheck.Use(middleware.RateLimitMiddleware(r.passwordRateLimit))
+		passwordCheck.Use(middleware.RequireJSONMiddleware(r.contentType))
+		passwordCheck.POST("/channels/:id/verify-password", r.ch.VerifyChannelPasswordHandler)
+	}
 	
 	{
 		// Read-only endpoints with lenient rate limiting
 		readOnly := router.Group("/api")
 		readOnly.Use(r.am.RequireAuth())
 		readOnly.Use(middleware.RateLimitMiddleware(r.readOnlyRateLimit))
+		readOnly.GET("/auth/session", r.ah.SessionHandler)
 		readOnly.GET("/user/channels/owned", r.uh.GetOwnedChannelsHandler)
 		readOnly.GET("/user/channels/joined", r.uh.GetJoinedChannelsHandler)
+		readOnly.GET("/user/channels/moderated", r.uh.GetModeratedChannelsHandler)
+		readOnly.GET("/user/channels/unread", r.mh.GetUnreadCountsHandler)
+		readOnly.GET("/user/tokens", r.th.GetApiTokensHandler)
+		readOnly.GET("/user/sessions", r.dsh.GetSessionsHandler)
+		readOnly.GET("/user/bans", r.uh.GetOwnBansHandler)
+		readOnly.GET("/user/notifications/settings", r.nh.GetNotificationSettingsHandler)
+		readOnly.GET("/users/:id", r.uh.GetUserProfileHandler)
 		readOnly.GET("/channels", r.ch.GetChannelsHandler)
 		readOnly.GET("/channels/me", r.ch.GetUserChannelsHandler)
 		readOnly.GET("/channels/:id", r.ch.GetChannelHandler)
 		readOnly.GET("/channels/:id/users", r.ch.GetChannelUsersHandler)
 		readOnly.GET("/channels/:id/bans", r.ch.GetChannelBansHandler)
+		readOnly.GET("/channels/:id/bans/:userId", r.ch.GetUserBanHandler)
 		readOnly.GET("/channels/:id/messages", r.mh.GetChannelMessagesHandler)
+		readOnly.GET("/channels/:id/preview-messages", r.mh.GetPreviewMessagesHandler)
 		readOnly.GET("/channels/:id/audit", r.audh.GetChannelAuditLogsHandler)
+		readOnly.GET("/channels/:id/moderation-stats", r.ch.GetModerationStatsHandler)
+		readOnly.GET("/channels/:id/reports", r.mh.GetChannelReportsHandler)
+		readOnly.GET("/categories", r.ch.GetCategoriesHandler)
+		readOnly.GET("/roles", r.rh.GetRolesHandler)
 		readOnly.GET("/search/users", r.sh.SearchUsersHandler)
 		readOnly.GET("/search/channels", r.sh.SearchChannelsHandler)
 		readOnly.GET("/search/messages", r.sh.SearchMessagesHandler)
@@ -88,16 +151,32 @@ func (r *Router) RegisterRoutes(router *gin.Engine) {
 		protected := router.Group("/api")
 		protected.Use(r.am.RequireAuth())
 		protected.Use(middleware.RateLimitMiddleware(r.generalRateLimit))
+		protected.Use(middleware.RequireJSONMiddleware(r.contentType))
 		
 		// User endpoints
 		protected.PATCH("/user", r.uh.UpdateUserHandler)
 		protected.DELETE("/user", r.uh.DeleteUserHandler)
+		protected.POST("/user/tokens", r.th.CreateApiTokenHandler)
+		protected.DELETE("/user/tokens/:id", r.th.RevokeApiTokenHandler)
+		protected.DELETE("/user/sessions/:id", r.dsh.RevokeSessionHandler)
+		protected.POST("/user/read-all", r.mh.MarkAllReadHandler)
+		protected.POST("/user/channels/read-all", r.mh.MarkAllReadHandler) // Previous path, kept for existing clients
 
 		// Channel endpoints
 		protected.POST("/channels", r.ch.CreateChannelHandler)
+		protected.POST("/channels/join-bulk", r.ch.BulkJoinChannelsHandler)
 		protected.POST("/channels/:id/join", r.ch.JoinChannelHandler)
 		protected.DELETE("/channels/:id/leave", r.ch.LeaveChannelHandler)
+		protected.PATCH("/channels/:id", r.ch.UpdateChannelHandler)
 		protected.DELETE("/channels/:id", r.ch.DeleteChannelHandler)
+		protected.PUT("/channels/:id/notifications", r.nh.UpdateNotificationPrefHandler)
+		protected.PUT("/channels/:id/category", r.ch.SetChannelCategoryHandler)
+		protected.POST("/categories", r.ch.CreateCategoryHandler)
+
+		// Message endpoints
+		protected.POST("/channels/:id/messages", r.mh.CreateMessageHandler)
+		protected.POST("/messages/:id/report", r.mh.ReportMessageHandler)
+		protected.PATCH("/reports/:id", r.mh.ResolveReportHandler)
 		
 		// Channel administration endpoints
 		protected.POST("/channels/:id/ban", r.ch.BanUserHandler)
@@ -105,6 +184,22 @@ func (r *Router) RegisterRoutes(router *gin.Engine) {
 		protected.DELETE("/channels/:id/ban/:userId", r.ch.UnbanUserHandler)
 		protected.POST("/channels/:id/promote", r.ch.PromoteUserHandler)
 		protected.POST("/channels/:id/demote", r.ch.DemoteUserHandler)
+		protected.POST("/channels/:id/lock", r.ch.LockChannelHandler)
+		protected.POST("/channels/:id/unlock", r.ch.UnlockChannelHandler)
+		protected.PUT("/channels/:id/topic", r.ch.SetChannelTopicHandler)
+	}
+
+	{
+		// System administration endpoints, restricted to admins, with standard rate limiting
+		admin := router.Group("/api/admin")
+		admin.Use(r.am.RequireAuth())
+		admin.Use(middleware.RateLimitMiddleware(r.generalRateLimit))
+		admin.Use(r.admh.RequireAdmin())
+		admin.Use(middleware.RequireJSONMiddleware(r.contentType))
+		admin.GET("/users", r.admh.ListUsersHandler)
+		admin.POST("/users/:id/suspend", r.admh.SuspendUserHandler)
+		admin.DELETE("/users/:id/suspend", r.admh.UnsuspendUserHandler)
+		admin.PATCH("/messages/:id/author", r.admh.ScrubMessageAuthorHandler)
 	}
 }
 
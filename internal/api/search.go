@@ -2,6 +2,7 @@ package api
 
 import (
 	"net/http"
+	"os"
 	"strconv"
 	"strings"
 
@@ -11,15 +12,56 @@ import (
 )
 
 type SearchHandlers struct {
-	service *s.SearchService
+	service     *s.SearchService
+	emptyStatus int
+	highlight   string // Delimiter wrapped around matched terms in message snippets
 }
 
 func NewSearchHandlers(db *gorm.DB) *SearchHandlers {
 	return &SearchHandlers{
-		service: s.NewSearchService(db),
+		service:     s.NewSearchService(db),
+		emptyStatus: EmptyResultStatusFromEnv(),
+		highlight:   HighlightDelimiterFromEnv(),
 	}
 }
 
+// EmptyResultStatusFromEnv reads the status returned by searches without results
+// from SEARCH_EMPTY_STATUS: "404" for Not Found, anything else for 200 with an empty list
+func EmptyResultStatusFromEnv() int {
+	return parseEmptyResultStatus(os.Getenv("SEARCH_EMPTY_STATUS"), http.StatusOK)
+}
+
+func parseEmptyResultStatus(value string, fallback int) int {
+	switch strings.TrimSpace(value) {
+	case "200":
+		return http.StatusOK
+	case "404":
+		return http.StatusNotFound
+	default:
+		return fallback
+	}
+}
+
+// SetEmptyResultStatus overrides the empty-result status read from the environment
+func (h *SearchHandlers) SetEmptyResultStatus(status int) {
+	h.emptyStatus = status
+}
+
+// respondEmpty answers a search without results, honouring the per-request
+// on_empty parameter ("200" or "404") over the server default
+func (h *SearchHandlers) respondEmpty(c *gin.Context, notFound string, empty interface{}) {
+	if parseEmptyResultStatus(c.Query("on_empty"), h.emptyStatus) == http.StatusNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": notFound})
+		return
+	}
+	c.JSON(http.StatusOK, empty)
+}
+
+// isQueryLimitError reports whether a search failed because the query exceeded the configured limits
+func isQueryLimitError(err error) bool {
+	return err.Error() == "search query is too long" || err.Error() == "search query has too many terms"
+}
+
 type UserSearchResult struct {
 	ID       string `json:"id"`
 	Username string `json:"username"`
@@ -27,14 +69,15 @@ type UserSearchResult struct {
 
 type UsersSearchResponse struct {
 	Users []UserSearchResult `json:"users"`
-	Total int64              `json:"total"`
+	PaginationMeta
 }
 
 type ChannelSearchResult struct {
-	ID        string `json:"id"`
-	Name      string `json:"name"`
-	IsVisible bool   `json:"is_visible"`
-	Owner     struct {
+	ID            string  `json:"id"`
+	Name          string  `json:"name"`
+	IsVisible     bool    `json:"is_visible"`
+	LastMessageAt *string `json:"last_message_at"` // null until the first message
+	Owner         struct {
 		ID       string `json:"id"`
 		Username string `json:"username"`
 	} `json:"owner"`
@@ -42,24 +85,28 @@ type ChannelSearchResult struct {
 
 type ChannelsSearchResponse struct {
 	Channels []ChannelSearchResult `json:"channels"`
-	Total    int64                 `json:"total"`
+	PaginationMeta
 }
 
 type MessageSearchResult struct {
-	ID        string `json:"id"`
-	Content   string `json:"content"`
-	UserID    string `json:"user_id"`
-	ChannelID string `json:"channel_id"`
-	CreatedAt string `json:"created_at"`
+	ID        string  `json:"id"`
+	Content   string  `json:"content"`
+	Snippet   string  `json:"snippet" example:"…the deploy **pipeline** is green…"` // Excerpt around the first match, which is wrapped in the highlight delimiter
+	UserID    string  `json:"user_id"`
+	ChannelID string  `json:"channel_id"`
+	CreatedAt string  `json:"created_at"`
+	EditedAt  *string `json:"edited_at"`  // null unless edited
+	IsDeleted bool    `json:"is_deleted"` // Deleted messages are left out of search, so always false
 	User      struct {
 		ID       string `json:"id"`
 		Username string `json:"username"`
 	} `json:"user"`
+	Attachments []AttachmentInfo `json:"attachments"`
 }
 
 type MessagesSearchResponse struct {
 	Messages []MessageSearchResult `json:"messages"`
-	Total    int64                 `json:"total"`
+	PaginationMeta
 }
 
 // SearchUsersHandler searches for users by username
@@ -69,11 +116,14 @@ type MessagesSearchResponse struct {
 // @Accept json
 // @Produce json
 // @Security CookieAuth
-// @Param q query string true "Search query (minimum 2 characters)"
-// @Param limit query int false "Number of results to return (default: 20, max: 50)"
+// @Param q query string true "Search query (minimum 2 characters, bounded length and term count)"
+// @Param page query int false "Page number (default: 1)"
+// @Param limit query int false "Number of results per page (default: 20, max: 50)"
+// @Param on_empty query string false "Status when nothing matches: 200 (empty list) or 404 (default: server setting)" Enums(200, 404)
 // @Success 200 {object} UsersSearchResponse "Users found"
-// @Failure 400 {object} ErrorResponse "Bad request - invalid query"
+// @Failure 400 {object} ErrorResponse "Bad request - invalid, too long or too complex query"
 // @Failure 401 {object} ErrorResponse "User not authenticated"
+// @Failure 404 {object} ErrorResponse "No users found (when empty results are reported as 404)"
 // @Router /api/search/users [get]
 func (h *SearchHandlers) SearchUsersHandler(c *gin.Context) {
 	userID, exists := c.Get("user_id")
@@ -88,23 +138,24 @@ func (h *SearchHandlers) SearchUsersHandler(c *gin.Context) {
 		return
 	}
 
-	// Parse limit parameter
-	limitStr := c.DefaultQuery("limit", "20")
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit <= 0 {
-		limit = 20
-	}
-	if limit > 50 {
-		limit = 50
-	}
+	page, limit, offset := parsePaginationLimits(c, DefaultPageLimit, MaxSearchLimit)
 
 	// Search users
-	users, total, err := h.service.SearchUsers(userID.(string), query, limit)
+	users, total, err := h.service.SearchUsers(userID.(string), query, limit, offset)
 	if err != nil {
+		if isQueryLimitError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search users"})
 		return
 	}
 
+	if len(users) == 0 {
+		h.respondEmpty(c, "No users found", UsersSearchResponse{Users: []UserSearchResult{}, PaginationMeta: newPaginationMeta(total, page, limit)})
+		return
+	}
+
 	// Convert to response format
 	var userResults []UserSearchResult
 	for _, user := range users {
@@ -115,8 +166,8 @@ func (h *SearchHandlers) SearchUsersHandler(c *gin.Context) {
 	}
 
 	response := UsersSearchResponse{
-		Users: userResults,
-		Total: total,
+		Users:          userResults,
+		PaginationMeta: newPaginationMeta(total, page, limit),
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -124,16 +175,22 @@ func (h *SearchHandlers) SearchUsersHandler(c *gin.Context) {
 
 // SearchChannelsHandler searches for channels by name
 // @Summary Search channels
-// @Description Search for visible channels by name (partial matching)
+// @Description Search for visible channels by name (partial matching). Owners who opted out are listed as "hidden".
 // @Tags Search
 // @Accept json
 // @Produce json
 // @Security CookieAuth
-// @Param q query string true "Search query (minimum 2 characters)"
-// @Param limit query int false "Number of results to return (default: 20, max: 50)"
+// @Param q query string true "Search query (minimum 2 characters, bounded length and term count)"
+// @Param page query int false "Page number (default: 1)"
+// @Param limit query int false "Number of results per page (default: 20, max: 50)"
+// @Param on_empty query string false "Status when nothing matches: 200 (empty list) or 404 (default: server setting)" Enums(200, 404)
+// @Param sort query string false "Sort by name, member count or latest message (default: name)" Enums(name, members, recent_activity)
+// @Param direction query string false "Sort direction (default: asc for name, desc otherwise)" Enums(asc, desc)
+// @Param discover query bool false "Only return channels the user can join: excludes joined channels and channels with an active ban (default: false)"
 // @Success 200 {object} ChannelsSearchResponse "Channels found"
-// @Failure 400 {object} ErrorResponse "Bad request - invalid query"
+// @Failure 400 {object} ErrorResponse "Bad request - invalid, too long or too complex query, invalid sort or invalid discover value"
 // @Failure 401 {object} ErrorResponse "User not authenticated"
+// @Failure 404 {object} ErrorResponse "No channels found (when empty results are reported as 404)"
 // @Router /api/search/channels [get]
 func (h *SearchHandlers) SearchChannelsHandler(c *gin.Context) {
 	userID, exists := c.Get("user_id")
@@ -148,39 +205,54 @@ func (h *SearchHandlers) SearchChannelsHandler(c *gin.Context) {
 		return
 	}
 
-	// Parse limit parameter
-	limitStr := c.DefaultQuery("limit", "20")
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit <= 0 {
-		limit = 20
+	page, limit, offset := parsePaginationLimits(c, DefaultPageLimit, MaxSearchLimit)
+
+	sort, err := parseChannelSort(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
-	if limit > 50 {
-		limit = 50
+
+	discover, err := strconv.ParseBool(c.DefaultQuery("discover", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid discover value"})
+		return
 	}
 
 	// Search channels
-	channels, total, err := h.service.SearchChannels(userID.(string), query, limit)
+	channels, total, err := h.service.SearchChannels(userID.(string), query, sort, discover, limit, offset)
 	if err != nil {
+		if isQueryLimitError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search channels"})
 		return
 	}
 
+	if len(channels) == 0 {
+		h.respondEmpty(c, "No channels found", ChannelsSearchResponse{Channels: []ChannelSearchResult{}, PaginationMeta: newPaginationMeta(total, page, limit)})
+		return
+	}
+
 	// Convert to response format
 	var channelResults []ChannelSearchResult
 	for _, channel := range channels {
 		channelResult := ChannelSearchResult{
-			ID:        channel.ID,
-			Name:      channel.Name,
-			IsVisible: channel.IsVisible,
+			ID:            channel.ID,
+			Name:          channel.Name,
+			IsVisible:     channel.IsVisible,
+			LastMessageAt: lastMessageAt(channel),
 		}
-		channelResult.Owner.ID = channel.Owner.ID
-		channelResult.Owner.Username = channel.Owner.Username
+		owner := listedOwner(channel)
+		channelResult.Owner.ID = owner.ID
+		channelResult.Owner.Username = owner.Username
 		channelResults = append(channelResults, channelResult)
 	}
 
 	response := ChannelsSearchResponse{
-		Channels: channelResults,
-		Total:    total,
+		Channels:       channelResults,
+		PaginationMeta: newPaginationMeta(total, page, limit),
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -193,14 +265,16 @@ func (h *SearchHandlers) SearchChannelsHandler(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Security CookieAuth
-// @Param q query string true "Search query (minimum 2 characters)"
+// @Param q query string true "Search query (minimum 2 characters, bounded length and term count)"
 // @Param channel_id query string true "Channel ID to search within"
-// @Param limit query int false "Number of results to return (default: 20, max: 50)"
+// @Param page query int false "Page number (default: 1)"
+// @Param limit query int false "Number of results per page (default: 20, max: 50)"
+// @Param on_empty query string false "Status when nothing matches: 200 (empty list) or 404 (default: server setting)" Enums(200, 404)
 // @Success 200 {object} MessagesSearchResponse "Messages found"
-// @Failure 400 {object} ErrorResponse "Bad request - invalid query or channel_id"
+// @Failure 400 {object} ErrorResponse "Bad request - invalid, too long or too complex query, or invalid channel_id"
 // @Failure 401 {object} ErrorResponse "User not authenticated"
 // @Failure 403 {object} ErrorResponse "You are not a member of this channel"
-// @Failure 404 {object} ErrorResponse "Channel not found"
+// @Failure 404 {object} ErrorResponse "Channel not found, or no messages found (when empty results are reported as 404)"
 // @Router /api/search/messages [get]
 func (h *SearchHandlers) SearchMessagesHandler(c *gin.Context) {
 	userID, exists := c.Get("user_id")
@@ -221,19 +295,15 @@ func (h *SearchHandlers) SearchMessagesHandler(c *gin.Context) {
 		return
 	}
 
-	// Parse limit parameter
-	limitStr := c.DefaultQuery("limit", "20")
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit <= 0 {
-		limit = 20
-	}
-	if limit > 50 {
-		limit = 50
-	}
+	page, limit, offset := parsePaginationLimits(c, DefaultPageLimit, MaxSearchLimit)
 
 	// Search messages
-	messages, total, err := h.service.SearchMessages(userID.(string), channelID, query, limit)
+	messages, total, err := h.service.SearchMessages(userID.(string), channelID, query, limit, offset)
 	if err != nil {
+		if isQueryLimitError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		if err.Error() == "channel not found" {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Channel not found"})
 			return
@@ -250,24 +320,32 @@ func (h *SearchHandlers) SearchMessagesHandler(c *gin.Context) {
 		return
 	}
 
+	if len(messages) == 0 {
+		h.respondEmpty(c, "No messages found", MessagesSearchResponse{Messages: []MessageSearchResult{}, PaginationMeta: newPaginationMeta(total, page, limit)})
+		return
+	}
+
 	// Convert to response format
 	var messageResults []MessageSearchResult
 	for _, message := range messages {
 		messageResult := MessageSearchResult{
 			ID:        message.ID,
 			Content:   message.Content,
+			Snippet:   buildSnippet(message.Content, query, snippetWindow, h.highlight),
 			UserID:    message.UserID,
 			ChannelID: message.ChannelID,
 			CreatedAt: message.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+			EditedAt:  editedAt(message),
 		}
+		messageResult.Attachments = toAttachmentInfos(message.Attachments)
 		messageResult.User.ID = message.User.ID
-		messageResult.User.Username = message.User.Username
+		messageResult.User.Username = authorName(message)
 		messageResults = append(messageResults, messageResult)
 	}
 
 	response := MessagesSearchResponse{
-		Messages: messageResults,
-		Total:    total,
+		Messages:       messageResults,
+		PaginationMeta: newPaginationMeta(total, page, limit),
 	}
 
 	c.JSON(http.StatusOK, response)
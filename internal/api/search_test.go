@@ -5,9 +5,13 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	"go-chat/internal/auth"
+	s "go-chat/internal/search"
 	. "go-chat/pkg/chat"
 
 	"github.com/gin-gonic/gin"
@@ -24,7 +28,7 @@ func setupSearchTestDB(t *testing.T) *gorm.DB {
 		t.Fatalf("Failed to connect to database: %v", err)
 	}
 
-	err = db.AutoMigrate(&User{}, &RefreshToken{}, &Role{}, &Channel{}, &UserChannel{}, &UserBan{}, &Message{}, &AuditLog{})
+	err = db.AutoMigrate(&User{}, &RefreshToken{}, &Role{}, &Channel{}, &UserChannel{}, &UserBan{}, &Message{}, &Attachment{}, &AuditLog{})
 	if err != nil {
 		t.Fatalf("Failed to migrate database: %v", err)
 	}
@@ -50,7 +54,7 @@ func TestSearchHandlers_SearchUsersHandler_Success(t *testing.T) {
 	// Setup handler
 	sh := NewSearchHandlers(db)
 	router := gin.New()
-	am := auth.NewAuthMiddleware()
+	am := auth.NewAuthMiddleware(db)
 	router.GET("/api/search/users", am.RequireAuth(), sh.SearchUsersHandler)
 	
 	// Create request
@@ -209,6 +213,12 @@ func TestSearchHandlers_SearchMessagesHandler_Success(t *testing.T) {
 	messages_data, ok := response["messages"].([]interface{})
 	require.True(t, ok)
 	assert.Len(t, messages_data, 2) // "test message" and "Testing search" should match
+
+	snippets := make([]string, 0, len(messages_data))
+	for _, message := range messages_data {
+		snippets = append(snippets, message.(map[string]interface{})["snippet"].(string))
+	}
+	assert.ElementsMatch(t, []string{"This is a **test** message", "**Test**ing search functionality"}, snippets)
 }
 
 func TestSearchHandlers_SearchMessagesHandler_NotChannelMember(t *testing.T) {
@@ -338,4 +348,211 @@ func TestSearchHandlers_SearchUsersHandler_EmptyQuery(t *testing.T) {
 func hashPasswordForSearch(password string) string {
 	hash, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	return string(hash)
-}
\ No newline at end of file
+}
+func TestSearchHandlers_QueryLimits(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := setupSearchTestDB(t)
+
+	searcher := &User{Username: "searcher", Password: hashPasswordForSearch("password123")}
+	require.NoError(t, db.Create(searcher).Error)
+
+	sh := NewSearchHandlers(db)
+	sh.service.SetQueryLimits(s.QueryLimits{MaxLength: 20, MaxTerms: 3})
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedError  string
+	}{
+		{
+			name:           "within limits",
+			query:          "john doe",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "over-long query",
+			query:          strings.Repeat("a", 21),
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "search query is too long",
+		},
+		{
+			name:           "too many terms",
+			query:          "a b c d",
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  "search query has too many terms",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, endpoint := range []string{"users", "channels", "messages"} {
+				target := fmt.Sprintf("/api/search/%s?q=%s&channel_id=missing", endpoint, url.QueryEscape(tt.query))
+
+				w := httptest.NewRecorder()
+				c, _ := gin.CreateTestContext(w)
+				c.Request = httptest.NewRequest("GET", target, nil)
+				c.Set("user_id", searcher.ID)
+
+				switch endpoint {
+				case "users":
+					sh.SearchUsersHandler(c)
+				case "channels":
+					sh.SearchChannelsHandler(c)
+				case "messages":
+					sh.SearchMessagesHandler(c)
+				}
+
+				if tt.expectedStatus == http.StatusOK {
+					// messages search reaches the channel lookup for valid queries
+					assert.NotEqual(t, http.StatusBadRequest, w.Code, endpoint)
+					continue
+				}
+
+				assert.Equal(t, tt.expectedStatus, w.Code, endpoint)
+				var response map[string]interface{}
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, tt.expectedError, response["error"], endpoint)
+			}
+		})
+	}
+}
+
+func TestSearchHandlers_EmptyResults(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := setupSearchTestDB(t)
+
+	searcher := &User{Username: "searcher", Password: hashPasswordForSearch("password123")}
+	require.NoError(t, db.Create(searcher).Error)
+	channel := &Channel{Name: "general", OwnerID: searcher.ID, IsVisible: true, LoggingDays: 30}
+	require.NoError(t, db.Create(channel).Error)
+	require.NoError(t, db.Create(&UserChannel{UserID: searcher.ID, ChannelID: channel.ID}).Error)
+
+	sh := NewSearchHandlers(db)
+
+	search := func(endpoint, extra string) *httptest.ResponseRecorder {
+		target := fmt.Sprintf("/api/search/%s?q=nomatch&channel_id=%s%s", endpoint, channel.ID, extra)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", target, nil)
+		c.Set("user_id", searcher.ID)
+
+		switch endpoint {
+		case "users":
+			sh.SearchUsersHandler(c)
+		case "channels":
+			sh.SearchChannelsHandler(c)
+		case "messages":
+			sh.SearchMessagesHandler(c)
+		}
+		return w
+	}
+
+	tests := []struct {
+		name           string
+		serverStatus   int
+		extra          string
+		expectedStatus int
+	}{
+		{"default returns empty list", http.StatusOK, "", http.StatusOK},
+		{"server configured for 404", http.StatusNotFound, "", http.StatusNotFound},
+		{"parameter requests 404", http.StatusOK, "&on_empty=404", http.StatusNotFound},
+		{"parameter requests 200", http.StatusNotFound, "&on_empty=200", http.StatusOK},
+		{"invalid parameter uses server setting", http.StatusNotFound, "&on_empty=teapot", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sh.SetEmptyResultStatus(tt.serverStatus)
+
+			for _, endpoint := range []string{"users", "channels", "messages"} {
+				w := search(endpoint, tt.extra)
+				assert.Equal(t, tt.expectedStatus, w.Code, endpoint)
+
+				var response map[string]interface{}
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				if tt.expectedStatus == http.StatusNotFound {
+					assert.Contains(t, response["error"], "No "+endpoint+" found", endpoint)
+					continue
+				}
+				assert.Equal(t, []interface{}{}, response[endpoint], endpoint)
+				assert.Equal(t, float64(0), response["total"], endpoint)
+			}
+		})
+	}
+}
+
+func TestSearchHandlers_SearchChannelsHandler_Discover(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := setupSearchTestDB(t)
+
+	owner := &User{Username: "owner", Password: hashPasswordForSearch("password123")}
+	searcher := &User{Username: "searcher", Password: hashPasswordForSearch("password123")}
+	require.NoError(t, db.Create(owner).Error)
+	require.NoError(t, db.Create(searcher).Error)
+
+	channels := map[string]*Channel{}
+	for _, name := range []string{"chat-open", "chat-joined", "chat-banned", "chat-expired-ban", "chat-lifted-ban"} {
+		channel := &Channel{Name: name, OwnerID: owner.ID, IsVisible: true}
+		require.NoError(t, db.Create(channel).Error)
+		channels[name] = channel
+	}
+	require.NoError(t, db.Create(&UserChannel{UserID: searcher.ID, ChannelID: channels["chat-joined"].ID}).Error)
+
+	past := time.Now().Add(-time.Hour)
+	require.NoError(t, db.Create(&UserBan{UserID: searcher.ID, ChannelID: channels["chat-banned"].ID, BannedBy: owner.ID}).Error)
+	require.NoError(t, db.Create(&UserBan{UserID: searcher.ID, ChannelID: channels["chat-expired-ban"].ID, BannedBy: owner.ID, ExpiresAt: &past}).Error)
+	lifted := &UserBan{UserID: searcher.ID, ChannelID: channels["chat-lifted-ban"].ID, BannedBy: owner.ID}
+	require.NoError(t, db.Create(lifted).Error)
+	// IsActive defaults to true on create, so lift the ban afterwards
+	require.NoError(t, db.Model(lifted).Update("is_active", false).Error)
+
+	sh := NewSearchHandlers(db)
+
+	search := func(extra string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/api/search/channels?q=chat"+extra, nil)
+		c.Set("user_id", searcher.ID)
+		sh.SearchChannelsHandler(c)
+		return w
+	}
+
+	names := func(w *httptest.ResponseRecorder) []string {
+		var response ChannelsSearchResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		var result []string
+		for _, channel := range response.Channels {
+			result = append(result, channel.Name)
+		}
+		assert.Equal(t, int64(len(result)), response.Total)
+		return result
+	}
+
+	tests := []struct {
+		name     string
+		extra    string
+		expected []string
+	}{
+		{"default includes every visible channel", "", []string{"chat-banned", "chat-expired-ban", "chat-joined", "chat-lifted-ban", "chat-open"}},
+		{"discover disabled", "&discover=false", []string{"chat-banned", "chat-expired-ban", "chat-joined", "chat-lifted-ban", "chat-open"}},
+		{"discover excludes joined and banned", "&discover=true", []string{"chat-expired-ban", "chat-lifted-ban", "chat-open"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := search(tt.extra)
+			require.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.expected, names(w))
+		})
+	}
+
+	t.Run("invalid discover value", func(t *testing.T) {
+		w := search("&discover=maybe")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
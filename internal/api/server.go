@@ -1,6 +1,8 @@
 package api
 
 import (
+	a "go-chat/internal/auth"
+	"go-chat/internal/config"
 	s "go-chat/internal/storage"
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
@@ -12,10 +14,19 @@ import (
 var certFile = "cert.pem"
 var keyFile = "key.pem"
 
+// Serve validates the configuration, then serves the API over TLS on port with
+// the loaded secret and database. Configuration problems are returned before
+// anything starts.
 func Serve(port string) error {
+	cfg, err := config.Load(port)
+	if err != nil {
+		return err
+	}
+	a.SetSecret(cfg.Secret)
+
 	r := gin.Default()
 
-	db, err := s.Connect()
+	db, err := s.ConnectWith(cfg.Database)
 
 	if err != nil {
 		panic(err)
@@ -23,10 +34,13 @@ func Serve(port string) error {
 
 	router := NewRouter(db)
 	router.RegisterRoutes(r)
+
+	stopSweeper := a.NewAuthService(db).StartRefreshTokenSweeper(a.RefreshTokenSweepIntervalFromEnv())
+	defer stopSweeper()
 	
 	// Swagger documentation endpoint
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	return r.RunTLS(port, certFile, keyFile)
+	return r.RunTLS(cfg.Port, certFile, keyFile)
 }
 
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	a "go-chat/internal/auth"
+	"go-chat/pkg/chat"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type DeviceSessionHandlers struct {
+	service *a.AuthService
+}
+
+func NewDeviceSessionHandlers(db *gorm.DB) *DeviceSessionHandlers {
+	return &DeviceSessionHandlers{
+		service: a.NewAuthService(db),
+	}
+}
+
+type DeviceSessionInfo struct {
+	ID          uint    `json:"id" example:"1"`
+	DeviceLabel string  `json:"device_label" example:"Mozilla/5.0 (X11; Linux x86_64) Firefox/128.0"` // User-Agent captured at sign-in
+	CreatedAt   string  `json:"created_at" example:"2023-01-01T00:00:00Z"`
+	LastUsedAt  *string `json:"last_used_at" example:"2023-01-02T00:00:00Z"` // null until the session is first refreshed
+	ExpiresAt   string  `json:"expires_at" example:"2023-01-08T00:00:00Z"`
+}
+
+type DeviceSessionsResponse struct {
+	Sessions []DeviceSessionInfo `json:"sessions"`
+}
+
+func toDeviceSessionInfo(session chat.RefreshToken) DeviceSessionInfo {
+	info := DeviceSessionInfo{
+		ID:          session.ID,
+		DeviceLabel: session.DeviceLabel,
+		CreatedAt:   session.CreatedAt.Format(time.RFC3339),
+		ExpiresAt:   time.Unix(session.ExpiresAt, 0).UTC().Format(time.RFC3339),
+	}
+	if session.LastUsedAt != nil {
+		lastUsed := session.LastUsedAt.Format(time.RFC3339)
+		info.LastUsedAt = &lastUsed
+	}
+	return info
+}
+
+// GetSessionsHandler lists the user's active sessions
+// @Summary List active sessions
+// @Description List the devices the authenticated user is signed in on, one per unexpired refresh token. Token values are never returned.
+// @Tags User Management
+// @Produce json
+// @Security CookieAuth
+// @Security Bearer
+// @Success 200 {object} DeviceSessionsResponse "List of active sessions"
+// @Failure 401 {object} ErrorResponse "User not authenticated"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/user/sessions [get]
+func (h *DeviceSessionHandlers) GetSessionsHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	sessions, err := h.service.ListSessions(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sessions"})
+		return
+	}
+
+	sessionList := make([]DeviceSessionInfo, 0, len(sessions))
+	for _, session := range sessions {
+		sessionList = append(sessionList, toDeviceSessionInfo(session))
+	}
+
+	c.JSON(http.StatusOK, DeviceSessionsResponse{Sessions: sessionList})
+}
+
+// RevokeSessionHandler signs the user out of one session
+// @Summary Revoke session
+// @Description Revoke one of the authenticated user's sessions so its refresh token can no longer be used. Access tokens already issued to it stay valid until they expire.
+// @Tags User Management
+// @Produce json
+// @Security CookieAuth
+// @Security Bearer
+// @Param id path int true "Session ID"
+// @Success 200 {object} MessageResponse "Session revoked successfully"
+// @Failure 400 {object} ErrorResponse "Invalid session ID"
+// @Failure 401 {object} ErrorResponse "User not authenticated"
+// @Failure 404 {object} ErrorResponse "Session not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/user/sessions/{id} [delete]
+func (h *DeviceSessionHandlers) RevokeSessionHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	sessionID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	if err := h.service.RevokeSession(userID.(string), uint(sessionID)); err != nil {
+		if err.Error() == "session not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked successfully"})
+}
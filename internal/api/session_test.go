@@ -0,0 +1,96 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeviceSessionEndpoints(t *testing.T) {
+	router, db := setupUserTest()
+	createTestUserForUserTests(db, "traveller", "password123")
+
+	// Sign in from two devices, keeping each device's cookies
+	login := func(userAgent string) map[string]string {
+		body, _ := json.Marshal(map[string]string{"username": "traveller", "password": "password123"})
+		req := httptest.NewRequest("POST", "/login", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", userAgent)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		cookies := make(map[string]string)
+		for _, cookie := range w.Result().Cookies() {
+			cookies[cookie.Name] = cookie.Value
+		}
+		require.NotEmpty(t, cookies["refresh_token"])
+		return cookies
+	}
+	laptop := login("Firefox on Linux")
+	phone := login("Safari on iOS")
+
+	send := func(method, path string, cookies map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		for name, value := range cookies {
+			req.AddCookie(&http.Cookie{Name: name, Value: value})
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	list := func() DeviceSessionsResponse {
+		w := send("GET", "/api/user/sessions", laptop)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), laptop["refresh_token"])
+		assert.NotContains(t, w.Body.String(), phone["refresh_token"])
+
+		var response DeviceSessionsResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+
+	sessions := list().Sessions
+	require.Len(t, sessions, 2)
+	assert.Equal(t, "Safari on iOS", sessions[0].DeviceLabel)
+	assert.Equal(t, "Firefox on Linux", sessions[1].DeviceLabel)
+	assert.Nil(t, sessions[0].LastUsedAt)
+
+	// Refreshing records when the session was last used
+	require.Equal(t, http.StatusOK, send("POST", "/api/refresh_token", phone).Code)
+	assert.NotNil(t, list().Sessions[0].LastUsedAt)
+
+	t.Run("cannot revoke another user's session", func(t *testing.T) {
+		other := createTestUserForUserTests(db, "intruder", "password123")
+		otherToken, _ := getAuthTokenForUser(other)
+
+		w := send("DELETE", fmt.Sprintf("/api/user/sessions/%d", sessions[0].ID), map[string]string{"token": otherToken})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("invalid session ID", func(t *testing.T) {
+		w := send("DELETE", "/api/user/sessions/phone", laptop)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("revoking a session signs that device out", func(t *testing.T) {
+		w := send("DELETE", fmt.Sprintf("/api/user/sessions/%d", sessions[0].ID), laptop)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		remaining := list().Sessions
+		require.Len(t, remaining, 1)
+		assert.Equal(t, "Firefox on Linux", remaining[0].DeviceLabel)
+
+		assert.Equal(t, http.StatusUnauthorized, send("POST", "/api/refresh_token", phone).Code)
+		assert.Equal(t, http.StatusOK, send("POST", "/api/refresh_token", laptop).Code)
+
+		w = send("DELETE", fmt.Sprintf("/api/user/sessions/%d", sessions[0].ID), laptop)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
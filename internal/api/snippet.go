@@ -0,0 +1,113 @@
+package api
+
+import (
+	"os"
+	"strings"
+	"unicode"
+)
+
+// snippetWindow is how many characters of context a search snippet keeps on each side of the match
+const snippetWindow = 40
+
+// defaultHighlightDelimiter wraps the matched term in snippets, Markdown bold by default
+const defaultHighlightDelimiter = "**"
+
+// snippetEllipsis marks content cut from either end of a snippet
+const snippetEllipsis = "…"
+
+// HighlightDelimiterFromEnv reads the delimiter wrapped around matched terms in
+// message search snippets from SEARCH_HIGHLIGHT_DELIMITER, "**" when unset
+func HighlightDelimiterFromEnv() string {
+	if delimiter := os.Getenv("SEARCH_HIGHLIGHT_DELIMITER"); delimiter != "" {
+		return delimiter
+	}
+	return defaultHighlightDelimiter
+}
+
+// SetHighlightDelimiter overrides the snippet highlight delimiter read from the environment
+func (h *SearchHandlers) SetHighlightDelimiter(delimiter string) {
+	h.highlight = delimiter
+}
+
+// buildSnippet extracts up to window characters around the first case-insensitive
+// match of query in content and wraps the match in delimiter. The whole query is
+// looked for first, then its individual terms, since full-text search also returns
+// partial matches. Without any match the start of the content is returned.
+func buildSnippet(content, query string, window int, delimiter string) string {
+	runes := []rune(content)
+	start, end := findMatch(runes, query)
+	if start < 0 {
+		if len(runes) <= 2*window {
+			return content
+		}
+		return string(runes[:2*window]) + snippetEllipsis
+	}
+
+	from := start - window
+	if from < 0 {
+		from = 0
+	}
+	to := end + window
+	if to > len(runes) {
+		to = len(runes)
+	}
+
+	var snippet strings.Builder
+	if from > 0 {
+		snippet.WriteString(snippetEllipsis)
+	}
+	snippet.WriteString(string(runes[from:start]))
+	snippet.WriteString(delimiter)
+	snippet.WriteString(string(runes[start:end]))
+	snippet.WriteString(delimiter)
+	snippet.WriteString(string(runes[end:to]))
+	if to < len(runes) {
+		snippet.WriteString(snippetEllipsis)
+	}
+	return snippet.String()
+}
+
+// findMatch returns the rune range of the earliest match of the query, or of
+// any of its terms when the whole query does not appear, and -1 without a match
+func findMatch(content []rune, query string) (int, int) {
+	folded := foldRunes(content)
+	if needle := foldRunes([]rune(strings.TrimSpace(query))); len(needle) > 0 {
+		if index := indexRunes(folded, needle); index >= 0 {
+			return index, index + len(needle)
+		}
+	}
+
+	start, end := -1, -1
+	for _, term := range strings.Fields(query) {
+		needle := foldRunes([]rune(term))
+		if index := indexRunes(folded, needle); index >= 0 && (start < 0 || index < start) {
+			start, end = index, index+len(needle)
+		}
+	}
+	return start, end
+}
+
+// foldRunes lowercases rune by rune so indexes line up with the original content
+func foldRunes(runes []rune) []rune {
+	folded := make([]rune, len(runes))
+	for i, r := range runes {
+		folded[i] = unicode.ToLower(r)
+	}
+	return folded
+}
+
+func indexRunes(haystack, needle []rune) int {
+	for i := 0; i+len(needle) <= len(haystack); i++ {
+		match := true
+		for j := range needle {
+			if haystack[i+j] != needle[j] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildSnippet(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		query    string
+		window   int
+		expected string
+	}{
+		{"match at start", "deploy went fine after the rollback", "deploy", 10, "**deploy** went fine…"},
+		{"match in middle", "yesterday the deploy went fine after all", "deploy", 8, "…day the **deploy** went fi…"},
+		{"match at end", "everything is ready for the deploy", "deploy", 5, "… the **deploy**"},
+		{"case insensitive", "The Deploy Pipeline is green", "deploy pipeline", 20, "The **Deploy Pipeline** is green"},
+		{"first term when the phrase is missing", "pipeline broke before the deploy", "deploy pipeline", 6, "**pipeline** broke…"},
+		{"multibyte content", "café crème brûlée", "CRÈME", 3, "…fé **crème** br…"},
+		{"no match", "nothing relevant here", "deploy", 5, "nothing re…"},
+		{"short content without match", "hi", "deploy", 5, "hi"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, buildSnippet(tt.content, tt.query, tt.window, "**"))
+		})
+	}
+}
+
+func TestBuildSnippet_Delimiter(t *testing.T) {
+	assert.Equal(t, "say ==hello==", buildSnippet("say hello", "HELLO", 10, "=="))
+}
+
+func TestHighlightDelimiterFromEnv(t *testing.T) {
+	t.Setenv("SEARCH_HIGHLIGHT_DELIMITER", "")
+	assert.Equal(t, "**", HighlightDelimiterFromEnv())
+
+	t.Setenv("SEARCH_HIGHLIGHT_DELIMITER", "__")
+	assert.Equal(t, "__", HighlightDelimiterFromEnv())
+}
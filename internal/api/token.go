@@ -0,0 +1,174 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	a "go-chat/internal/auth"
+	"go-chat/pkg/chat"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ApiTokenHandlers struct {
+	service *a.AuthService
+}
+
+func NewApiTokenHandlers(db *gorm.DB) *ApiTokenHandlers {
+	return &ApiTokenHandlers{
+		service: a.NewAuthService(db),
+	}
+}
+
+type CreateApiTokenRequest struct {
+	Label string `json:"label" binding:"required" example:"deploy-bot"`
+}
+
+type ApiTokenInfo struct {
+	ID         uint    `json:"id" example:"1"`
+	Label      string  `json:"label" example:"deploy-bot"`
+	CreatedAt  string  `json:"created_at" example:"2023-01-01T00:00:00Z"`
+	LastUsedAt *string `json:"last_used_at" example:"2023-01-02T00:00:00Z"`
+	Revoked    bool    `json:"revoked" example:"false"`
+}
+
+type CreateApiTokenResponse struct {
+	Message  string       `json:"message" example:"API token created successfully"`
+	Token    string       `json:"token" example:"gct_3q2-7wEJ8Lx0aZ..."`
+	ApiToken ApiTokenInfo `json:"api_token"`
+}
+
+type ApiTokensResponse struct {
+	Tokens []ApiTokenInfo `json:"tokens"`
+}
+
+func toApiTokenInfo(token chat.ApiToken) ApiTokenInfo {
+	info := ApiTokenInfo{
+		ID:        token.ID,
+		Label:     token.Label,
+		CreatedAt: token.CreatedAt.Format(time.RFC3339),
+		Revoked:   token.Revoked,
+	}
+	if token.LastUsedAt != nil {
+		lastUsed := token.LastUsedAt.Format(time.RFC3339)
+		info.LastUsedAt = &lastUsed
+	}
+	return info
+}
+
+// CreateApiTokenHandler mints a new API token
+// @Summary Create API token
+// @Description Create a long-lived API token for programmatic access. The token is only returned once; send it as "Authorization: Bearer <token>".
+// @Tags User Management
+// @Accept json
+// @Produce json
+// @Security CookieAuth
+// @Security Bearer
+// @Param request body CreateApiTokenRequest true "Create API token request"
+// @Success 201 {object} CreateApiTokenResponse "API token created successfully"
+// @Failure 400 {object} ErrorResponse "Bad request"
+// @Failure 401 {object} ErrorResponse "User not authenticated"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/user/tokens [post]
+func (h *ApiTokenHandlers) CreateApiTokenHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req CreateApiTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	token, apiToken, err := h.service.CreateApiToken(userID.(string), req.Label)
+	if err != nil {
+		if err.Error() == "token label cannot be empty" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API token"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, CreateApiTokenResponse{
+		Message:  "API token created successfully",
+		Token:    token,
+		ApiToken: toApiTokenInfo(*apiToken),
+	})
+}
+
+// GetApiTokensHandler lists the user's API tokens
+// @Summary List API tokens
+// @Description List the authenticated user's API tokens, including revoked ones. Token values are never returned.
+// @Tags User Management
+// @Produce json
+// @Security CookieAuth
+// @Security Bearer
+// @Success 200 {object} ApiTokensResponse "List of API tokens"
+// @Failure 401 {object} ErrorResponse "User not authenticated"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/user/tokens [get]
+func (h *ApiTokenHandlers) GetApiTokensHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	tokens, err := h.service.ListApiTokens(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch API tokens"})
+		return
+	}
+
+	tokenList := make([]ApiTokenInfo, 0, len(tokens))
+	for _, token := range tokens {
+		tokenList = append(tokenList, toApiTokenInfo(token))
+	}
+
+	c.JSON(http.StatusOK, ApiTokensResponse{Tokens: tokenList})
+}
+
+// RevokeApiTokenHandler revokes one of the user's API tokens
+// @Summary Revoke API token
+// @Description Revoke an API token so it can no longer authenticate requests
+// @Tags User Management
+// @Produce json
+// @Security CookieAuth
+// @Security Bearer
+// @Param id path int true "API token ID"
+// @Success 200 {object} MessageResponse "API token revoked successfully"
+// @Failure 400 {object} ErrorResponse "Invalid token ID"
+// @Failure 401 {object} ErrorResponse "User not authenticated"
+// @Failure 404 {object} ErrorResponse "API token not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/user/tokens/{id} [delete]
+func (h *ApiTokenHandlers) RevokeApiTokenHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	tokenID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid token ID"})
+		return
+	}
+
+	if err := h.service.RevokeApiToken(userID.(string), uint(tokenID)); err != nil {
+		if err.Error() == "api token not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke API token"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "API token revoked successfully"})
+}
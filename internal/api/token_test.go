@@ -0,0 +1,86 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApiTokenEndpoints(t *testing.T) {
+	router, db := setupUserTest()
+
+	user := createTestUserForUserTests(db, "tokenowner", "password123")
+	cookieToken, _ := getAuthTokenForUser(user)
+
+	withCookie := func(req *http.Request) *http.Request {
+		req.AddCookie(&http.Cookie{Name: "token", Value: cookieToken})
+		return req
+	}
+	withBearer := func(req *http.Request, token string) *http.Request {
+		req.Header.Set("Authorization", "Bearer "+token)
+		return req
+	}
+
+	// Mint a token
+	body, _ := json.Marshal(CreateApiTokenRequest{Label: "ci"})
+	req := httptest.NewRequest("POST", "/api/user/tokens", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, withCookie(req))
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var created CreateApiTokenResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.NotEmpty(t, created.Token)
+	assert.Equal(t, "ci", created.ApiToken.Label)
+
+	t.Run("valid token authenticates", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/user/tokens", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, withBearer(req, created.Token))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response ApiTokensResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Tokens, 1)
+		assert.NotNil(t, response.Tokens[0].LastUsedAt)
+		assert.NotContains(t, w.Body.String(), created.Token)
+	})
+
+	t.Run("missing label is rejected", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/user/tokens", bytes.NewBufferString(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, withCookie(req))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("cannot revoke another user's token", func(t *testing.T) {
+		other := createTestUserForUserTests(db, "intruder", "password123")
+		otherToken, _ := getAuthTokenForUser(other)
+
+		req := httptest.NewRequest("DELETE", fmt.Sprintf("/api/user/tokens/%d", created.ApiToken.ID), nil)
+		req.AddCookie(&http.Cookie{Name: "token", Value: otherToken})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("revoked token fails", func(t *testing.T) {
+		req := httptest.NewRequest("DELETE", fmt.Sprintf("/api/user/tokens/%d", created.ApiToken.ID), nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, withCookie(req))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		req = httptest.NewRequest("GET", "/api/user/tokens", nil)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, withBearer(req, created.Token))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
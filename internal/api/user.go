@@ -2,7 +2,9 @@ package api
 
 import (
 	"net/http"
+	"time"
 
+	c "go-chat/internal/channel"
 	u "go-chat/internal/user"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -12,9 +14,9 @@ type UserHandlers struct {
 	service *u.UserService
 }
 
-func NewUserHandlers(db *gorm.DB) *UserHandlers {
+func NewUserHandlers(db *gorm.DB, members *c.MemberCache) *UserHandlers {
 	return &UserHandlers{
-		service: u.NewUserService(db),
+		service: u.NewUserService(db, members),
 	}
 }
 
@@ -37,9 +39,10 @@ type UpdateUserResponse struct {
 // @Security CookieAuth
 // @Param request body UpdateUserRequest true "Update user request"
 // @Success 200 {object} UpdateUserResponse "User updated successfully"
-// @Failure 400 {object} ErrorResponse "Bad request or username already exists"
+// @Failure 400 {object} ErrorResponse "Bad request, reserved username or password too long (over 72 bytes)"
 // @Failure 401 {object} ErrorResponse "User not authenticated"
 // @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 409 {object} ErrorResponse "Username already exists"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /api/user [patch]
 func (h *UserHandlers) UpdateUserHandler(c *gin.Context) {
@@ -64,6 +67,8 @@ func (h *UserHandlers) UpdateUserHandler(c *gin.Context) {
 	user, err := h.service.UpdateUser(userID.(string), serviceReq)
 	if err != nil {
 		if err.Error() == "username already exists" {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		} else if err.Error() == "password too long" || err.Error() == "username is reserved" {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		} else if err.Error() == "user not found" {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
@@ -84,7 +89,7 @@ func (h *UserHandlers) UpdateUserHandler(c *gin.Context) {
 
 // DeleteUserHandler deletes user account
 // @Summary Delete user account
-// @Description Soft delete user account and clear authentication cookies
+// @Description Soft delete user account and clear authentication cookies. Owned channels are transferred to another Administrator or deleted, per server policy.
 // @Tags User Management
 // @Accept json
 // @Produce json
@@ -118,21 +123,138 @@ func (h *UserHandlers) DeleteUserHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Account deleted successfully"})
 }
 
+type UserProfileResponse struct {
+	ID             string `json:"id" example:"a1b2c3d4"`
+	Username       string `json:"username" example:"john_doe"`
+	CreatedAt      string `json:"created_at" example:"2023-01-01T00:00:00Z"`
+	OwnedChannels  int64  `json:"owned_channels" example:"2"`
+	JoinedChannels int64  `json:"joined_channels" example:"5"`
+}
+
+// GetUserProfileHandler gets another user's public profile
+// @Summary Get user profile
+// @Description Get the public profile of a user: username, join date and channel counts. Channels that hide their owner are not counted as owned.
+// @Tags User Management
+// @Accept json
+// @Produce json
+// @Security CookieAuth
+// @Param id path string true "User ID"
+// @Success 200 {object} UserProfileResponse "Public profile"
+// @Failure 401 {object} ErrorResponse "User not authenticated"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/users/{id} [get]
+func (h *UserHandlers) GetUserProfileHandler(c *gin.Context) {
+	if _, exists := c.Get("user_id"); !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	profile, err := h.service.GetPublicProfile(c.Param("id"))
+	if err != nil {
+		if err.Error() == "user not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user profile"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, UserProfileResponse{
+		ID:             profile.ID,
+		Username:       profile.Username,
+		CreatedAt:      profile.CreatedAt.Format(time.RFC3339),
+		OwnedChannels:  profile.OwnedChannels,
+		JoinedChannels: profile.JoinedChannels,
+	})
+}
+
+// OwnBanInfo is one of the authenticated user's bans, described from their side
+type OwnBanInfo struct {
+	ID               uint     `json:"id" example:"1"`
+	ChannelID        string   `json:"channel_id" example:"ch123"`
+	ChannelName      string   `json:"channel_name" example:"general"`
+	Reason           string   `json:"reason" example:"spam"`
+	BannedAt         string   `json:"banned_at" example:"2023-01-01T00:00:00Z"`
+	ExpiresAt        *string  `json:"expires_at" example:"2023-01-02T00:00:00Z"` // null for permanent bans
+	RemainingSeconds *int64   `json:"remaining_seconds" example:"3600"`           // null for permanent bans
+	BannedBy         UserInfo `json:"banned_by"`
+}
+
+type OwnBansResponse struct {
+	Bans []OwnBanInfo `json:"bans"`
+}
+
+// GetOwnBansHandler lists where the user is banned
+// @Summary Get own bans
+// @Description Get the authenticated user's active bans across all channels, newest first, with the channel, reason, expiry and who issued them
+// @Tags User Management
+// @Accept json
+// @Produce json
+// @Security CookieAuth
+// @Success 200 {object} OwnBansResponse "Active bans"
+// @Failure 401 {object} ErrorResponse "User not authenticated"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/user/bans [get]
+func (h *UserHandlers) GetOwnBansHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	bans, err := h.service.GetActiveBans(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bans"})
+		return
+	}
+
+	now := time.Now()
+	response := OwnBansResponse{Bans: make([]OwnBanInfo, 0, len(bans))}
+	for _, ban := range bans {
+		info := toBanInfo(ban, now)
+		response.Bans = append(response.Bans, OwnBanInfo{
+			ID:               info.ID,
+			ChannelID:        ban.ChannelID,
+			ChannelName:      ban.Channel.Name,
+			Reason:           info.Reason,
+			BannedAt:         info.BannedAt,
+			ExpiresAt:        info.ExpiresAt,
+			RemainingSeconds: info.RemainingSeconds,
+			BannedBy:         info.BannedBy,
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
 type ChannelOwner struct {
 	ID       string `json:"id" example:"a1b2c3d4"`
 	Username string `json:"username" example:"john_doe"`
 }
 
 type ChannelInfo struct {
-	ID        string       `json:"id" example:"ch123"`
-	Name      string       `json:"name" example:"general"`
-	IsVisible bool         `json:"is_visible" example:"true"`
-	CreatedAt string       `json:"created_at" example:"2023-01-01T00:00:00Z"`
-	Owner     ChannelOwner `json:"owner"`
+	ID            string       `json:"id" example:"ch123"`
+	Name          string       `json:"name" example:"general"`
+	IsVisible     bool         `json:"is_visible" example:"true"`
+	HideOwner     bool         `json:"hide_owner" example:"false"`
+	LoggingDays   uint         `json:"logging_days" example:"30"`
+	MaxMembers    int          `json:"max_members" example:"0"`
+	AllowPreview  bool         `json:"allow_preview" example:"false"`
+	CreatedAt     string       `json:"created_at" example:"2023-01-01T00:00:00Z"`
+	LastMessageAt *string      `json:"last_message_at" example:"2023-01-02T00:00:00Z"` // null until the first message
+	CategoryID    *uint        `json:"category_id" example:"1"`                        // null when uncategorized
+	Owner         ChannelOwner `json:"owner"`
+
+	AnnounceMembership bool   `json:"announce_membership" example:"false"` // Joins, leaves and bans are posted as system messages
+	Description        string `json:"description" example:"Anything goes"`
+	Topic              string `json:"topic" example:"Release planning"`
 }
 
 type ChannelsResponse struct {
-	Channels []ChannelInfo `json:"channels"`
+	Channels []ChannelInfo  `json:"channels"`
+	Groups   []ChannelGroup `json:"groups,omitempty"` // The same channels by category, with group_by=category
+	PaginationMeta
 }
 
 // GetOwnedChannelsHandler gets channels owned by user
@@ -142,6 +264,8 @@ type ChannelsResponse struct {
 // @Accept json
 // @Produce json
 // @Security CookieAuth
+// @Param page query int false "Page number (default: 1)"
+// @Param limit query int false "Number of results per page (default: 20, max: 100)"
 // @Success 200 {object} ChannelsResponse "List of owned channels"
 // @Failure 401 {object} ErrorResponse "User not authenticated"
 // @Failure 500 {object} ErrorResponse "Internal server error"
@@ -153,27 +277,19 @@ func (h *UserHandlers) GetOwnedChannelsHandler(c *gin.Context) {
 		return
 	}
 
-	channels, err := h.service.GetOwnedChannels(userID.(string))
+	page, limit, offset := parsePagination(c)
+	channels, total, err := h.service.GetOwnedChannels(userID.(string), limit, offset)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch owned channels"})
 		return
 	}
 
-	var channelList []gin.H
+	channelList := make([]ChannelInfo, 0, len(channels))
 	for _, channel := range channels {
-		channelList = append(channelList, gin.H{
-			"id":         channel.ID,
-			"name":       channel.Name,
-			"is_visible": channel.IsVisible,
-			"created_at": channel.CreatedAt,
-			"owner": gin.H{
-				"id":       channel.Owner.ID,
-				"username": channel.Owner.Username,
-			},
-		})
+		channelList = append(channelList, toChannelInfo(channel))
 	}
 
-	c.JSON(http.StatusOK, gin.H{"channels": channelList})
+	c.JSON(http.StatusOK, ChannelsResponse{Channels: channelList, PaginationMeta: newPaginationMeta(total, page, limit)})
 }
 
 // GetJoinedChannelsHandler gets channels joined by user
@@ -183,6 +299,8 @@ func (h *UserHandlers) GetOwnedChannelsHandler(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Security CookieAuth
+// @Param page query int false "Page number (default: 1)"
+// @Param limit query int false "Number of results per page (default: 20, max: 100)"
 // @Success 200 {object} ChannelsResponse "List of joined channels"
 // @Failure 401 {object} ErrorResponse "User not authenticated"
 // @Failure 500 {object} ErrorResponse "Internal server error"
@@ -194,25 +312,52 @@ func (h *UserHandlers) GetJoinedChannelsHandler(c *gin.Context) {
 		return
 	}
 
-	channels, err := h.service.GetJoinedChannels(userID.(string))
+	page, limit, offset := parsePagination(c)
+	channels, total, err := h.service.GetJoinedChannels(userID.(string), limit, offset)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch joined channels"})
 		return
 	}
 
-	var channelList []gin.H
+	channelList := make([]ChannelInfo, 0, len(channels))
 	for _, channel := range channels {
-		channelList = append(channelList, gin.H{
-			"id":         channel.ID,
-			"name":       channel.Name,
-			"is_visible": channel.IsVisible,
-			"created_at": channel.CreatedAt,
-			"owner": gin.H{
-				"id":       channel.Owner.ID,
-				"username": channel.Owner.Username,
-			},
-		})
+		channelList = append(channelList, toChannelInfo(channel))
 	}
 
-	c.JSON(http.StatusOK, gin.H{"channels": channelList})
-}
\ No newline at end of file
+	c.JSON(http.StatusOK, ChannelsResponse{Channels: channelList, PaginationMeta: newPaginationMeta(total, page, limit)})
+}
+
+// GetModeratedChannelsHandler gets channels the user can moderate
+// @Summary Get moderated channels
+// @Description Get all channels the authenticated user owns or holds a Moderator or Administrator role in
+// @Tags User Management
+// @Accept json
+// @Produce json
+// @Security CookieAuth
+// @Param page query int false "Page number (default: 1)"
+// @Param limit query int false "Number of results per page (default: 20, max: 100)"
+// @Success 200 {object} ChannelsResponse "List of moderated channels"
+// @Failure 401 {object} ErrorResponse "User not authenticated"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/user/channels/moderated [get]
+func (h *UserHandlers) GetModeratedChannelsHandler(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	page, limit, offset := parsePagination(c)
+	channels, total, err := h.service.GetModeratedChannels(userID.(string), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch moderated channels"})
+		return
+	}
+
+	channelList := make([]ChannelInfo, 0, len(channels))
+	for _, channel := range channels {
+		channelList = append(channelList, toChannelInfo(channel))
+	}
+
+	c.JSON(http.StatusOK, ChannelsResponse{Channels: channelList, PaginationMeta: newPaginationMeta(total, page, limit)})
+}
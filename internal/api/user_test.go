@@ -5,13 +5,18 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"go-chat/internal/auth"
+	c "go-chat/internal/channel"
+	u "go-chat/internal/user"
 	. "go-chat/pkg/chat"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
@@ -137,7 +142,29 @@ func TestUpdateUserEndpoint(t *testing.T) {
 
 		router.ServeHTTP(w, req)
 
-		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("should reject reserved usernames", func(t *testing.T) {
+		user := createTestUserForUserTests(db, "impostor", "password123")
+		token, _ := getAuthTokenForUser(user)
+
+		for _, username := range []string{ScrubbedUsername, DeletedUsername} {
+			jsonData, _ := json.Marshal(map[string]interface{}{"username": username})
+
+			req := httptest.NewRequest("PATCH", "/api/user", bytes.NewBuffer(jsonData))
+			req.Header.Set("Content-Type", "application/json")
+			req.AddCookie(&http.Cookie{
+				Name:  "token",
+				Value: token,
+			})
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code, username)
+			assert.Contains(t, w.Body.String(), "username is reserved")
+		}
 	})
 
 	t.Run("should require authentication", func(t *testing.T) {
@@ -195,6 +222,152 @@ func TestDeleteUserEndpoint(t *testing.T) {
 	})
 }
 
+func TestDeleteUserEndpoint_OwnedChannels(t *testing.T) {
+	setup := func(t *testing.T) (*gin.Engine, *gorm.DB, map[string]Role) {
+		router, db := setupUserTest()
+		roles := make(map[string]Role)
+		for _, name := range DefaultRoles {
+			var role Role
+			require.NoError(t, db.Where(Role{Name: name}).Assign(Role{Priority: RolePriorities[name]}).FirstOrCreate(&role).Error)
+			roles[name] = role
+		}
+		return router, db, roles
+	}
+	join := func(db *gorm.DB, user *User, channel *Channel, role Role) {
+		require.NoError(t, db.Create(&UserChannel{UserID: user.ID, ChannelID: channel.ID, RoleID: &role.ID}).Error)
+	}
+	deleteAccount := func(t *testing.T, router *gin.Engine, user *User) {
+		token, _ := getAuthTokenForUser(user)
+		req := httptest.NewRequest("DELETE", "/api/user", nil)
+		req.AddCookie(&http.Cookie{Name: "token", Value: token})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	}
+
+	t.Run("ownership moves to the longest-standing administrator", func(t *testing.T) {
+		router, db, roles := setup(t)
+		owner := createTestUserForUserTests(db, "leaving-owner", "password123")
+		moderator := createTestUserForUserTests(db, "staying-mod", "password123")
+		firstAdmin := createTestUserForUserTests(db, "first-admin", "password123")
+		secondAdmin := createTestUserForUserTests(db, "second-admin", "password123")
+
+		channel := createTestChannelForUserTests(db, owner, "handed-over", true)
+		join(db, owner, channel, roles["Administrator"])
+		join(db, moderator, channel, roles["Moderator"])
+		join(db, firstAdmin, channel, roles["Administrator"])
+		join(db, secondAdmin, channel, roles["Administrator"])
+
+		deleteAccount(t, router, owner)
+
+		var stored Channel
+		require.NoError(t, db.First(&stored, "id = ?", channel.ID).Error)
+		assert.Equal(t, firstAdmin.ID, stored.OwnerID)
+	})
+
+	t.Run("channel without another administrator is deleted", func(t *testing.T) {
+		_, db, roles := setup(t)
+		owner := createTestUserForUserTests(db, "sole-owner", "password123")
+		member := createTestUserForUserTests(db, "plain-member", "password123")
+
+		solo := createTestChannelForUserTests(db, owner, "solo", true)
+		join(db, owner, solo, roles["Administrator"])
+		withMember := createTestChannelForUserTests(db, owner, "with-member", true)
+		join(db, owner, withMember, roles["Administrator"])
+		join(db, member, withMember, roles["Member"])
+
+		// Membership checks cache standing; the deletion must not leave it stale
+		members := c.NewMemberCache(time.Minute)
+		channels := c.NewChannelService(db, members)
+		membership, err := channels.GetMembership(member.ID, withMember)
+		require.NoError(t, err)
+		require.True(t, membership.IsMember)
+
+		require.NoError(t, u.NewUserService(db, members).DeleteUser(owner.ID))
+
+		var remaining int64
+		db.Model(&Channel{}).Where("id IN ?", []string{solo.ID, withMember.ID}).Count(&remaining)
+		assert.Equal(t, int64(0), remaining)
+		var memberships int64
+		db.Model(&UserChannel{}).Where("channel_id = ?", withMember.ID).Count(&memberships)
+		assert.Equal(t, int64(0), memberships)
+
+		membership, err = channels.GetMembership(member.ID, withMember)
+		require.NoError(t, err)
+		assert.False(t, membership.IsMember)
+	})
+
+	t.Run("delete policy removes channels even with administrators", func(t *testing.T) {
+		t.Setenv("ORPHANED_CHANNEL_POLICY", "delete")
+		router, db, roles := setup(t)
+		owner := createTestUserForUserTests(db, "strict-owner", "password123")
+		admin := createTestUserForUserTests(db, "strict-admin", "password123")
+
+		channel := createTestChannelForUserTests(db, owner, "strict", true)
+		join(db, owner, channel, roles["Administrator"])
+		join(db, admin, channel, roles["Administrator"])
+
+		deleteAccount(t, router, owner)
+
+		var remaining int64
+		db.Model(&Channel{}).Where("id = ?", channel.ID).Count(&remaining)
+		assert.Equal(t, int64(0), remaining)
+	})
+}
+
+func TestDeletedUserHiddenFromListings(t *testing.T) {
+	router, db := setupUserTest()
+	require.NoError(t, db.AutoMigrate(&Message{}, &Attachment{}, &ChannelReadState{}))
+
+	owner := createTestUserForUserTests(db, "listing-owner", "password123")
+	deleted := createTestUserForUserTests(db, "listing-gone", "password123")
+
+	channel := &Channel{Name: "listing-channel", IsVisible: true, OwnerID: owner.ID, LoggingDays: 30}
+	require.NoError(t, db.Create(channel).Error)
+	joinUserToChannel(db, owner, channel)
+	joinUserToChannel(db, deleted, channel)
+	require.NoError(t, db.Create(&Message{ChannelID: channel.ID, UserID: deleted.ID, Content: "still here"}).Error)
+
+	deletedToken, _ := getAuthTokenForUser(deleted)
+	req := httptest.NewRequest("DELETE", "/api/user", nil)
+	req.AddCookie(&http.Cookie{Name: "token", Value: deletedToken})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	token, _ := getAuthTokenForUser(owner)
+	get := func(path string, response interface{}) {
+		req := httptest.NewRequest("GET", path, nil)
+		req.AddCookie(&http.Cookie{Name: "token", Value: token})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), response))
+	}
+
+	t.Run("member list", func(t *testing.T) {
+		var response UsersResponse
+		get("/api/channels/"+channel.ID+"/users", &response)
+		require.Len(t, response.Users, 1)
+		assert.Equal(t, owner.ID, response.Users[0].ID)
+	})
+
+	t.Run("user search", func(t *testing.T) {
+		var response UsersSearchResponse
+		get("/api/search/users?q=listing&on_empty=200", &response)
+		assert.Equal(t, int64(0), response.Total)
+		assert.Empty(t, response.Users)
+	})
+
+	t.Run("messages keep a placeholder author", func(t *testing.T) {
+		var response MessagesResponse
+		get("/api/channels/"+channel.ID+"/messages", &response)
+		require.Len(t, response.Messages, 1)
+		assert.Equal(t, "still here", response.Messages[0].Content)
+		assert.Equal(t, DeletedUsername, response.Messages[0].User.Username)
+	})
+}
+
 func TestGetOwnedChannelsEndpoint(t *testing.T) {
 	router, db := setupUserTest()
 
@@ -354,4 +527,207 @@ func TestGetJoinedChannelsEndpoint(t *testing.T) {
 
 		assert.Equal(t, http.StatusUnauthorized, w.Code)
 	})
-}
\ No newline at end of file
+}
+func TestGetModeratedChannelsEndpoint(t *testing.T) {
+	router, db := setupUserTest()
+
+	user := createTestUserForUserTests(db, "moderator", "password123")
+	other := createTestUserForUserTests(db, "other-owner", "password123")
+
+	var moderatorRole, memberRole Role
+	db.FirstOrCreate(&moderatorRole, Role{Name: "Moderator"})
+	db.FirstOrCreate(&memberRole, Role{Name: "Member"})
+
+	moderated := createTestChannelForUserTests(db, other, "moderated-channel", true)
+	db.Create(&UserChannel{UserID: user.ID, ChannelID: moderated.ID, RoleID: &moderatorRole.ID})
+
+	owned := createTestChannelForUserTests(db, user, "owned-channel", false)
+
+	member := createTestChannelForUserTests(db, other, "member-channel", true)
+	db.Create(&UserChannel{UserID: user.ID, ChannelID: member.ID, RoleID: &memberRole.ID})
+
+	token, _ := getAuthTokenForUser(user)
+	req := httptest.NewRequest("GET", "/api/user/channels/moderated", nil)
+	req.AddCookie(&http.Cookie{Name: "token", Value: token})
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response ChannelsResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, int64(2), response.Total)
+	require.Len(t, response.Channels, 2)
+	assert.Equal(t, moderated.ID, response.Channels[0].ID)
+	assert.Equal(t, owned.ID, response.Channels[1].ID)
+}
+
+func TestGetOwnBansEndpoint(t *testing.T) {
+	router, db := setupUserTest()
+
+	user := createTestUserForUserTests(db, "troublemaker", "password123")
+	strict := createTestUserForUserTests(db, "strict-owner", "password123")
+	lenient := createTestUserForUserTests(db, "lenient-owner", "password123")
+
+	permanent := createTestChannelForUserTests(db, strict, "strict-channel", true)
+	temporary := createTestChannelForUserTests(db, lenient, "lenient-channel", false)
+	lifted := createTestChannelForUserTests(db, lenient, "lifted-channel", true)
+	expired := createTestChannelForUserTests(db, lenient, "expired-channel", true)
+
+	now := time.Now()
+	inOneHour := now.Add(time.Hour)
+	anHourAgo := now.Add(-time.Hour)
+	require.NoError(t, db.Create(&UserBan{UserID: user.ID, ChannelID: permanent.ID, BannedBy: strict.ID, Reason: "spam", IsActive: true, Model: gorm.Model{CreatedAt: now.Add(-2 * time.Hour)}}).Error)
+	require.NoError(t, db.Create(&UserBan{UserID: user.ID, ChannelID: temporary.ID, BannedBy: lenient.ID, Reason: "cool down", ExpiresAt: &inOneHour, IsActive: true, Model: gorm.Model{CreatedAt: now.Add(-time.Minute)}}).Error)
+	// Neither a lifted nor an expired ban restricts the user any more
+	require.NoError(t, db.Create(&UserBan{UserID: user.ID, ChannelID: lifted.ID, BannedBy: lenient.ID, Reason: "mistake", IsActive: true}).Error)
+	require.NoError(t, db.Model(&UserBan{}).Where("channel_id = ?", lifted.ID).Update("is_active", false).Error)
+	require.NoError(t, db.Create(&UserBan{UserID: user.ID, ChannelID: expired.ID, BannedBy: lenient.ID, Reason: "old", ExpiresAt: &anHourAgo, IsActive: true}).Error)
+	// Other users' bans are not listed
+	require.NoError(t, db.Create(&UserBan{UserID: strict.ID, ChannelID: temporary.ID, BannedBy: lenient.ID, Reason: "other", IsActive: true}).Error)
+
+	token, _ := getAuthTokenForUser(user)
+	req := httptest.NewRequest("GET", "/api/user/bans", nil)
+	req.AddCookie(&http.Cookie{Name: "token", Value: token})
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response OwnBansResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Bans, 2)
+
+	newest := response.Bans[0]
+	assert.Equal(t, temporary.ID, newest.ChannelID)
+	assert.Equal(t, "lenient-channel", newest.ChannelName)
+	assert.Equal(t, "cool down", newest.Reason)
+	assert.Equal(t, UserInfo{ID: lenient.ID, Username: "lenient-owner"}, newest.BannedBy)
+	require.NotNil(t, newest.ExpiresAt)
+	assert.Equal(t, inOneHour.Format(time.RFC3339), *newest.ExpiresAt)
+	require.NotNil(t, newest.RemainingSeconds)
+	assert.InDelta(t, 3600, *newest.RemainingSeconds, 5)
+
+	oldest := response.Bans[1]
+	assert.Equal(t, permanent.ID, oldest.ChannelID)
+	assert.Equal(t, "strict-channel", oldest.ChannelName)
+	assert.Equal(t, "spam", oldest.Reason)
+	assert.Equal(t, "strict-owner", oldest.BannedBy.Username)
+	assert.Nil(t, oldest.ExpiresAt)
+	assert.Nil(t, oldest.RemainingSeconds)
+}
+
+func TestGetUserProfileEndpoint(t *testing.T) {
+	router, db := setupUserTest()
+
+	viewer := createTestUserForUserTests(db, "viewer", "password123")
+	token, _ := getAuthTokenForUser(viewer)
+
+	getProfile := func(userID string, withAuth bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/users/"+userID, nil)
+		if withAuth {
+			req.AddCookie(&http.Cookie{
+				Name:  "token",
+				Value: token,
+			})
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should return public profile", func(t *testing.T) {
+		user := createTestUserForUserTests(db, "profileowner", "password123")
+		createTestChannelForUserTests(db, user, "profile-public", true)
+		createTestChannelForUserTests(db, user, "profile-private", false)
+		anonymous := createTestChannelForUserTests(db, user, "profile-anonymous", true)
+		db.Model(anonymous).Update("hide_owner", true)
+
+		otherUser := createTestUserForUserTests(db, "profileother", "password123")
+		joinUserToChannel(db, user, createTestChannelForUserTests(db, otherUser, "profile-joined", true))
+
+		w := getProfile(user.ID, true)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, user.ID, response["id"])
+		assert.Equal(t, "profileowner", response["username"])
+		assert.NotEmpty(t, response["created_at"])
+		assert.Equal(t, float64(2), response["owned_channels"])
+		assert.Equal(t, float64(1), response["joined_channels"])
+		assert.NotContains(t, response, "password")
+		assert.NotContains(t, response, "is_admin")
+	})
+
+	t.Run("should return 404 for unknown user", func(t *testing.T) {
+		w := getProfile("missing", true)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("should return 404 for deleted user", func(t *testing.T) {
+		user := createTestUserForUserTests(db, "profiledeleted", "password123")
+		db.Delete(user)
+
+		w := getProfile(user.ID, true)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("should require authentication", func(t *testing.T) {
+		w := getProfile(viewer.ID, false)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+func TestPasswordTooLong(t *testing.T) {
+	router, db := setupUserTest()
+
+	longPassword := strings.Repeat("é", 37) // 74 bytes in 37 characters
+
+	send := func(method, path string, body interface{}, token string) *httptest.ResponseRecorder {
+		jsonBody, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, path, bytes.NewBuffer(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.AddCookie(&http.Cookie{Name: "token", Value: token})
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("register rejects over 72 bytes", func(t *testing.T) {
+		w := send("POST", "/register", map[string]string{"username": "longpass", "password": longPassword}, "")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, "password too long", response["error"])
+
+		var count int64
+		db.Model(&User{}).Where("username = ?", "longpass").Count(&count)
+		assert.Equal(t, int64(0), count)
+	})
+
+	t.Run("update rejects over 72 bytes", func(t *testing.T) {
+		user := createTestUserForUserTests(db, "shortpass", "password123")
+		token, _ := getAuthTokenForUser(user)
+
+		w := send("PATCH", "/api/user", map[string]string{"password": longPassword}, token)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		assert.Equal(t, "password too long", response["error"])
+	})
+
+	t.Run("72 bytes is accepted", func(t *testing.T) {
+		user := createTestUserForUserTests(db, "maxpass", "password123")
+		token, _ := getAuthTokenForUser(user)
+
+		w := send("PATCH", "/api/user", map[string]string{"password": strings.Repeat("a", 72)}, token)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
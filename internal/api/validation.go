@@ -0,0 +1,63 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// ValidationErrorResponse reports which request fields are invalid, keyed by JSON field name
+type ValidationErrorResponse struct {
+	Error  string            `json:"error" example:"Invalid request"`
+	Errors map[string]string `json:"errors,omitempty"` // Field name to problem, for invalid request bodies
+}
+
+// bindJSON binds the request body into req and replies 400 when it cannot.
+// Failed binding rules are reported per field instead of the raw validator
+// message, which exposes Go type and field names.
+func bindJSON(c *gin.Context, req interface{}) bool {
+	err := c.ShouldBindJSON(req)
+	if err == nil {
+		return true
+	}
+
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return false
+	}
+
+	fields := make(map[string]string, len(validationErrors))
+	for _, fieldError := range validationErrors {
+		fields[jsonFieldName(req, fieldError)] = validationMessage(fieldError)
+	}
+	c.JSON(http.StatusBadRequest, ValidationErrorResponse{Error: "Invalid request", Errors: fields})
+	return false
+}
+
+// jsonFieldName returns the name clients use for the failing field
+func jsonFieldName(req interface{}, fieldError validator.FieldError) string {
+	t := reflect.TypeOf(req)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if field, ok := t.FieldByName(fieldError.StructField()); ok {
+		if name := strings.Split(field.Tag.Get("json"), ",")[0]; name != "" && name != "-" {
+			return name
+		}
+	}
+	return strings.ToLower(fieldError.Field())
+}
+
+func validationMessage(fieldError validator.FieldError) string {
+	switch fieldError.Tag() {
+	case "required":
+		return "is required"
+	default:
+		return "is invalid"
+	}
+}
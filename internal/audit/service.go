@@ -2,6 +2,7 @@ package audit
 
 import (
 	"encoding/json"
+	"errors"
 	"time"
 
 	. "go-chat/pkg/chat"
@@ -27,6 +28,11 @@ const (
 	ActionDemoteUser    = "DEMOTE_USER"
 	ActionJoinChannel   = "JOIN_CHANNEL"
 	ActionLeaveChannel  = "LEAVE_CHANNEL"
+	ActionScrubMessage  = "SCRUB_MESSAGE_AUTHOR"
+	ActionLockChannel   = "LOCK_CHANNEL"
+	ActionUnlockChannel = "UNLOCK_CHANNEL"
+	ActionSuspendUser   = "SUSPEND_USER"
+	ActionUnsuspendUser = "UNSUSPEND_USER"
 )
 
 type AuditMetadata struct {
@@ -37,6 +43,7 @@ type AuditMetadata struct {
 	ExpiresAt *string   `json:"expires_at,omitempty"`
 	IsTemp    bool      `json:"is_temp,omitempty"`
 	Password  bool      `json:"password_protected,omitempty"`
+	MessageID string    `json:"message_id,omitempty"`
 }
 
 // LogChannelCreation logs when a channel is created
@@ -119,8 +126,8 @@ func (s *AuditService) LogUserUnban(actorID, targetID, channelID string) error {
 	return s.db.Create(&auditLog).Error
 }
 
-// LogUserRoleChange logs when a user's role is changed (promote/demote)
-func (s *AuditService) LogUserRoleChange(actorID, targetID, channelID, oldRole, newRole string, isPromotion bool) error {
+// LogUserRoleChange logs when a user's role is changed (promote/demote), with an optional reason
+func (s *AuditService) LogUserRoleChange(actorID, targetID, channelID, oldRole, newRole, reason string, isPromotion bool) error {
 	action := ActionPromoteUser
 	description := "Promoted user"
 	
@@ -130,6 +137,7 @@ func (s *AuditService) LogUserRoleChange(actorID, targetID, channelID, oldRole,
 	}
 
 	metadata := AuditMetadata{
+		Reason:  reason,
 		OldRole: oldRole,
 		NewRole: newRole,
 	}
@@ -173,6 +181,99 @@ func (s *AuditService) LogChannelLeave(userID, channelID, channelName string) er
 	return s.db.Create(&auditLog).Error
 }
 
+// LogMessageAuthorScrub logs when an administrator re-attributes a message to the scrubbed placeholder
+func (s *AuditService) LogMessageAuthorScrub(actorID, originalAuthorID, channelID, messageID, reason string) error {
+	metadata := AuditMetadata{
+		Reason:    reason,
+		MessageID: messageID,
+	}
+	metadataJSON, _ := json.Marshal(metadata)
+
+	auditLog := AuditLog{
+		Action:      ActionScrubMessage,
+		ActorID:     actorID,
+		TargetID:    &originalAuthorID,
+		ChannelID:   &channelID,
+		Description: "Scrubbed message author",
+		Metadata:    string(metadataJSON),
+	}
+
+	return s.db.Create(&auditLog).Error
+}
+
+// LogChannelLock logs when a moderator locks a channel
+func (s *AuditService) LogChannelLock(actorID, channelID string, lockedUntil *time.Time) error {
+	metadata := AuditMetadata{}
+	if lockedUntil != nil {
+		lockedUntilStr := lockedUntil.Format(time.RFC3339)
+		metadata.ExpiresAt = &lockedUntilStr
+		metadata.Duration = time.Until(*lockedUntil).String()
+	}
+	metadataJSON, _ := json.Marshal(metadata)
+
+	auditLog := AuditLog{
+		Action:      ActionLockChannel,
+		ActorID:     actorID,
+		ChannelID:   &channelID,
+		Description: "Locked channel",
+		Metadata:    string(metadataJSON),
+	}
+
+	return s.db.Create(&auditLog).Error
+}
+
+// LogChannelUnlock logs when a moderator lifts a channel lock
+func (s *AuditService) LogChannelUnlock(actorID, channelID string) error {
+	auditLog := AuditLog{
+		Action:      ActionUnlockChannel,
+		ActorID:     actorID,
+		ChannelID:   &channelID,
+		Description: "Unlocked channel",
+		Metadata:    "{}",
+	}
+
+	return s.db.Create(&auditLog).Error
+}
+
+// LogUserSuspension logs when a system administrator suspends an account
+func (s *AuditService) LogUserSuspension(actorID, targetID, reason string, suspendedUntil *time.Time) error {
+	description := "Permanently suspended user"
+	metadata := AuditMetadata{
+		Reason: reason,
+	}
+	if suspendedUntil != nil {
+		description = "Temporarily suspended user"
+		suspendedUntilStr := suspendedUntil.Format(time.RFC3339)
+		metadata.ExpiresAt = &suspendedUntilStr
+		metadata.Duration = time.Until(*suspendedUntil).String()
+		metadata.IsTemp = true
+	}
+	metadataJSON, _ := json.Marshal(metadata)
+
+	auditLog := AuditLog{
+		Action:      ActionSuspendUser,
+		ActorID:     actorID,
+		TargetID:    &targetID,
+		Description: description,
+		Metadata:    string(metadataJSON),
+	}
+
+	return s.db.Create(&auditLog).Error
+}
+
+// LogUserUnsuspension logs when a system administrator lifts a suspension
+func (s *AuditService) LogUserUnsuspension(actorID, targetID string) error {
+	auditLog := AuditLog{
+		Action:      ActionUnsuspendUser,
+		ActorID:     actorID,
+		TargetID:    &targetID,
+		Description: "Lifted user suspension",
+		Metadata:    "{}",
+	}
+
+	return s.db.Create(&auditLog).Error
+}
+
 // GetAuditLogs retrieves audit logs with pagination and filtering
 func (s *AuditService) GetAuditLogs(channelID *string, actorID *string, action *string, limit, offset int) ([]AuditLog, int64, error) {
 	query := s.db.Model(&AuditLog{}).
@@ -208,17 +309,21 @@ func (s *AuditService) GetAuditLogs(channelID *string, actorID *string, action *
 	return logs, total, err
 }
 
-// GetChannelAuditLogs retrieves audit logs for a specific channel (owner only)
-func (s *AuditService) GetChannelAuditLogs(requestorID, channelID string, limit, offset int) ([]AuditLog, int64, error) {
+// GetChannelAuditLogs retrieves audit logs for a specific channel (owner only),
+// optionally narrowed to one actor and one action
+func (s *AuditService) GetChannelAuditLogs(requestorID, channelID string, actorID *string, action *string, limit, offset int) ([]AuditLog, int64, error) {
 	// First check if requestor is channel owner
 	var channel Channel
 	if err := s.db.Where("id = ?", channelID).First(&channel).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, 0, errors.New("channel not found")
+		}
 		return nil, 0, err
 	}
 
 	if channel.OwnerID != requestorID {
-		return nil, 0, gorm.ErrRecordNotFound // Or custom error
+		return nil, 0, errors.New("only channel owners can view audit logs")
 	}
 
-	return s.GetAuditLogs(&channelID, nil, nil, limit, offset)
+	return s.GetAuditLogs(&channelID, actorID, action, limit, offset)
 }
\ No newline at end of file
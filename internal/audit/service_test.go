@@ -226,7 +226,7 @@ func TestAuditService_LogUserRoleChange_Promotion(t *testing.T) {
 	require.NoError(t, db.Create(promotedUser).Error)
 
 	// Test logging promotion
-	err := service.LogUserRoleChange(admin.ID, promotedUser.ID, "chan123", "Member", "Moderator", true)
+	err := service.LogUserRoleChange(admin.ID, promotedUser.ID, "chan123", "Member", "Moderator", "keeps the peace", true)
 	require.NoError(t, err)
 
 	// Verify audit log was created
@@ -243,6 +243,7 @@ func TestAuditService_LogUserRoleChange_Promotion(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, "Member", metadata.OldRole)
 	assert.Equal(t, "Moderator", metadata.NewRole)
+	assert.Equal(t, "keeps the peace", metadata.Reason)
 }
 
 func TestAuditService_LogUserRoleChange_Demotion(t *testing.T) {
@@ -256,7 +257,7 @@ func TestAuditService_LogUserRoleChange_Demotion(t *testing.T) {
 	require.NoError(t, db.Create(demotedUser).Error)
 
 	// Test logging demotion
-	err := service.LogUserRoleChange(admin.ID, demotedUser.ID, "chan123", "Moderator", "Member", false)
+	err := service.LogUserRoleChange(admin.ID, demotedUser.ID, "chan123", "Moderator", "Member", "", false)
 	require.NoError(t, err)
 
 	// Verify audit log was created
@@ -413,13 +414,25 @@ func TestAuditService_GetChannelAuditLogs_OwnerOnly(t *testing.T) {
 	require.NoError(t, err)
 
 	// Test owner can access logs
-	logs, total, err := service.GetChannelAuditLogs(owner.ID, channel.ID, 10, 0)
+	logs, total, err := service.GetChannelAuditLogs(owner.ID, channel.ID, nil, nil, 10, 0)
 	require.NoError(t, err)
 	assert.Equal(t, int64(1), total)
 	assert.Len(t, logs, 1)
 
 	// Test non-owner cannot access logs
-	_, _, err = service.GetChannelAuditLogs(nonOwner.ID, channel.ID, 10, 0)
+	_, _, err = service.GetChannelAuditLogs(nonOwner.ID, channel.ID, nil, nil, 10, 0)
 	require.Error(t, err)
-	assert.Equal(t, gorm.ErrRecordNotFound, err)
+	assert.Equal(t, "only channel owners can view audit logs", err.Error())
+}
+
+func TestAuditService_GetChannelAuditLogs_ChannelNotFound(t *testing.T) {
+	db := setupAuditTestDB(t)
+	service := NewAuditService(db)
+
+	owner := &User{Username: "owner", Password: hashPasswordForAudit("password123")}
+	require.NoError(t, db.Create(owner).Error)
+
+	_, _, err := service.GetChannelAuditLogs(owner.ID, "missing-channel", nil, nil, 10, 0)
+	require.Error(t, err)
+	assert.Equal(t, "channel not found", err.Error())
 }
\ No newline at end of file
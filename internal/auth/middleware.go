@@ -1,23 +1,45 @@
 package auth
 
 import (
+	"errors"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
 )
 
+// secret signs and verifies access tokens once set from the validated
+// configuration. Until then APP_SECRET is read on every use.
+var secret string
+
+// SetSecret makes tokens use the secret loaded and validated at startup
+func SetSecret(s string) {
+	secret = s
+}
+
 func getSecret() string {
+	if secret != "" {
+		return secret
+	}
 	return os.Getenv("APP_SECRET")
 }
 
 type AuthMiddleware struct {
+	service *AuthService
 }
 
-func NewAuthMiddleware() *AuthMiddleware {
-	return &AuthMiddleware{}
+// NewAuthMiddleware creates the auth middleware. The database is used to
+// resolve API tokens; with a nil db only cookie authentication is accepted.
+func NewAuthMiddleware(db *gorm.DB) *AuthMiddleware {
+	am := &AuthMiddleware{}
+	if db != nil {
+		am.service = NewAuthService(db)
+	}
+	return am
 }
 
 func GenerateToken(userID string, username string) (string, error) {
@@ -53,6 +75,11 @@ func ValidateToken(tokenString string) (jwt.MapClaims, error) {
 
 func (am *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if header := c.GetHeader("Authorization"); header != "" {
+			am.requireApiToken(c, header)
+			return
+		}
+
 		token, err := c.Cookie("token")
 
 		if err != nil {
@@ -68,10 +95,74 @@ func (am *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 			return
 		}
 
-		c.Set("user_id", claims["user_id"].(string))
+		userID := claims["user_id"].(string)
+		if am.service != nil {
+			if err := am.service.CheckSuspended(userID); err != nil {
+				abortSuspended(c, err)
+				return
+			}
+		}
+
+		c.Set("user_id", userID)
 		c.Set("username", claims["username"].(string))
+		if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+			c.Set("token_expires_at", exp.Time)
+		}
 
 		c.Next()
 	}
 }
 
+// requireApiToken authenticates a request carrying an
+// "Authorization: Bearer <token>" header
+func (am *AuthMiddleware) requireApiToken(c *gin.Context, header string) {
+	token, found := strings.CutPrefix(header, "Bearer ")
+	if !found || token == "" || am.service == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		c.Abort()
+		return
+	}
+
+	user, err := am.service.ValidateApiToken(token)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		c.Abort()
+		return
+	}
+
+	if err := checkSuspended(user, time.Now()); err != nil {
+		abortSuspended(c, err)
+		return
+	}
+
+	c.Set("user_id", user.ID)
+	c.Set("username", user.Username)
+
+	c.Next()
+}
+
+// SuspendedResponse is the body sent to a suspended account, telling the
+// client why and until when it is locked out
+func SuspendedResponse(err *SuspendedError) gin.H {
+	body := gin.H{
+		"error":           "Account suspended",
+		"reason":          err.Reason,
+		"suspended_until": nil,
+	}
+	if err.Until != nil {
+		body["suspended_until"] = err.Until.Format(time.RFC3339)
+	}
+	return body
+}
+
+// abortSuspended rejects the request with 403 for a suspended account, or
+// with 500 when the suspension could not be checked
+func abortSuspended(c *gin.Context, err error) {
+	var suspended *SuspendedError
+	if errors.As(err, &suspended) {
+		c.JSON(http.StatusForbidden, SuspendedResponse(suspended))
+	} else {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify account status"})
+	}
+	c.Abort()
+}
@@ -1,12 +1,14 @@
 package auth
 
 import (
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"testing"
 	"time"
 
+	. "go-chat/pkg/chat"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -222,8 +224,27 @@ func TestValidateToken(t *testing.T) {
 	}
 }
 
+func TestSetSecret(t *testing.T) {
+	SetSecret("configured-secret-for-testing-0123456789")
+	t.Cleanup(func() { SetSecret("") })
+
+	token, err := GenerateToken("user123", "testuser")
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+	if _, err := ValidateToken(token); err != nil {
+		t.Errorf("Expected token signed with the configured secret to validate, got %v", err)
+	}
+
+	// The configured secret, not APP_SECRET, signed the token
+	SetSecret("")
+	if _, err := ValidateToken(token); err == nil {
+		t.Errorf("Expected token to be rejected with the environment secret")
+	}
+}
+
 func TestAuthMiddleware_RequireAuth(t *testing.T) {
-	middleware := NewAuthMiddleware()
+	middleware := NewAuthMiddleware(nil)
 	
 	// Generate a valid token
 	validToken, err := GenerateToken("123", "testuser")
@@ -354,7 +375,7 @@ func TestAuthMiddleware_RequireAuth(t *testing.T) {
 func TestAuthMiddleware_Integration(t *testing.T) {
 	// Test the middleware in a real Gin router
 	router := gin.New()
-	middleware := NewAuthMiddleware()
+	middleware := NewAuthMiddleware(nil)
 	
 	router.Use(middleware.RequireAuth())
 	router.GET("/protected", func(c *gin.Context) {
@@ -395,4 +416,134 @@ func TestAuthMiddleware_Integration(t *testing.T) {
 	if w2.Code != 401 {
 		t.Errorf("Expected status 401, got %d", w2.Code)
 	}
-}
\ No newline at end of file
+}
+func TestAuthMiddleware_ApiToken(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewAuthService(db)
+
+	user, err := service.Register("botuser", "testpassword")
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+	token, apiToken, err := service.CreateApiToken(user.ID, "script")
+	if err != nil {
+		t.Fatalf("Failed to create api token: %v", err)
+	}
+
+	router := gin.New()
+	router.Use(NewAuthMiddleware(db).RequireAuth())
+	router.GET("/protected", func(c *gin.Context) {
+		userID, _ := c.Get("user_id")
+		username, _ := c.Get("username")
+		c.JSON(200, gin.H{"user_id": userID, "username": username})
+	})
+
+	request := func(header string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/protected", nil)
+		req.Header.Set("Authorization", header)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request("Bearer " + token)
+	if w.Code != 200 {
+		t.Fatalf("Expected status 200 for valid api token, got %d", w.Code)
+	}
+	expectedBody := `{"user_id":"` + user.ID + `","username":"botuser"}`
+	if w.Body.String() != expectedBody {
+		t.Errorf("Expected body %s, got %s", expectedBody, w.Body.String())
+	}
+
+	if w := request("Basic " + token); w.Code != 401 {
+		t.Errorf("Expected status 401 for non-bearer scheme, got %d", w.Code)
+	}
+
+	if err := service.RevokeApiToken(user.ID, apiToken.ID); err != nil {
+		t.Fatalf("Failed to revoke api token: %v", err)
+	}
+
+	if w := request("Bearer " + token); w.Code != 401 {
+		t.Errorf("Expected status 401 for revoked api token, got %d", w.Code)
+	}
+}
+
+func TestAuthMiddleware_Suspended(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewAuthService(db)
+
+	user, err := service.Register("suspended", "testpassword")
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+	cookieToken, err := GenerateToken(user.ID, user.Username)
+	if err != nil {
+		t.Fatalf("Failed to generate token: %v", err)
+	}
+	apiToken, _, err := service.CreateApiToken(user.ID, "script")
+	if err != nil {
+		t.Fatalf("Failed to create api token: %v", err)
+	}
+
+	router := gin.New()
+	router.Use(NewAuthMiddleware(db).RequireAuth())
+	router.GET("/protected", func(c *gin.Context) {
+		c.JSON(200, gin.H{"message": "success"})
+	})
+
+	withCookie := func() *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/protected", nil)
+		req.AddCookie(&http.Cookie{Name: "token", Value: cookieToken})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	withApiToken := func() *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/protected", nil)
+		req.Header.Set("Authorization", "Bearer "+apiToken)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	now := time.Now()
+	if err := db.Model(&User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+		"suspended_at":     now,
+		"suspended_reason": "spam",
+	}).Error; err != nil {
+		t.Fatalf("Failed to suspend user: %v", err)
+	}
+
+	w := withCookie()
+	if w.Code != 403 {
+		t.Fatalf("Expected status 403 for suspended user, got %d", w.Code)
+	}
+	expectedBody := `{"error":"Account suspended","reason":"spam","suspended_until":null}`
+	if w.Body.String() != expectedBody {
+		t.Errorf("Expected body %s, got %s", expectedBody, w.Body.String())
+	}
+	if w := withApiToken(); w.Code != 403 {
+		t.Errorf("Expected status 403 for suspended user's api token, got %d", w.Code)
+	}
+
+	_, err = service.Login("suspended", "testpassword")
+	var suspended *SuspendedError
+	if !errors.As(err, &suspended) || suspended.Reason != "spam" || suspended.Until != nil {
+		t.Errorf("Expected permanent suspension error on login, got %v", err)
+	}
+	if _, err := service.Login("suspended", "wrongpassword"); err == nil || err.Error() != "invalid password" {
+		t.Errorf("Expected wrong password to be reported before the suspension, got %v", err)
+	}
+
+	// An expired temporary suspension no longer applies
+	if err := db.Model(&User{}).Where("id = ?", user.ID).
+		Update("suspended_until", now.Add(-time.Minute)).Error; err != nil {
+		t.Fatalf("Failed to expire suspension: %v", err)
+	}
+	if w := withCookie(); w.Code != 200 {
+		t.Errorf("Expected status 200 after suspension expired, got %d", w.Code)
+	}
+	if w := withApiToken(); w.Code != 200 {
+		t.Errorf("Expected status 200 for api token after suspension expired, got %d", w.Code)
+	}
+}
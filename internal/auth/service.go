@@ -2,22 +2,57 @@ package auth
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
-	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
+	"go-chat/internal/logger"
 	. "go-chat/pkg/chat"
 	. "go-chat/internal/utils"
 	"gorm.io/gorm"
 )
 
 type AuthService struct {
-	db *gorm.DB
+	db               *gorm.DB
+	logger           *slog.Logger
+	maxRefreshTokens int
 }
 
 func NewAuthService(db *gorm.DB) *AuthService {
-	return &AuthService{db: db}
+	return &AuthService{
+		db:               db,
+		logger:           logger.Default(),
+		maxRefreshTokens: MaxRefreshTokensFromEnv(),
+	}
+}
+
+// DefaultMaxRefreshTokens is how many sessions a user keeps before the oldest is pruned
+const DefaultMaxRefreshTokens = 10
+
+// MaxRefreshTokensFromEnv reads MAX_REFRESH_TOKENS, the number of refresh tokens kept
+// per user. "0" disables the cap; unset or invalid values fall back to DefaultMaxRefreshTokens.
+func MaxRefreshTokensFromEnv() int {
+	max, err := strconv.Atoi(os.Getenv("MAX_REFRESH_TOKENS"))
+	if err != nil || max < 0 {
+		return DefaultMaxRefreshTokens
+	}
+	return max
+}
+
+// SetMaxRefreshTokens overrides the per-user refresh token cap read from the environment
+func (s *AuthService) SetMaxRefreshTokens(max int) {
+	s.maxRefreshTokens = max
+}
+
+// SetLogger replaces the logger used to report non-fatal failures
+func (s *AuthService) SetLogger(l *slog.Logger) {
+	s.logger = l
 }
 
 func (s *AuthService) Register(username, password string) (*User, error) {
@@ -27,6 +62,21 @@ func (s *AuthService) Register(username, password string) (*User, error) {
 	if password == "" {
 		return nil, errors.New("password cannot be empty")
 	}
+	if err := ValidatePasswordLength(password); err != nil {
+		return nil, err
+	}
+	if IsReservedUsername(username) {
+		return nil, errors.New("username is reserved")
+	}
+
+	var existing User
+	err := s.db.Unscoped().Select("id").Where("username = ?", username).First(&existing).Error
+	if err == nil {
+		return nil, errors.New("username already exists")
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
 
 	hashedPassword, err := HashString(password)
 
@@ -55,10 +105,37 @@ func (s *AuthService) Login(username, password string) (*User, error) {
 		return nil, errors.New("invalid password")
 	}
 
+	if err := checkSuspended(&user, time.Now()); err != nil {
+		return nil, err
+	}
+
+	// Upgrade hashes made with a lower cost while the plaintext is at hand
+	if NeedsRehash(user.Password) {
+		s.rehashPassword(&user, password)
+	}
+
 	return &user, nil
 }
 
-func (s *AuthService) CreateRefreshToken(userID string) (string, error) {
+// rehashPassword stores a new hash of password at the current cost. Failures
+// are logged and leave the old hash in place.
+func (s *AuthService) rehashPassword(user *User, password string) {
+	hash, err := HashString(password)
+	if err == nil {
+		err = s.db.Model(&User{}).Where("id = ?", user.ID).Update("password", hash).Error
+	}
+	if err != nil {
+		s.logger.Warn("failed to rehash password", "user_id", user.ID, "error", err)
+		return
+	}
+	user.Password = hash
+}
+
+// maxDeviceLabelLength bounds the User-Agent stored as a session's device label
+const maxDeviceLabelLength = 255
+
+// CreateRefreshToken starts a session for the user, labelled with the client's User-Agent
+func (s *AuthService) CreateRefreshToken(userID, userAgent string) (string, error) {
 	tokenBytes := make([]byte, 32)
 
 	if _, err := rand.Read(tokenBytes); err != nil {
@@ -66,56 +143,238 @@ func (s *AuthService) CreateRefreshToken(userID string) (string, error) {
 	}
 
 	token := base64.URLEncoding.EncodeToString(tokenBytes)
-	hash, err := HashString(token)
-	if err != nil {
-		return "", err
-	}
 
 	refreshToken := RefreshToken{
-		UserID:    userID,
-		TokenHash: hash,
-		ExpiresAt: time.Now().Add(time.Hour * 24 * 7).Unix(),
+		UserID:      userID,
+		TokenHash:   hashToken(token),
+		ExpiresAt:   time.Now().Add(time.Hour * 24 * 7).Unix(),
+		DeviceLabel: deviceLabel(userAgent),
 	}
 
-	if err := s.db.Create(&refreshToken).Error; err != nil {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&refreshToken).Error; err != nil {
+			return err
+		}
+		return s.pruneRefreshTokens(tx, userID)
+	})
+	if err != nil {
 		return "", err
 	}
 
 	return token, nil
 }
 
+// pruneRefreshTokens removes the user's oldest refresh tokens beyond the cap, so
+// each login beyond it signs out the least recent session
+func (s *AuthService) pruneRefreshTokens(tx *gorm.DB, userID string) error {
+	if s.maxRefreshTokens <= 0 {
+		return nil
+	}
+
+	var staleIDs []uint
+	err := tx.Model(&RefreshToken{}).
+		Where("user_id = ?", userID).
+		Order("created_at DESC, id DESC").
+		Offset(s.maxRefreshTokens).
+		Pluck("id", &staleIDs).Error
+	if err != nil || len(staleIDs) == 0 {
+		return err
+	}
+
+	return tx.Unscoped().Delete(&RefreshToken{}, staleIDs).Error
+}
+
+// findRefreshToken looks up an unexpired refresh token by its digest
+func (s *AuthService) findRefreshToken(token string) (*RefreshToken, error) {
+	var rt RefreshToken
+	err := s.db.Where("token_hash = ? AND expires_at > ?", hashToken(token), time.Now().Unix()).First(&rt).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.New("invalid refresh token")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rt, nil
+}
+
 func (s *AuthService) ValidateRefreshToken(token string) (*User, error) {
-	var refreshTokens []RefreshToken
-	if err := s.db.Where("expires_at > ?", time.Now().Unix()).Find(&refreshTokens).Error; err != nil {
-		fmt.Println(err, "error in retrieval")
+	rt, err := s.findRefreshToken(token)
+	if err != nil {
 		return nil, err
 	}
 
-	for _, rt := range refreshTokens {
-		isValid := VerifyHashedString(token, rt.TokenHash)
-		fmt.Println(rt.TokenHash, token, isValid)
-		if isValid {
-			var user User
-			fmt.Println(rt.UserID)
-			if err := s.db.Where("id = ?", rt.UserID).First(&user).Error; err != nil {
-				return nil, err
-			}
-			go s.db.Delete(&RefreshToken{}, "user_id = ? AND expires_at < ?", rt.UserID, time.Now())
-			return &user, nil
-		}
+	var user User
+	if err := s.db.Where("id = ?", rt.UserID).First(&user).Error; err != nil {
+		return nil, err
+	}
+	if err := checkSuspended(&user, time.Now()); err != nil {
+		return nil, err
+	}
+	s.db.Model(&RefreshToken{}).Where("id = ?", rt.ID).Update("last_used_at", time.Now())
+	go s.db.Delete(&RefreshToken{}, "user_id = ? AND expires_at < ?", rt.UserID, time.Now().Unix())
+	return &user, nil
+}
+
+// SuspendedError is returned when a suspended account signs in, refreshes its
+// session or makes an authenticated request
+type SuspendedError struct {
+	Reason string
+	Until  *time.Time // nil for a permanent suspension
+}
+
+func (e *SuspendedError) Error() string {
+	return "account suspended"
+}
+
+func checkSuspended(user *User, now time.Time) error {
+	if !user.IsSuspendedAt(now) {
+		return nil
 	}
+	return &SuspendedError{Reason: user.SuspendedReason, Until: user.SuspendedUntil}
+}
+
+// CheckSuspended returns a *SuspendedError when the user's account is
+// currently suspended. Unknown users are not reported as suspended.
+func (s *AuthService) CheckSuspended(userID string) error {
+	var user User
+	err := s.db.Select("id", "suspended_at", "suspended_until", "suspended_reason").
+		Where("id = ?", userID).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return checkSuspended(&user, time.Now())
+}
+
+// deviceLabel trims a User-Agent down to a storable session label
+func deviceLabel(userAgent string) string {
+	label := []rune(strings.TrimSpace(userAgent))
+	if len(label) > maxDeviceLabelLength {
+		label = label[:maxDeviceLabelLength]
+	}
+	return string(label)
+}
+
+// ListSessions returns the user's unexpired refresh tokens, most recent first
+func (s *AuthService) ListSessions(userID string) ([]RefreshToken, error) {
+	var sessions []RefreshToken
+	err := s.db.Where("user_id = ? AND expires_at > ?", userID, time.Now().Unix()).
+		Order("created_at DESC, id DESC").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
 
-	return nil, errors.New("invalid refresh token")
+// RevokeSession signs the user out of one session by deleting its refresh token
+func (s *AuthService) RevokeSession(userID string, sessionID uint) error {
+	result := s.db.Where("id = ? AND user_id = ?", sessionID, userID).Delete(&RefreshToken{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("session not found")
+	}
+	return nil
+}
+
+// GetRefreshTokenExpiry returns when the given refresh token expires
+func (s *AuthService) GetRefreshTokenExpiry(token string) (time.Time, error) {
+	rt, err := s.findRefreshToken(token)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(rt.ExpiresAt, 0), nil
 }
 
 func (s *AuthService) RevokeRefreshToken(token string) error {
-	var refreshTokens []RefreshToken
-	s.db.Where("expires_at > ?", time.Now().Unix()).Find(&refreshTokens)
+	rt, err := s.findRefreshToken(token)
+	if err != nil {
+		return nil
+	}
+	return s.db.Delete(rt).Error
+}
+
+// ApiTokenPrefix marks plaintext API tokens so they are recognisable in
+// scripts and secret scanners
+const ApiTokenPrefix = "gct_"
+
+// hashToken digests a random bearer token for storage and lookup. Refresh and
+// API tokens carry 256 bits of entropy, so a fast hash is enough and lets
+// them be found by index instead of compared one by one.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateApiToken mints a new API token for the user. The plaintext token is
+// returned once and only its hash is persisted.
+func (s *AuthService) CreateApiToken(userID, label string) (string, *ApiToken, error) {
+	if label == "" {
+		return "", nil, errors.New("token label cannot be empty")
+	}
 
-	for _, rt := range refreshTokens {
-		if VerifyHashedString(token, rt.TokenHash) {
-			return s.db.Delete(&rt).Error
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", nil, err
+	}
+	token := ApiTokenPrefix + base64.RawURLEncoding.EncodeToString(tokenBytes)
+
+	apiToken := ApiToken{
+		UserID:    userID,
+		Label:     label,
+		TokenHash: hashToken(token),
+	}
+
+	if err := s.db.Create(&apiToken).Error; err != nil {
+		return "", nil, err
+	}
+
+	return token, &apiToken, nil
+}
+
+func (s *AuthService) ListApiTokens(userID string) ([]ApiToken, error) {
+	var tokens []ApiToken
+	if err := s.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&tokens).Error; err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+func (s *AuthService) RevokeApiToken(userID string, tokenID uint) error {
+	var apiToken ApiToken
+	if err := s.db.Where("id = ? AND user_id = ?", tokenID, userID).First(&apiToken).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("api token not found")
 		}
+		return err
 	}
-	return nil
+
+	if apiToken.Revoked {
+		return nil
+	}
+
+	return s.db.Model(&apiToken).Update("revoked", true).Error
+}
+
+// ValidateApiToken resolves a plaintext API token to its owner and records
+// when it was last used
+func (s *AuthService) ValidateApiToken(token string) (*User, error) {
+	var apiToken ApiToken
+	err := s.db.Where("token_hash = ? AND revoked = ?", hashToken(token), false).First(&apiToken).Error
+	if err != nil {
+		return nil, errors.New("invalid api token")
+	}
+
+	var user User
+	if err := s.db.Where("id = ?", apiToken.UserID).First(&user).Error; err != nil {
+		return nil, errors.New("invalid api token")
+	}
+
+	s.db.Model(&apiToken).Update("last_used_at", time.Now())
+
+	return &user, nil
 }
@@ -4,7 +4,9 @@ import (
 	"testing"
 	"time"
 
+	. "go-chat/internal/utils"
 	. "go-chat/pkg/chat"
+	"golang.org/x/crypto/bcrypt"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
@@ -15,7 +17,7 @@ func setupTestDB(t *testing.T) *gorm.DB {
 		t.Fatalf("Failed to connect to database: %v", err)
 	}
 
-	err = db.AutoMigrate(&User{}, &RefreshToken{}, &Role{})
+	err = db.AutoMigrate(&User{}, &RefreshToken{}, &ApiToken{}, &Role{})
 	if err != nil {
 		t.Fatalf("Failed to migrate database: %v", err)
 	}
@@ -54,6 +56,13 @@ func TestAuthService_Register(t *testing.T) {
 			expectError: true,
 			errorMsg:    "password cannot be empty",
 		},
+		{
+			name:        "reserved username",
+			username:    "[scrubbed]",
+			password:    "testpassword",
+			expectError: true,
+			errorMsg:    "username is reserved",
+		},
 		{
 			name:        "second valid user",
 			username:    "testuser2",
@@ -178,6 +187,42 @@ func TestAuthService_Login(t *testing.T) {
 	}
 }
 
+func TestAuthService_Login_RehashesLowCostPassword(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewAuthService(db)
+
+	originalCost := HashCost
+	t.Cleanup(func() { HashCost = originalCost })
+
+	HashCost = bcrypt.MinCost
+	user, err := service.Register("legacy", "testpassword")
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+
+	HashCost = bcrypt.MinCost + 1
+
+	if _, err := service.Login("legacy", "wrongpassword"); err == nil {
+		t.Fatal("Expected error for wrong password")
+	}
+	var stored User
+	db.First(&stored, "id = ?", user.ID)
+	if cost, _ := bcrypt.Cost([]byte(stored.Password)); cost != bcrypt.MinCost {
+		t.Errorf("Failed login should not rehash, got cost %d", cost)
+	}
+
+	if _, err := service.Login("legacy", "testpassword"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	db.First(&stored, "id = ?", user.ID)
+	if cost, _ := bcrypt.Cost([]byte(stored.Password)); cost != HashCost {
+		t.Errorf("Expected stored hash cost %d after login, got %d", HashCost, cost)
+	}
+	if !VerifyHashedString("testpassword", stored.Password) {
+		t.Error("Rehashed password should still verify")
+	}
+}
+
 func TestAuthService_CreateRefreshToken(t *testing.T) {
 	db := setupTestDB(t)
 	service := NewAuthService(db)
@@ -188,7 +233,7 @@ func TestAuthService_CreateRefreshToken(t *testing.T) {
 		t.Fatalf("Failed to create test user: %v", err)
 	}
 
-	token, err := service.CreateRefreshToken(user.ID)
+	token, err := service.CreateRefreshToken(user.ID, "")
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
 		return
@@ -210,8 +255,12 @@ func TestAuthService_CreateRefreshToken(t *testing.T) {
 		t.Error("Token should not be expired immediately after creation")
 	}
 
+	if refreshToken.TokenHash == token || refreshToken.TokenHash != hashToken(token) {
+		t.Error("Expected the token to be stored as its SHA-256 digest")
+	}
+
 	// Test creating multiple tokens for same user
-	token2, err := service.CreateRefreshToken(user.ID)
+	token2, err := service.CreateRefreshToken(user.ID, "")
 	if err != nil {
 		t.Errorf("Unexpected error creating second token: %v", err)
 		return
@@ -222,6 +271,74 @@ func TestAuthService_CreateRefreshToken(t *testing.T) {
 	}
 }
 
+func TestAuthService_CreateRefreshToken_PrunesOldest(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewAuthService(db)
+	service.SetMaxRefreshTokens(3)
+
+	user, err := service.Register("testuser", "testpassword")
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+	other, err := service.Register("otheruser", "testpassword")
+	if err != nil {
+		t.Fatalf("Failed to create other user: %v", err)
+	}
+	otherToken, err := service.CreateRefreshToken(other.ID, "")
+	if err != nil {
+		t.Fatalf("Failed to create refresh token: %v", err)
+	}
+
+	var tokens []string
+	for i := 0; i < 5; i++ {
+		token, err := service.CreateRefreshToken(user.ID, "")
+		if err != nil {
+			t.Fatalf("Failed to create refresh token %d: %v", i, err)
+		}
+		tokens = append(tokens, token)
+	}
+
+	var count int64
+	db.Unscoped().Model(&RefreshToken{}).Where("user_id = ?", user.ID).Count(&count)
+	if count != 3 {
+		t.Errorf("Expected 3 refresh tokens to be kept, got %d", count)
+	}
+
+	for i, token := range tokens {
+		_, err := service.ValidateRefreshToken(token)
+		if i < 2 && err == nil {
+			t.Errorf("Expected pruned token %d to be rejected", i)
+		}
+		if i >= 2 && err != nil {
+			t.Errorf("Expected recent token %d to validate, got %v", i, err)
+		}
+	}
+
+	if _, err := service.ValidateRefreshToken(otherToken); err != nil {
+		t.Errorf("Expected other users' tokens to be left alone, got %v", err)
+	}
+}
+
+func TestMaxRefreshTokensFromEnv(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected int
+	}{
+		{"", DefaultMaxRefreshTokens},
+		{"5", 5},
+		{"0", 0},
+		{"-1", DefaultMaxRefreshTokens},
+		{"many", DefaultMaxRefreshTokens},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("MAX_REFRESH_TOKENS", tt.value)
+		if got := MaxRefreshTokensFromEnv(); got != tt.expected {
+			t.Errorf("MAX_REFRESH_TOKENS=%q: expected %d, got %d", tt.value, tt.expected, got)
+		}
+	}
+}
+
 func TestAuthService_ValidateRefreshToken(t *testing.T) {
 	db := setupTestDB(t)
 	service := NewAuthService(db)
@@ -233,7 +350,7 @@ func TestAuthService_ValidateRefreshToken(t *testing.T) {
 	}
 
 	// Create a refresh token
-	token, err := service.CreateRefreshToken(user.ID)
+	token, err := service.CreateRefreshToken(user.ID, "")
 	if err != nil {
 		t.Fatalf("Failed to create refresh token: %v", err)
 	}
@@ -299,7 +416,7 @@ func TestAuthService_RevokeRefreshToken(t *testing.T) {
 	}
 
 	// Create a refresh token
-	token, err := service.CreateRefreshToken(user.ID)
+	token, err := service.CreateRefreshToken(user.ID, "")
 	if err != nil {
 		t.Fatalf("Failed to create refresh token: %v", err)
 	}
@@ -327,4 +444,101 @@ func TestAuthService_RevokeRefreshToken(t *testing.T) {
 	if err != nil {
 		t.Errorf("Revoking non-existent token should not error: %v", err)
 	}
-}
\ No newline at end of file
+}
+func TestAuthService_ApiTokens(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewAuthService(db)
+
+	user, err := service.Register("botowner", "testpassword")
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+	other, err := service.Register("someoneelse", "testpassword")
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+
+	if _, _, err := service.CreateApiToken(user.ID, ""); err == nil || err.Error() != "token label cannot be empty" {
+		t.Errorf("Expected empty label error, got: %v", err)
+	}
+
+	token, apiToken, err := service.CreateApiToken(user.ID, "ci")
+	if err != nil {
+		t.Fatalf("Failed to create api token: %v", err)
+	}
+	if apiToken.TokenHash == token {
+		t.Error("Expected token to be stored hashed")
+	}
+
+	validatedUser, err := service.ValidateApiToken(token)
+	if err != nil {
+		t.Fatalf("Token should be valid: %v", err)
+	}
+	if validatedUser.ID != user.ID {
+		t.Errorf("Expected user ID '%s', got '%s'", user.ID, validatedUser.ID)
+	}
+
+	var stored ApiToken
+	db.First(&stored, apiToken.ID)
+	if stored.LastUsedAt == nil {
+		t.Error("Expected last_used_at to be recorded")
+	}
+
+	if _, err := service.ValidateApiToken("gct_not-a-real-token"); err == nil {
+		t.Error("Expected unknown token to be rejected")
+	}
+
+	// Another user cannot revoke the token
+	if err := service.RevokeApiToken(other.ID, apiToken.ID); err == nil || err.Error() != "api token not found" {
+		t.Errorf("Expected api token not found error, got: %v", err)
+	}
+
+	if err := service.RevokeApiToken(user.ID, apiToken.ID); err != nil {
+		t.Fatalf("Unexpected error revoking token: %v", err)
+	}
+	if _, err := service.ValidateApiToken(token); err == nil {
+		t.Error("Token should be invalid after revocation")
+	}
+
+	tokens, err := service.ListApiTokens(user.ID)
+	if err != nil {
+		t.Fatalf("Failed to list api tokens: %v", err)
+	}
+	if len(tokens) != 1 || !tokens[0].Revoked {
+		t.Errorf("Expected one revoked token, got %+v", tokens)
+	}
+}
+
+func TestAuthService_GetRefreshTokenExpiry(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewAuthService(db)
+
+	user, err := service.Register("testuser", "testpassword")
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+	token, err := service.CreateRefreshToken(user.ID, "")
+	if err != nil {
+		t.Fatalf("Failed to create refresh token: %v", err)
+	}
+
+	var stored RefreshToken
+	db.Where("user_id = ?", user.ID).First(&stored)
+
+	expiresAt, err := service.GetRefreshTokenExpiry(token)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expiresAt.Unix() != stored.ExpiresAt {
+		t.Errorf("Expected expiry %d, got %d", stored.ExpiresAt, expiresAt.Unix())
+	}
+
+	if _, err := service.GetRefreshTokenExpiry("junk"); err == nil {
+		t.Error("Expected an error for an unknown token")
+	}
+
+	db.Model(&RefreshToken{}).Where("id = ?", stored.ID).Update("expires_at", time.Now().Add(-time.Minute).Unix())
+	if _, err := service.GetRefreshTokenExpiry(token); err == nil {
+		t.Error("Expected an error for an expired token")
+	}
+}
@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"os"
+	"time"
+
+	. "go-chat/pkg/chat"
+)
+
+// DefaultRefreshTokenSweepInterval is how often expired refresh tokens are purged
+const DefaultRefreshTokenSweepInterval = time.Hour
+
+// RefreshTokenSweepIntervalFromEnv reads REFRESH_TOKEN_SWEEP_INTERVAL as a duration such as "1h".
+// "0" disables the sweeper; unset or invalid values fall back to DefaultRefreshTokenSweepInterval.
+func RefreshTokenSweepIntervalFromEnv() time.Duration {
+	interval, err := time.ParseDuration(os.Getenv("REFRESH_TOKEN_SWEEP_INTERVAL"))
+	if err != nil || interval < 0 {
+		return DefaultRefreshTokenSweepInterval
+	}
+	return interval
+}
+
+// SweepExpiredRefreshTokens permanently deletes every refresh token past its
+// expiry, revoked or not, and returns how many were removed
+func (s *AuthService) SweepExpiredRefreshTokens() (int64, error) {
+	result := s.db.Unscoped().Where("expires_at < ?", time.Now().Unix()).Delete(&RefreshToken{})
+	return result.RowsAffected, result.Error
+}
+
+// StartRefreshTokenSweeper sweeps expired refresh tokens every interval until
+// the returned function is called. A zero interval starts nothing.
+func (s *AuthService) StartRefreshTokenSweeper(interval time.Duration) (stop func()) {
+	if interval <= 0 {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				removed, err := s.SweepExpiredRefreshTokens()
+				if err != nil {
+					s.logger.Warn("failed to sweep expired refresh tokens", "error", err)
+					continue
+				}
+				if removed > 0 {
+					s.logger.Info("swept expired refresh tokens", "count", removed)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() { close(done) }
+}
@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	. "go-chat/pkg/chat"
+)
+
+func TestAuthService_SweepExpiredRefreshTokens(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewAuthService(db)
+
+	user, err := service.Register("sweeper", "testpassword")
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+
+	valid, err := service.CreateRefreshToken(user.ID, "")
+	if err != nil {
+		t.Fatalf("Failed to create refresh token: %v", err)
+	}
+
+	now := time.Now()
+	expired := []RefreshToken{
+		{UserID: user.ID, TokenHash: "expired-1", ExpiresAt: now.Add(-time.Hour).Unix()},
+		{UserID: user.ID, TokenHash: "expired-2", ExpiresAt: now.Add(-24 * time.Hour).Unix()},
+	}
+	if err := db.Create(&expired).Error; err != nil {
+		t.Fatalf("Failed to create expired tokens: %v", err)
+	}
+	// Revoked tokens are soft-deleted; expired ones are purged all the same
+	if err := db.Delete(&expired[1]).Error; err != nil {
+		t.Fatalf("Failed to revoke token: %v", err)
+	}
+
+	removed, err := service.SweepExpiredRefreshTokens()
+	if err != nil {
+		t.Fatalf("Sweep failed: %v", err)
+	}
+	if removed != 2 {
+		t.Errorf("Expected 2 tokens removed, got %d", removed)
+	}
+
+	var remaining int64
+	db.Unscoped().Model(&RefreshToken{}).Count(&remaining)
+	if remaining != 1 {
+		t.Errorf("Expected 1 token left, got %d", remaining)
+	}
+	if _, err := service.ValidateRefreshToken(valid); err != nil {
+		t.Errorf("Expected the unexpired token to survive the sweep, got %v", err)
+	}
+
+	if removed, err := service.SweepExpiredRefreshTokens(); err != nil || removed != 0 {
+		t.Errorf("Expected nothing left to sweep, got %d (%v)", removed, err)
+	}
+}
+
+func TestAuthService_StartRefreshTokenSweeper(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewAuthService(db)
+
+	user, err := service.Register("sweeper", "testpassword")
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+	if err := db.Create(&RefreshToken{UserID: user.ID, TokenHash: "expired", ExpiresAt: time.Now().Add(-time.Hour).Unix()}).Error; err != nil {
+		t.Fatalf("Failed to create expired token: %v", err)
+	}
+
+	stop := service.StartRefreshTokenSweeper(10 * time.Millisecond)
+	defer stop()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		var count int64
+		db.Unscoped().Model(&RefreshToken{}).Count(&count)
+		if count == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the sweeper to remove the expired token")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRefreshTokenSweepIntervalFromEnv(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected time.Duration
+	}{
+		{"", DefaultRefreshTokenSweepInterval},
+		{"15m", 15 * time.Minute},
+		{"0", 0},
+		{"-1h", DefaultRefreshTokenSweepInterval},
+		{"often", DefaultRefreshTokenSweepInterval},
+	}
+	for _, tt := range tests {
+		t.Setenv("REFRESH_TOKEN_SWEEP_INTERVAL", tt.value)
+		if got := RefreshTokenSweepIntervalFromEnv(); got != tt.expected {
+			t.Errorf("REFRESH_TOKEN_SWEEP_INTERVAL=%q: expected %v, got %v", tt.value, tt.expected, got)
+		}
+	}
+}
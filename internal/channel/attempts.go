@@ -0,0 +1,96 @@
+package channel
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"time"
+
+	. "go-chat/internal/utils"
+	. "go-chat/pkg/chat"
+)
+
+// PasswordAttemptPolicy limits wrong channel password attempts per user and channel
+type PasswordAttemptPolicy struct {
+	MaxAttempts int           // Wrong passwords allowed before attempts are refused; 0 disables the limit
+	Lockout     time.Duration // How long attempts are refused once the limit is reached
+}
+
+// DefaultPasswordAttemptPolicy is used when no override is configured
+var DefaultPasswordAttemptPolicy = PasswordAttemptPolicy{
+	MaxAttempts: 5,
+	Lockout:     15 * time.Minute,
+}
+
+// PasswordAttemptPolicyFromEnv reads CHANNEL_PASSWORD_MAX_ATTEMPTS and CHANNEL_PASSWORD_LOCKOUT
+// (a duration such as "15m"), falling back to DefaultPasswordAttemptPolicy for unset or invalid values
+func PasswordAttemptPolicyFromEnv() PasswordAttemptPolicy {
+	policy := DefaultPasswordAttemptPolicy
+	if n, err := strconv.Atoi(os.Getenv("CHANNEL_PASSWORD_MAX_ATTEMPTS")); err == nil && n >= 0 {
+		policy.MaxAttempts = n
+	}
+	if d, err := time.ParseDuration(os.Getenv("CHANNEL_PASSWORD_LOCKOUT")); err == nil && d > 0 {
+		policy.Lockout = d
+	}
+	return policy
+}
+
+// maxTrackedAttempts caps how many users' attempts are tracked; entries that are
+// not locked out are dropped when it fills up
+const maxTrackedAttempts = 4096
+
+type passwordAttempts struct {
+	failures    int
+	lockedUntil time.Time
+}
+
+// SetPasswordAttemptPolicy overrides the password attempt policy read from the environment
+func (s *ChannelService) SetPasswordAttemptPolicy(policy PasswordAttemptPolicy) {
+	s.attemptsMu.Lock()
+	defer s.attemptsMu.Unlock()
+
+	s.attemptPolicy = policy
+	s.attempts = make(map[memberKey]*passwordAttempts)
+}
+
+// checkPassword verifies a password for a protected channel, counting wrong
+// ones against the user. Once the policy's limit is reached every attempt is
+// refused until the lockout ends, even with the right password. A correct
+// password clears the count.
+func (s *ChannelService) checkPassword(userID string, channel *Channel, password string) error {
+	s.attemptsMu.Lock()
+	defer s.attemptsMu.Unlock()
+
+	key := memberKey{userID: userID, channelID: channel.ID}
+	now := time.Now()
+	entry := s.attempts[key]
+	if entry != nil && now.Before(entry.lockedUntil) {
+		return errors.New("too many attempts, try later")
+	}
+
+	if VerifyHashedString(password, *channel.Password) {
+		delete(s.attempts, key)
+		return nil
+	}
+
+	if s.attemptPolicy.MaxAttempts == 0 {
+		return errors.New("invalid password")
+	}
+	if entry == nil {
+		if len(s.attempts) >= maxTrackedAttempts {
+			for k, e := range s.attempts {
+				if !now.Before(e.lockedUntil) {
+					delete(s.attempts, k)
+				}
+			}
+		}
+		entry = &passwordAttempts{}
+		s.attempts[key] = entry
+	}
+	entry.failures++
+	if entry.failures >= s.attemptPolicy.MaxAttempts {
+		entry.failures = 0
+		entry.lockedUntil = now.Add(s.attemptPolicy.Lockout)
+	}
+	return errors.New("invalid password")
+}
@@ -0,0 +1,99 @@
+package channel
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChannelService_PasswordAttempts(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewChannelService(db, NewMemberCache(0))
+	service.SetPasswordAttemptPolicy(PasswordAttemptPolicy{MaxAttempts: 3, Lockout: 200 * time.Millisecond})
+	owner := createTestUser(t, db, "owner")
+
+	channel, err := service.CreateChannel(owner.ID, "vault", stringPtr("secret"), true, nil)
+	if err != nil {
+		t.Fatalf("Failed to create channel: %v", err)
+	}
+
+	expectJoinError := func(t *testing.T, userID, password, expected string) {
+		t.Helper()
+		err := service.JoinChannel(userID, channel.ID, stringPtr(password))
+		if err == nil || err.Error() != expected {
+			t.Fatalf("Expected %q, got %v", expected, err)
+		}
+	}
+
+	t.Run("locks after the threshold", func(t *testing.T) {
+		guesser := createTestUser(t, db, "guesser")
+		for i := 0; i < 3; i++ {
+			expectJoinError(t, guesser.ID, "guess", "invalid password")
+		}
+		// Locked out, so even the right password is refused
+		expectJoinError(t, guesser.ID, "secret", "too many attempts, try later")
+		if _, err := service.VerifyChannelPassword(guesser.ID, channel.ID, "secret"); err == nil || err.Error() != "too many attempts, try later" {
+			t.Errorf("Expected verification to be locked out too, got %v", err)
+		}
+
+		// Other users are not affected
+		bystander := createTestUser(t, db, "bystander")
+		if err := service.JoinChannel(bystander.ID, channel.ID, stringPtr("secret")); err != nil {
+			t.Errorf("Expected another user to join, got %v", err)
+		}
+	})
+
+	t.Run("lockout ends after the window", func(t *testing.T) {
+		patient := createTestUser(t, db, "patient")
+		for i := 0; i < 3; i++ {
+			expectJoinError(t, patient.ID, "guess", "invalid password")
+		}
+		expectJoinError(t, patient.ID, "secret", "too many attempts, try later")
+
+		time.Sleep(250 * time.Millisecond)
+		if err := service.JoinChannel(patient.ID, channel.ID, stringPtr("secret")); err != nil {
+			t.Errorf("Expected to join once the lockout ended, got %v", err)
+		}
+	})
+
+	t.Run("success resets the count", func(t *testing.T) {
+		forgetful := createTestUser(t, db, "forgetful")
+		for i := 0; i < 2; i++ {
+			if valid, err := service.VerifyChannelPassword(forgetful.ID, channel.ID, "guess"); err != nil || valid {
+				t.Fatalf("Expected an invalid password, got valid=%v err=%v", valid, err)
+			}
+		}
+		if valid, err := service.VerifyChannelPassword(forgetful.ID, channel.ID, "secret"); err != nil || !valid {
+			t.Fatalf("Expected the password to be valid, got valid=%v err=%v", valid, err)
+		}
+
+		// Two more misses would have locked the user out without the reset
+		for i := 0; i < 2; i++ {
+			expectJoinError(t, forgetful.ID, "guess", "invalid password")
+		}
+		if err := service.JoinChannel(forgetful.ID, channel.ID, stringPtr("secret")); err != nil {
+			t.Errorf("Expected to join, got %v", err)
+		}
+	})
+
+	t.Run("no limit when disabled", func(t *testing.T) {
+		service.SetPasswordAttemptPolicy(PasswordAttemptPolicy{MaxAttempts: 0, Lockout: time.Minute})
+		persistent := createTestUser(t, db, "persistent")
+		for i := 0; i < 10; i++ {
+			expectJoinError(t, persistent.ID, "guess", "invalid password")
+		}
+	})
+}
+
+func TestPasswordAttemptPolicyFromEnv(t *testing.T) {
+	t.Setenv("CHANNEL_PASSWORD_MAX_ATTEMPTS", "3")
+	t.Setenv("CHANNEL_PASSWORD_LOCKOUT", "1h")
+	if policy := PasswordAttemptPolicyFromEnv(); policy != (PasswordAttemptPolicy{MaxAttempts: 3, Lockout: time.Hour}) {
+		t.Errorf("Unexpected policy %+v", policy)
+	}
+
+	t.Setenv("CHANNEL_PASSWORD_MAX_ATTEMPTS", "-1")
+	t.Setenv("CHANNEL_PASSWORD_LOCKOUT", "soon")
+	if policy := PasswordAttemptPolicyFromEnv(); policy != DefaultPasswordAttemptPolicy {
+		t.Errorf("Expected the default policy, got %+v", policy)
+	}
+}
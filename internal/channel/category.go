@@ -0,0 +1,98 @@
+package channel
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	. "go-chat/pkg/chat"
+	"gorm.io/gorm"
+)
+
+// MaxCategoryNameLength bounds category names, in characters
+const MaxCategoryNameLength = 50
+
+// CreateCategory creates a category owned by the requester, or a global one when
+// global is set, which only system administrators may do. Names are unique,
+// case-insensitively, among the global categories and among each user's own.
+func (s *ChannelService) CreateCategory(requesterID, name string, global bool) (*ChannelCategory, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("category name cannot be empty")
+	}
+	if utf8.RuneCountInString(name) > MaxCategoryNameLength {
+		return nil, errors.New("category name is too long")
+	}
+
+	category := ChannelCategory{Name: name}
+	scope := s.db.Model(&ChannelCategory{}).Where("LOWER(name) = ?", strings.ToLower(name))
+	if global {
+		var requester User
+		if err := s.db.First(&requester, "id = ?", requesterID).Error; err != nil {
+			return nil, err
+		}
+		if !requester.IsAdmin {
+			return nil, errors.New("only administrators can create global categories")
+		}
+		scope = scope.Where("owner_id IS NULL")
+	} else {
+		category.OwnerID = &requesterID
+		scope = scope.Where("owner_id = ?", requesterID)
+	}
+
+	var existing int64
+	if err := scope.Count(&existing).Error; err != nil {
+		return nil, err
+	}
+	if existing > 0 {
+		return nil, errors.New("category already exists")
+	}
+
+	if err := s.db.Create(&category).Error; err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+// GetCategories returns the global categories and the user's own, by name
+func (s *ChannelService) GetCategories(userID string) ([]ChannelCategory, error) {
+	var categories []ChannelCategory
+	err := s.db.Where("owner_id IS NULL OR owner_id = ?", userID).
+		Order("name ASC, id ASC").
+		Find(&categories).Error
+	return categories, err
+}
+
+// SetChannelCategory lists the channel under a category, or under none when
+// categoryID is nil. Only the channel owner can do so, with a global category
+// or one of their own.
+func (s *ChannelService) SetChannelCategory(requesterID, channelID string, categoryID *uint) (*Channel, error) {
+	channel, err := s.GetChannel(channelID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("channel not found")
+		}
+		return nil, err
+	}
+
+	if channel.OwnerID != requesterID {
+		return nil, errors.New("only channel owner can assign a category")
+	}
+
+	if categoryID != nil {
+		var category ChannelCategory
+		err := s.db.Where("id = ? AND (owner_id IS NULL OR owner_id = ?)", *categoryID, requesterID).First(&category).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, errors.New("category not found")
+			}
+			return nil, err
+		}
+	}
+
+	if err := s.db.Model(&Channel{}).Where("id = ?", channel.ID).Update("category_id", categoryID).Error; err != nil {
+		return nil, err
+	}
+
+	return s.GetChannel(channelID)
+}
@@ -0,0 +1,131 @@
+package channel
+
+import (
+	"strings"
+	"testing"
+
+	. "go-chat/pkg/chat"
+)
+
+func TestChannelService_CreateCategory(t *testing.T) {
+	db := setupTestDB(t)
+	if err := db.AutoMigrate(&ChannelCategory{}); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+	service := NewChannelService(db, NewMemberCache(0))
+
+	user := createTestUser(t, db, "organizer")
+	other := createTestUser(t, db, "neighbour")
+	admin := createTestUser(t, db, "sysadmin")
+	db.Model(&User{}).Where("id = ?", admin.ID).Update("is_admin", true)
+
+	own, err := service.CreateCategory(user.ID, "  Dev  ", false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if own.Name != "Dev" || own.OwnerID == nil || *own.OwnerID != user.ID {
+		t.Errorf("Expected a trimmed category owned by the user, got %+v", own)
+	}
+
+	tests := []struct {
+		name        string
+		requesterID string
+		category    string
+		global      bool
+		expectedErr string
+	}{
+		{"empty name", user.ID, "   ", false, "category name cannot be empty"},
+		{"name too long", user.ID, strings.Repeat("a", MaxCategoryNameLength+1), false, "category name is too long"},
+		{"duplicate of own category", user.ID, "dev", false, "category already exists"},
+		{"same name for another user", other.ID, "Dev", false, ""},
+		{"global by non-admin", user.ID, "General", true, "only administrators can create global categories"},
+		{"global by admin", admin.ID, "Dev", true, ""},
+		{"duplicate global", admin.ID, "DEV", true, "category already exists"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.CreateCategory(tt.requesterID, tt.category, tt.global)
+			if tt.expectedErr == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.expectedErr {
+				t.Errorf("Expected error %q, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+
+	categories, err := service.GetCategories(user.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(categories) != 2 {
+		t.Fatalf("Expected the user's category and the global one, got %d", len(categories))
+	}
+}
+
+func TestChannelService_SetChannelCategory(t *testing.T) {
+	db := setupTestDB(t)
+	if err := db.AutoMigrate(&ChannelCategory{}); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+	service := NewChannelService(db, NewMemberCache(0))
+
+	owner := createTestUser(t, db, "owner")
+	other := createTestUser(t, db, "other")
+
+	channel, err := service.CreateChannel(owner.ID, "backend", nil, true, nil)
+	if err != nil {
+		t.Fatalf("Failed to create channel: %v", err)
+	}
+
+	own := ChannelCategory{Name: "Dev", OwnerID: &owner.ID}
+	foreign := ChannelCategory{Name: "Theirs", OwnerID: &other.ID}
+	global := ChannelCategory{Name: "General"}
+	for _, category := range []*ChannelCategory{&own, &foreign, &global} {
+		if err := db.Create(category).Error; err != nil {
+			t.Fatalf("Failed to create category: %v", err)
+		}
+	}
+	missing := global.ID + 100
+
+	tests := []struct {
+		name        string
+		requesterID string
+		categoryID  *uint
+		expectedErr string
+	}{
+		{"owner assigns own category", owner.ID, &own.ID, ""},
+		{"owner assigns global category", owner.ID, &global.ID, ""},
+		{"owner cannot use another user's category", owner.ID, &foreign.ID, "category not found"},
+		{"unknown category", owner.ID, &missing, "category not found"},
+		{"non-owner cannot assign", other.ID, &foreign.ID, "only channel owner can assign a category"},
+		{"owner clears the category", owner.ID, nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated, err := service.SetChannelCategory(tt.requesterID, channel.ID, tt.categoryID)
+			if tt.expectedErr != "" {
+				if err == nil || err.Error() != tt.expectedErr {
+					t.Errorf("Expected error %q, got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if (updated.CategoryID == nil) != (tt.categoryID == nil) ||
+				(tt.categoryID != nil && *updated.CategoryID != *tt.categoryID) {
+				t.Errorf("Expected category %v, got %v", tt.categoryID, updated.CategoryID)
+			}
+		})
+	}
+
+	if _, err := service.SetChannelCategory(owner.ID, "missing", &own.ID); err == nil || err.Error() != "channel not found" {
+		t.Errorf("Expected channel not found, got %v", err)
+	}
+}
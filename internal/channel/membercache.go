@@ -0,0 +1,87 @@
+package channel
+
+import (
+	"sync"
+	"time"
+)
+
+// MemberCache holds users' standing in channels for a short while, so that hot
+// paths such as posting a message do not read user_channels every time. The
+// services that write memberships, roles or bans must share the cache of the
+// services reading them, and packages that write user_channels directly
+// invalidate it through the exported methods below.
+type MemberCache struct {
+	ttl time.Duration
+
+	mu      sync.RWMutex
+	entries map[memberKey]cachedMember
+}
+
+// NewMemberCache returns a cache keeping entries for ttl. A zero TTL disables it.
+func NewMemberCache(ttl time.Duration) *MemberCache {
+	return &MemberCache{
+		ttl:     ttl,
+		entries: make(map[memberKey]cachedMember),
+	}
+}
+
+func (c *MemberCache) get(key memberKey) (cachedMember, bool) {
+	if c.ttl <= 0 {
+		return cachedMember{}, false
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	cached, ok := c.entries[key]
+	if !ok || !time.Now().Before(cached.expiresAt) {
+		return cachedMember{}, false
+	}
+	return cached, true
+}
+
+func (c *MemberCache) store(key memberKey, member cachedMember) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.entries) >= maxCachedMembers {
+		c.entries = make(map[memberKey]cachedMember)
+	}
+	member.expiresAt = time.Now().Add(c.ttl)
+	c.entries[key] = member
+}
+
+// drop removes the entries for which match returns true
+func (c *MemberCache) drop(match func(memberKey) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.entries {
+		if match(key) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// InvalidateMember drops the cached standing of the user in the channel. Every
+// path that changes membership, roles or bans must call it.
+func (c *MemberCache) InvalidateMember(userID, channelID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, memberKey{userID, channelID})
+}
+
+// InvalidateUser drops the cached standing of the user in every channel
+func (c *MemberCache) InvalidateUser(userID string) {
+	c.drop(func(key memberKey) bool { return key.userID == userID })
+}
+
+// InvalidateChannel drops every cached standing in the channel
+func (c *MemberCache) InvalidateChannel(channelID string) {
+	c.drop(func(key memberKey) bool { return key.channelID == channelID })
+}
@@ -2,33 +2,223 @@ package channel
 
 import (
 	"errors"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	a "go-chat/internal/audit"
+	"go-chat/internal/logger"
+	r "go-chat/internal/role"
 	. "go-chat/internal/utils"
 	. "go-chat/pkg/chat"
 	"gorm.io/gorm"
 )
 
+// PasswordPolicy sets the minimum strength of channel passwords
+type PasswordPolicy struct {
+	MinLength  int // Minimum length in characters
+	MinClasses int // Minimum number of character classes: lowercase, uppercase, digits, symbols
+}
+
+// DefaultPasswordPolicy is used when no override is configured
+var DefaultPasswordPolicy = PasswordPolicy{
+	MinLength:  6,
+	MinClasses: 1,
+}
+
+// PasswordPolicyFromEnv reads CHANNEL_PASSWORD_MIN_LENGTH and CHANNEL_PASSWORD_MIN_CLASSES,
+// falling back to DefaultPasswordPolicy for unset or invalid values
+func PasswordPolicyFromEnv() PasswordPolicy {
+	policy := DefaultPasswordPolicy
+	if n, err := strconv.Atoi(os.Getenv("CHANNEL_PASSWORD_MIN_LENGTH")); err == nil && n > 0 {
+		policy.MinLength = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("CHANNEL_PASSWORD_MIN_CLASSES")); err == nil && n > 0 && n <= 4 {
+		policy.MinClasses = n
+	}
+	return policy
+}
+
+// Validate rejects passwords weaker than the policy
+func (p PasswordPolicy) Validate(password string) error {
+	if err := ValidatePasswordLength(password); err != nil {
+		return err
+	}
+	if utf8.RuneCountInString(password) < p.MinLength {
+		return errors.New("channel password is too short")
+	}
+
+	var lower, upper, digit, symbol int
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			symbol = 1
+		}
+	}
+	if lower+upper+digit+symbol < p.MinClasses {
+		return errors.New("channel password is too simple")
+	}
+	return nil
+}
+
+// DefaultMemberCacheTTL bounds how long a cached membership is trusted. Changes
+// made through the service invalidate it immediately; changes made elsewhere
+// are picked up after at most this long.
+const DefaultMemberCacheTTL = 30 * time.Second
+
+// DefaultMaxOwnedChannels is how many channels one user may own
+const DefaultMaxOwnedChannels = 50
+
+// MaxOwnedChannelsFromEnv reads MAX_OWNED_CHANNELS, the number of channels a user may own.
+// "0" disables the cap; unset or invalid values fall back to DefaultMaxOwnedChannels.
+func MaxOwnedChannelsFromEnv() int {
+	max, err := strconv.Atoi(os.Getenv("MAX_OWNED_CHANNELS"))
+	if err != nil || max < 0 {
+		return DefaultMaxOwnedChannels
+	}
+	return max
+}
+
+// maxCachedMembers caps the cache size; the cache is reset when it fills up
+const maxCachedMembers = 4096
+
+// MemberCacheTTLFromEnv reads CHANNEL_MEMBER_CACHE_TTL as a duration such as "30s".
+// "0" disables the cache; unset or invalid values fall back to DefaultMemberCacheTTL.
+func MemberCacheTTLFromEnv() time.Duration {
+	ttl, err := time.ParseDuration(os.Getenv("CHANNEL_MEMBER_CACHE_TTL"))
+	if err != nil || ttl < 0 {
+		return DefaultMemberCacheTTL
+	}
+	return ttl
+}
+
+type memberKey struct {
+	userID    string
+	channelID string
+}
+
+// cachedMember is a user's standing in a channel as last read from the database
+type cachedMember struct {
+	userChannel  *UserChannel // nil when the user is not a member
+	banned       bool
+	banExpiresAt *time.Time // nil for permanent bans
+	expiresAt    time.Time
+}
+
+// isBannedAt evaluates the ban at the given time, so that temporary bans end on
+// time even while cached
+func (m cachedMember) isBannedAt(now time.Time) bool {
+	return m.banned && (m.banExpiresAt == nil || now.Before(*m.banExpiresAt))
+}
+
 type ChannelService struct {
-	db           *gorm.DB
-	auditService *a.AuditService
+	db             *gorm.DB
+	auditService   *a.AuditService
+	roleService    *r.RoleService
+	logger         *slog.Logger
+	passwordPolicy PasswordPolicy
+	maxOwned       int
+
+	members *MemberCache
+
+	attemptsMu    sync.Mutex
+	attemptPolicy PasswordAttemptPolicy
+	attempts      map[memberKey]*passwordAttempts
 }
 
-func NewChannelService(db *gorm.DB) *ChannelService {
+// NewChannelService returns a service reading memberships through members, which
+// must be shared with every other service writing them
+func NewChannelService(db *gorm.DB, members *MemberCache) *ChannelService {
 	return &ChannelService{
-		db:           db,
-		auditService: a.NewAuditService(db),
+		db:             db,
+		auditService:   a.NewAuditService(db),
+		roleService:    r.NewRoleService(db),
+		logger:         logger.Default(),
+		passwordPolicy: PasswordPolicyFromEnv(),
+		maxOwned:       MaxOwnedChannelsFromEnv(),
+		members:        members,
+		attemptPolicy:  PasswordAttemptPolicyFromEnv(),
+		attempts:       make(map[memberKey]*passwordAttempts),
 	}
 }
 
-func (s *ChannelService) CreateChannel(ownerID, name string, password *string, isVisible bool) (*Channel, error) {
+// SetPasswordPolicy overrides the channel password policy read from the environment
+func (s *ChannelService) SetPasswordPolicy(policy PasswordPolicy) {
+	s.passwordPolicy = policy
+}
+
+// SetMaxOwnedChannels overrides the per-user owned channel cap read from the environment
+func (s *ChannelService) SetMaxOwnedChannels(max int) {
+	s.maxOwned = max
+}
+
+// SetLogger replaces the logger used to report non-fatal failures
+func (s *ChannelService) SetLogger(l *slog.Logger) {
+	s.logger = l
+}
+
+// logAuditError reports a failed audit write. Audit failures never fail the
+// operation being audited.
+func (s *ChannelService) logAuditError(action, channelID string, err error) {
+	s.logger.Warn("failed to write audit log", "action", action, "channel_id", channelID, "error", err)
+}
+
+// DefaultLoggingDays is the message retention applied when none is requested
+const DefaultLoggingDays = 30
+
+// MaxLoggingDays caps how long a channel may retain message history
+const MaxLoggingDays = 365
+
+// CreateChannel creates a channel owned by ownerID. A nil loggingDays uses
+// DefaultLoggingDays; 0 disables message history.
+func (s *ChannelService) CreateChannel(ownerID, name string, password *string, isVisible bool, loggingDays *int) (*Channel, error) {
 	if name == "" {
 		return nil, errors.New("channel name cannot be empty")
 	}
 
+	retention := DefaultLoggingDays
+	if loggingDays != nil {
+		if *loggingDays < 0 || *loggingDays > MaxLoggingDays {
+			return nil, errors.New("logging days must be between 0 and 365")
+		}
+		retention = *loggingDays
+	}
+
+	// Deleted channels are soft-deleted and excluded from the count
+	if s.maxOwned > 0 {
+		var owned int64
+		if err := s.db.Model(&Channel{}).Where("owner_id = ?", ownerID).Count(&owned).Error; err != nil {
+			return nil, err
+		}
+		if owned >= int64(s.maxOwned) {
+			return nil, errors.New("you have reached the maximum number of owned channels")
+		}
+	}
+
+	var sameName int64
+	if err := s.db.Model(&Channel{}).Where("owner_id = ? AND LOWER(name) = LOWER(?)", ownerID, name).Count(&sameName).Error; err != nil {
+		return nil, err
+	}
+	if sameName > 0 {
+		return nil, errors.New("you already own a channel with this name")
+	}
+
 	var hashedPassword *string
 	if password != nil && *password != "" {
+		if err := s.passwordPolicy.Validate(*password); err != nil {
+			return nil, err
+		}
 		hash, err := HashString(*password)
 		if err != nil {
 			return nil, err
@@ -41,13 +231,17 @@ func (s *ChannelService) CreateChannel(ownerID, name string, password *string, i
 		OwnerID:     ownerID,
 		Password:    hashedPassword,
 		IsVisible:   isVisible,
-		LoggingDays: 30, // default 30 days
+		LoggingDays: uint(retention),
 	}
 
 	if err := s.db.Create(&channel).Error; err != nil {
 		return nil, err
 	}
 
+	if err := s.db.First(&channel.Owner, "id = ?", ownerID).Error; err != nil {
+		return nil, err
+	}
+
 	// Add owner as administrator of the channel
 	memberRole, err := s.getOrCreateRole("Administrator")
 	if err != nil {
@@ -63,30 +257,119 @@ func (s *ChannelService) CreateChannel(ownerID, name string, password *string, i
 	if err := s.db.Create(&userChannel).Error; err != nil {
 		return nil, err
 	}
+	s.members.InvalidateMember(ownerID, channel.ID)
 
 	// Log channel creation
 	hasPassword := password != nil && *password != ""
 	if err := s.auditService.LogChannelCreation(ownerID, channel.ID, channel.Name, isVisible, hasPassword); err != nil {
-		// Log error but don't fail the operation
-		// TODO: Add proper logging
+		s.logAuditError(a.ActionCreateChannel, channel.ID, err)
 	}
 
 	return &channel, nil
 }
 
-func (s *ChannelService) GetVisibleChannels() ([]Channel, error) {
+// ChannelListOrder keeps channel listings stable across pages
+const ChannelListOrder = "channels.name ASC, channels.id ASC"
+
+// Sort fields for channel discovery
+const (
+	SortByName           = "name"
+	SortByMembers        = "members"
+	SortByRecentActivity = "recent_activity"
+)
+
+// ChannelSort selects the order of a channel listing. The zero value sorts by name, A to Z.
+type ChannelSort struct {
+	Field      string
+	Desc       bool
+	ByCategory bool // Keep channels of a category together, categories by name and uncategorized last
+}
+
+// ParseChannelSort validates a sort field and direction ("asc" or "desc"). An empty
+// field sorts by name. An empty direction is ascending for names and descending
+// (largest or most recent first) for members and recent activity.
+func ParseChannelSort(field, direction string) (ChannelSort, error) {
+	sort := ChannelSort{Field: field}
+	switch field {
+	case "", SortByName:
+		sort.Field = SortByName
+	case SortByMembers, SortByRecentActivity:
+		sort.Desc = true
+	default:
+		return ChannelSort{}, errors.New("invalid sort field")
+	}
+
+	switch direction {
+	case "":
+	case "asc":
+		sort.Desc = false
+	case "desc":
+		sort.Desc = true
+	default:
+		return ChannelSort{}, errors.New("invalid sort direction")
+	}
+
+	return sort, nil
+}
+
+// categoryOrder sorts channels by category name, uncategorized channels last
+const categoryOrder = "(SELECT name FROM channel_categories WHERE channel_categories.id = channels.category_id) IS NULL, " +
+	"(SELECT name FROM channel_categories WHERE channel_categories.id = channels.category_id) ASC, channels.category_id ASC, "
+
+// OrderClause returns the ORDER BY expression for the sort. Ties, including channels
+// without members or messages, fall back to name order.
+func (o ChannelSort) OrderClause() string {
+	if o.ByCategory {
+		return categoryOrder + ChannelSort{Field: o.Field, Desc: o.Desc}.OrderClause()
+	}
+
+	direction := "ASC"
+	if o.Desc {
+		direction = "DESC"
+	}
+
+	switch o.Field {
+	case SortByMembers:
+		return "(SELECT COUNT(*) FROM user_channels WHERE user_channels.channel_id = channels.id AND user_channels.deleted_at IS NULL) " + direction + ", " + ChannelListOrder
+	case SortByRecentActivity:
+		return "channels.last_message_at " + direction + ", " + ChannelListOrder
+	default:
+		return "channels.name " + direction + ", channels.id " + direction
+	}
+}
+
+// GetVisibleChannels returns a page of public channels in the given order, along with the total count
+func (s *ChannelService) GetVisibleChannels(sort ChannelSort, limit, offset int) ([]Channel, int64, error) {
+	query := s.db.Model(&Channel{}).Where("is_visible = ?", true)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if sort.ByCategory {
+		query = query.Preload("Category")
+	}
+
 	var channels []Channel
-	err := s.db.Where("is_visible = ?", true).Preload("Owner").Find(&channels).Error
-	return channels, err
+	err := query.Preload("Owner").Order(sort.OrderClause()).Limit(limit).Offset(offset).Find(&channels).Error
+	return channels, total, err
 }
 
-func (s *ChannelService) GetUserChannels(userID string) ([]Channel, error) {
+// GetUserChannels returns a page of the channels the user has joined ordered by name, along with the total count
+func (s *ChannelService) GetUserChannels(userID string, limit, offset int) ([]Channel, int64, error) {
+	query := s.db.Model(&Channel{}).
+		Joins("JOIN user_channels ON channels.id = user_channels.channel_id AND user_channels.deleted_at IS NULL").
+		Where("user_channels.user_id = ?", userID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
 	var channels []Channel
-	err := s.db.Joins("JOIN user_channels ON channels.id = user_channels.channel_id").
-		Where("user_channels.user_id = ?", userID).
-		Preload("Owner").
-		Find(&channels).Error
-	return channels, err
+	err := query.Preload("Owner").Order(ChannelListOrder).Limit(limit).Offset(offset).Find(&channels).Error
+	return channels, total, err
 }
 
 func (s *ChannelService) GetChannel(channelID string) (*Channel, error) {
@@ -98,7 +381,73 @@ func (s *ChannelService) GetChannel(channelID string) (*Channel, error) {
 	return &channel, nil
 }
 
+// Membership describes how a user relates to a channel
+type Membership struct {
+	IsMember bool
+	IsOwner  bool
+	IsBanned bool
+}
+
+func (s *ChannelService) GetMembership(userID string, channel *Channel) (Membership, error) {
+	membership := Membership{IsOwner: channel.OwnerID == userID}
+
+	member, err := s.lookupMember(userID, channel.ID)
+	if err != nil {
+		return membership, err
+	}
+	membership.IsMember = member.userChannel != nil
+	membership.IsBanned = member.isBannedAt(time.Now())
+
+	return membership, nil
+}
+
+// GetMember returns the user's membership in the channel, with its role, served
+// from the membership cache. It is meant for hot paths that authorize every
+// message, so banned users and non-members are rejected here.
+func (s *ChannelService) GetMember(userID, channelID string) (*UserChannel, error) {
+	member, err := s.lookupMember(userID, channelID)
+	if err != nil {
+		return nil, err
+	}
+	if member.isBannedAt(time.Now()) {
+		return nil, errors.New("you are banned from this channel")
+	}
+	if member.userChannel == nil {
+		return nil, errors.New("you are not a member of this channel")
+	}
+
+	// Copied so that callers cannot change the cached entry
+	userChannel := *member.userChannel
+	return &userChannel, nil
+}
+
+// GetMemberRole returns the user's role in the channel, served from the
+// membership cache
+func (s *ChannelService) GetMemberRole(userID, channelID string) (*Role, error) {
+	member, err := s.lookupMember(userID, channelID)
+	if err != nil {
+		return nil, err
+	}
+	if member.userChannel == nil {
+		return nil, errors.New("you are not a member of this channel")
+	}
+
+	role := member.userChannel.Role
+	return &role, nil
+}
+
+// JoinChannel adds the user to the channel as a Member
 func (s *ChannelService) JoinChannel(userID, channelID string, password *string) error {
+	return s.joinChannel(userID, channelID, password, "Member")
+}
+
+// JoinChannelAsGuest adds the user to the channel as a Guest, who can read the
+// channel but not post. Channel owners can promote guests to Member later.
+func (s *ChannelService) JoinChannelAsGuest(userID, channelID string, password *string) error {
+	return s.joinChannel(userID, channelID, password, "Guest")
+}
+
+func (s *ChannelService) joinChannel(userID, channelID string, password *string, roleName string) error {
 	channel, err := s.GetChannel(channelID)
 	if err != nil {
 		return err
@@ -111,18 +460,36 @@ func (s *ChannelService) JoinChannel(userID, channelID string, password *string)
 		return errors.New("user already in channel")
 	}
 
+	banned, err := s.IsUserBanned(userID, channelID)
+	if err != nil {
+		return err
+	}
+	if banned {
+		return errors.New("you are banned from this channel")
+	}
+
 	// Check password if channel is password protected
 	if channel.Password != nil {
 		if password == nil || *password == "" {
 			return errors.New("password required for this channel")
 		}
-		if !VerifyHashedString(*password, *channel.Password) {
-			return errors.New("invalid password")
+		if err := s.checkPassword(userID, channel, *password); err != nil {
+			return err
+		}
+	}
+
+	// The owner is a member too, so they count toward the cap
+	if channel.MaxMembers > 0 {
+		var members int64
+		if err := s.db.Model(&UserChannel{}).Where("channel_id = ?", channelID).Count(&members).Error; err != nil {
+			return err
+		}
+		if members >= int64(channel.MaxMembers) {
+			return errors.New("channel is full")
 		}
 	}
 
-	// Get default member role
-	memberRole, err := s.getOrCreateRole("Member")
+	role, err := s.getOrCreateRole(roleName)
 	if err != nil {
 		return err
 	}
@@ -130,22 +497,42 @@ func (s *ChannelService) JoinChannel(userID, channelID string, password *string)
 	userChannel := UserChannel{
 		UserID:    userID,
 		ChannelID: channelID,
-		RoleID:    &memberRole.ID,
+		RoleID:    &role.ID,
 	}
 
 	if err := s.db.Create(&userChannel).Error; err != nil {
 		return err
 	}
+	s.members.InvalidateMember(userID, channelID)
 
 	// Log channel join
 	if err := s.auditService.LogChannelJoin(userID, channelID, channel.Name); err != nil {
-		// Log error but don't fail the operation
-		// TODO: Add proper logging
+		s.logAuditError(a.ActionJoinChannel, channelID, err)
 	}
+	s.announceMembership(userID, channel, userID, "joined")
 
 	return nil
 }
 
+// VerifyChannelPassword reports whether joining the channel would accept the
+// password, without joining. Channels without a password accept any. Wrong
+// passwords count toward the same attempt limit as joining.
+func (s *ChannelService) VerifyChannelPassword(userID, channelID, password string) (bool, error) {
+	channel, err := s.GetChannel(channelID)
+	if err != nil {
+		return false, err
+	}
+	if channel.Password == nil {
+		return true, nil
+	}
+
+	err = s.checkPassword(userID, channel, password)
+	if err != nil && err.Error() == "invalid password" {
+		return false, nil
+	}
+	return err == nil, err
+}
+
 func (s *ChannelService) LeaveChannel(userID, channelID string) error {
 	// Don't allow owner to leave their own channel
 	channel, err := s.GetChannel(channelID)
@@ -159,16 +546,103 @@ func (s *ChannelService) LeaveChannel(userID, channelID string) error {
 	if err := s.db.Where("user_id = ? AND channel_id = ?", userID, channelID).Delete(&UserChannel{}).Error; err != nil {
 		return err
 	}
+	s.members.InvalidateMember(userID, channelID)
 
 	// Log channel leave
 	if err := s.auditService.LogChannelLeave(userID, channelID, channel.Name); err != nil {
-		// Log error but don't fail the operation
-		// TODO: Add proper logging
+		s.logAuditError(a.ActionLeaveChannel, channelID, err)
 	}
+	s.announceMembership(userID, channel, userID, "left")
 
 	return nil
 }
 
+type UpdateChannelRequest struct {
+	HideOwner    *bool
+	MaxMembers   *int // 0 removes the cap; lowering it does not remove existing members
+	AllowPreview *bool
+	Password     *string // Empty removes the password
+
+	AnnounceMembership *bool
+	Description        *string // Empty clears it
+}
+
+// MaxDescriptionLength bounds channel descriptions, in characters
+const MaxDescriptionLength = 500
+
+// MaxTopicLength bounds channel topics, in characters
+const MaxTopicLength = 200
+
+// UpdateChannel applies the owner's changes to the channel settings. Only
+// non-nil fields are updated.
+func (s *ChannelService) UpdateChannel(requesterID, channelID string, req UpdateChannelRequest) (*Channel, error) {
+	channel, err := s.GetChannel(channelID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("channel not found")
+		}
+		return nil, err
+	}
+
+	if channel.OwnerID != requesterID {
+		return nil, errors.New("only channel owner can update channel")
+	}
+
+	updates := make(map[string]interface{})
+
+	if req.HideOwner != nil {
+		updates["hide_owner"] = *req.HideOwner
+	}
+
+	if req.MaxMembers != nil {
+		if *req.MaxMembers < 0 {
+			return nil, errors.New("max members cannot be negative")
+		}
+		updates["max_members"] = *req.MaxMembers
+	}
+
+	if req.AllowPreview != nil {
+		updates["allow_preview"] = *req.AllowPreview
+	}
+
+	if req.AnnounceMembership != nil {
+		updates["announce_membership"] = *req.AnnounceMembership
+	}
+
+	if req.Description != nil {
+		description := strings.TrimSpace(*req.Description)
+		if utf8.RuneCountInString(description) > MaxDescriptionLength {
+			return nil, errors.New("description is too long")
+		}
+		updates["description"] = description
+	}
+
+	if req.Password != nil {
+		if *req.Password == "" {
+			updates["password"] = nil
+		} else {
+			if err := s.passwordPolicy.Validate(*req.Password); err != nil {
+				return nil, err
+			}
+			hash, err := HashString(*req.Password)
+			if err != nil {
+				return nil, err
+			}
+			updates["password"] = hash
+		}
+	}
+
+	if len(updates) == 0 {
+		return channel, nil
+	}
+
+	if err := s.db.Model(&Channel{}).Where("id = ?", channel.ID).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+
+	return s.GetChannel(channelID)
+}
+
 func (s *ChannelService) DeleteChannel(userID, channelID string) error {
 	channel, err := s.GetChannel(channelID)
 	if err != nil {
@@ -190,11 +664,11 @@ func (s *ChannelService) DeleteChannel(userID, channelID string) error {
 	if err := s.db.Delete(&Channel{}, "id = ?", channelID).Error; err != nil {
 		return err
 	}
+	s.members.InvalidateChannel(channelID)
 
 	// Log channel deletion
 	if err := s.auditService.LogChannelDeletion(userID, channelID, channelName); err != nil {
-		// Log error but don't fail the operation
-		// TODO: Add proper logging
+		s.logAuditError(a.ActionDeleteChannel, channelID, err)
 	}
 
 	return nil
@@ -202,49 +676,56 @@ func (s *ChannelService) DeleteChannel(userID, channelID string) error {
 
 func (s *ChannelService) GetChannelUsers(channelID string) ([]User, error) {
 	var users []User
-	err := s.db.Joins("JOIN user_channels ON users.id = user_channels.user_id").
+	// The join bypasses the soft-delete scope of user_channels, so memberships
+	// ended by leaving or a ban are filtered out explicitly
+	err := s.db.Joins("JOIN user_channels ON users.id = user_channels.user_id AND user_channels.deleted_at IS NULL").
 		Where("user_channels.channel_id = ?", channelID).
 		Find(&users).Error
 	return users, err
 }
 
-func (s *ChannelService) BanUser(adminID, userID, channelID, reason string) error {
+// BanUser permanently bans the user from the channel and returns the created ban
+func (s *ChannelService) BanUser(adminID, userID, channelID, reason string) (*UserBan, error) {
 	// Check if admin is the channel owner or has admin privileges
 	channel, err := s.GetChannel(channelID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if channel.OwnerID != adminID {
-		// TODO: Add role-based permission check for administrators/moderators
-		return errors.New("only channel owner can ban users")
+	if !s.canModerate(adminID, channel) {
+		return nil, errors.New("only channel owners and moderators can ban users")
 	}
 
 	// Cannot ban yourself
 	if adminID == userID {
-		return errors.New("cannot ban yourself")
+		return nil, errors.New("cannot ban yourself")
 	}
 
 	// Cannot ban the channel owner
 	if channel.OwnerID == userID {
-		return errors.New("cannot ban channel owner")
+		return nil, errors.New("cannot ban channel owner")
+	}
+
+	// Check if user is already banned. Banning removes the membership, so
+	// this comes before the membership check.
+	var existingBan UserBan
+	err = s.db.Where("user_id = ? AND channel_id = ? AND is_active = ?", userID, channelID, true).First(&existingBan).Error
+	if err == nil {
+		return nil, errors.New("user is already banned")
 	}
 
 	// Check if user is in the channel
 	var userChannel UserChannel
-	err = s.db.Where("user_id = ? AND channel_id = ?", userID, channelID).First(&userChannel).Error
+	err = s.db.Preload("Role").Where("user_id = ? AND channel_id = ?", userID, channelID).First(&userChannel).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("user is not in this channel")
+			return nil, errors.New("user is not in this channel")
 		}
-		return err
+		return nil, err
 	}
 
-	// Check if user is already banned
-	var existingBan UserBan
-	err = s.db.Where("user_id = ? AND channel_id = ? AND is_active = ?", userID, channelID, true).First(&existingBan).Error
-	if err == nil {
-		return errors.New("user is already banned")
+	if !s.outranks(adminID, channel, &userChannel) {
+		return nil, errors.New("cannot ban a member with an equal or higher role")
 	}
 
 	// Create ban record
@@ -258,60 +739,70 @@ func (s *ChannelService) BanUser(adminID, userID, channelID, reason string) erro
 	}
 
 	if err := s.db.Create(&ban).Error; err != nil {
-		return err
+		if isUniqueViolation(err) {
+			// A concurrent request banned the user since the check above
+			return nil, errors.New("user is already banned")
+		}
+		return nil, err
 	}
 
 	// Remove user from channel
 	if err := s.db.Delete(&userChannel).Error; err != nil {
-		return err
+		return nil, err
 	}
+	s.members.InvalidateMember(userID, channelID)
 
 	// Log user ban
 	if err := s.auditService.LogUserBan(adminID, userID, channelID, reason, false, nil); err != nil {
-		// Log error but don't fail the operation
-		// TODO: Add proper logging
+		s.logAuditError(a.ActionBanUser, channelID, err)
 	}
+	s.announceMembership(adminID, channel, userID, "was banned")
 
-	return nil
+	return s.loadBan(ban.ID)
 }
 
-func (s *ChannelService) TempBanUser(adminID, userID, channelID, reason string, duration time.Duration) error {
+// TempBanUser bans the user from the channel for duration and returns the created ban
+func (s *ChannelService) TempBanUser(adminID, userID, channelID, reason string, duration time.Duration) (*UserBan, error) {
 	// Check if admin is the channel owner or has admin privileges
 	channel, err := s.GetChannel(channelID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if channel.OwnerID != adminID {
-		// TODO: Add role-based permission check for administrators/moderators
-		return errors.New("only channel owner can ban users")
+	if !s.canModerate(adminID, channel) {
+		return nil, errors.New("only channel owners and moderators can ban users")
 	}
 
 	// Cannot ban yourself
 	if adminID == userID {
-		return errors.New("cannot ban yourself")
+		return nil, errors.New("cannot ban yourself")
 	}
 
 	// Cannot ban the channel owner
 	if channel.OwnerID == userID {
-		return errors.New("cannot ban channel owner")
+		return nil, errors.New("cannot ban channel owner")
+	}
+
+	// Check if user is already banned. Banning removes the membership, so
+	// this comes before the membership check.
+	var existingBan UserBan
+	err = s.db.Where("user_id = ? AND channel_id = ? AND is_active = ?", userID, channelID, true).First(&existingBan).Error
+	if err == nil {
+		return nil, errors.New("user is already banned")
 	}
 
 	// Check if user is in the channel
 	var userChannel UserChannel
-	err = s.db.Where("user_id = ? AND channel_id = ?", userID, channelID).First(&userChannel).Error
+	err = s.db.Preload("Role").Where("user_id = ? AND channel_id = ?", userID, channelID).First(&userChannel).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("user is not in this channel")
+			return nil, errors.New("user is not in this channel")
 		}
-		return err
+		return nil, err
 	}
 
-	// Check if user is already banned
-	var existingBan UserBan
-	err = s.db.Where("user_id = ? AND channel_id = ? AND is_active = ?", userID, channelID, true).First(&existingBan).Error
-	if err == nil {
-		return errors.New("user is already banned")
+	if !s.outranks(adminID, channel, &userChannel) {
+		return nil, errors.New("cannot ban a member with an equal or higher role")
 	}
 
 	// Create temporary ban record
@@ -326,21 +817,26 @@ func (s *ChannelService) TempBanUser(adminID, userID, channelID, reason string,
 	}
 
 	if err := s.db.Create(&ban).Error; err != nil {
-		return err
+		if isUniqueViolation(err) {
+			// A concurrent request banned the user since the check above
+			return nil, errors.New("user is already banned")
+		}
+		return nil, err
 	}
 
 	// Remove user from channel
 	if err := s.db.Delete(&userChannel).Error; err != nil {
-		return err
+		return nil, err
 	}
+	s.members.InvalidateMember(userID, channelID)
 
 	// Log temporary user ban
 	if err := s.auditService.LogUserBan(adminID, userID, channelID, reason, true, &expiresAt); err != nil {
-		// Log error but don't fail the operation
-		// TODO: Add proper logging
+		s.logAuditError(a.ActionTempBanUser, channelID, err)
 	}
+	s.announceMembership(adminID, channel, userID, "was banned")
 
-	return nil
+	return s.loadBan(ban.ID)
 }
 
 func (s *ChannelService) UnbanUser(adminID, userID, channelID string) error {
@@ -370,11 +866,11 @@ func (s *ChannelService) UnbanUser(adminID, userID, channelID string) error {
 	if err := s.db.Save(&ban).Error; err != nil {
 		return err
 	}
+	s.members.InvalidateMember(userID, channelID)
 
 	// Log user unban
 	if err := s.auditService.LogUserUnban(adminID, userID, channelID); err != nil {
-		// Log error but don't fail the operation
-		// TODO: Add proper logging
+		s.logAuditError(a.ActionUnbanUser, channelID, err)
 	}
 
 	return nil
@@ -401,6 +897,35 @@ func (s *ChannelService) IsUserBanned(userID, channelID string) (bool, error) {
 	return true, nil
 }
 
+// GetUserBan returns the active ban of a user in the channel, for owners and moderators
+func (s *ChannelService) GetUserBan(requesterID, channelID, userID string) (*UserBan, error) {
+	channel, err := s.GetChannel(channelID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("channel not found")
+		}
+		return nil, err
+	}
+
+	if !s.canModerate(requesterID, channel) {
+		return nil, errors.New("only channel owners and moderators can view bans")
+	}
+
+	var ban UserBan
+	err = s.db.Preload("User").Preload("BannedByUser").
+		Where("user_id = ? AND channel_id = ? AND is_active = ?", userID, channelID, true).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now()).
+		First(&ban).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("user is not banned")
+		}
+		return nil, err
+	}
+
+	return &ban, nil
+}
+
 func (s *ChannelService) GetChannelBans(adminID, channelID string) ([]UserBan, error) {
 	// Check if admin is the channel owner or has admin privileges
 	channel, err := s.GetChannel(channelID)
@@ -418,7 +943,12 @@ func (s *ChannelService) GetChannelBans(adminID, channelID string) ([]UserBan, e
 	return bans, err
 }
 
-func (s *ChannelService) PromoteUser(requesterID, channelID, targetUserID, roleName string) error {
+// PromoteUser changes the member's role in the channel, recording the optional reason in the audit log
+func (s *ChannelService) PromoteUser(requesterID, channelID, targetUserID, roleName, reason string) error {
+	if !IsValidRole(roleName) {
+		return errors.New("invalid role")
+	}
+
 	// Check if channel exists
 	var channel Channel
 	if err := s.db.First(&channel, "id = ?", channelID).Error; err != nil {
@@ -449,27 +979,32 @@ func (s *ChannelService) PromoteUser(requesterID, channelID, targetUserID, roleN
 	}
 
 	// Get the target role
-	role, err := s.getOrCreateRole(roleName)
+	role, err := s.roleService.FindRole(roleName)
 	if err != nil {
 		return err
 	}
 
-	// Update user role
-	userChannel.RoleID = &role.ID
-	if err := s.db.Save(&userChannel).Error; err != nil {
+	// Update through a bare model: with the loaded record GORM would also save the
+	// preloaded Role association and write its old ID back to role_id
+	if err := s.db.Model(&UserChannel{}).Where("id = ?", userChannel.ID).Update("role_id", role.ID).Error; err != nil {
 		return err
 	}
+	s.members.InvalidateMember(targetUserID, channelID)
 
 	// Log user promotion
-	if err := s.auditService.LogUserRoleChange(requesterID, targetUserID, channelID, oldRoleName, roleName, true); err != nil {
-		// Log error but don't fail the operation
-		// TODO: Add proper logging
+	if err := s.auditService.LogUserRoleChange(requesterID, targetUserID, channelID, oldRoleName, roleName, reason, true); err != nil {
+		s.logAuditError(a.ActionPromoteUser, channelID, err)
 	}
 
 	return nil
 }
 
-func (s *ChannelService) DemoteUser(requesterID, channelID, targetUserID, roleName string) error {
+// DemoteUser changes the member's role in the channel, recording the optional reason in the audit log
+func (s *ChannelService) DemoteUser(requesterID, channelID, targetUserID, roleName, reason string) error {
+	if !IsValidRole(roleName) {
+		return errors.New("invalid role")
+	}
+
 	// Check if channel exists
 	var channel Channel
 	if err := s.db.First(&channel, "id = ?", channelID).Error; err != nil {
@@ -500,32 +1035,379 @@ func (s *ChannelService) DemoteUser(requesterID, channelID, targetUserID, roleNa
 	}
 
 	// Get the target role
-	role, err := s.getOrCreateRole(roleName)
+	role, err := s.roleService.FindRole(roleName)
 	if err != nil {
 		return err
 	}
 
-	// Update user role
-	userChannel.RoleID = &role.ID
-	if err := s.db.Save(&userChannel).Error; err != nil {
+	// Update through a bare model: with the loaded record GORM would also save the
+	// preloaded Role association and write its old ID back to role_id
+	if err := s.db.Model(&UserChannel{}).Where("id = ?", userChannel.ID).Update("role_id", role.ID).Error; err != nil {
 		return err
 	}
+	s.members.InvalidateMember(targetUserID, channelID)
 
 	// Log user demotion
-	if err := s.auditService.LogUserRoleChange(requesterID, targetUserID, channelID, oldRoleName, roleName, false); err != nil {
-		// Log error but don't fail the operation
-		// TODO: Add proper logging
+	if err := s.auditService.LogUserRoleChange(requesterID, targetUserID, channelID, oldRoleName, roleName, reason, false); err != nil {
+		s.logAuditError(a.ActionDemoteUser, channelID, err)
 	}
 
 	return nil
 }
 
+// LockChannel pauses the channel so that only the owner and moderators can post. A zero
+// duration locks the channel until it is explicitly unlocked. A system message announcing
+// the lock is posted to the channel.
+func (s *ChannelService) LockChannel(requesterID, channelID string, duration time.Duration) (*Channel, *Message, error) {
+	if duration < 0 {
+		return nil, nil, errors.New("invalid lock duration")
+	}
+
+	channel, err := s.GetChannel(channelID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, errors.New("channel not found")
+		}
+		return nil, nil, err
+	}
+
+	if !s.canModerate(requesterID, channel) {
+		return nil, nil, errors.New("only channel owners and moderators can lock the channel")
+	}
+
+	now := time.Now()
+	var lockedUntil *time.Time
+	content := "Channel locked by a moderator"
+	if duration > 0 {
+		until := now.Add(duration)
+		lockedUntil = &until
+		content += " for " + duration.String()
+	}
+
+	if err := s.db.Model(&Channel{}).Where("id = ?", channel.ID).Updates(map[string]interface{}{
+		"locked_at":    now,
+		"locked_until": lockedUntil,
+	}).Error; err != nil {
+		return nil, nil, err
+	}
+	channel.LockedAt = &now
+	channel.LockedUntil = lockedUntil
+
+	message, err := s.postSystemMessage(requesterID, channel, content)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Log channel lock
+	if err := s.auditService.LogChannelLock(requesterID, channelID, lockedUntil); err != nil {
+		s.logAuditError(a.ActionLockChannel, channelID, err)
+	}
+
+	return channel, message, nil
+}
+
+// UnlockChannel lifts a channel lock and posts a system message announcing it
+func (s *ChannelService) UnlockChannel(requesterID, channelID string) (*Channel, *Message, error) {
+	channel, err := s.GetChannel(channelID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, errors.New("channel not found")
+		}
+		return nil, nil, err
+	}
+
+	if !s.canModerate(requesterID, channel) {
+		return nil, nil, errors.New("only channel owners and moderators can unlock the channel")
+	}
+
+	if !channel.IsLockedAt(time.Now()) {
+		return nil, nil, errors.New("channel is not locked")
+	}
+
+	if err := s.db.Model(&Channel{}).Where("id = ?", channel.ID).Updates(map[string]interface{}{
+		"locked_at":    nil,
+		"locked_until": nil,
+	}).Error; err != nil {
+		return nil, nil, err
+	}
+	channel.LockedAt = nil
+	channel.LockedUntil = nil
+
+	message, err := s.postSystemMessage(requesterID, channel, "Channel unlocked")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Log channel unlock
+	if err := s.auditService.LogChannelUnlock(requesterID, channelID); err != nil {
+		s.logAuditError(a.ActionUnlockChannel, channelID, err)
+	}
+
+	return channel, message, nil
+}
+
+// SetChannelTopic changes the current topic of the channel, for its owner and
+// moderators. An empty topic clears it. A system message announcing the new
+// topic is posted to the channel.
+func (s *ChannelService) SetChannelTopic(requesterID, channelID, topic string) (*Channel, *Message, error) {
+	topic = strings.TrimSpace(topic)
+	if utf8.RuneCountInString(topic) > MaxTopicLength {
+		return nil, nil, errors.New("topic is too long")
+	}
+
+	channel, err := s.GetChannel(channelID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, errors.New("channel not found")
+		}
+		return nil, nil, err
+	}
+
+	if !s.canModerate(requesterID, channel) {
+		return nil, nil, errors.New("only channel owners and moderators can set the topic")
+	}
+
+	if err := s.db.Model(&Channel{}).Where("id = ?", channel.ID).Update("topic", topic).Error; err != nil {
+		return nil, nil, err
+	}
+	channel.Topic = topic
+
+	content := "Topic cleared"
+	if topic != "" {
+		content = "Topic changed to: " + topic
+	}
+	message, err := s.postSystemMessage(requesterID, channel, content)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return channel, message, nil
+}
+
+// ModerationStats summarises moderation activity in a channel over a time window
+type ModerationStats struct {
+	From             time.Time
+	To               time.Time
+	Bans             int64 // permanent bans issued in the window
+	TempBans         int64 // temporary bans issued in the window
+	Unbans           int64
+	ActiveBans       int64 // bans in effect at the end of the window
+	Promotions       int64
+	Demotions        int64
+	Locks            int64
+	ScrubbedMessages int64
+}
+
+// GetModerationStats counts moderation events recorded in the audit log between
+// from and to (inclusive), along with the bans still in effect at to
+func (s *ChannelService) GetModerationStats(requesterID, channelID string, from, to time.Time) (*ModerationStats, error) {
+	if to.Before(from) {
+		return nil, errors.New("invalid time range")
+	}
+
+	channel, err := s.GetChannel(channelID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("channel not found")
+		}
+		return nil, err
+	}
+
+	if !s.canModerate(requesterID, channel) {
+		return nil, errors.New("only channel owners and moderators can view moderation stats")
+	}
+
+	var counts []struct {
+		Action string
+		Count  int64
+	}
+	if err := s.db.Model(&AuditLog{}).
+		Select("action, COUNT(*) AS count").
+		Where("channel_id = ? AND created_at BETWEEN ? AND ?", channelID, from, to).
+		Group("action").
+		Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+
+	stats := &ModerationStats{From: from, To: to}
+	for _, c := range counts {
+		switch c.Action {
+		case a.ActionBanUser:
+			stats.Bans = c.Count
+		case a.ActionTempBanUser:
+			stats.TempBans = c.Count
+		case a.ActionUnbanUser:
+			stats.Unbans = c.Count
+		case a.ActionPromoteUser:
+			stats.Promotions = c.Count
+		case a.ActionDemoteUser:
+			stats.Demotions = c.Count
+		case a.ActionLockChannel:
+			stats.Locks = c.Count
+		case a.ActionScrubMessage:
+			stats.ScrubbedMessages = c.Count
+		}
+	}
+
+	if err := s.db.Model(&UserBan{}).
+		Where("channel_id = ? AND is_active = ? AND created_at <= ?", channelID, true, to).
+		Where("expires_at IS NULL OR expires_at > ?", to).
+		Count(&stats.ActiveBans).Error; err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// IsValidRole reports whether name is one of the DefaultRoles
+func IsValidRole(name string) bool {
+	for _, role := range DefaultRoles {
+		if role == name {
+			return true
+		}
+	}
+	return false
+}
+
+// canModerate reports whether the user owns the channel or holds a moderating role in it
+func (s *ChannelService) canModerate(userID string, channel *Channel) bool {
+	if channel.OwnerID == userID {
+		return true
+	}
+
+	member, err := s.lookupMember(userID, channel.ID)
+	if err != nil || member.userChannel == nil {
+		return false
+	}
+	return member.userChannel.IsModerator()
+}
+
+// outranks reports whether the user may act on the target member. The owner
+// outranks everyone; other members need a higher role priority.
+func (s *ChannelService) outranks(userID string, channel *Channel, target *UserChannel) bool {
+	if channel.OwnerID == userID {
+		return true
+	}
+
+	member, err := s.lookupMember(userID, channel.ID)
+	if err != nil || member.userChannel == nil {
+		return false
+	}
+	return s.roleService.CanActOn(member.userChannel.Role, target.Role)
+}
+
+// lookupMember returns the user's standing in the channel, from the cache when
+// a fresh entry exists
+func (s *ChannelService) lookupMember(userID, channelID string) (cachedMember, error) {
+	key := memberKey{userID, channelID}
+
+	if cached, ok := s.members.get(key); ok {
+		return cached, nil
+	}
+
+	var member cachedMember
+
+	var userChannel UserChannel
+	err := s.db.Preload("Role").Where("user_id = ? AND channel_id = ?", userID, channelID).First(&userChannel).Error
+	if err == nil {
+		member.userChannel = &userChannel
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return member, err
+	}
+
+	var ban UserBan
+	err = s.db.Where("user_id = ? AND channel_id = ? AND is_active = ?", userID, channelID, true).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now()).
+		First(&ban).Error
+	if err == nil {
+		member.banned = true
+		member.banExpiresAt = ban.ExpiresAt
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return member, err
+	}
+
+	s.members.store(key, member)
+
+	return member, nil
+}
+
+// postSystemMessage records a server-generated message in the channel. Like regular
+// messages it is only persisted when the channel keeps history.
+func (s *ChannelService) postSystemMessage(actorID string, channel *Channel, content string) (*Message, error) {
+	message := Message{
+		Content:   content,
+		UserID:    actorID,
+		ChannelID: channel.ID,
+		IsSystem:  true,
+		Format:    MessageFormatPlain,
+	}
+
+	if channel.LoggingDays == 0 {
+		seq, err := NextMessageSeq(s.db, channel.ID)
+		if err != nil {
+			return nil, err
+		}
+		message.Seq = seq
+		message.CreatedAt = time.Now()
+		message.UpdatedAt = message.CreatedAt
+		if err := RecordChannelActivity(s.db, channel.ID, message.CreatedAt); err != nil {
+			return nil, err
+		}
+		return &message, nil
+	}
+
+	if err := s.db.Create(&message).Error; err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+// announceMembership posts "<username> <event>" as a system message in channels
+// that announce membership changes. The change itself has already happened,
+// so failures are only logged.
+func (s *ChannelService) announceMembership(actorID string, channel *Channel, userID, event string) {
+	if !channel.AnnounceMembership {
+		return
+	}
+
+	var user User
+	if err := s.db.Select("id", "username").First(&user, "id = ?", userID).Error; err != nil {
+		s.logger.Warn("failed to announce membership change", "channel_id", channel.ID, "error", err)
+		return
+	}
+	if _, err := s.postSystemMessage(actorID, channel, user.Username+" "+event); err != nil {
+		s.logger.Warn("failed to announce membership change", "channel_id", channel.ID, "error", err)
+	}
+}
+
+// loadBan returns the ban with its user and banner loaded
+func (s *ChannelService) loadBan(banID uint) (*UserBan, error) {
+	var ban UserBan
+	if err := s.db.Preload("User").Preload("BannedByUser").First(&ban, banID).Error; err != nil {
+		return nil, err
+	}
+	return &ban, nil
+}
+
+// isUniqueViolation reports whether err was raised by a unique index, as
+// reported by SQLite and Postgres when gorm does not translate errors
+func isUniqueViolation(err error) bool {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return true
+	}
+	message := err.Error()
+	return strings.Contains(message, "UNIQUE constraint failed") || strings.Contains(message, "SQLSTATE 23505")
+}
+
+// getOrCreateRole backs the fixed roles granted on create and join, so that a
+// database that was never seeded still works. It must only be called with one
+// of the DefaultRoles.
 func (s *ChannelService) getOrCreateRole(roleName string) (*Role, error) {
 	var role Role
 	err := s.db.Where("name = ?", roleName).First(&role).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			role = Role{Name: roleName}
+			role = Role{Name: roleName, Priority: RolePriorities[roleName]}
 			if err := s.db.Create(&role).Error; err != nil {
 				return nil, err
 			}
@@ -1,9 +1,17 @@
 package channel
 
 import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	a "go-chat/internal/audit"
+	"go-chat/internal/logger"
+	. "go-chat/internal/utils"
 	. "go-chat/pkg/chat"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
@@ -21,14 +29,8 @@ func setupTestDB(t *testing.T) *gorm.DB {
 	}
 
 	// Create default roles
-	roles := []Role{
-		{Name: "Administrator"},
-		{Name: "Moderator"},
-		{Name: "Member"},
-		{Name: "Guest"},
-	}
-	for _, role := range roles {
-		db.Create(&role)
+	for _, name := range DefaultRoles {
+		db.Create(&Role{Name: name, Priority: RolePriorities[name]})
 	}
 
 	return db
@@ -44,7 +46,7 @@ func createTestUser(t *testing.T, db *gorm.DB, username string) *User {
 
 func TestChannelService_CreateChannel(t *testing.T) {
 	db := setupTestDB(t)
-	service := NewChannelService(db)
+	service := NewChannelService(db, NewMemberCache(0))
 	user := createTestUser(t, db, "testuser")
 
 	tests := []struct {
@@ -85,7 +87,7 @@ func TestChannelService_CreateChannel(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			channel, err := service.CreateChannel(tt.ownerID, tt.channelName, tt.password, tt.isVisible)
+			channel, err := service.CreateChannel(tt.ownerID, tt.channelName, tt.password, tt.isVisible, nil)
 
 			if tt.expectError {
 				if err == nil {
@@ -134,28 +136,192 @@ func TestChannelService_CreateChannel(t *testing.T) {
 	}
 }
 
+func TestChannelService_CreateChannel_DuplicateName(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewChannelService(db, NewMemberCache(0))
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+
+	if _, err := service.CreateChannel(alice.ID, "General", nil, true, nil); err != nil {
+		t.Fatalf("Failed to create channel: %v", err)
+	}
+
+	_, err := service.CreateChannel(alice.ID, "general", nil, false, nil)
+	if err == nil || err.Error() != "you already own a channel with this name" {
+		t.Errorf("Expected 'you already own a channel with this name', got %v", err)
+	}
+
+	if _, err := service.CreateChannel(bob.ID, "General", nil, true, nil); err != nil {
+		t.Errorf("Another owner should be able to use the same name: %v", err)
+	}
+
+	var count int64
+	db.Model(&Channel{}).Where("LOWER(name) = ?", "general").Count(&count)
+	if count != 2 {
+		t.Errorf("Expected 2 channels named 'general', got %d", count)
+	}
+}
+
+func TestChannelService_CreateChannel_MaxOwned(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewChannelService(db, NewMemberCache(0))
+	service.SetMaxOwnedChannels(3)
+	owner := createTestUser(t, db, "hoarder")
+	other := createTestUser(t, db, "other")
+
+	var channels []*Channel
+	for _, name := range []string{"one", "two", "three"} {
+		channel, err := service.CreateChannel(owner.ID, name, nil, true, nil)
+		if err != nil {
+			t.Fatalf("Failed to create channel %s within the cap: %v", name, err)
+		}
+		channels = append(channels, channel)
+	}
+
+	_, err := service.CreateChannel(owner.ID, "four", nil, true, nil)
+	if err == nil || err.Error() != "you have reached the maximum number of owned channels" {
+		t.Fatalf("Expected 'you have reached the maximum number of owned channels', got %v", err)
+	}
+
+	// The cap is per owner
+	if _, err := service.CreateChannel(other.ID, "four", nil, true, nil); err != nil {
+		t.Errorf("Another user should be able to create a channel: %v", err)
+	}
+
+	// Deleted channels no longer count
+	if err := service.DeleteChannel(owner.ID, channels[0].ID); err != nil {
+		t.Fatalf("Failed to delete channel: %v", err)
+	}
+	if _, err := service.CreateChannel(owner.ID, "four", nil, true, nil); err != nil {
+		t.Errorf("Expected deleting a channel to free a slot, got %v", err)
+	}
+
+	service.SetMaxOwnedChannels(0)
+	if _, err := service.CreateChannel(owner.ID, "five", nil, true, nil); err != nil {
+		t.Errorf("Expected no cap when disabled, got %v", err)
+	}
+}
+
+func TestMaxOwnedChannelsFromEnv(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected int
+	}{
+		{"", DefaultMaxOwnedChannels},
+		{"5", 5},
+		{"0", 0},
+		{"-1", DefaultMaxOwnedChannels},
+		{"many", DefaultMaxOwnedChannels},
+	}
+	for _, tt := range tests {
+		t.Setenv("MAX_OWNED_CHANNELS", tt.value)
+		if got := MaxOwnedChannelsFromEnv(); got != tt.expected {
+			t.Errorf("MAX_OWNED_CHANNELS=%q: expected %d, got %d", tt.value, tt.expected, got)
+		}
+	}
+}
+
+func TestChannelService_PasswordPolicy(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewChannelService(db, NewMemberCache(0))
+	service.SetPasswordPolicy(PasswordPolicy{MinLength: 8, MinClasses: 2})
+	owner := createTestUser(t, db, "owner")
+
+	tests := []struct {
+		name     string
+		password string
+		errorMsg string
+	}{
+		{"too short", "abc12", "channel password is too short"},
+		{"single character class", "abcdefgh", "channel password is too simple"},
+		{"letters and digits", "abcd1234", ""},
+		{"letters and symbols", "abcd-efg!", ""},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.CreateChannel(owner.ID, fmt.Sprintf("locked-%d", i), stringPtr(tt.password), true, nil)
+			if tt.errorMsg == "" {
+				if err != nil {
+					t.Errorf("Expected password to be accepted, got %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.errorMsg {
+				t.Errorf("Expected '%s', got %v", tt.errorMsg, err)
+			}
+		})
+	}
+
+	t.Run("password change", func(t *testing.T) {
+		channel, err := service.CreateChannel(owner.ID, "open", nil, true, nil)
+		if err != nil {
+			t.Fatalf("Failed to create channel: %v", err)
+		}
+
+		_, err = service.UpdateChannel(owner.ID, channel.ID, UpdateChannelRequest{Password: stringPtr("short1")})
+		if err == nil || err.Error() != "channel password is too short" {
+			t.Errorf("Expected 'channel password is too short', got %v", err)
+		}
+
+		updated, err := service.UpdateChannel(owner.ID, channel.ID, UpdateChannelRequest{Password: stringPtr("longer123")})
+		if err != nil {
+			t.Fatalf("Expected password change to succeed: %v", err)
+		}
+		if updated.Password == nil || !VerifyHashedString("longer123", *updated.Password) {
+			t.Error("Expected channel password to be set")
+		}
+
+		removed, err := service.UpdateChannel(owner.ID, channel.ID, UpdateChannelRequest{Password: stringPtr("")})
+		if err != nil {
+			t.Fatalf("Expected password removal to succeed: %v", err)
+		}
+		if removed.Password != nil {
+			t.Error("Expected channel password to be removed")
+		}
+	})
+}
+
+func TestPasswordPolicyFromEnv(t *testing.T) {
+	t.Setenv("CHANNEL_PASSWORD_MIN_LENGTH", "12")
+	t.Setenv("CHANNEL_PASSWORD_MIN_CLASSES", "3")
+	if policy := PasswordPolicyFromEnv(); policy != (PasswordPolicy{MinLength: 12, MinClasses: 3}) {
+		t.Errorf("Expected configured policy, got %+v", policy)
+	}
+
+	t.Setenv("CHANNEL_PASSWORD_MIN_LENGTH", "-1")
+	t.Setenv("CHANNEL_PASSWORD_MIN_CLASSES", "5")
+	if policy := PasswordPolicyFromEnv(); policy != DefaultPasswordPolicy {
+		t.Errorf("Expected default policy for invalid values, got %+v", policy)
+	}
+}
+
 func TestChannelService_GetVisibleChannels(t *testing.T) {
 	db := setupTestDB(t)
-	service := NewChannelService(db)
+	service := NewChannelService(db, NewMemberCache(0))
 	user := createTestUser(t, db, "testuser")
 
 	// Create visible and invisible channels
-	visibleChannel, err := service.CreateChannel(user.ID, "visible", nil, true)
+	visibleChannel, err := service.CreateChannel(user.ID, "visible", nil, true, nil)
 	if err != nil {
 		t.Fatalf("Failed to create visible channel: %v", err)
 	}
 
-	_, err = service.CreateChannel(user.ID, "invisible", nil, false)
+	_, err = service.CreateChannel(user.ID, "invisible", nil, false, nil)
 	if err != nil {
 		t.Fatalf("Failed to create invisible channel: %v", err)
 	}
 
-	channels, err := service.GetVisibleChannels()
+	channels, total, err := service.GetVisibleChannels(ChannelSort{}, 20, 0)
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
 		return
 	}
 
+	if total != 1 {
+		t.Errorf("Expected total 1, got %d", total)
+	}
+
 	if len(channels) != 1 {
 		t.Errorf("Expected 1 visible channel, got %d", len(channels))
 		return
@@ -166,24 +332,101 @@ func TestChannelService_GetVisibleChannels(t *testing.T) {
 	}
 }
 
+func TestChannelService_GetVisibleChannels_Sort(t *testing.T) {
+	db := setupTestDB(t)
+	if err := db.AutoMigrate(&Message{}); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+	service := NewChannelService(db, NewMemberCache(0))
+	owner := createTestUser(t, db, "owner")
+
+	// alpha: 1 member, busy: 3 members, crowded: 4 members
+	members := map[string]int{"alpha": 0, "busy": 2, "crowded": 3}
+	channels := map[string]*Channel{}
+	for _, name := range []string{"alpha", "busy", "crowded"} {
+		channel, err := service.CreateChannel(owner.ID, name, nil, true, nil)
+		if err != nil {
+			t.Fatalf("Failed to create channel: %v", err)
+		}
+		channels[name] = channel
+		for i := 0; i < members[name]; i++ {
+			user := createTestUser(t, db, fmt.Sprintf("%s-member-%d", name, i))
+			if err := service.JoinChannel(user.ID, channel.ID, nil); err != nil {
+				t.Fatalf("Failed to join channel: %v", err)
+			}
+		}
+	}
+
+	// alpha has the latest message, crowded never had one
+	now := time.Now()
+	for name, at := range map[string]time.Time{"busy": now.Add(-time.Hour), "alpha": now} {
+		message := Message{Content: "hi", UserID: owner.ID, ChannelID: channels[name].ID}
+		message.CreatedAt = at
+		if err := db.Create(&message).Error; err != nil {
+			t.Fatalf("Failed to create message: %v", err)
+		}
+	}
+
+	tests := []struct {
+		field     string
+		direction string
+		expected  []string
+	}{
+		{"", "", []string{"alpha", "busy", "crowded"}},
+		{"name", "desc", []string{"crowded", "busy", "alpha"}},
+		{"members", "", []string{"crowded", "busy", "alpha"}},
+		{"members", "asc", []string{"alpha", "busy", "crowded"}},
+		{"recent_activity", "", []string{"alpha", "busy", "crowded"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("sort=%s direction=%s", tt.field, tt.direction), func(t *testing.T) {
+			sort, err := ParseChannelSort(tt.field, tt.direction)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			result, _, err := service.GetVisibleChannels(sort, 20, 0)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var names []string
+			for _, channel := range result {
+				names = append(names, channel.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("Expected order %v, got %v", tt.expected, names)
+			}
+		})
+	}
+
+	if _, err := ParseChannelSort("popularity", ""); err == nil || err.Error() != "invalid sort field" {
+		t.Errorf("Expected 'invalid sort field', got %v", err)
+	}
+	if _, err := ParseChannelSort("members", "sideways"); err == nil || err.Error() != "invalid sort direction" {
+		t.Errorf("Expected 'invalid sort direction', got %v", err)
+	}
+}
+
 func TestChannelService_GetUserChannels(t *testing.T) {
 	db := setupTestDB(t)
-	service := NewChannelService(db)
+	service := NewChannelService(db, NewMemberCache(0))
 	user1 := createTestUser(t, db, "user1")
 	user2 := createTestUser(t, db, "user2")
 
 	// Create channels for different users
-	channel1, err := service.CreateChannel(user1.ID, "channel1", nil, true)
+	channel1, err := service.CreateChannel(user1.ID, "channel1", nil, true, nil)
 	if err != nil {
 		t.Fatalf("Failed to create channel1: %v", err)
 	}
 
-	_, err = service.CreateChannel(user2.ID, "channel2", nil, true)
+	_, err = service.CreateChannel(user2.ID, "channel2", nil, true, nil)
 	if err != nil {
 		t.Fatalf("Failed to create channel2: %v", err)
 	}
 
-	channels, err := service.GetUserChannels(user1.ID)
+	channels, _, err := service.GetUserChannels(user1.ID, 20, 0)
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
 		return
@@ -201,16 +444,16 @@ func TestChannelService_GetUserChannels(t *testing.T) {
 
 func TestChannelService_JoinChannel(t *testing.T) {
 	db := setupTestDB(t)
-	service := NewChannelService(db)
+	service := NewChannelService(db, NewMemberCache(0))
 	owner := createTestUser(t, db, "owner")
 
 	// Create channels with different configurations
-	publicChannel, err := service.CreateChannel(owner.ID, "public", nil, true)
+	publicChannel, err := service.CreateChannel(owner.ID, "public", nil, true, nil)
 	if err != nil {
 		t.Fatalf("Failed to create public channel: %v", err)
 	}
 
-	privateChannel, err := service.CreateChannel(owner.ID, "private", stringPtr("secret"), true)
+	privateChannel, err := service.CreateChannel(owner.ID, "private", stringPtr("secret"), true, nil)
 	if err != nil {
 		t.Fatalf("Failed to create private channel: %v", err)
 	}
@@ -260,6 +503,20 @@ func TestChannelService_JoinChannel(t *testing.T) {
 			password:    nil,
 			expectError: true,
 		},
+		{
+			name: "join channel while banned",
+			setupUser: func(t *testing.T) *User {
+				user := createTestUser(t, db, "user6")
+				if err := db.Create(&UserBan{UserID: user.ID, ChannelID: publicChannel.ID, BannedBy: owner.ID}).Error; err != nil {
+					t.Fatalf("Failed to create ban: %v", err)
+				}
+				return user
+			},
+			channelID:   publicChannel.ID,
+			password:    nil,
+			expectError: true,
+			errorMsg:    "you are banned from this channel",
+		},
 	}
 
 	// Test joining same channel twice
@@ -320,13 +577,109 @@ func TestChannelService_JoinChannel(t *testing.T) {
 	}
 }
 
+func TestChannelService_VerifyChannelPassword(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewChannelService(db, NewMemberCache(0))
+	owner := createTestUser(t, db, "owner")
+	visitor := createTestUser(t, db, "visitor")
+
+	publicChannel, err := service.CreateChannel(owner.ID, "public", nil, true, nil)
+	if err != nil {
+		t.Fatalf("Failed to create public channel: %v", err)
+	}
+	privateChannel, err := service.CreateChannel(owner.ID, "private", stringPtr("secret"), true, nil)
+	if err != nil {
+		t.Fatalf("Failed to create private channel: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		channelID string
+		password  string
+		expected  bool
+	}{
+		{"correct password", privateChannel.ID, "secret", true},
+		{"incorrect password", privateChannel.ID, "wrong", false},
+		{"unprotected channel", publicChannel.ID, "anything", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			valid, err := service.VerifyChannelPassword(visitor.ID, tt.channelID, tt.password)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if valid != tt.expected {
+				t.Errorf("Expected valid=%v, got %v", tt.expected, valid)
+			}
+		})
+	}
+
+	if _, err := service.VerifyChannelPassword(visitor.ID, "missing", "secret"); err == nil {
+		t.Errorf("Expected an error for a missing channel")
+	}
+
+	// Checking a password never joins the channel
+	var count int64
+	db.Model(&UserChannel{}).Where("user_id = ?", visitor.ID).Count(&count)
+	if count != 0 {
+		t.Errorf("Expected no memberships, got %d", count)
+	}
+}
+
+func TestChannelService_JoinChannel_MaxMembers(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewChannelService(db, NewMemberCache(0))
+	owner := createTestUser(t, db, "owner")
+
+	channel, err := service.CreateChannel(owner.ID, "small", nil, true, nil)
+	if err != nil {
+		t.Fatalf("Failed to create channel: %v", err)
+	}
+
+	if _, err := service.UpdateChannel(owner.ID, channel.ID, UpdateChannelRequest{MaxMembers: intPtr(-1)}); err == nil || err.Error() != "max members cannot be negative" {
+		t.Errorf("Expected 'max members cannot be negative', got %v", err)
+	}
+
+	// The owner takes one of the three seats
+	if _, err := service.UpdateChannel(owner.ID, channel.ID, UpdateChannelRequest{MaxMembers: intPtr(3)}); err != nil {
+		t.Fatalf("Failed to set member limit: %v", err)
+	}
+
+	for _, username := range []string{"first", "second"} {
+		user := createTestUser(t, db, username)
+		if err := service.JoinChannel(user.ID, channel.ID, nil); err != nil {
+			t.Fatalf("Join up to the limit should succeed: %v", err)
+		}
+	}
+
+	latecomer := createTestUser(t, db, "latecomer")
+	err = service.JoinChannel(latecomer.ID, channel.ID, nil)
+	if err == nil || err.Error() != "channel is full" {
+		t.Fatalf("Expected 'channel is full', got %v", err)
+	}
+
+	var count int64
+	db.Model(&UserChannel{}).Where("channel_id = ?", channel.ID).Count(&count)
+	if count != 3 {
+		t.Errorf("Expected 3 members, got %d", count)
+	}
+
+	// Removing the cap lets the user in
+	if _, err := service.UpdateChannel(owner.ID, channel.ID, UpdateChannelRequest{MaxMembers: intPtr(0)}); err != nil {
+		t.Fatalf("Failed to remove member limit: %v", err)
+	}
+	if err := service.JoinChannel(latecomer.ID, channel.ID, nil); err != nil {
+		t.Errorf("Join without a limit should succeed: %v", err)
+	}
+}
+
 func TestChannelService_LeaveChannel(t *testing.T) {
 	db := setupTestDB(t)
-	service := NewChannelService(db)
+	service := NewChannelService(db, NewMemberCache(0))
 	owner := createTestUser(t, db, "owner")
 	user := createTestUser(t, db, "user")
 
-	channel, err := service.CreateChannel(owner.ID, "testchannel", nil, true)
+	channel, err := service.CreateChannel(owner.ID, "testchannel", nil, true, nil)
 	if err != nil {
 		t.Fatalf("Failed to create channel: %v", err)
 	}
@@ -394,13 +747,85 @@ func TestChannelService_LeaveChannel(t *testing.T) {
 	}
 }
 
+func TestChannelService_AnnounceMembership(t *testing.T) {
+	db := setupTestDB(t)
+	if err := db.AutoMigrate(&Message{}); err != nil {
+		t.Fatalf("Failed to migrate messages: %v", err)
+	}
+	service := NewChannelService(db, NewMemberCache(0))
+	owner := createTestUser(t, db, "owner")
+
+	announced, err := service.CreateChannel(owner.ID, "announced", nil, true, nil)
+	if err != nil {
+		t.Fatalf("Failed to create channel: %v", err)
+	}
+	if _, err := service.UpdateChannel(owner.ID, announced.ID, UpdateChannelRequest{AnnounceMembership: boolPtr(true)}); err != nil {
+		t.Fatalf("Failed to enable announcements: %v", err)
+	}
+	quiet, err := service.CreateChannel(owner.ID, "quiet", nil, true, nil)
+	if err != nil {
+		t.Fatalf("Failed to create channel: %v", err)
+	}
+
+	systemMessages := func(channelID string) []Message {
+		var messages []Message
+		db.Where("channel_id = ?", channelID).Order("created_at ASC").Find(&messages)
+		return messages
+	}
+
+	alice := createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	carol := createTestUser(t, db, "carol")
+	for _, channelID := range []string{announced.ID, quiet.ID} {
+		for _, user := range []*User{alice, bob, carol} {
+			if err := service.JoinChannel(user.ID, channelID, nil); err != nil {
+				t.Fatalf("Failed to join: %v", err)
+			}
+		}
+		if err := service.LeaveChannel(alice.ID, channelID); err != nil {
+			t.Fatalf("Failed to leave: %v", err)
+		}
+		if _, err := service.BanUser(owner.ID, bob.ID, channelID, "spam"); err != nil {
+			t.Fatalf("Failed to ban: %v", err)
+		}
+		if _, err := service.TempBanUser(owner.ID, carol.ID, channelID, "timeout", time.Hour); err != nil {
+			t.Fatalf("Failed to temp ban: %v", err)
+		}
+	}
+
+	messages := systemMessages(announced.ID)
+	expected := []struct {
+		content string
+		userID  string
+	}{
+		{"alice joined", alice.ID},
+		{"bob joined", bob.ID},
+		{"carol joined", carol.ID},
+		{"alice left", alice.ID},
+		{"bob was banned", owner.ID},
+		{"carol was banned", owner.ID},
+	}
+	if len(messages) != len(expected) {
+		t.Fatalf("Expected %d system messages, got %d", len(expected), len(messages))
+	}
+	for i, message := range messages {
+		if message.Content != expected[i].content || message.UserID != expected[i].userID || !message.IsSystem {
+			t.Errorf("Message %d: expected system message %q by %s, got %+v", i, expected[i].content, expected[i].userID, message)
+		}
+	}
+
+	if messages := systemMessages(quiet.ID); len(messages) != 0 {
+		t.Errorf("Expected no messages in a channel without announcements, got %d", len(messages))
+	}
+}
+
 func TestChannelService_DeleteChannel(t *testing.T) {
 	db := setupTestDB(t)
-	service := NewChannelService(db)
+	service := NewChannelService(db, NewMemberCache(0))
 	owner := createTestUser(t, db, "owner")
 	user := createTestUser(t, db, "user")
 
-	channel, err := service.CreateChannel(owner.ID, "testchannel", nil, true)
+	channel, err := service.CreateChannel(owner.ID, "testchannel", nil, true, nil)
 	if err != nil {
 		t.Fatalf("Failed to create channel: %v", err)
 	}
@@ -475,12 +900,12 @@ func TestChannelService_DeleteChannel(t *testing.T) {
 
 func TestChannelService_GetChannelUsers(t *testing.T) {
 	db := setupTestDB(t)
-	service := NewChannelService(db)
+	service := NewChannelService(db, NewMemberCache(0))
 	owner := createTestUser(t, db, "owner")
 	user1 := createTestUser(t, db, "user1")
 	user2 := createTestUser(t, db, "user2")
 
-	channel, err := service.CreateChannel(owner.ID, "testchannel", nil, true)
+	channel, err := service.CreateChannel(owner.ID, "testchannel", nil, true, nil)
 	if err != nil {
 		t.Fatalf("Failed to create channel: %v", err)
 	}
@@ -523,12 +948,12 @@ func TestChannelService_GetChannelUsers(t *testing.T) {
 
 func TestChannelService_BanUser(t *testing.T) {
 	db := setupTestDB(t)
-	service := NewChannelService(db)
+	service := NewChannelService(db, NewMemberCache(0))
 	owner := createTestUser(t, db, "owner")
 	user := createTestUser(t, db, "user")
 	nonMember := createTestUser(t, db, "nonmember")
 
-	channel, err := service.CreateChannel(owner.ID, "testchannel", nil, true)
+	channel, err := service.CreateChannel(owner.ID, "testchannel", nil, true, nil)
 	if err != nil {
 		t.Fatalf("Failed to create channel: %v", err)
 	}
@@ -563,7 +988,7 @@ func TestChannelService_BanUser(t *testing.T) {
 			channelID:   channel.ID,
 			reason:      "test",
 			expectError: true,
-			errorMsg:    "only channel owner can ban users",
+			errorMsg:    "only channel owners and moderators can ban users",
 		},
 		{
 			name:        "try to ban yourself",
@@ -595,7 +1020,7 @@ func TestChannelService_BanUser(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := service.BanUser(tt.adminID, tt.userID, tt.channelID, tt.reason)
+			_, err := service.BanUser(tt.adminID, tt.userID, tt.channelID, tt.reason)
 
 			if tt.expectError {
 				if err == nil {
@@ -645,13 +1070,13 @@ func TestChannelService_BanUser(t *testing.T) {
 	t.Run("try to ban channel owner as owner", func(t *testing.T) {
 		// Create another user to be the target
 		anotherOwner := createTestUser(t, db, "anotherowner")
-		anotherChannel, err := service.CreateChannel(anotherOwner.ID, "anotherchannel", nil, true)
+		anotherChannel, err := service.CreateChannel(anotherOwner.ID, "anotherchannel", nil, true, nil)
 		if err != nil {
 			t.Fatalf("Failed to create another channel: %v", err)
 		}
 		
 		// Owner tries to ban themselves (should fail with "cannot ban yourself")
-		err = service.BanUser(anotherOwner.ID, anotherOwner.ID, anotherChannel.ID, "test")
+		_, err = service.BanUser(anotherOwner.ID, anotherOwner.ID, anotherChannel.ID, "test")
 		if err == nil {
 			t.Errorf("Expected error when owner tries to ban themselves")
 		} else if err.Error() != "cannot ban yourself" {
@@ -662,11 +1087,11 @@ func TestChannelService_BanUser(t *testing.T) {
 
 func TestChannelService_TempBanUser(t *testing.T) {
 	db := setupTestDB(t)
-	service := NewChannelService(db)
+	service := NewChannelService(db, NewMemberCache(0))
 	owner := createTestUser(t, db, "owner")
 	user := createTestUser(t, db, "user")
 
-	channel, err := service.CreateChannel(owner.ID, "testchannel", nil, true)
+	channel, err := service.CreateChannel(owner.ID, "testchannel", nil, true, nil)
 	if err != nil {
 		t.Fatalf("Failed to create channel: %v", err)
 	}
@@ -704,13 +1129,13 @@ func TestChannelService_TempBanUser(t *testing.T) {
 			reason:      "test",
 			duration:    1 * time.Hour,
 			expectError: true,
-			errorMsg:    "only channel owner can ban users",
+			errorMsg:    "only channel owners and moderators can ban users",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := service.TempBanUser(tt.adminID, tt.userID, tt.channelID, tt.reason, tt.duration)
+			_, err := service.TempBanUser(tt.adminID, tt.userID, tt.channelID, tt.reason, tt.duration)
 
 			if tt.expectError {
 				if err == nil {
@@ -748,14 +1173,115 @@ func TestChannelService_TempBanUser(t *testing.T) {
 	}
 }
 
+func TestChannelService_BanUser_RoleHierarchy(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewChannelService(db, NewMemberCache(0))
+
+	owner := createTestUser(t, db, "owner")
+	moderator := createTestUser(t, db, "moderator")
+	otherModerator := createTestUser(t, db, "othermod")
+	member := createTestUser(t, db, "member")
+	channel, err := service.CreateChannel(owner.ID, "hierarchy", nil, true, nil)
+	if err != nil {
+		t.Fatalf("Failed to create channel: %v", err)
+	}
+	for _, user := range []*User{moderator, otherModerator, member} {
+		if err := service.JoinChannel(user.ID, channel.ID, nil); err != nil {
+			t.Fatalf("Failed to join channel: %v", err)
+		}
+	}
+	for _, user := range []*User{moderator, otherModerator} {
+		if err := service.PromoteUser(owner.ID, channel.ID, user.ID, "Moderator", ""); err != nil {
+			t.Fatalf("Failed to promote user: %v", err)
+		}
+	}
+
+	if _, err := service.BanUser(member.ID, otherModerator.ID, channel.ID, "test"); err == nil || err.Error() != "only channel owners and moderators can ban users" {
+		t.Errorf("Expected member to be refused, got %v", err)
+	}
+	if _, err := service.BanUser(moderator.ID, otherModerator.ID, channel.ID, "test"); err == nil || err.Error() != "cannot ban a member with an equal or higher role" {
+		t.Errorf("Expected moderator to be refused on an equal role, got %v", err)
+	}
+	if _, err := service.TempBanUser(moderator.ID, otherModerator.ID, channel.ID, "test", time.Hour); err == nil || err.Error() != "cannot ban a member with an equal or higher role" {
+		t.Errorf("Expected moderator to be refused on an equal role, got %v", err)
+	}
+	if _, err := service.BanUser(moderator.ID, member.ID, channel.ID, "spam"); err != nil {
+		t.Errorf("Expected moderator to ban a member, got %v", err)
+	}
+	if _, err := service.BanUser(owner.ID, otherModerator.ID, channel.ID, "test"); err != nil {
+		t.Errorf("Expected owner to ban a moderator, got %v", err)
+	}
+}
+
+func TestChannelService_BanUser_Concurrent(t *testing.T) {
+	db := setupTestDB(t)
+	// A single connection keeps every goroutine on the same in-memory database
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("Failed to get database handle: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	service := NewChannelService(db, NewMemberCache(0))
+
+	owner := createTestUser(t, db, "owner")
+	user := createTestUser(t, db, "user")
+	channel, err := service.CreateChannel(owner.ID, "concurrent", nil, true, nil)
+	if err != nil {
+		t.Fatalf("Failed to create channel: %v", err)
+	}
+	if err := service.JoinChannel(user.ID, channel.ID, nil); err != nil {
+		t.Fatalf("Failed to join channel: %v", err)
+	}
+
+	const attempts = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, attempts)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := service.BanUser(owner.ID, user.ID, channel.ID, "spam")
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case err.Error() == "user is already banned", err.Error() == "user is not in this channel":
+		default:
+			t.Errorf("Unexpected error: %v", err)
+		}
+	}
+	if succeeded != 1 {
+		t.Errorf("Expected exactly one ban to succeed, got %d", succeeded)
+	}
+
+	var active int64
+	db.Model(&UserBan{}).Where("user_id = ? AND channel_id = ? AND is_active = ?", user.ID, channel.ID, true).Count(&active)
+	if active != 1 {
+		t.Errorf("Expected exactly one active ban, got %d", active)
+	}
+
+	// The index itself rejects a duplicate that slips past the existence check
+	duplicate := UserBan{UserID: user.ID, ChannelID: channel.ID, BannedBy: owner.ID, IsActive: true}
+	if err := db.Create(&duplicate).Error; err == nil || !isUniqueViolation(err) {
+		t.Errorf("Expected a unique violation, got %v", err)
+	}
+}
+
 func TestChannelService_UnbanUser(t *testing.T) {
 	db := setupTestDB(t)
-	service := NewChannelService(db)
+	service := NewChannelService(db, NewMemberCache(0))
 	owner := createTestUser(t, db, "owner")
 	user := createTestUser(t, db, "user")
 	nonAdmin := createTestUser(t, db, "nonadmin")
 
-	channel, err := service.CreateChannel(owner.ID, "testchannel", nil, true)
+	channel, err := service.CreateChannel(owner.ID, "testchannel", nil, true, nil)
 	if err != nil {
 		t.Fatalf("Failed to create channel: %v", err)
 	}
@@ -766,7 +1292,7 @@ func TestChannelService_UnbanUser(t *testing.T) {
 		t.Fatalf("Failed to add user to channel: %v", err)
 	}
 
-	err = service.BanUser(owner.ID, user.ID, channel.ID, "test ban")
+	_, err = service.BanUser(owner.ID, user.ID, channel.ID, "test ban")
 	if err != nil {
 		t.Fatalf("Failed to ban user for test: %v", err)
 	}
@@ -840,12 +1366,12 @@ func TestChannelService_UnbanUser(t *testing.T) {
 
 func TestChannelService_IsUserBanned(t *testing.T) {
 	db := setupTestDB(t)
-	service := NewChannelService(db)
+	service := NewChannelService(db, NewMemberCache(0))
 	owner := createTestUser(t, db, "owner")
 	user := createTestUser(t, db, "user")
 	unbannedUser := createTestUser(t, db, "unbanned")
 
-	channel, err := service.CreateChannel(owner.ID, "testchannel", nil, true)
+	channel, err := service.CreateChannel(owner.ID, "testchannel", nil, true, nil)
 	if err != nil {
 		t.Fatalf("Failed to create channel: %v", err)
 	}
@@ -857,7 +1383,7 @@ func TestChannelService_IsUserBanned(t *testing.T) {
 	}
 
 	// Ban one user permanently
-	err = service.BanUser(owner.ID, user.ID, channel.ID, "test ban")
+	_, err = service.BanUser(owner.ID, user.ID, channel.ID, "test ban")
 	if err != nil {
 		t.Fatalf("Failed to ban user for test: %v", err)
 	}
@@ -929,13 +1455,13 @@ func TestChannelService_IsUserBanned(t *testing.T) {
 
 func TestChannelService_GetChannelBans(t *testing.T) {
 	db := setupTestDB(t)
-	service := NewChannelService(db)
+	service := NewChannelService(db, NewMemberCache(0))
 	owner := createTestUser(t, db, "owner")
 	user1 := createTestUser(t, db, "user1")
 	user2 := createTestUser(t, db, "user2")
 	nonAdmin := createTestUser(t, db, "nonadmin")
 
-	channel, err := service.CreateChannel(owner.ID, "testchannel", nil, true)
+	channel, err := service.CreateChannel(owner.ID, "testchannel", nil, true, nil)
 	if err != nil {
 		t.Fatalf("Failed to create channel: %v", err)
 	}
@@ -951,12 +1477,12 @@ func TestChannelService_GetChannelBans(t *testing.T) {
 		t.Fatalf("Failed to add user2 to channel: %v", err)
 	}
 
-	err = service.BanUser(owner.ID, user1.ID, channel.ID, "spam")
+	_, err = service.BanUser(owner.ID, user1.ID, channel.ID, "spam")
 	if err != nil {
 		t.Fatalf("Failed to ban user1: %v", err)
 	}
 
-	err = service.TempBanUser(owner.ID, user2.ID, channel.ID, "timeout", 24*time.Hour)
+	_, err = service.TempBanUser(owner.ID, user2.ID, channel.ID, "timeout", 24*time.Hour)
 	if err != nil {
 		t.Fatalf("Failed to temp ban user2: %v", err)
 	}
@@ -1023,4 +1549,240 @@ func TestChannelService_GetChannelBans(t *testing.T) {
 
 func stringPtr(s string) *string {
 	return &s
-}
\ No newline at end of file
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func TestChannelService_AuditFailureIsLogged(t *testing.T) {
+	// setupTestDB does not migrate audit_logs, so every audit write fails
+	db := setupTestDB(t)
+	service := NewChannelService(db, NewMemberCache(0))
+
+	var logs bytes.Buffer
+	service.SetLogger(logger.New(&logs, slog.LevelWarn))
+
+	owner := createTestUser(t, db, "owner")
+	channel, err := service.CreateChannel(owner.ID, "audited", nil, true, nil)
+	if err != nil {
+		t.Fatalf("Audit failure should not fail channel creation: %v", err)
+	}
+	if channel == nil || channel.ID == "" {
+		t.Fatalf("Expected channel to be created")
+	}
+
+	output := logs.String()
+	if !strings.Contains(output, "level=WARN") || !strings.Contains(output, "failed to write audit log") {
+		t.Errorf("Expected audit failure warning, got: %q", output)
+	}
+	if !strings.Contains(output, "action="+a.ActionCreateChannel) || !strings.Contains(output, "channel_id="+channel.ID) {
+		t.Errorf("Expected warning to identify the action and channel, got: %q", output)
+	}
+}
+
+func TestChannelService_MemberCache(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewChannelService(db, NewMemberCache(time.Minute))
+
+	owner := createTestUser(t, db, "owner")
+	member := createTestUser(t, db, "member")
+	channel, err := service.CreateChannel(owner.ID, "cached", nil, true, nil)
+	if err != nil {
+		t.Fatalf("Failed to create channel: %v", err)
+	}
+	if err := service.JoinChannel(member.ID, channel.ID, nil); err != nil {
+		t.Fatalf("Failed to join channel: %v", err)
+	}
+
+	queries := 0
+	db.Callback().Query().After("gorm:query").Register("test:count_queries", func(*gorm.DB) { queries++ })
+
+	role, err := service.GetMemberRole(member.ID, channel.ID)
+	if err != nil || role.Name != "Member" {
+		t.Fatalf("Expected Member role, got %v (%v)", role, err)
+	}
+
+	queries = 0
+	if _, err := service.GetMemberRole(member.ID, channel.ID); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if membership, _ := service.GetMembership(member.ID, channel); !membership.IsMember {
+		t.Errorf("Expected cached membership")
+	}
+	if queries != 0 {
+		t.Errorf("Expected cached membership to be served without queries, got %d", queries)
+	}
+
+	t.Run("leave and join invalidate the cache", func(t *testing.T) {
+		if err := service.LeaveChannel(member.ID, channel.ID); err != nil {
+			t.Fatalf("Failed to leave: %v", err)
+		}
+		if _, err := service.GetMemberRole(member.ID, channel.ID); err == nil {
+			t.Errorf("Expected not a member after leaving")
+		}
+		if err := service.JoinChannel(member.ID, channel.ID, nil); err != nil {
+			t.Fatalf("Failed to rejoin: %v", err)
+		}
+		if _, err := service.GetMemberRole(member.ID, channel.ID); err != nil {
+			t.Errorf("Expected member after rejoining, got %v", err)
+		}
+	})
+
+	t.Run("ban invalidates the cache", func(t *testing.T) {
+		if _, err := service.BanUser(owner.ID, member.ID, channel.ID, "spam"); err != nil {
+			t.Fatalf("Failed to ban: %v", err)
+		}
+		membership, err := service.GetMembership(member.ID, channel)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if membership.IsMember || !membership.IsBanned {
+			t.Errorf("Expected banned non-member after ban, got %+v", membership)
+		}
+		if _, err := service.GetMemberRole(member.ID, channel.ID); err == nil || err.Error() != "you are not a member of this channel" {
+			t.Errorf("Expected not a member error, got %v", err)
+		}
+	})
+
+	t.Run("unban invalidates the cache", func(t *testing.T) {
+		if err := service.UnbanUser(owner.ID, member.ID, channel.ID); err != nil {
+			t.Fatalf("Failed to unban: %v", err)
+		}
+		if membership, _ := service.GetMembership(member.ID, channel); membership.IsBanned {
+			t.Errorf("Expected ban to be lifted, got %+v", membership)
+		}
+	})
+
+	t.Run("changes made elsewhere wait for the TTL", func(t *testing.T) {
+		if err := service.JoinChannel(member.ID, channel.ID, nil); err != nil {
+			t.Fatalf("Failed to rejoin: %v", err)
+		}
+		if _, err := service.GetMemberRole(member.ID, channel.ID); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		db.Where("user_id = ? AND channel_id = ?", member.ID, channel.ID).Delete(&UserChannel{})
+		if _, err := service.GetMemberRole(member.ID, channel.ID); err != nil {
+			t.Errorf("Expected stale cached membership within the TTL, got %v", err)
+		}
+
+		uncached := NewChannelService(db, NewMemberCache(0))
+		if _, err := uncached.GetMemberRole(member.ID, channel.ID); err == nil {
+			t.Errorf("Expected membership to be read from the database with the cache disabled")
+		}
+	})
+}
+
+func TestMemberCacheTTLFromEnv(t *testing.T) {
+	t.Setenv("CHANNEL_MEMBER_CACHE_TTL", "5s")
+	if ttl := MemberCacheTTLFromEnv(); ttl != 5*time.Second {
+		t.Errorf("Expected 5s, got %v", ttl)
+	}
+
+	t.Setenv("CHANNEL_MEMBER_CACHE_TTL", "0")
+	if ttl := MemberCacheTTLFromEnv(); ttl != 0 {
+		t.Errorf("Expected cache to be disabled, got %v", ttl)
+	}
+
+	t.Setenv("CHANNEL_MEMBER_CACHE_TTL", "soon")
+	if ttl := MemberCacheTTLFromEnv(); ttl != DefaultMemberCacheTTL {
+		t.Errorf("Expected default TTL for invalid value, got %v", ttl)
+	}
+}
+
+func TestChannelService_PromoteUser_Roles(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewChannelService(db, NewMemberCache(0))
+
+	owner := createTestUser(t, db, "owner")
+	member := createTestUser(t, db, "member")
+	channel, err := service.CreateChannel(owner.ID, "roles", nil, true, nil)
+	if err != nil {
+		t.Fatalf("Failed to create channel: %v", err)
+	}
+	if err := service.JoinChannel(member.ID, channel.ID, nil); err != nil {
+		t.Fatalf("Failed to join channel: %v", err)
+	}
+
+	var rolesBefore int64
+	db.Model(&Role{}).Count(&rolesBefore)
+
+	for _, invalid := range []string{"Modderator", "moderator", "Owner", ""} {
+		if err := service.PromoteUser(owner.ID, channel.ID, member.ID, invalid, ""); err == nil || err.Error() != "invalid role" {
+			t.Errorf("Expected invalid role error for %q, got %v", invalid, err)
+		}
+		if err := service.DemoteUser(owner.ID, channel.ID, member.ID, invalid, ""); err == nil || err.Error() != "invalid role" {
+			t.Errorf("Expected invalid role error for %q, got %v", invalid, err)
+		}
+	}
+
+	var rolesAfter int64
+	db.Model(&Role{}).Count(&rolesAfter)
+	if rolesAfter != rolesBefore {
+		t.Errorf("Expected no roles to be created, went from %d to %d", rolesBefore, rolesAfter)
+	}
+
+	for _, roleName := range DefaultRoles {
+		if err := service.PromoteUser(owner.ID, channel.ID, member.ID, roleName, ""); err != nil {
+			t.Errorf("Expected %s to be accepted, got %v", roleName, err)
+			continue
+		}
+		role, err := service.GetMemberRole(member.ID, channel.ID)
+		if err != nil || role.Name != roleName {
+			t.Errorf("Expected member to hold %s, got %v (%v)", roleName, role, err)
+		}
+	}
+}
+
+func TestChannelService_MemberCacheShared(t *testing.T) {
+	db := setupTestDB(t)
+	members := NewMemberCache(time.Minute)
+	first := NewChannelService(db, members)
+	second := NewChannelService(db, members)
+
+	owner := createTestUser(t, db, "owner")
+	member := createTestUser(t, db, "member")
+	channel, err := first.CreateChannel(owner.ID, "shared", nil, true, nil)
+	if err != nil {
+		t.Fatalf("Failed to create channel: %v", err)
+	}
+	if err := first.JoinChannel(member.ID, channel.ID, nil); err != nil {
+		t.Fatalf("Failed to join channel: %v", err)
+	}
+
+	t.Run("changes through one service reach the others", func(t *testing.T) {
+		if membership, _ := second.GetMembership(member.ID, channel); !membership.IsMember {
+			t.Fatalf("Expected member, got %+v", membership)
+		}
+		if _, err := first.BanUser(owner.ID, member.ID, channel.ID, "spam"); err != nil {
+			t.Fatalf("Failed to ban: %v", err)
+		}
+		membership, _ := second.GetMembership(member.ID, channel)
+		if membership.IsMember || !membership.IsBanned {
+			t.Errorf("Expected the ban to be seen by the other service, got %+v", membership)
+		}
+	})
+
+	t.Run("direct writes invalidate through the cache", func(t *testing.T) {
+		if err := first.UnbanUser(owner.ID, member.ID, channel.ID); err != nil {
+			t.Fatalf("Failed to unban: %v", err)
+		}
+		if err := first.JoinChannel(member.ID, channel.ID, nil); err != nil {
+			t.Fatalf("Failed to rejoin: %v", err)
+		}
+		if _, err := second.GetMemberRole(member.ID, channel.ID); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		db.Where("user_id = ?", member.ID).Delete(&UserChannel{})
+		members.InvalidateUser(member.ID)
+		if _, err := second.GetMemberRole(member.ID, channel.ID); err == nil {
+			t.Errorf("Expected membership to be reread after InvalidateUser")
+		}
+	})
+}
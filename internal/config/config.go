@@ -0,0 +1,81 @@
+// Package config reads and validates the settings the server cannot start without
+package config
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	s "go-chat/internal/storage"
+)
+
+// MinSecretLength is the shortest APP_SECRET accepted for signing tokens.
+// make generate-secret produces 64 characters.
+const MinSecretLength = 32
+
+type Config struct {
+	Secret   string   // APP_SECRET, signs access tokens
+	Port     string   // Listen address, such as ":9876"
+	Database s.Config // DB_DRIVER and DB_DSN
+}
+
+// Error lists every problem found in the configuration, so they can all be
+// fixed before the next start
+type Error struct {
+	Problems []string
+}
+
+func (e *Error) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Load reads the configuration from the environment, validates it and checks
+// that the database is reachable
+func Load(port string) (*Config, error) {
+	config := &Config{
+		Secret:   os.Getenv("APP_SECRET"),
+		Port:     port,
+		Database: s.ConfigFromEnv(),
+	}
+
+	problems := config.problems()
+	if err := pingDatabase(config.Database); err != nil {
+		problems = append(problems, "database is not reachable: "+err.Error())
+	}
+	if len(problems) > 0 {
+		return nil, &Error{Problems: problems}
+	}
+
+	return config, nil
+}
+
+func (c *Config) problems() []string {
+	var problems []string
+
+	if c.Secret == "" {
+		problems = append(problems, "APP_SECRET is required (run make generate-secret)")
+	} else if len(c.Secret) < MinSecretLength {
+		problems = append(problems, "APP_SECRET must be at least "+strconv.Itoa(MinSecretLength)+" characters")
+	}
+
+	_, portNumber, err := net.SplitHostPort(c.Port)
+	if n, convErr := strconv.Atoi(portNumber); err != nil || convErr != nil || n < 1 || n > 65535 {
+		problems = append(problems, "invalid port "+strconv.Quote(c.Port)+", expected :1-65535")
+	}
+
+	return problems
+}
+
+func pingDatabase(config s.Config) error {
+	db, err := s.Open(config)
+	if err != nil {
+		return err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	defer sqlDB.Close()
+	return sqlDB.Ping()
+}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const validSecret = "0123456789abcdef0123456789abcdef"
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name             string
+		secret           string
+		port             string
+		expectedProblems []string
+	}{
+		{
+			name:   "valid",
+			secret: validSecret,
+			port:   ":9876",
+		},
+		{
+			name:             "missing secret",
+			port:             ":9876",
+			expectedProblems: []string{"APP_SECRET is required (run make generate-secret)"},
+		},
+		{
+			name:             "short secret",
+			secret:           "too-short",
+			port:             ":9876",
+			expectedProblems: []string{"APP_SECRET must be at least 32 characters"},
+		},
+		{
+			name: "every problem is reported at once",
+			port: ":99999",
+			expectedProblems: []string{
+				"APP_SECRET is required (run make generate-secret)",
+				`invalid port ":99999", expected :1-65535`,
+			},
+		},
+		{
+			name:             "port without colon",
+			secret:           validSecret,
+			port:             "9876",
+			expectedProblems: []string{`invalid port "9876", expected :1-65535`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("APP_SECRET", tt.secret)
+			t.Setenv("DB_DRIVER", "sqlite")
+			t.Setenv("DB_DSN", ":memory:")
+
+			config, err := Load(tt.port)
+
+			if tt.expectedProblems == nil {
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				if config.Secret != tt.secret || config.Port != tt.port {
+					t.Errorf("Unexpected config: %+v", config)
+				}
+				return
+			}
+
+			var configErr *Error
+			if !errors.As(err, &configErr) {
+				t.Fatalf("Expected a configuration error, got %v", err)
+			}
+			if !reflect.DeepEqual(configErr.Problems, tt.expectedProblems) {
+				t.Errorf("Expected problems %q, got %q", tt.expectedProblems, configErr.Problems)
+			}
+			for _, problem := range tt.expectedProblems {
+				if !strings.Contains(err.Error(), problem) {
+					t.Errorf("Expected message to list %q, got %q", problem, err.Error())
+				}
+			}
+		})
+	}
+}
+
+func TestLoad_UnreachableDatabase(t *testing.T) {
+	t.Setenv("APP_SECRET", validSecret)
+	t.Setenv("DB_DRIVER", "mysql")
+
+	_, err := Load(":9876")
+
+	var configErr *Error
+	if !errors.As(err, &configErr) || len(configErr.Problems) != 1 ||
+		configErr.Problems[0] != "database is not reachable: unsupported database driver: mysql" {
+		t.Errorf("Expected an unreachable database, got %v", err)
+	}
+}
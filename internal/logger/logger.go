@@ -0,0 +1,43 @@
+// Package logger provides the structured logger shared by the services
+package logger
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+var (
+	defaultLogger *slog.Logger
+	once          sync.Once
+)
+
+// New creates a text logger writing to w at the given level
+func New(w io.Writer, level slog.Level) *slog.Logger {
+	return slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{Level: level}))
+}
+
+// ParseLevel maps a LOG_LEVEL value (debug, info, warn, error) to a slog level.
+// Unknown or empty values default to info.
+func ParseLevel(level string) slog.Level {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// Default returns the process-wide logger, writing to stderr at the level set by LOG_LEVEL
+func Default() *slog.Logger {
+	once.Do(func() {
+		defaultLogger = New(os.Stderr, ParseLevel(os.Getenv("LOG_LEVEL")))
+	})
+	return defaultLogger
+}
@@ -0,0 +1,45 @@
+package logger
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected slog.Level
+	}{
+		{"debug", slog.LevelDebug},
+		{"INFO", slog.LevelInfo},
+		{"warn", slog.LevelWarn},
+		{"warning", slog.LevelWarn},
+		{" error ", slog.LevelError},
+		{"", slog.LevelInfo},
+		{"verbose", slog.LevelInfo},
+	}
+
+	for _, tt := range tests {
+		if got := ParseLevel(tt.input); got != tt.expected {
+			t.Errorf("ParseLevel(%q) = %v, want %v", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestNew_RespectsLevel(t *testing.T) {
+	var buf bytes.Buffer
+	log := New(&buf, slog.LevelWarn)
+
+	log.Info("hidden")
+	log.Warn("shown")
+
+	output := buf.String()
+	if strings.Contains(output, "hidden") {
+		t.Errorf("Expected info message to be filtered, got: %q", output)
+	}
+	if !strings.Contains(output, "shown") {
+		t.Errorf("Expected warn message to be logged, got: %q", output)
+	}
+}
@@ -0,0 +1,36 @@
+package message
+
+import (
+	"errors"
+	"net/url"
+
+	. "go-chat/pkg/chat"
+)
+
+// MaxAttachments bounds how many attachments a single message may carry
+const MaxAttachments = 5
+
+// maxAttachmentURLLength keeps attachment URLs within what browsers and proxies accept
+const maxAttachmentURLLength = 2048
+
+// ValidateAttachments checks the attachments of a new message. Only absolute
+// http and https URLs are accepted, since clients open them directly.
+func ValidateAttachments(attachments []Attachment) error {
+	if len(attachments) > MaxAttachments {
+		return errors.New("too many attachments")
+	}
+
+	for _, attachment := range attachments {
+		if len(attachment.URL) > maxAttachmentURLLength {
+			return errors.New("invalid attachment url")
+		}
+		parsed, err := url.Parse(attachment.URL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return errors.New("invalid attachment url")
+		}
+		if attachment.Size < 0 {
+			return errors.New("invalid attachment size")
+		}
+	}
+	return nil
+}
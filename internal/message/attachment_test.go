@@ -0,0 +1,55 @@
+package message
+
+import (
+	"strings"
+	"testing"
+
+	. "go-chat/pkg/chat"
+)
+
+func TestValidateAttachments(t *testing.T) {
+	valid := Attachment{URL: "https://files.example.com/report.pdf", MimeType: "application/pdf", Size: 1024}
+
+	tests := []struct {
+		name        string
+		attachments []Attachment
+		expectedErr string
+	}{
+		{"no attachments", nil, ""},
+		{"valid attachment", []Attachment{valid}, ""},
+		{"plain http", []Attachment{{URL: "http://files.example.com/a.png"}}, ""},
+		{"at the limit", repeatAttachment(valid), ""},
+		{"over the limit", append(repeatAttachment(valid), valid), "too many attachments"},
+		{"not a url", []Attachment{{URL: "not a url"}}, "invalid attachment url"},
+		{"relative url", []Attachment{{URL: "/files/a.png"}}, "invalid attachment url"},
+		{"script url", []Attachment{{URL: "javascript:alert(1)"}}, "invalid attachment url"},
+		{"unsupported scheme", []Attachment{{URL: "ftp://files.example.com/a"}}, "invalid attachment url"},
+		{"missing host", []Attachment{{URL: "https://"}}, "invalid attachment url"},
+		{"too long", []Attachment{{URL: "https://files.example.com/" + strings.Repeat("a", maxAttachmentURLLength)}}, "invalid attachment url"},
+		{"negative size", []Attachment{{URL: valid.URL, Size: -1}}, "invalid attachment size"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAttachments(tt.attachments)
+			if tt.expectedErr == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.expectedErr {
+				t.Errorf("Expected error %q, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
+
+// repeatAttachment returns MaxAttachments copies of the attachment
+func repeatAttachment(attachment Attachment) []Attachment {
+	attachments := make([]Attachment, MaxAttachments)
+	for i := range attachments {
+		attachments[i] = attachment
+	}
+	return attachments
+}
@@ -0,0 +1,155 @@
+package message
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	. "go-chat/pkg/chat"
+	"gorm.io/gorm"
+)
+
+// MaxReportReasonLength bounds the reason given with a report, in characters
+const MaxReportReasonLength = 500
+
+// ReportMessage flags a message for the moderators of its channel. Only members
+// of the channel can report, and not their own messages. Reporting the same
+// message again updates the reporter's existing report and reopens it.
+func (s *MessageService) ReportMessage(reporterID, messageID, reason string) (*MessageReport, error) {
+	reason = strings.TrimSpace(reason)
+	if utf8.RuneCountInString(reason) > MaxReportReasonLength {
+		return nil, errors.New("report reason is too long")
+	}
+
+	message, _, err := s.loadMessageForActor(reporterID, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if message.UserID == reporterID {
+		return nil, errors.New("you cannot report your own message")
+	}
+
+	var report MessageReport
+	err = s.db.Where("message_id = ? AND reporter_id = ?", messageID, reporterID).First(&report).Error
+	switch {
+	case err == nil:
+		err = s.db.Model(&report).Updates(map[string]interface{}{
+			"reason":      reason,
+			"status":      ReportStatusOpen,
+			"resolved_by": nil,
+			"resolved_at": nil,
+		}).Error
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		report = MessageReport{
+			MessageID:  messageID,
+			ReporterID: reporterID,
+			ChannelID:  message.ChannelID,
+			Reason:     reason,
+			Status:     ReportStatusOpen,
+		}
+		err = s.db.Create(&report).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.loadReport(report.ID)
+}
+
+// GetChannelReports lists the channel's open reports, oldest first, for its
+// owner and moderators
+func (s *MessageService) GetChannelReports(requesterID, channelID string) ([]MessageReport, error) {
+	var channel Channel
+	if err := s.db.First(&channel, "id = ?", channelID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("channel not found")
+		}
+		return nil, err
+	}
+
+	canModerate, err := s.canModerate(requesterID, &channel)
+	if err != nil {
+		return nil, err
+	}
+	if !canModerate {
+		return nil, errors.New("only channel owners and moderators can view reports")
+	}
+
+	var reports []MessageReport
+	err = s.reportQuery().
+		Where("channel_id = ? AND status = ?", channelID, ReportStatusOpen).
+		Order("created_at ASC").
+		Find(&reports).Error
+	return reports, err
+}
+
+// ResolveReport sets the status of a report. Closing it records the moderator;
+// setting it back to open clears that.
+func (s *MessageService) ResolveReport(requesterID string, reportID uint, status string) (*MessageReport, error) {
+	if status != ReportStatusOpen && status != ReportStatusResolved && status != ReportStatusDismissed {
+		return nil, errors.New("invalid report status")
+	}
+
+	var report MessageReport
+	if err := s.db.Preload("Channel").First(&report, reportID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("report not found")
+		}
+		return nil, err
+	}
+
+	canModerate, err := s.canModerate(requesterID, &report.Channel)
+	if err != nil {
+		return nil, err
+	}
+	if !canModerate {
+		return nil, errors.New("only channel owners and moderators can resolve reports")
+	}
+
+	updates := map[string]interface{}{"status": status, "resolved_by": nil, "resolved_at": nil}
+	if status != ReportStatusOpen {
+		updates["resolved_by"] = requesterID
+		updates["resolved_at"] = time.Now()
+	}
+	if err := s.db.Model(&report).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+
+	return s.loadReport(report.ID)
+}
+
+// reportQuery loads reports with the reporter and the reported message, which
+// stays visible to moderators after its author deletes it
+func (s *MessageService) reportQuery() *gorm.DB {
+	return s.db.Preload("Reporter").
+		Preload("Message", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("Message.User").
+		Preload("Message.Attachments")
+}
+
+func (s *MessageService) loadReport(reportID uint) (*MessageReport, error) {
+	var report MessageReport
+	if err := s.reportQuery().First(&report, reportID).Error; err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// canModerate reports whether the user owns the channel or holds a moderating role in it
+func (s *MessageService) canModerate(userID string, channel *Channel) (bool, error) {
+	if channel.OwnerID == userID {
+		return true, nil
+	}
+
+	var userChannel UserChannel
+	err := s.db.Preload("Role").Where("user_id = ? AND channel_id = ?", userID, channel.ID).First(&userChannel).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return userChannel.IsModerator(), nil
+}
@@ -0,0 +1,42 @@
+package message
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// MaxConsecutiveNewlines is the longest run of newlines kept in message content,
+// enough for one blank line between paragraphs
+const MaxConsecutiveNewlines = 2
+
+var (
+	// Terminal escape sequences: CSI (colors, cursor movement), OSC (window
+	// title, hyperlinks) terminated by BEL or ST, and the shorter escapes such
+	// as terminal reset or character set selection
+	escapeSequence = regexp.MustCompile("\x1b\\[[0-?]*[ -/]*[@-~]|\x1b\\][^\x07\x1b]*(?:\x07|\x1b\\\\)?|\x1b[ -/]*[0-~]")
+	newlineRun     = regexp.MustCompile("\n{" + strconv.Itoa(MaxConsecutiveNewlines+1) + ",}")
+)
+
+// SanitizeContent makes user-supplied message content safe to render in a
+// terminal. It removes escape sequences and control characters other than
+// newlines and tabs, normalizes line endings, drops invalid UTF-8 and
+// collapses runs of more than MaxConsecutiveNewlines newlines.
+func SanitizeContent(content string) string {
+	content = strings.ToValidUTF8(content, "")
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	content = escapeSequence.ReplaceAllString(content, "")
+
+	content = strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' {
+			return r
+		}
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, content)
+
+	return newlineRun.ReplaceAllString(content, strings.Repeat("\n", MaxConsecutiveNewlines))
+}
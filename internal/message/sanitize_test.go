@@ -0,0 +1,38 @@
+package message
+
+import "testing"
+
+func TestSanitizeContent(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{"plain text", "hello world", "hello world"},
+		{"unicode is kept", "héllo 世界 👋 مرحبا", "héllo 世界 👋 مرحبا"},
+		{"single newlines and tabs are kept", "line one\nline two\n\tindented", "line one\nline two\n\tindented"},
+		{"one blank line is kept", "para one\n\npara two", "para one\n\npara two"},
+		{"newline runs are collapsed", "top\n\n\n\n\nbottom", "top\n\nbottom"},
+		{"NUL is stripped", "nul\x00byte", "nulbyte"},
+		{"other C0 controls are stripped", "bell\x07 back\x08space \x0bvt", "bell backspace vt"},
+		{"DEL and C1 controls are stripped", "del\x7f c1\u0085\u009b", "del c1"},
+		{"CRLF becomes LF", "windows\r\nline", "windows\nline"},
+		{"carriage return is stripped", "overwrite\rme", "overwriteme"},
+		{"color codes are stripped", "\x1b[31mred\x1b[0m text", "red text"},
+		{"cursor movement is stripped", "\x1b[2J\x1b[1;1Hcleared", "cleared"},
+		{"window title is stripped", "\x1b]0;pwned\x07title", "title"},
+		{"hyperlink is stripped", "\x1b]8;;https://evil.example\x1b\\link\x1b]8;;\x1b\\", "link"},
+		{"terminal reset is stripped", "\x1bcreset", "reset"},
+		{"charset selection is stripped", "\x1b(Bascii", "ascii"},
+		{"lone escape is stripped", "esc\x1b", "esc"},
+		{"invalid UTF-8 is dropped", "bad\xffbyte", "badbyte"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SanitizeContent(tt.content); got != tt.expected {
+				t.Errorf("SanitizeContent(%q) = %q, want %q", tt.content, got, tt.expected)
+			}
+		})
+	}
+}
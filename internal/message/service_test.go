@@ -0,0 +1,258 @@
+package message
+
+import (
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"go-chat/internal/channel"
+	. "go-chat/pkg/chat"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to database: %v", err)
+	}
+	if err := db.AutoMigrate(&User{}, &Role{}, &Channel{}, &UserChannel{}, &UserBan{}, &Message{}, &Attachment{}); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+	return db
+}
+
+func TestLoadMessageForActor(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewMessageService(db, channel.NewMemberCache(0))
+
+	moderatorRole := &Role{Name: "Moderator"}
+	memberRole := &Role{Name: "Member"}
+	for _, value := range []interface{}{moderatorRole, memberRole} {
+		if err := db.Create(value).Error; err != nil {
+			t.Fatalf("Failed to create role: %v", err)
+		}
+	}
+
+	users := make(map[string]*User)
+	for _, name := range []string{"owner", "moderator", "elsewhere", "banned"} {
+		user := &User{Username: name, Password: "hashedpassword"}
+		if err := db.Create(user).Error; err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+		users[name] = user
+	}
+
+	general := &Channel{Name: "general", OwnerID: users["owner"].ID, LoggingDays: 30}
+	other := &Channel{Name: "other", OwnerID: users["owner"].ID, LoggingDays: 30}
+	for _, channel := range []*Channel{general, other} {
+		if err := db.Create(channel).Error; err != nil {
+			t.Fatalf("Failed to create channel: %v", err)
+		}
+	}
+	memberships := []UserChannel{
+		{UserID: users["moderator"].ID, ChannelID: general.ID, RoleID: &moderatorRole.ID},
+		{UserID: users["elsewhere"].ID, ChannelID: other.ID, RoleID: &moderatorRole.ID},
+		{UserID: users["banned"].ID, ChannelID: general.ID, RoleID: &memberRole.ID},
+	}
+	for _, membership := range memberships {
+		if err := db.Create(&membership).Error; err != nil {
+			t.Fatalf("Failed to create membership: %v", err)
+		}
+	}
+	if err := db.Create(&UserBan{UserID: users["banned"].ID, ChannelID: general.ID, BannedBy: users["owner"].ID, IsActive: true}).Error; err != nil {
+		t.Fatalf("Failed to create ban: %v", err)
+	}
+
+	message := &Message{Content: "hello", UserID: users["owner"].ID, ChannelID: general.ID}
+	if err := db.Create(message).Error; err != nil {
+		t.Fatalf("Failed to create message: %v", err)
+	}
+
+	loaded, role, err := service.loadMessageForActor(users["moderator"].ID, message.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if loaded.ID != message.ID || role.Name != "Moderator" {
+		t.Errorf("Expected message %s with Moderator role, got %s with %q", message.ID, loaded.ID, role.Name)
+	}
+
+	tests := []struct {
+		name        string
+		userID      string
+		messageID   string
+		expectedErr string
+	}{
+		// A moderator of another channel gains nothing over this message
+		{"member of another channel", users["elsewhere"].ID, message.ID, "you are not a member of this channel"},
+		{"banned member", users["banned"].ID, message.ID, "you are banned from this channel"},
+		{"unknown message", users["moderator"].ID, "missing", "message not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := service.loadMessageForActor(tt.userID, tt.messageID)
+			if err == nil || err.Error() != tt.expectedErr {
+				t.Errorf("Expected %q, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestCreateMessage_MemberCache(t *testing.T) {
+	db := setupTestDB(t)
+	members := channel.NewMemberCache(time.Minute)
+	channels := channel.NewChannelService(db, members)
+	service := NewMessageService(db, members)
+
+	role := &Role{Name: "Member"}
+	owner := &User{Username: "owner", Password: "hashedpassword"}
+	member := &User{Username: "member", Password: "hashedpassword"}
+	for _, value := range []interface{}{role, owner, member} {
+		if err := db.Create(value).Error; err != nil {
+			t.Fatalf("Failed to create fixture: %v", err)
+		}
+	}
+	general := &Channel{Name: "general", OwnerID: owner.ID, LoggingDays: 30}
+	if err := db.Create(general).Error; err != nil {
+		t.Fatalf("Failed to create channel: %v", err)
+	}
+	if err := db.Create(&UserChannel{UserID: member.ID, ChannelID: general.ID, RoleID: &role.ID}).Error; err != nil {
+		t.Fatalf("Failed to create membership: %v", err)
+	}
+
+	if _, err := service.CreateMessage(member.ID, general.ID, "first", "", nil); err != nil {
+		t.Fatalf("Failed to create message: %v", err)
+	}
+
+	membershipQueries := 0
+	db.Callback().Query().After("gorm:query").Register("test:count_membership_queries", func(tx *gorm.DB) {
+		if tx.Statement.Table == "user_channels" || tx.Statement.Table == "user_bans" {
+			membershipQueries++
+		}
+	})
+
+	message, err := service.CreateMessage(member.ID, general.ID, "second", "", nil)
+	if err != nil {
+		t.Fatalf("Failed to create message: %v", err)
+	}
+	if membershipQueries != 0 {
+		t.Errorf("Expected the sender's standing to come from the cache, got %d queries", membershipQueries)
+	}
+	if message.User.Username != "member" {
+		t.Errorf("Expected the message to carry its author, got %q", message.User.Username)
+	}
+
+	// A ban through a service sharing the cache applies to the next message
+	if _, err := channels.BanUser(owner.ID, member.ID, general.ID, "spam"); err != nil {
+		t.Fatalf("Failed to ban: %v", err)
+	}
+	if _, err := service.CreateMessage(member.ID, general.ID, "third", "", nil); err == nil || err.Error() != "you are banned from this channel" {
+		t.Errorf("Expected banned error, got %v", err)
+	}
+}
+
+func TestCreateMessage_Seq(t *testing.T) {
+	db := setupTestDB(t)
+	// A single connection keeps every goroutine on the same in-memory database
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("Failed to get database handle: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	service := NewMessageService(db, channel.NewMemberCache(0))
+
+	owner := &User{Username: "owner", Password: "hashedpassword"}
+	role := &Role{Name: "Administrator"}
+	for _, value := range []interface{}{owner, role} {
+		if err := db.Create(value).Error; err != nil {
+			t.Fatalf("Failed to create fixture: %v", err)
+		}
+	}
+	logged := &Channel{Name: "logged", OwnerID: owner.ID, LoggingDays: 30}
+	unlogged := &Channel{Name: "unlogged", OwnerID: owner.ID, LoggingDays: 0}
+	for _, channel := range []*Channel{logged, unlogged} {
+		if err := db.Create(channel).Error; err != nil {
+			t.Fatalf("Failed to create channel: %v", err)
+		}
+		if err := db.Create(&UserChannel{UserID: owner.ID, ChannelID: channel.ID, RoleID: &role.ID}).Error; err != nil {
+			t.Fatalf("Failed to create membership: %v", err)
+		}
+	}
+
+	for _, channel := range []*Channel{logged, unlogged} {
+		t.Run(channel.Name, func(t *testing.T) {
+			const messages = 8
+			var wg sync.WaitGroup
+			seqs := make(chan int64, messages)
+			for i := 0; i < messages; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					message, err := service.CreateMessage(owner.ID, channel.ID, "hello", "", nil)
+					if err != nil {
+						t.Errorf("Failed to create message: %v", err)
+						return
+					}
+					seqs <- message.Seq
+				}()
+			}
+			wg.Wait()
+			close(seqs)
+
+			var got []int64
+			for seq := range seqs {
+				got = append(got, seq)
+			}
+			sort.Slice(got, func(i, j int) bool { return got[i] < got[j] })
+			for i, seq := range got {
+				if seq != int64(i+1) {
+					t.Fatalf("Expected distinct sequence numbers 1 to %d, got %v", messages, got)
+				}
+			}
+		})
+	}
+
+	// Stored messages carry their number
+	var stored []Message
+	db.Where("channel_id = ?", logged.ID).Order("seq").Find(&stored)
+	if len(stored) != 8 || stored[0].Seq != 1 || stored[7].Seq != 8 {
+		t.Errorf("Expected 8 stored messages numbered 1 to 8, got %d", len(stored))
+	}
+}
+
+func TestScrubMessageAuthor_IgnoresLiveAccountWithPlaceholderName(t *testing.T) {
+	db := setupTestDB(t)
+	if err := db.AutoMigrate(&AuditLog{}); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+	service := NewMessageService(db, channel.NewMemberCache(0))
+
+	admin := &User{Username: "admin", Password: "hashedpassword", IsAdmin: true}
+	author := &User{Username: "author", Password: "hashedpassword"}
+	// An account that took the name before it was reserved
+	impostor := &User{Username: ScrubbedUsername, Password: "hashedpassword"}
+	for _, user := range []*User{admin, author, impostor} {
+		if err := db.Create(user).Error; err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+	channel := &Channel{Name: "general", OwnerID: author.ID, LoggingDays: 30}
+	if err := db.Create(channel).Error; err != nil {
+		t.Fatalf("Failed to create channel: %v", err)
+	}
+	message := &Message{Content: "spam", UserID: author.ID, ChannelID: channel.ID}
+	if err := db.Create(message).Error; err != nil {
+		t.Fatalf("Failed to create message: %v", err)
+	}
+
+	if _, err := service.ScrubMessageAuthor(admin.ID, message.ID, "compromised"); err == nil {
+		t.Fatal("Expected scrubbing to fail while a live account holds the placeholder name")
+	}
+
+	var stored Message
+	db.First(&stored, "id = ?", message.ID)
+	if stored.UserID != author.ID {
+		t.Errorf("Expected the message to stay with its author, got %s", stored.UserID)
+	}
+}
@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegistry_WriteText(t *testing.T) {
+	registry := NewRegistry()
+	requests := registry.NewCounterVec("test_requests_total", "Requests.", "method", "path")
+	rejections := registry.NewCounterVec("test_rejections_total", "Rejections.")
+	latency := registry.NewHistogramVec("test_latency_seconds", "Latency.", []float64{0.1, 1}, "method")
+
+	requests.Inc("GET", "/a")
+	requests.Add(2, "GET", "/a")
+	requests.Inc("POST", `/say "hi"`)
+	latency.Observe(0.05, "GET")
+	latency.Observe(0.5, "GET")
+	latency.Observe(3, "GET")
+
+	var out strings.Builder
+	if err := registry.WriteText(&out); err != nil {
+		t.Fatalf("WriteText failed: %v", err)
+	}
+
+	expected := `# HELP test_requests_total Requests.
+# TYPE test_requests_total counter
+test_requests_total{method="GET",path="/a"} 3
+test_requests_total{method="POST",path="/say \"hi\""} 1
+# HELP test_rejections_total Rejections.
+# TYPE test_rejections_total counter
+test_rejections_total 0
+# HELP test_latency_seconds Latency.
+# TYPE test_latency_seconds histogram
+test_latency_seconds_bucket{method="GET",le="0.1"} 1
+test_latency_seconds_bucket{method="GET",le="1"} 2
+test_latency_seconds_bucket{method="GET",le="+Inf"} 3
+test_latency_seconds_sum{method="GET"} 3.55
+test_latency_seconds_count{method="GET"} 3
+`
+	if got := out.String(); got != expected {
+		t.Errorf("Unexpected output:\n%s\nwant:\n%s", got, expected)
+	}
+
+	rejections.Inc()
+	out.Reset()
+	registry.WriteText(&out)
+	if !strings.Contains(out.String(), "test_rejections_total 1\n") {
+		t.Errorf("Expected unlabelled counter to be incremented, got:\n%s", out.String())
+	}
+}
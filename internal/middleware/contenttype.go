@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"mime"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContentTypeConfig holds configuration for request body content-type enforcement
+type ContentTypeConfig struct {
+	Strict bool // Reject write requests whose body is not application/json
+}
+
+// ContentTypeConfigFromEnv builds the content-type configuration from the environment.
+// Enforcement is strict unless STRICT_CONTENT_TYPE is set to "false".
+func ContentTypeConfigFromEnv() ContentTypeConfig {
+	return ContentTypeConfig{
+		Strict: os.Getenv("STRICT_CONTENT_TYPE") != "false",
+	}
+}
+
+// hasBody reports whether the request carries a body that will be bound
+func hasBody(r *http.Request) bool {
+	return r.ContentLength > 0 || (r.ContentLength == -1 && r.Body != nil && r.Body != http.NoBody)
+}
+
+// RequireJSONMiddleware rejects write requests with a body that isn't declared as
+// application/json with 415 Unsupported Media Type. Requests without a body pass
+// through, and nothing is enforced when the configuration is lenient.
+func RequireJSONMiddleware(config ContentTypeConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !config.Strict {
+			c.Next()
+			return
+		}
+
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			c.Next()
+			return
+		}
+
+		if !hasBody(c.Request) {
+			c.Next()
+			return
+		}
+
+		mediaType, _, err := mime.ParseMediaType(c.GetHeader("Content-Type"))
+		if err != nil || mediaType != "application/json" {
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{
+				"error":   "Unsupported content type",
+				"message": "Content-Type must be application/json",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
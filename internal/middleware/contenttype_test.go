@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequireJSONMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		config         ContentTypeConfig
+		method         string
+		contentType    string
+		body           string
+		expectedStatus int
+	}{
+		{
+			name:           "strict accepts application/json",
+			config:         ContentTypeConfig{Strict: true},
+			method:         http.MethodPost,
+			contentType:    "application/json",
+			body:           `{"name": "general"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "strict accepts application/json with charset",
+			config:         ContentTypeConfig{Strict: true},
+			method:         http.MethodPatch,
+			contentType:    "application/json; charset=utf-8",
+			body:           `{"name": "general"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "strict rejects text/plain",
+			config:         ContentTypeConfig{Strict: true},
+			method:         http.MethodPost,
+			contentType:    "text/plain",
+			body:           `{"name": "general"}`,
+			expectedStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:           "strict rejects missing content type",
+			config:         ContentTypeConfig{Strict: true},
+			method:         http.MethodPost,
+			contentType:    "",
+			body:           `{"name": "general"}`,
+			expectedStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:           "strict rejects malformed content type",
+			config:         ContentTypeConfig{Strict: true},
+			method:         http.MethodPut,
+			contentType:    "application/json; =broken",
+			body:           `{"name": "general"}`,
+			expectedStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:           "strict allows write without body",
+			config:         ContentTypeConfig{Strict: true},
+			method:         http.MethodPost,
+			contentType:    "",
+			body:           "",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "strict ignores read requests",
+			config:         ContentTypeConfig{Strict: true},
+			method:         http.MethodGet,
+			contentType:    "text/plain",
+			body:           "hello",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "lenient accepts wrong content type",
+			config:         ContentTypeConfig{Strict: false},
+			method:         http.MethodPost,
+			contentType:    "text/plain",
+			body:           `{"name": "general"}`,
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(RequireJSONMiddleware(tt.config))
+			router.Handle(tt.method, "/test", func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(tt.method, "/test", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
+
+func TestContentTypeConfigFromEnv(t *testing.T) {
+	t.Setenv("STRICT_CONTENT_TYPE", "")
+	if !ContentTypeConfigFromEnv().Strict {
+		t.Error("Expected strict enforcement by default")
+	}
+
+	t.Setenv("STRICT_CONTENT_TYPE", "false")
+	if ContentTypeConfigFromEnv().Strict {
+		t.Error("Expected lenient enforcement when STRICT_CONTENT_TYPE=false")
+	}
+}
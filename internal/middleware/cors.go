@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// corsMaxAge is how long browsers may cache a preflight response, in seconds
+const corsMaxAge = 600
+
+// CORSConfig holds the cross-origin policy for browser clients
+type CORSConfig struct {
+	Origins          OriginConfig // Origins allowed to call the API; same host only when empty
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	AllowCredentials bool // Let browsers send the auth cookies cross-origin
+}
+
+// CORSConfigFromEnv reads the allowed origins from ALLOWED_ORIGINS, shared with the
+// WebSocket origin check, and the comma-separated CORS_ALLOWED_METHODS and
+// CORS_ALLOWED_HEADERS. Credentials are allowed unless CORS_ALLOW_CREDENTIALS is "false".
+func CORSConfigFromEnv() CORSConfig {
+	config := CORSConfig{
+		Origins:          OriginConfigFromEnv(),
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+		AllowedHeaders:   []string{"Content-Type", "Authorization"},
+		AllowCredentials: os.Getenv("CORS_ALLOW_CREDENTIALS") != "false",
+	}
+	if methods := splitList(os.Getenv("CORS_ALLOWED_METHODS")); len(methods) > 0 {
+		config.AllowedMethods = methods
+	}
+	if headers := splitList(os.Getenv("CORS_ALLOWED_HEADERS")); len(headers) > 0 {
+		config.AllowedHeaders = headers
+	}
+	return config
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// CORSMiddleware adds CORS headers for allowed origins and answers preflight
+// requests itself with 204, or 403 when the origin is not allowed. Requests
+// from other origins are still served, without CORS headers, so browsers
+// withhold the response from the calling page.
+func CORSMiddleware(config CORSConfig) gin.HandlerFunc {
+	methods := strings.Join(config.AllowedMethods, ", ")
+	headers := strings.Join(config.AllowedHeaders, ", ")
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+
+		c.Writer.Header().Add("Vary", "Origin")
+		allowed := config.Origins.IsAllowed(origin, c.Request.Host)
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+
+		if !allowed {
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		c.Header("Access-Control-Allow-Origin", origin)
+		if config.AllowCredentials {
+			c.Header("Access-Control-Allow-Credentials", "true")
+		}
+
+		if preflight {
+			c.Header("Access-Control-Allow-Methods", methods)
+			c.Header("Access-Control-Allow-Headers", headers)
+			c.Header("Access-Control-Max-Age", strconv.Itoa(corsMaxAge))
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCORSMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	config := CORSConfig{
+		Origins:          OriginConfig{AllowedOrigins: []string{"https://chat.example.com"}},
+		AllowedMethods:   []string{"GET", "POST"},
+		AllowedHeaders:   []string{"Content-Type"},
+		AllowCredentials: true,
+	}
+
+	router := gin.New()
+	router.Use(CORSMiddleware(config))
+	router.GET("/api/channels", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"channels": []string{}})
+	})
+
+	tests := []struct {
+		name           string
+		method         string
+		origin         string
+		preflight      bool
+		expectedStatus int
+		expectedOrigin string
+	}{
+		{"allowed origin", "GET", "https://chat.example.com", false, http.StatusOK, "https://chat.example.com"},
+		{"disallowed origin", "GET", "https://evil.example.com", false, http.StatusOK, ""},
+		{"no origin", "GET", "", false, http.StatusOK, ""},
+		{"preflight from allowed origin", "OPTIONS", "https://chat.example.com", true, http.StatusNoContent, "https://chat.example.com"},
+		{"preflight from disallowed origin", "OPTIONS", "https://evil.example.com", true, http.StatusForbidden, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/channels", nil)
+			req.Host = "api.example.com"
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.preflight {
+				req.Header.Set("Access-Control-Request-Method", "POST")
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.expectedOrigin {
+				t.Errorf("Expected Access-Control-Allow-Origin %q, got %q", tt.expectedOrigin, got)
+			}
+
+			if tt.expectedOrigin == "" {
+				if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+					t.Errorf("Expected no credentials header, got %q", got)
+				}
+				return
+			}
+			if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+				t.Errorf("Expected credentials to be allowed, got %q", got)
+			}
+			if tt.preflight {
+				if got := w.Header().Get("Access-Control-Allow-Methods"); got != "GET, POST" {
+					t.Errorf("Expected allowed methods, got %q", got)
+				}
+				if got := w.Header().Get("Access-Control-Allow-Headers"); got != "Content-Type" {
+					t.Errorf("Expected allowed headers, got %q", got)
+				}
+			}
+		})
+	}
+}
+
+func TestCORSConfigFromEnv(t *testing.T) {
+	t.Setenv("ALLOWED_ORIGINS", "https://chat.example.com")
+	t.Setenv("CORS_ALLOWED_METHODS", "GET, POST")
+	t.Setenv("CORS_ALLOWED_HEADERS", "Content-Type, X-Request-ID")
+	t.Setenv("CORS_ALLOW_CREDENTIALS", "false")
+
+	config := CORSConfigFromEnv()
+	if !reflect.DeepEqual(config.Origins.AllowedOrigins, []string{"https://chat.example.com"}) {
+		t.Errorf("Unexpected origins %v", config.Origins.AllowedOrigins)
+	}
+	if !reflect.DeepEqual(config.AllowedMethods, []string{"GET", "POST"}) {
+		t.Errorf("Unexpected methods %v", config.AllowedMethods)
+	}
+	if !reflect.DeepEqual(config.AllowedHeaders, []string{"Content-Type", "X-Request-ID"}) {
+		t.Errorf("Unexpected headers %v", config.AllowedHeaders)
+	}
+	if config.AllowCredentials {
+		t.Errorf("Expected credentials to be disabled")
+	}
+
+	t.Setenv("CORS_ALLOWED_METHODS", "")
+	t.Setenv("CORS_ALLOW_CREDENTIALS", "")
+	config = CORSConfigFromEnv()
+	if len(config.AllowedMethods) == 0 || !config.AllowCredentials {
+		t.Errorf("Expected default methods and credentials, got %+v", config)
+	}
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"go-chat/internal/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// unmatchedRoute labels requests that matched no route, so that arbitrary
+// paths do not each create a new series
+const unmatchedRoute = "unmatched"
+
+// MetricsMiddleware records the count and latency of every request by route template
+func MetricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		method := c.Request.Method
+		metrics.HTTPRequests.Inc(method, route, strconv.Itoa(c.Writer.Status()))
+		metrics.HTTPRequestDuration.Observe(time.Since(start).Seconds(), method, route)
+	}
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/url"
+	"os"
+	"strings"
+)
+
+// OriginConfig holds the origins allowed to open cookie-authenticated connections
+type OriginConfig struct {
+	AllowedOrigins []string // Scheme and host, e.g. "https://chat.example.com"; empty means same host only
+}
+
+// OriginConfigFromEnv reads a comma-separated allowlist from ALLOWED_ORIGINS.
+// When unset, only same-host origins are accepted.
+func OriginConfigFromEnv() OriginConfig {
+	var origins []string
+	for _, origin := range strings.Split(os.Getenv("ALLOWED_ORIGINS"), ",") {
+		if origin = normalizeOrigin(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return OriginConfig{AllowedOrigins: origins}
+}
+
+func normalizeOrigin(origin string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(origin)), "/")
+}
+
+// IsAllowed reports whether the origin is in the allowlist, or matches host
+// when no allowlist is configured
+func (c OriginConfig) IsAllowed(origin, host string) bool {
+	origin = normalizeOrigin(origin)
+
+	if len(c.AllowedOrigins) == 0 {
+		u, err := url.Parse(origin)
+		if err != nil || u.Host == "" {
+			return false
+		}
+		return strings.EqualFold(u.Host, host)
+	}
+
+	for _, allowed := range c.AllowedOrigins {
+		if origin == allowed {
+			return true
+		}
+	}
+	return false
+}
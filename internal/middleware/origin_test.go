@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"testing"
+)
+
+func TestOriginConfig_IsAllowed(t *testing.T) {
+	allowlist := OriginConfig{AllowedOrigins: []string{"https://chat.example.com", "http://localhost:3000"}}
+
+	tests := []struct {
+		name     string
+		config   OriginConfig
+		host     string
+		origin   string
+		expected bool
+	}{
+		{"allowed origin", allowlist, "api.example.com", "https://chat.example.com", true},
+		{"allowed origin is case-insensitive", allowlist, "api.example.com", "HTTPS://Chat.Example.com", true},
+		{"second allowed origin", allowlist, "api.example.com", "http://localhost:3000", true},
+		{"disallowed origin", allowlist, "api.example.com", "https://evil.example.com", false},
+		{"scheme must match", allowlist, "api.example.com", "http://chat.example.com", false},
+		{"port must match", allowlist, "api.example.com", "http://localhost:4000", false},
+		{"allowlist ignores same host", allowlist, "api.example.com", "https://api.example.com", false},
+		{"same host by default", OriginConfig{}, "chat.example.com:8443", "https://chat.example.com:8443", true},
+		{"other host rejected by default", OriginConfig{}, "chat.example.com:8443", "https://evil.example.com", false},
+		{"malformed origin rejected by default", OriginConfig{}, "chat.example.com", "null", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.config.IsAllowed(tt.origin, tt.host); got != tt.expected {
+				t.Errorf("IsAllowed(%q) = %v, expected %v", tt.origin, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestOriginConfigFromEnv(t *testing.T) {
+	t.Setenv("ALLOWED_ORIGINS", " https://chat.example.com/ , ,http://localhost:3000")
+
+	config := OriginConfigFromEnv()
+	expected := []string{"https://chat.example.com", "http://localhost:3000"}
+	if len(config.AllowedOrigins) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, config.AllowedOrigins)
+	}
+	for i := range expected {
+		if config.AllowedOrigins[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected, config.AllowedOrigins)
+		}
+	}
+
+	t.Setenv("ALLOWED_ORIGINS", "")
+	if config := OriginConfigFromEnv(); len(config.AllowedOrigins) != 0 {
+		t.Errorf("Expected no allowed origins, got %v", config.AllowedOrigins)
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"sync"
 	"time"
 
+	"go-chat/internal/metrics"
+
 	"github.com/gin-gonic/gin"
 	"golang.org/x/time/rate"
 )
@@ -109,6 +111,7 @@ func RateLimitMiddleware(limiter *IPRateLimiter) gin.HandlerFunc {
 		rateLimiter := limiter.GetLimiter(clientIP)
 		
 		if !rateLimiter.Allow() {
+			metrics.RateLimitRejections.Inc()
 			c.Header("Retry-After", "1") // Suggest retry after 1 second
 			c.JSON(http.StatusTooManyRequests, gin.H{
 				"error":   "Rate limit exceeded",
@@ -131,6 +134,14 @@ var (
 		CleanupInterval:   5 * time.Minute,
 	}
 	
+	// PasswordRateLimit for endpoints that check a password without logging in,
+	// which would otherwise allow brute forcing it
+	PasswordRateLimit = RateLimitConfig{
+		RequestsPerSecond: 1,             // 1 request per second
+		BurstSize:         5,             // Allow burst of 5
+		CleanupInterval:   5 * time.Minute,
+	}
+	
 	// StandardRateLimit for general API endpoints
 	StandardRateLimit = RateLimitConfig{
 		RequestsPerSecond: 30,            // 30 requests per second
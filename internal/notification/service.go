@@ -0,0 +1,111 @@
+package notification
+
+import (
+	"errors"
+
+	. "go-chat/pkg/chat"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultMode applies to channels without a stored preference
+const DefaultMode = NotificationModeAll
+
+type NotificationService struct {
+	db *gorm.DB
+}
+
+func NewNotificationService(db *gorm.DB) *NotificationService {
+	return &NotificationService{db: db}
+}
+
+func IsValidNotificationMode(mode string) bool {
+	switch mode {
+	case NotificationModeAll, NotificationModeMentions, NotificationModeNone:
+		return true
+	}
+	return false
+}
+
+func (s *NotificationService) SetChannelPreference(userID, channelID, mode string) (*ChannelNotificationPref, error) {
+	if !IsValidNotificationMode(mode) {
+		return nil, errors.New("invalid notification mode")
+	}
+
+	var channel Channel
+	if err := s.db.First(&channel, "id = ?", channelID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("channel not found")
+		}
+		return nil, err
+	}
+
+	var userChannel UserChannel
+	if err := s.db.Where("user_id = ? AND channel_id = ?", userID, channelID).First(&userChannel).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("you are not a member of this channel")
+		}
+		return nil, err
+	}
+
+	pref := ChannelNotificationPref{
+		UserID:    userID,
+		ChannelID: channelID,
+		Mode:      mode,
+	}
+	err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "channel_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"mode", "updated_at"}),
+	}).Create(&pref).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &pref, nil
+}
+
+// ChannelSetting is the resolved notification mode of one joined channel
+type ChannelSetting struct {
+	ChannelID   string
+	ChannelName string
+	Mode        string
+	IsDefault   bool // No preference stored; Mode is DefaultMode
+}
+
+// GetSettingsSummary resolves the user's notification mode for every channel
+// they joined, ordered by channel name
+func (s *NotificationService) GetSettingsSummary(userID string) ([]ChannelSetting, error) {
+	var rows []struct {
+		ChannelID   string
+		ChannelName string
+		Mode        *string
+	}
+	err := s.db.Table("user_channels").
+		Select("channels.id AS channel_id, channels.name AS channel_name, channel_notification_prefs.mode AS mode").
+		Joins("JOIN channels ON channels.id = user_channels.channel_id AND channels.deleted_at IS NULL").
+		Joins("LEFT JOIN channel_notification_prefs ON channel_notification_prefs.channel_id = channels.id"+
+			" AND channel_notification_prefs.user_id = user_channels.user_id"+
+			" AND channel_notification_prefs.deleted_at IS NULL").
+		Where("user_channels.user_id = ? AND user_channels.deleted_at IS NULL", userID).
+		Order("channels.name ASC, channels.id ASC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	settings := make([]ChannelSetting, 0, len(rows))
+	for _, row := range rows {
+		setting := ChannelSetting{
+			ChannelID:   row.ChannelID,
+			ChannelName: row.ChannelName,
+			Mode:        DefaultMode,
+			IsDefault:   row.Mode == nil,
+		}
+		if row.Mode != nil {
+			setting.Mode = *row.Mode
+		}
+		settings = append(settings, setting)
+	}
+
+	return settings, nil
+}
@@ -0,0 +1,106 @@
+package notification
+
+import (
+	"testing"
+
+	. "go-chat/pkg/chat"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+
+	err = db.AutoMigrate(&User{}, &Channel{}, &Role{}, &UserChannel{}, &ChannelNotificationPref{})
+	require.NoError(t, err)
+
+	return db
+}
+
+func createMember(t *testing.T, db *gorm.DB, username string, channel *Channel) *User {
+	user := &User{Username: username, Password: "hashed"}
+	require.NoError(t, db.Create(user).Error)
+	require.NoError(t, db.Create(&UserChannel{UserID: user.ID, ChannelID: channel.ID}).Error)
+	return user
+}
+
+func TestNotificationService_SetChannelPreference(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewNotificationService(db)
+
+	owner := &User{Username: "owner", Password: "hashed"}
+	require.NoError(t, db.Create(owner).Error)
+	channel := &Channel{Name: "general", OwnerID: owner.ID}
+	require.NoError(t, db.Create(channel).Error)
+	member := createMember(t, db, "member", channel)
+
+	outsider := &User{Username: "outsider", Password: "hashed"}
+	require.NoError(t, db.Create(outsider).Error)
+
+	_, err := service.SetChannelPreference(member.ID, channel.ID, "loud")
+	assert.EqualError(t, err, "invalid notification mode")
+
+	_, err = service.SetChannelPreference(member.ID, "missing", NotificationModeNone)
+	assert.EqualError(t, err, "channel not found")
+
+	_, err = service.SetChannelPreference(outsider.ID, channel.ID, NotificationModeNone)
+	assert.EqualError(t, err, "you are not a member of this channel")
+
+	// Updating an existing preference replaces it rather than adding a row
+	_, err = service.SetChannelPreference(member.ID, channel.ID, NotificationModeNone)
+	require.NoError(t, err)
+	_, err = service.SetChannelPreference(member.ID, channel.ID, NotificationModeMentions)
+	require.NoError(t, err)
+
+	var prefs []ChannelNotificationPref
+	db.Where("user_id = ? AND channel_id = ?", member.ID, channel.ID).Find(&prefs)
+	require.Len(t, prefs, 1)
+	assert.Equal(t, NotificationModeMentions, prefs[0].Mode)
+}
+
+func TestNotificationService_GetSettingsSummary(t *testing.T) {
+	db := setupTestDB(t)
+	service := NewNotificationService(db)
+
+	owner := &User{Username: "owner", Password: "hashed"}
+	require.NoError(t, db.Create(owner).Error)
+	general := &Channel{Name: "general", OwnerID: owner.ID}
+	random := &Channel{Name: "random", OwnerID: owner.ID}
+	other := &Channel{Name: "other", OwnerID: owner.ID}
+	require.NoError(t, db.Create(general).Error)
+	require.NoError(t, db.Create(random).Error)
+	require.NoError(t, db.Create(other).Error)
+
+	member := createMember(t, db, "member", general)
+	require.NoError(t, db.Create(&UserChannel{UserID: member.ID, ChannelID: random.ID}).Error)
+
+	// Another member's preference must not leak into the summary
+	neighbor := createMember(t, db, "neighbor", general)
+	_, err := service.SetChannelPreference(neighbor.ID, general.ID, NotificationModeNone)
+	require.NoError(t, err)
+
+	settings, err := service.GetSettingsSummary(member.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []ChannelSetting{
+		{ChannelID: general.ID, ChannelName: "general", Mode: DefaultMode, IsDefault: true},
+		{ChannelID: random.ID, ChannelName: "random", Mode: DefaultMode, IsDefault: true},
+	}, settings)
+
+	_, err = service.SetChannelPreference(member.ID, random.ID, NotificationModeMentions)
+	require.NoError(t, err)
+
+	settings, err = service.GetSettingsSummary(member.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []ChannelSetting{
+		{ChannelID: general.ID, ChannelName: "general", Mode: DefaultMode, IsDefault: true},
+		{ChannelID: random.ID, ChannelName: "random", Mode: NotificationModeMentions, IsDefault: false},
+	}, settings)
+
+	settings, err = service.GetSettingsSummary(owner.ID)
+	require.NoError(t, err)
+	assert.Empty(t, settings)
+}
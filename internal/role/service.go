@@ -0,0 +1,69 @@
+package role
+
+import (
+	"errors"
+
+	. "go-chat/pkg/chat"
+	"gorm.io/gorm"
+)
+
+type RoleService struct {
+	db *gorm.DB
+}
+
+func NewRoleService(db *gorm.DB) *RoleService {
+	return &RoleService{db: db}
+}
+
+// FindRole looks up one of the roles seeded at startup; it never creates roles
+func (s *RoleService) FindRole(name string) (*Role, error) {
+	var role Role
+	if err := s.db.Where("name = ?", name).First(&role).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("invalid role")
+		}
+		return nil, err
+	}
+	return &role, nil
+}
+
+// CanActOn reports whether a member holding actorRole may moderate one holding
+// targetRole, which requires strictly outranking them
+func (s *RoleService) CanActOn(actorRole, targetRole Role) bool {
+	return actorRole.Priority > targetRole.Priority
+}
+
+// ListRoles returns the seeded roles, highest priority first
+func (s *RoleService) ListRoles() ([]Role, error) {
+	var roles []Role
+	err := s.db.Where("name IN ?", DefaultRoles).Order("priority DESC").Find(&roles).Error
+	return roles, err
+}
+
+// Permissions summarizes what a member holding a role may do in a channel.
+// Channel owners may do everything regardless of their role.
+type Permissions struct {
+	CanPost           bool // Post messages
+	CanPostWhenLocked bool // Post while the channel is locked to moderators
+	CanBan            bool // Ban members of lower roles
+	CanLock           bool // Lock and unlock the channel
+	CanReviewReports  bool // List and resolve reported messages
+	CanViewModeration bool // View bans and moderation statistics
+	CanPromote        bool // Change members' roles; only owners can
+}
+
+// PermissionsOf derives the permissions of a role from the same checks the
+// channel and message services apply to members
+func PermissionsOf(role Role) Permissions {
+	member := UserChannel{Role: role}
+	moderator := member.IsModerator()
+	return Permissions{
+		CanPost:           !member.IsGuest(),
+		CanPostWhenLocked: moderator,
+		CanBan:            moderator,
+		CanLock:           moderator,
+		CanReviewReports:  moderator,
+		CanViewModeration: moderator,
+		CanPromote:        false,
+	}
+}
@@ -0,0 +1,68 @@
+package role
+
+import (
+	"testing"
+
+	. "go-chat/pkg/chat"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to database: %v", err)
+	}
+	if err := db.AutoMigrate(&Role{}); err != nil {
+		t.Fatalf("Failed to migrate database: %v", err)
+	}
+	for _, name := range DefaultRoles {
+		db.Create(&Role{Name: name, Priority: RolePriorities[name]})
+	}
+	return db
+}
+
+func TestRoleService_CanActOn(t *testing.T) {
+	service := NewRoleService(setupTestDB(t))
+
+	roles := make(map[string]Role)
+	for _, name := range DefaultRoles {
+		role, err := service.FindRole(name)
+		if err != nil {
+			t.Fatalf("Failed to find role %s: %v", name, err)
+		}
+		roles[name] = *role
+	}
+
+	// expected[actor][target]
+	expected := map[string]map[string]bool{
+		"Administrator": {"Administrator": false, "Moderator": true, "Member": true, "Guest": true},
+		"Moderator":     {"Administrator": false, "Moderator": false, "Member": true, "Guest": true},
+		"Member":        {"Administrator": false, "Moderator": false, "Member": false, "Guest": true},
+		"Guest":         {"Administrator": false, "Moderator": false, "Member": false, "Guest": false},
+	}
+
+	for actor, targets := range expected {
+		for target, want := range targets {
+			if got := service.CanActOn(roles[actor], roles[target]); got != want {
+				t.Errorf("CanActOn(%s, %s) = %v, want %v", actor, target, got, want)
+			}
+		}
+	}
+}
+
+func TestRoleService_FindRole(t *testing.T) {
+	service := NewRoleService(setupTestDB(t))
+
+	role, err := service.FindRole("Moderator")
+	if err != nil {
+		t.Fatalf("Expected Moderator to be found, got %v", err)
+	}
+	if role.Priority != RolePriorities["Moderator"] {
+		t.Errorf("Expected priority %d, got %d", RolePriorities["Moderator"], role.Priority)
+	}
+
+	if _, err := service.FindRole("Owner"); err == nil || err.Error() != "invalid role" {
+		t.Errorf("Expected invalid role error, got %v", err)
+	}
+}
@@ -2,21 +2,76 @@ package search
 
 import (
 	"errors"
+	"os"
+	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
+	ch "go-chat/internal/channel"
 	. "go-chat/pkg/chat"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// QueryLimits bounds the size and complexity of search queries
+type QueryLimits struct {
+	MaxLength int // Maximum query length in characters
+	MaxTerms  int // Maximum number of whitespace-separated terms
+}
+
+// DefaultQueryLimits are used when no override is configured
+var DefaultQueryLimits = QueryLimits{
+	MaxLength: 100,
+	MaxTerms:  8,
+}
+
+// QueryLimitsFromEnv reads SEARCH_MAX_QUERY_LENGTH and SEARCH_MAX_QUERY_TERMS,
+// falling back to DefaultQueryLimits for unset or invalid values
+func QueryLimitsFromEnv() QueryLimits {
+	limits := DefaultQueryLimits
+	if n, err := strconv.Atoi(os.Getenv("SEARCH_MAX_QUERY_LENGTH")); err == nil && n > 0 {
+		limits.MaxLength = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("SEARCH_MAX_QUERY_TERMS")); err == nil && n > 0 {
+		limits.MaxTerms = n
+	}
+	return limits
+}
+
+// Validate rejects queries exceeding the configured limits
+func (l QueryLimits) Validate(query string) error {
+	if utf8.RuneCountInString(query) > l.MaxLength {
+		return errors.New("search query is too long")
+	}
+	if len(strings.Fields(query)) > l.MaxTerms {
+		return errors.New("search query has too many terms")
+	}
+	return nil
+}
+
 type SearchService struct {
-	db *gorm.DB
+	db     *gorm.DB
+	limits QueryLimits
 }
 
 func NewSearchService(db *gorm.DB) *SearchService {
-	return &SearchService{db: db}
+	return &SearchService{
+		db:     db,
+		limits: QueryLimitsFromEnv(),
+	}
+}
+
+// SetQueryLimits overrides the query limits read from the environment
+func (s *SearchService) SetQueryLimits(limits QueryLimits) {
+	s.limits = limits
 }
 
-func (s *SearchService) SearchUsers(searcherID, query string, limit int) ([]User, int64, error) {
+func (s *SearchService) SearchUsers(searcherID, query string, limit, offset int) ([]User, int64, error) {
+	if err := s.limits.Validate(query); err != nil {
+		return nil, 0, err
+	}
+
 	// Clean query for SQL LIKE
 	likeQuery := "%" + strings.ToLower(query) + "%"
 
@@ -31,7 +86,8 @@ func (s *SearchService) SearchUsers(searcherID, query string, limit int) ([]User
 	var users []User
 	searchQuery := s.db.Where("LOWER(username) LIKE ? AND id != ?", likeQuery, searcherID).
 		Order("username ASC").
-		Limit(limit)
+		Limit(limit).
+		Offset(offset)
 
 	if err := searchQuery.Find(&users).Error; err != nil {
 		return nil, 0, err
@@ -40,13 +96,30 @@ func (s *SearchService) SearchUsers(searcherID, query string, limit int) ([]User
 	return users, total, nil
 }
 
-func (s *SearchService) SearchChannels(searcherID, query string, limit int) ([]Channel, int64, error) {
+// SearchChannels searches visible channels by name. In discover mode, channels the
+// searcher already joined or is actively banned from are left out.
+func (s *SearchService) SearchChannels(searcherID, query string, sort ch.ChannelSort, discover bool, limit, offset int) ([]Channel, int64, error) {
+	if err := s.limits.Validate(query); err != nil {
+		return nil, 0, err
+	}
+
 	// Clean query for SQL LIKE
 	likeQuery := "%" + strings.ToLower(query) + "%"
 
+	matching := func(db *gorm.DB) *gorm.DB {
+		db = db.Where("LOWER(name) LIKE ? AND is_visible = ?", likeQuery, true)
+		if discover {
+			db = db.Where("id NOT IN (?)", s.db.Model(&UserChannel{}).Select("channel_id").Where("user_id = ?", searcherID)).
+				Where("id NOT IN (?)", s.db.Model(&UserBan{}).Select("channel_id").
+					Where("user_id = ? AND is_active = ?", searcherID, true).
+					Where("expires_at IS NULL OR expires_at > ?", time.Now()))
+		}
+		return db
+	}
+
 	// Count total matching visible channels
 	var total int64
-	countQuery := s.db.Model(&Channel{}).Where("LOWER(name) LIKE ? AND is_visible = ?", likeQuery, true)
+	countQuery := s.db.Model(&Channel{}).Scopes(matching)
 	if err := countQuery.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
@@ -54,9 +127,10 @@ func (s *SearchService) SearchChannels(searcherID, query string, limit int) ([]C
 	// Find matching visible channels with owner information
 	var channels []Channel
 	searchQuery := s.db.Preload("Owner").
-		Where("LOWER(name) LIKE ? AND is_visible = ?", likeQuery, true).
-		Order("name ASC").
-		Limit(limit)
+		Scopes(matching).
+		Order(sort.OrderClause()).
+		Limit(limit).
+		Offset(offset)
 
 	if err := searchQuery.Find(&channels).Error; err != nil {
 		return nil, 0, err
@@ -65,7 +139,11 @@ func (s *SearchService) SearchChannels(searcherID, query string, limit int) ([]C
 	return channels, total, nil
 }
 
-func (s *SearchService) SearchMessages(searcherID, channelID, query string, limit int) ([]Message, int64, error) {
+func (s *SearchService) SearchMessages(searcherID, channelID, query string, limit, offset int) ([]Message, int64, error) {
+	if err := s.limits.Validate(query); err != nil {
+		return nil, 0, err
+	}
+
 	// Check if channel exists
 	var channel Channel
 	if err := s.db.First(&channel, "id = ?", channelID).Error; err != nil {
@@ -89,26 +167,50 @@ func (s *SearchService) SearchMessages(searcherID, channelID, query string, limi
 		return nil, 0, err
 	}
 
-	// Clean query for SQL LIKE
-	likeQuery := "%" + strings.ToLower(query) + "%"
+	// Postgres ranks by full-text relevance; other databases fall back to a substring scan
+	matching, order := s.likeMessageQuery(query)
+	if s.db.Dialector.Name() == "postgres" {
+		matching, order = fullTextMessageQuery(query)
+	}
 
 	// Count total matching messages in the channel
 	var total int64
-	countQuery := s.db.Model(&Message{}).Where("channel_id = ? AND LOWER(content) LIKE ?", channelID, likeQuery)
+	countQuery := s.db.Model(&Message{}).Where("channel_id = ?", channelID).Where(matching)
 	if err := countQuery.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	// Find matching messages with user information
 	var messages []Message
-	searchQuery := s.db.Preload("User").
-		Where("channel_id = ? AND LOWER(content) LIKE ?", channelID, likeQuery).
-		Order("created_at DESC").
-		Limit(limit)
+	searchQuery := s.db.Preload("User").Preload("Attachments").
+		Where("channel_id = ?", channelID).
+		Where(matching).
+		Order(order).
+		Limit(limit).
+		Offset(offset)
 
 	if err := searchQuery.Find(&messages).Error; err != nil {
 		return nil, 0, err
 	}
 
 	return messages, total, nil
-}
\ No newline at end of file
+}
+
+// likeMessageQuery matches message content containing the query, newest first
+func (s *SearchService) likeMessageQuery(query string) (clause.Expr, clause.Expr) {
+	// Clean query for SQL LIKE
+	likeQuery := "%" + strings.ToLower(query) + "%"
+	return clause.Expr{SQL: "LOWER(content) LIKE ?", Vars: []interface{}{likeQuery}},
+		clause.Expr{SQL: "created_at DESC"}
+}
+
+// fullTextMessageQuery matches messages containing any query term using the
+// content_tsv column maintained on Postgres, ranking messages that contain more
+// of the terms first and breaking ties by recency
+func fullTextMessageQuery(query string) (clause.Expr, clause.Expr) {
+	// plainto_tsquery normalizes the input into terms joined by &; joining them
+	// by | instead lets partial matches through to be ranked
+	tsQuery := "replace(plainto_tsquery('simple', ?)::text, '&', '|')::tsquery"
+	return clause.Expr{SQL: "content_tsv @@ " + tsQuery, Vars: []interface{}{query}},
+		clause.Expr{SQL: "ts_rank(content_tsv, " + tsQuery + ") DESC, created_at DESC", Vars: []interface{}{query}}
+}
@@ -0,0 +1,59 @@
+package search
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"go-chat/internal/storage"
+	. "go-chat/pkg/chat"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSearchService_SearchMessages_FullTextRanking runs against the Postgres
+// database in TEST_POSTGRES_DSN, inside a transaction that is rolled back
+func TestSearchService_SearchMessages_FullTextRanking(t *testing.T) {
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN not set")
+	}
+	t.Setenv("DB_DRIVER", storage.DriverPostgres)
+	t.Setenv("DB_DSN", dsn)
+
+	db, err := storage.Connect()
+	require.NoError(t, err)
+
+	tx := db.Begin()
+	require.NoError(t, tx.Error)
+	defer tx.Rollback()
+
+	user := &User{Username: "fts_searcher", Password: "hashed"}
+	require.NoError(t, tx.Create(user).Error)
+
+	var role Role
+	require.NoError(t, tx.First(&role, "name = ?", "Member").Error)
+
+	channel := &Channel{Name: "fts-channel", OwnerID: user.ID, LoggingDays: 30}
+	require.NoError(t, tx.Create(channel).Error)
+	require.NoError(t, tx.Create(&UserChannel{UserID: user.ID, ChannelID: channel.ID, RoleID: &role.ID}).Error)
+
+	// The partial match is the newest, so recency alone would list it first
+	now := time.Now()
+	both := &Message{ChannelID: channel.ID, UserID: user.ID, Content: "the deploy pipeline is green again", CreatedAt: now.Add(-2 * time.Minute)}
+	partial := &Message{ChannelID: channel.ID, UserID: user.ID, Content: "who broke the pipeline", CreatedAt: now.Add(-time.Minute)}
+	unrelated := &Message{ChannelID: channel.ID, UserID: user.ID, Content: "lunch anyone?", CreatedAt: now}
+	for _, message := range []*Message{both, partial, unrelated} {
+		require.NoError(t, tx.Create(message).Error)
+	}
+
+	service := NewSearchService(tx)
+	messages, total, err := service.SearchMessages(user.ID, channel.ID, "Deploy pipeline", 10, 0)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(2), total)
+	require.Len(t, messages, 2)
+	assert.Equal(t, both.ID, messages[0].ID)
+	assert.Equal(t, partial.ID, messages[1].ID)
+}
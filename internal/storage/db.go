@@ -1,10 +1,13 @@
 package storage
 
 import (
-	"log"
+	"errors"
+	"os"
+	"strings"
 
 	. "go-chat/pkg/chat"
 
+	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
@@ -12,50 +15,235 @@ const (
 	DBPath = "gochat.db"
 )
 
+// Supported database drivers
+const (
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+)
+
+// Config selects the database backend.
+//
+// Queries stay portable by matching case-insensitively with LOWER(column) LIKE
+// on a lowercased pattern rather than Postgres' ILIKE, which SQLite lacks.
+// SQLite's LIKE is case-insensitive for ASCII only, while Postgres' LIKE is
+// case-sensitive, so new queries must lowercase both sides too.
+type Config struct {
+	Driver string // DriverSQLite or DriverPostgres
+	DSN    string // Database file for SQLite, connection string for Postgres
+}
+
+// ConfigFromEnv reads DB_DRIVER and DB_DSN, defaulting to SQLite in DBPath
+func ConfigFromEnv() Config {
+	config := Config{
+		Driver: strings.ToLower(strings.TrimSpace(os.Getenv("DB_DRIVER"))),
+		DSN:    os.Getenv("DB_DSN"),
+	}
+	if config.Driver == "" {
+		config.Driver = DriverSQLite
+	}
+	if config.DSN == "" && config.Driver == DriverSQLite {
+		config.DSN = DBPath
+	}
+	return config
+}
+
+// Open connects to the configured database without migrating it
+func Open(config Config) (*gorm.DB, error) {
+	var dialector gorm.Dialector
+	switch config.Driver {
+	case DriverSQLite:
+		dialector = sqlite.Open(config.DSN)
+	case DriverPostgres:
+		if config.DSN == "" {
+			return nil, errors.New("DB_DSN is required for postgres")
+		}
+		dialector = postgres.Open(config.DSN)
+	default:
+		return nil, errors.New("unsupported database driver: " + config.Driver)
+	}
+
+	return gorm.Open(dialector, &gorm.Config{})
+}
+
+// Connect opens the database configured in the environment, migrates every
+// model and seeds the default roles
 func Connect() (*gorm.DB, error) {
-	db, err := gorm.Open(sqlite.Open(DBPath), &gorm.Config{})
+	return ConnectWith(ConfigFromEnv())
+}
+
+// ConnectWith opens the given database, migrates every model and seeds the
+// default roles
+func ConnectWith(config Config) (*gorm.DB, error) {
+	db, err := Open(config)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := deactivateDuplicateBans(db); err != nil {
+		return nil, err
+	}
+	backfillActivity := db.Migrator().HasTable(&Channel{}) && !db.Migrator().HasColumn(&Channel{}, "LastMessageAt")
+	backfillSeq := db.Migrator().HasTable(&Message{}) && !db.Migrator().HasColumn(&Message{}, "Seq")
+	backfillPlaceholders := db.Migrator().HasTable(&User{}) && !db.Migrator().HasColumn(&User{}, "IsPlaceholder")
+
 	err = db.AutoMigrate(
 		&User{},
 		&RefreshToken{},
+		&ApiToken{},
 		&UserIP{},
+		&ChannelCategory{},
 		&Channel{},
 		&Role{},
 		&UserChannel{},
+		&UserBan{},
+		&ChannelNotificationPref{},
+		&ChannelReadState{},
+		&Message{},
+		&Attachment{},
+		&MessageReport{},
+		&AuditLog{},
 	)
 
 	if err != nil {
 		return nil, err
 	}
 
-	seedRoles(db)
+	if backfillActivity {
+		if err := backfillLastMessageAt(db); err != nil {
+			return nil, err
+		}
+	}
+
+	if backfillSeq {
+		if err := backfillMessageSeq(db); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := dropBcryptRefreshTokens(db); err != nil {
+		return nil, err
+	}
+
+	if backfillPlaceholders {
+		if err := markPlaceholderUsers(db); err != nil {
+			return nil, err
+		}
+	}
+
+	if db.Dialector.Name() == DriverPostgres {
+		if err := migrateFullTextSearch(db); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := dropLegacyIndexes(db); err != nil {
+		return nil, err
+	}
+
+	if err := SeedRoles(db); err != nil {
+		return nil, err
+	}
 
 	return db, nil
 }
 
-func seedRoles(db *gorm.DB) {
-	roles := []Role{
-		{Name: "Administrator"},
-		{Name: "Moderator"},
-		{Name: "Member"},
-		{Name: "Guest"},
+// deactivateDuplicateBans keeps only the newest active ban of each user in a
+// channel, so that the unique index on active bans can be created on databases
+// from before it existed
+func deactivateDuplicateBans(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&UserBan{}) {
+		return nil
 	}
 
-	for _, role := range roles {
-		var existing Role
-		err := db.First(&existing, "name = ?", role.Name).Error
-		if err == nil {
+	newest := db.Model(&UserBan{}).Select("MAX(id)").Where("is_active = ?", true).Group("user_id, channel_id")
+	return db.Model(&UserBan{}).
+		Where("is_active = ? AND id NOT IN (?)", true, newest).
+		Update("is_active", false).Error
+}
+
+// backfillLastMessageAt sets the latest activity of channels from their stored
+// messages, for databases from before it was tracked
+func backfillLastMessageAt(db *gorm.DB) error {
+	latest := db.Model(&Message{}).Select("MAX(created_at)").Where("messages.channel_id = channels.id")
+	return db.Model(&Channel{}).Where("1 = 1").UpdateColumn("last_message_at", latest).Error
+}
+
+// backfillMessageSeq numbers the stored messages of each channel in creation
+// order and moves the channel counters past them, for databases from before
+// messages were sequenced. Deleted messages keep their place.
+func backfillMessageSeq(db *gorm.DB) error {
+	if err := db.Exec(`UPDATE messages SET seq = (
+		SELECT COUNT(*) FROM messages AS earlier
+		WHERE earlier.channel_id = messages.channel_id
+		AND (earlier.created_at < messages.created_at OR (earlier.created_at = messages.created_at AND earlier.id <= messages.id)))`).Error; err != nil {
+		return err
+	}
+
+	latest := db.Unscoped().Model(&Message{}).Select("COALESCE(MAX(seq), 0)").Where("messages.channel_id = channels.id")
+	return db.Unscoped().Model(&Channel{}).Where("1 = 1").UpdateColumn("last_seq", latest).Error
+}
+
+// markPlaceholderUsers flags the scrubbed author created before placeholders
+// were flagged. It is the only account with that name and no password.
+func markPlaceholderUsers(db *gorm.DB) error {
+	return db.Unscoped().Model(&User{}).
+		Where("username = ? AND (password = '' OR password IS NULL)", ScrubbedUsername).
+		Update("is_placeholder", true).Error
+}
+
+// dropBcryptRefreshTokens deletes refresh tokens stored as bcrypt hashes
+// before they were stored as SHA-256 digests. They can no longer be looked up,
+// so their sessions sign in again.
+func dropBcryptRefreshTokens(db *gorm.DB) error {
+	return db.Unscoped().Where("token_hash LIKE ?", "$2%").Delete(&RefreshToken{}).Error
+}
+
+// dropLegacyIndexes removes indexes that earlier schemas created and that the
+// current models no longer declare, since AutoMigrate never drops them
+func dropLegacyIndexes(db *gorm.DB) error {
+	legacy := []struct {
+		model interface{}
+		name  string
+	}{
+		// Channel names used to be globally unique; they are now unique per owner
+		{&Channel{}, "idx_channels_name"},
+		// Superseded by composite indexes with the same leading column
+		{&Message{}, "idx_messages_channel_id"},
+		{&UserBan{}, "idx_user_bans_channel_id"},
+	}
+
+	for _, index := range legacy {
+		if !db.Migrator().HasIndex(index.model, index.name) {
 			continue
 		}
+		if err := db.Migrator().DropIndex(index.model, index.name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-		log.Printf("failed to find role %s: %v", role.Name, err)
-		log.Printf("inserting role %s", role.Name)
+// migrateFullTextSearch maintains a tsvector of message content, indexed for
+// full-text search. It is Postgres-only and not part of the Message model.
+func migrateFullTextSearch(db *gorm.DB) error {
+	if err := db.Exec(`ALTER TABLE messages ADD COLUMN IF NOT EXISTS content_tsv tsvector
+		GENERATED ALWAYS AS (to_tsvector('simple', coalesce(content, ''))) STORED`).Error; err != nil {
+		return err
+	}
+	return db.Exec("CREATE INDEX IF NOT EXISTS idx_messages_content_tsv ON messages USING GIN (content_tsv)").Error
+}
 
-		if err := db.Create(&role).Error; err != nil {
-			log.Printf("failed to insert role %s: %v", role.Name, err)
+// SeedRoles creates any of the DefaultRoles that are missing and keeps their
+// priorities current. It is safe to run on every start.
+func SeedRoles(db *gorm.DB) error {
+	for _, name := range DefaultRoles {
+		var role Role
+		err := db.Where(Role{Name: name}).
+			Assign(map[string]interface{}{"priority": RolePriorities[name]}).
+			FirstOrCreate(&role).Error
+		if err != nil {
+			return err
 		}
 	}
+	return nil
 }
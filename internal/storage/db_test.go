@@ -0,0 +1,390 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "go-chat/pkg/chat"
+
+	"gorm.io/gorm"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		driver   string
+		dsn      string
+		expected Config
+	}{
+		{"defaults to sqlite file", "", "", Config{Driver: DriverSQLite, DSN: DBPath}},
+		{"sqlite with custom path", "sqlite", "/tmp/chat.db", Config{Driver: DriverSQLite, DSN: "/tmp/chat.db"}},
+		{"postgres", " Postgres ", "postgres://chat@localhost/chat", Config{Driver: DriverPostgres, DSN: "postgres://chat@localhost/chat"}},
+		{"postgres without dsn", "postgres", "", Config{Driver: DriverPostgres}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DB_DRIVER", tt.driver)
+			t.Setenv("DB_DSN", tt.dsn)
+
+			if config := ConfigFromEnv(); config != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, config)
+			}
+		})
+	}
+}
+
+func TestOpen_InvalidConfig(t *testing.T) {
+	if _, err := Open(Config{Driver: "oracle", DSN: "db"}); err == nil || err.Error() != "unsupported database driver: oracle" {
+		t.Errorf("Expected unsupported driver error, got %v", err)
+	}
+	if _, err := Open(Config{Driver: DriverPostgres}); err == nil || err.Error() != "DB_DSN is required for postgres" {
+		t.Errorf("Expected missing DSN error, got %v", err)
+	}
+}
+
+// TestConnect migrates a fresh SQLite database, or the Postgres database in
+// TEST_POSTGRES_DSN when it is set
+func TestConnect(t *testing.T) {
+	if dsn := os.Getenv("TEST_POSTGRES_DSN"); dsn != "" {
+		t.Setenv("DB_DRIVER", DriverPostgres)
+		t.Setenv("DB_DSN", dsn)
+	} else {
+		t.Setenv("DB_DRIVER", DriverSQLite)
+		t.Setenv("DB_DSN", filepath.Join(t.TempDir(), "test.db"))
+	}
+
+	db, err := Connect()
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+
+	for _, model := range []interface{}{&User{}, &Channel{}, &UserChannel{}, &Message{}, &AuditLog{}, &ChannelReadState{}} {
+		if !db.Migrator().HasTable(model) {
+			t.Errorf("Expected table for %T to be migrated", model)
+		}
+	}
+
+	var roles int64
+	db.Model(&Role{}).Where("name IN ?", []string{"Administrator", "Moderator", "Member", "Guest"}).Count(&roles)
+	if roles != 4 {
+		t.Errorf("Expected 4 seeded roles, got %d", roles)
+	}
+}
+
+func TestConnect_CreatesQueryIndexes(t *testing.T) {
+	t.Setenv("DB_DRIVER", DriverSQLite)
+	t.Setenv("DB_DSN", filepath.Join(t.TempDir(), "test.db"))
+
+	db, err := Connect()
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+
+	indexes := []struct {
+		model interface{}
+		name  string
+	}{
+		{&Message{}, "idx_messages_channel_created"},
+		{&UserChannel{}, "idx_user_channels_user_id"},
+		{&UserChannel{}, "idx_user_channels_channel_id"},
+		{&UserBan{}, "idx_user_bans_channel_active"},
+		{&AuditLog{}, "idx_audit_logs_channel_created"},
+	}
+	for _, index := range indexes {
+		if !db.Migrator().HasIndex(index.model, index.name) {
+			t.Errorf("Expected index %s on %T", index.name, index.model)
+		}
+	}
+
+	for _, legacy := range []string{"idx_messages_channel_id", "idx_user_bans_channel_id"} {
+		if db.Migrator().HasIndex(&Message{}, legacy) || db.Migrator().HasIndex(&UserBan{}, legacy) {
+			t.Errorf("Expected superseded index %s to be dropped", legacy)
+		}
+	}
+
+	// The ordered history and audit queries must be served by the composite
+	// index, without sorting the channel's rows in a temporary b-tree
+	plans := []struct {
+		name  string
+		index string
+		query func(tx *gorm.DB) *gorm.DB
+	}{
+		{"message history", "idx_messages_channel_created", func(tx *gorm.DB) *gorm.DB {
+			return tx.Where("channel_id = ?", "channel").Order("created_at DESC").Limit(50).Find(&[]Message{})
+		}},
+		{"channel audit log", "idx_audit_logs_channel_created", func(tx *gorm.DB) *gorm.DB {
+			return tx.Where("channel_id = ?", "channel").Order("created_at DESC").Limit(50).Find(&[]AuditLog{})
+		}},
+	}
+	for _, plan := range plans {
+		t.Run(plan.name, func(t *testing.T) {
+			detail := explainQueryPlan(t, db, db.ToSQL(plan.query))
+			if !strings.Contains(detail, plan.index) {
+				t.Errorf("Expected plan to use %s, got %q", plan.index, detail)
+			}
+			if strings.Contains(detail, "TEMP B-TREE") {
+				t.Errorf("Expected plan without a sort step, got %q", detail)
+			}
+		})
+	}
+}
+
+// explainQueryPlan returns SQLite's query plan details for query, one step per line
+func explainQueryPlan(t *testing.T, db *gorm.DB, query string) string {
+	t.Helper()
+
+	rows, err := db.Raw("EXPLAIN QUERY PLAN " + query).Rows()
+	if err != nil {
+		t.Fatalf("Failed to explain %q: %v", query, err)
+	}
+	defer rows.Close()
+
+	var steps []string
+	for rows.Next() {
+		var id, parent, notUsed int
+		var detail string
+		if err := rows.Scan(&id, &parent, &notUsed, &detail); err != nil {
+			t.Fatalf("Failed to scan query plan: %v", err)
+		}
+		steps = append(steps, detail)
+	}
+	return strings.Join(steps, "\n")
+}
+
+func TestConnect_DeactivatesDuplicateBans(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "test.db")
+	t.Setenv("DB_DRIVER", DriverSQLite)
+	t.Setenv("DB_DSN", dsn)
+
+	// A database from before active bans were unique
+	legacy, err := Open(Config{Driver: DriverSQLite, DSN: dsn})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	if err := legacy.AutoMigrate(&UserBan{}); err != nil {
+		t.Fatalf("Failed to migrate bans: %v", err)
+	}
+	if err := legacy.Migrator().DropIndex(&UserBan{}, "idx_user_bans_active_user"); err != nil {
+		t.Fatalf("Failed to drop index: %v", err)
+	}
+	older := UserBan{UserID: "user", ChannelID: "channel", BannedBy: "admin", IsActive: true}
+	newer := UserBan{UserID: "user", ChannelID: "channel", BannedBy: "admin", IsActive: true}
+	legacy.Create(&older)
+	legacy.Create(&newer)
+
+	db, err := Connect()
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	if !db.Migrator().HasIndex(&UserBan{}, "idx_user_bans_active_user") {
+		t.Errorf("Expected unique index on active bans")
+	}
+
+	var active []UserBan
+	db.Where("is_active = ?", true).Find(&active)
+	if len(active) != 1 || active[0].ID != newer.ID {
+		t.Errorf("Expected only the newest ban to stay active, got %+v", active)
+	}
+
+	duplicate := UserBan{UserID: "user", ChannelID: "channel", BannedBy: "admin", IsActive: true}
+	if err := db.Create(&duplicate).Error; err == nil {
+		t.Errorf("Expected a second active ban to be rejected")
+	}
+}
+
+func TestConnect_BackfillsLastMessageAt(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "test.db")
+	t.Setenv("DB_DRIVER", DriverSQLite)
+	t.Setenv("DB_DSN", dsn)
+
+	// A database from before channel activity was tracked
+	legacy, err := Open(Config{Driver: DriverSQLite, DSN: dsn})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	if err := legacy.AutoMigrate(&Channel{}, &Message{}); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	if err := legacy.Migrator().DropColumn(&Channel{}, "LastMessageAt"); err != nil {
+		t.Fatalf("Failed to drop column: %v", err)
+	}
+	legacy.Exec("INSERT INTO channels (id, name, created_at, updated_at) VALUES ('active', 'active', ?, ?), ('idle', 'idle', ?, ?)",
+		time.Now(), time.Now(), time.Now(), time.Now())
+	latest := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	for id, at := range map[string]time.Time{"m1": latest.Add(-time.Hour), "m2": latest} {
+		legacy.Exec("INSERT INTO messages (id, content, user_id, channel_id, created_at, updated_at) VALUES (?, 'hi', 'user', 'active', ?, ?)", id, at, at)
+	}
+
+	db, err := Connect()
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+
+	var active, idle Channel
+	db.First(&active, "id = ?", "active")
+	db.First(&idle, "id = ?", "idle")
+	if active.LastMessageAt == nil || !active.LastMessageAt.Equal(latest) {
+		t.Errorf("Expected last_message_at %v, got %v", latest, active.LastMessageAt)
+	}
+	if idle.LastMessageAt != nil {
+		t.Errorf("Expected no activity for a channel without messages, got %v", idle.LastMessageAt)
+	}
+}
+
+func TestConnect_BackfillsMessageSeq(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "test.db")
+	t.Setenv("DB_DRIVER", DriverSQLite)
+	t.Setenv("DB_DSN", dsn)
+
+	// A database from before messages were sequenced
+	legacy, err := Open(Config{Driver: DriverSQLite, DSN: dsn})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	if err := legacy.AutoMigrate(&Channel{}, &Message{}); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	if err := legacy.Migrator().DropColumn(&Message{}, "Seq"); err != nil {
+		t.Fatalf("Failed to drop column: %v", err)
+	}
+	if err := legacy.Migrator().DropColumn(&Channel{}, "LastSeq"); err != nil {
+		t.Fatalf("Failed to drop column: %v", err)
+	}
+	legacy.Exec("INSERT INTO channels (id, name, created_at, updated_at) VALUES ('busy', 'busy', ?, ?), ('quiet', 'quiet', ?, ?)",
+		time.Now(), time.Now(), time.Now(), time.Now())
+	base := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	legacy.Exec("INSERT INTO messages (id, content, user_id, channel_id, created_at, updated_at) VALUES "+
+		"('m3', 'third', 'user', 'busy', ?, ?), ('m1', 'first', 'user', 'busy', ?, ?), ('m2', 'tied', 'user', 'busy', ?, ?)",
+		base.Add(time.Minute), base.Add(time.Minute), base, base, base, base)
+	legacy.Exec("UPDATE messages SET deleted_at = ? WHERE id = 'm2'", time.Now())
+
+	db, err := Connect()
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+
+	var messages []Message
+	db.Unscoped().Where("channel_id = ?", "busy").Order("seq").Find(&messages)
+	var order []string
+	for _, message := range messages {
+		order = append(order, message.ID)
+	}
+	if strings.Join(order, ",") != "m1,m2,m3" || messages[2].Seq != 3 {
+		t.Errorf("Expected m1,m2,m3 numbered 1 to 3, got %v", messages)
+	}
+
+	var busy, quiet Channel
+	db.First(&busy, "id = ?", "busy")
+	db.First(&quiet, "id = ?", "quiet")
+	if busy.LastSeq != 3 || quiet.LastSeq != 0 {
+		t.Errorf("Expected counters 3 and 0, got %d and %d", busy.LastSeq, quiet.LastSeq)
+	}
+
+	next := Message{Content: "fourth", UserID: "user", ChannelID: "busy"}
+	if err := db.Create(&next).Error; err != nil {
+		t.Fatalf("Failed to create message: %v", err)
+	}
+	if next.Seq != 4 {
+		t.Errorf("Expected the next message to continue at 4, got %d", next.Seq)
+	}
+}
+
+func TestConnect_DropsBcryptRefreshTokens(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "test.db")
+	t.Setenv("DB_DRIVER", DriverSQLite)
+	t.Setenv("DB_DSN", dsn)
+
+	// Sessions from before refresh tokens were stored as digests
+	legacy, err := Open(Config{Driver: DriverSQLite, DSN: dsn})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	if err := legacy.AutoMigrate(&RefreshToken{}); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	expiresAt := time.Now().Add(time.Hour).Unix()
+	legacy.Create(&RefreshToken{UserID: "user", TokenHash: "$2a$10$legacybcrypthash", ExpiresAt: expiresAt})
+	legacy.Create(&RefreshToken{UserID: "user", TokenHash: "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08", ExpiresAt: expiresAt})
+
+	db, err := Connect()
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+
+	var hashes []string
+	db.Unscoped().Model(&RefreshToken{}).Pluck("token_hash", &hashes)
+	if len(hashes) != 1 || strings.HasPrefix(hashes[0], "$2") {
+		t.Errorf("Expected only the digest token to remain, got %v", hashes)
+	}
+}
+
+func TestConnect_MarksPlaceholderUsers(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "test.db")
+	t.Setenv("DB_DRIVER", DriverSQLite)
+	t.Setenv("DB_DSN", dsn)
+
+	// A database from before placeholder accounts were flagged
+	legacy, err := Open(Config{Driver: DriverSQLite, DSN: dsn})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	if err := legacy.AutoMigrate(&User{}); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	if err := legacy.Migrator().DropColumn(&User{}, "IsPlaceholder"); err != nil {
+		t.Fatalf("Failed to drop column: %v", err)
+	}
+	legacy.Exec("INSERT INTO users (id, username, password, created_at, updated_at) VALUES ('scrub', ?, '', ?, ?), ('alice', 'alice', 'hash', ?, ?)",
+		ScrubbedUsername, time.Now(), time.Now(), time.Now(), time.Now())
+
+	db, err := Connect()
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+
+	var placeholder, alice User
+	db.First(&placeholder, "id = ?", "scrub")
+	db.First(&alice, "id = ?", "alice")
+	if !placeholder.IsPlaceholder {
+		t.Error("Expected the scrubbed author to be flagged as a placeholder")
+	}
+	if alice.IsPlaceholder {
+		t.Error("Expected a regular account not to be flagged")
+	}
+}
+
+func TestSeedRoles_Idempotent(t *testing.T) {
+	db, err := Open(Config{Driver: DriverSQLite, DSN: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	if err := db.AutoMigrate(&Role{}); err != nil {
+		t.Fatalf("Failed to migrate roles: %v", err)
+	}
+	// A role left over from before priorities existed
+	db.Create(&Role{Name: "Administrator"})
+
+	for i := 0; i < 2; i++ {
+		if err := SeedRoles(db); err != nil {
+			t.Fatalf("Seeding run %d failed: %v", i+1, err)
+		}
+	}
+
+	var names []string
+	db.Model(&Role{}).Order("id").Pluck("name", &names)
+	if strings.Join(names, ",") != strings.Join(DefaultRoles, ",") {
+		t.Errorf("Expected roles %v seeded once, got %v", DefaultRoles, names)
+	}
+
+	for _, name := range DefaultRoles {
+		var role Role
+		db.Where("name = ?", name).First(&role)
+		if role.Priority != RolePriorities[name] {
+			t.Errorf("Expected %s priority %d, got %d", name, RolePriorities[name], role.Priority)
+		}
+	}
+}
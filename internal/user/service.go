@@ -3,18 +3,66 @@ package user
 import (
 	"errors"
 	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
 
+	"go-chat/internal/audit"
+	"go-chat/internal/channel"
+	"go-chat/internal/logger"
+	"go-chat/internal/utils"
 	"go-chat/pkg/chat"
-	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
+// OrphanedChannelPolicy decides what happens to the channels of a deleted account
+type OrphanedChannelPolicy string
+
+const (
+	// OrphanedChannelsTransfer hands each channel to its highest-ranking remaining
+	// Administrator, and deletes the channels that have none
+	OrphanedChannelsTransfer OrphanedChannelPolicy = "transfer"
+	// OrphanedChannelsDelete deletes every channel the account owned
+	OrphanedChannelsDelete OrphanedChannelPolicy = "delete"
+)
+
+// OrphanedChannelPolicyFromEnv reads ORPHANED_CHANNEL_POLICY, defaulting to transfer
+func OrphanedChannelPolicyFromEnv() OrphanedChannelPolicy {
+	if OrphanedChannelPolicy(os.Getenv("ORPHANED_CHANNEL_POLICY")) == OrphanedChannelsDelete {
+		return OrphanedChannelsDelete
+	}
+	return OrphanedChannelsTransfer
+}
+
 type UserService struct {
-	db *gorm.DB
+	db           *gorm.DB
+	logger       *slog.Logger
+	orphanPolicy OrphanedChannelPolicy
+	auditService *audit.AuditService
+	members      *channel.MemberCache
+}
+
+// NewUserService returns a service invalidating members when it removes
+// memberships, so it must be given the cache the channel services read
+func NewUserService(db *gorm.DB, members *channel.MemberCache) *UserService {
+	return &UserService{
+		db:           db,
+		members:      members,
+		logger:       logger.Default(),
+		orphanPolicy: OrphanedChannelPolicyFromEnv(),
+		auditService: audit.NewAuditService(db),
+	}
 }
 
-func NewUserService(db *gorm.DB) *UserService {
-	return &UserService{db: db}
+// SetLogger replaces the logger used to report non-fatal failures
+func (s *UserService) SetLogger(l *slog.Logger) {
+	s.logger = l
+}
+
+// SetOrphanedChannelPolicy overrides the orphaned channel policy read from the environment
+func (s *UserService) SetOrphanedChannelPolicy(policy OrphanedChannelPolicy) {
+	s.orphanPolicy = policy
 }
 
 type UpdateUserRequest struct {
@@ -23,6 +71,12 @@ type UpdateUserRequest struct {
 }
 
 func (s *UserService) UpdateUser(userID string, req UpdateUserRequest) (*chat.User, error) {
+	if req.Password != nil {
+		if err := utils.ValidatePasswordLength(*req.Password); err != nil {
+			return nil, err
+		}
+	}
+
 	var user chat.User
 	if err := s.db.First(&user, "id = ?", userID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -34,6 +88,10 @@ func (s *UserService) UpdateUser(userID string, req UpdateUserRequest) (*chat.Us
 	updates := make(map[string]interface{})
 
 	if req.Username != nil {
+		if chat.IsReservedUsername(*req.Username) {
+			return nil, errors.New("username is reserved")
+		}
+
 		// Check if username already exists
 		var existingUser chat.User
 		result := s.db.First(&existingUser, "username = ? AND id != ?", *req.Username, userID)
@@ -47,11 +105,11 @@ func (s *UserService) UpdateUser(userID string, req UpdateUserRequest) (*chat.Us
 	}
 
 	if req.Password != nil {
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(*req.Password), bcrypt.DefaultCost)
+		hashedPassword, err := utils.HashString(*req.Password)
 		if err != nil {
 			return nil, fmt.Errorf("failed to hash password: %w", err)
 		}
-		updates["password"] = string(hashedPassword)
+		updates["password"] = hashedPassword
 	}
 
 	if len(updates) == 0 {
@@ -79,41 +137,346 @@ func (s *UserService) DeleteUser(userID string) error {
 		return fmt.Errorf("failed to find user: %w", err)
 	}
 
-	// Soft delete the user (GORM will handle setting DeletedAt)
-	if err := s.db.Delete(&user).Error; err != nil {
-		return fmt.Errorf("failed to delete user: %w", err)
+	var deletedChannels []string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if deletedChannels, err = s.releaseOwnedChannels(tx, userID); err != nil {
+			return fmt.Errorf("failed to release owned channels: %w", err)
+		}
+
+		// Soft delete the user (GORM will handle setting DeletedAt)
+		if err := tx.Delete(&user).Error; err != nil {
+			return fmt.Errorf("failed to delete user: %w", err)
+		}
+
+		// Leave all channels so member counts and capacity checks stop including the account
+		if err := tx.Where("user_id = ?", userID).Delete(&chat.UserChannel{}).Error; err != nil {
+			return fmt.Errorf("failed to leave channels: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.members.InvalidateUser(userID)
+	for _, channelID := range deletedChannels {
+		s.members.InvalidateChannel(channelID)
 	}
 
 	// Clean up refresh tokens
 	if err := s.db.Where("user_id = ?", userID).Delete(&chat.RefreshToken{}).Error; err != nil {
 		// Log error but don't fail the operation
-		fmt.Printf("Warning: failed to clean up refresh tokens for user %s: %v\n", userID, err)
+		s.logger.Warn("failed to clean up refresh tokens", "user_id", userID, "error", err)
 	}
 
 	return nil
 }
 
-func (s *UserService) GetOwnedChannels(userID string) ([]chat.Channel, error) {
+// releaseOwnedChannels keeps the channels of a deleted account administrable by
+// transferring or deleting them according to the orphaned channel policy. It
+// returns the IDs of the channels it deleted.
+func (s *UserService) releaseOwnedChannels(tx *gorm.DB, userID string) ([]string, error) {
 	var channels []chat.Channel
-	err := s.db.Preload("Owner").Where("owner_id = ?", userID).Find(&channels).Error
+	if err := tx.Where("owner_id = ?", userID).Find(&channels).Error; err != nil {
+		return nil, err
+	}
+
+	var deleted []string
+
+	for _, channel := range channels {
+		if s.orphanPolicy == OrphanedChannelsTransfer {
+			successor, err := s.findSuccessor(tx, userID, channel.ID)
+			if err != nil {
+				return nil, err
+			}
+			if successor != nil {
+				if err := tx.Model(&chat.Channel{}).Where("id = ?", channel.ID).Update("owner_id", successor.UserID).Error; err != nil {
+					return nil, err
+				}
+				continue
+			}
+		}
+
+		if err := tx.Where("channel_id = ?", channel.ID).Delete(&chat.UserChannel{}).Error; err != nil {
+			return nil, err
+		}
+		if err := tx.Delete(&chat.Channel{}, "id = ?", channel.ID).Error; err != nil {
+			return nil, err
+		}
+		deleted = append(deleted, channel.ID)
+	}
+	return deleted, nil
+}
+
+// findSuccessor returns the remaining member of the channel with the highest
+// role, at least Administrator, preferring the longest-standing one. It returns
+// nil when there is none.
+func (s *UserService) findSuccessor(tx *gorm.DB, ownerID, channelID string) (*chat.UserChannel, error) {
+	var successor chat.UserChannel
+	err := tx.Joins("JOIN roles ON roles.id = user_channels.role_id").
+		Joins("JOIN users ON users.id = user_channels.user_id AND users.deleted_at IS NULL").
+		Where("user_channels.channel_id = ? AND user_channels.user_id != ?", channelID, ownerID).
+		Where("roles.priority >= ?", chat.RolePriorities["Administrator"]).
+		Order("roles.priority DESC, user_channels.created_at ASC, user_channels.id ASC").
+		First(&successor).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to get owned channels: %w", err)
+		return nil, err
 	}
-	return channels, nil
+	return &successor, nil
 }
 
-func (s *UserService) GetJoinedChannels(userID string) ([]chat.Channel, error) {
-	var channels []chat.Channel
-	
-	err := s.db.
-		Preload("Owner").
-		Joins("JOIN user_channels ON channels.id = user_channels.channel_id").
+func (s *UserService) IsAdmin(userID string) (bool, error) {
+	var user chat.User
+	if err := s.db.First(&user, "id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to find user: %w", err)
+	}
+	return user.IsAdmin, nil
+}
+
+// PublicProfile is what any authenticated user may see about another user
+type PublicProfile struct {
+	ID             string
+	Username       string
+	CreatedAt      time.Time
+	OwnedChannels  int64 // Channels that show their owner publicly
+	JoinedChannels int64
+}
+
+func (s *UserService) GetPublicProfile(userID string) (*PublicProfile, error) {
+	var user chat.User
+	if err := s.db.First(&user, "id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("user not found")
+		}
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	profile := PublicProfile{
+		ID:        user.ID,
+		Username:  user.Username,
+		CreatedAt: user.CreatedAt,
+	}
+
+	// Channels whose owner opted out of listings are not attributed to them here either
+	err := s.db.Model(&chat.Channel{}).Where("owner_id = ? AND hide_owner = ?", userID, false).Count(&profile.OwnedChannels).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count owned channels: %w", err)
+	}
+
+	err = s.db.Model(&chat.UserChannel{}).
+		Joins("JOIN channels ON channels.id = user_channels.channel_id AND channels.deleted_at IS NULL").
 		Where("user_channels.user_id = ?", userID).
-		Find(&channels).Error
-		
+		Count(&profile.JoinedChannels).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count joined channels: %w", err)
+	}
+
+	return &profile, nil
+}
+
+// UserSummary is what system administrators see of a user when listing users
+type UserSummary struct {
+	ID             string
+	Username       string
+	IsAdmin        bool
+	CreatedAt      time.Time
+	SuspendedAt    *time.Time
+	SuspendedUntil *time.Time
+	OwnedChannels  int64
+	JoinedChannels int64
+}
+
+// IsSuspendedAt reports whether the account is suspended at the given time
+func (u UserSummary) IsSuspendedAt(now time.Time) bool {
+	user := chat.User{SuspendedAt: u.SuspendedAt, SuspendedUntil: u.SuspendedUntil}
+	return user.IsSuspendedAt(now)
+}
+
+// ListUsers pages through all users by username, for system administrators. A
+// non-empty query keeps users whose username contains it, case-insensitively.
+func (s *UserService) ListUsers(query string, limit, offset int) ([]UserSummary, int64, error) {
+	users := s.db.Model(&chat.User{})
+	if query = strings.TrimSpace(query); query != "" {
+		users = users.Where("LOWER(username) LIKE ?", "%"+strings.ToLower(query)+"%")
+	}
+
+	var total int64
+	if err := users.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	owned := s.db.Model(&chat.Channel{}).Select("COUNT(*)").Where("channels.owner_id = users.id")
+	joined := s.db.Model(&chat.UserChannel{}).Select("COUNT(*)").
+		Joins("JOIN channels ON channels.id = user_channels.channel_id AND channels.deleted_at IS NULL").
+		Where("user_channels.user_id = users.id")
+
+	var summaries []UserSummary
+	err := users.Select("users.id, users.username, users.is_admin, users.created_at, users.suspended_at, users.suspended_until, (?) AS owned_channels, (?) AS joined_channels", owned, joined).
+		Order("users.username ASC, users.id ASC").
+		Limit(limit).
+		Offset(offset).
+		Scan(&summaries).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	return summaries, total, nil
+}
+
+// SuspendUser suspends an account server-wide. A zero duration suspends it
+// until lifted; otherwise the suspension expires on its own. System
+// administrators cannot be suspended.
+func (s *UserService) SuspendUser(adminID, userID, reason string, duration time.Duration) (*chat.User, error) {
+	if duration < 0 {
+		return nil, errors.New("invalid suspension duration")
+	}
+
+	var user chat.User
+	if err := s.db.First(&user, "id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("user not found")
+		}
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+	if user.IsAdmin {
+		return nil, errors.New("cannot suspend a system administrator")
+	}
+
+	now := time.Now()
+	var suspendedUntil *time.Time
+	if duration > 0 {
+		until := now.Add(duration)
+		suspendedUntil = &until
+	}
+
+	if err := s.db.Model(&chat.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+		"suspended_at":     now,
+		"suspended_until":  suspendedUntil,
+		"suspended_reason": reason,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("failed to suspend user: %w", err)
+	}
+	user.SuspendedAt = &now
+	user.SuspendedUntil = suspendedUntil
+	user.SuspendedReason = reason
+
+	if err := s.auditService.LogUserSuspension(adminID, user.ID, reason, suspendedUntil); err != nil {
+		s.logger.Warn("failed to write audit log", "action", audit.ActionSuspendUser, "user_id", user.ID, "error", err)
+	}
+
+	return &user, nil
+}
+
+// UnsuspendUser lifts an account's suspension before it expires
+func (s *UserService) UnsuspendUser(adminID, userID string) error {
+	var user chat.User
+	if err := s.db.First(&user, "id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("user not found")
+		}
+		return fmt.Errorf("failed to find user: %w", err)
+	}
+	if !user.IsSuspendedAt(time.Now()) {
+		return errors.New("user is not suspended")
+	}
+
+	if err := s.db.Model(&chat.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+		"suspended_at":     nil,
+		"suspended_until":  nil,
+		"suspended_reason": "",
+	}).Error; err != nil {
+		return fmt.Errorf("failed to unsuspend user: %w", err)
+	}
+
+	if err := s.auditService.LogUserUnsuspension(adminID, user.ID); err != nil {
+		s.logger.Warn("failed to write audit log", "action", audit.ActionUnsuspendUser, "user_id", user.ID, "error", err)
+	}
+
+	return nil
+}
+
+// channelListOrder keeps channel listings stable across pages
+const channelListOrder = "channels.name ASC, channels.id ASC"
+
+func (s *UserService) GetOwnedChannels(userID string, limit, offset int) ([]chat.Channel, int64, error) {
+	query := s.db.Model(&chat.Channel{}).Where("owner_id = ?", userID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count owned channels: %w", err)
+	}
+
+	var channels []chat.Channel
+	err := query.Preload("Owner").Order(channelListOrder).Limit(limit).Offset(offset).Find(&channels).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get owned channels: %w", err)
+	}
+	return channels, total, nil
+}
+
+func (s *UserService) GetJoinedChannels(userID string, limit, offset int) ([]chat.Channel, int64, error) {
+	query := s.db.Model(&chat.Channel{}).
+		Joins("JOIN user_channels ON channels.id = user_channels.channel_id AND user_channels.deleted_at IS NULL").
+		Where("user_channels.user_id = ?", userID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count joined channels: %w", err)
+	}
+
+	var channels []chat.Channel
+	err := query.Preload("Owner").Order(channelListOrder).Limit(limit).Offset(offset).Find(&channels).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get joined channels: %w", err)
+	}
+
+	return channels, total, nil
+}
+
+// GetActiveBans returns the user's bans that are still in force across all
+// channels, with the channel and the banner loaded, newest first
+func (s *UserService) GetActiveBans(userID string) ([]chat.UserBan, error) {
+	var bans []chat.UserBan
+	err := s.db.Joins("Channel").Preload("BannedByUser").
+		Where("user_bans.user_id = ? AND user_bans.is_active = ?", userID, true).
+		Where("user_bans.expires_at IS NULL OR user_bans.expires_at > ?", time.Now()).
+		Order("user_bans.created_at DESC").
+		Find(&bans).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active bans: %w", err)
+	}
+	return bans, nil
+}
+
+// moderatingRoles are the channel roles that grant moderation rights
+var moderatingRoles = []string{"Administrator", "Moderator"}
+
+// GetModeratedChannels returns the channels the user owns or holds a moderating role in
+func (s *UserService) GetModeratedChannels(userID string, limit, offset int) ([]chat.Channel, int64, error) {
+	moderated := s.db.Model(&chat.UserChannel{}).
+		Select("user_channels.channel_id").
+		Joins("JOIN roles ON roles.id = user_channels.role_id").
+		Where("user_channels.user_id = ? AND roles.name IN ?", userID, moderatingRoles)
+
+	query := s.db.Model(&chat.Channel{}).Where("owner_id = ? OR id IN (?)", userID, moderated)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count moderated channels: %w", err)
+	}
+
+	var channels []chat.Channel
+	err := query.Preload("Owner").Order(channelListOrder).Limit(limit).Offset(offset).Find(&channels).Error
 	if err != nil {
-		return nil, fmt.Errorf("failed to get joined channels: %w", err)
+		return nil, 0, fmt.Errorf("failed to get moderated channels: %w", err)
 	}
-	
-	return channels, nil
-}
\ No newline at end of file
+
+	return channels, total, nil
+}
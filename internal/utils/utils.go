@@ -1,11 +1,43 @@
 package utils
 
 import (
+	"errors"
+	"os"
+	"strconv"
+
 	"golang.org/x/crypto/bcrypt"
 )
 
+// HashCost is the bcrypt cost used for new hashes
+var HashCost = HashCostFromEnv()
+
+// HashCostFromEnv reads BCRYPT_COST, falling back to bcrypt.DefaultCost for
+// unset or out-of-range values
+func HashCostFromEnv() int {
+	cost, err := strconv.Atoi(os.Getenv("BCRYPT_COST"))
+	if err != nil || cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return bcrypt.DefaultCost
+	}
+	return cost
+}
+
+// MaxPasswordBytes is the longest input bcrypt hashes in full
+const MaxPasswordBytes = 72
+
+// ValidatePasswordLength rejects passwords longer than bcrypt can hash
+func ValidatePasswordLength(password string) error {
+	if len(password) > MaxPasswordBytes {
+		return errors.New("password too long")
+	}
+	return nil
+}
+
 func HashString(originalString string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(originalString), bcrypt.DefaultCost)
+	if err := ValidatePasswordLength(originalString); err != nil {
+		return "", err
+	}
+
+	bytes, err := bcrypt.GenerateFromPassword([]byte(originalString), HashCost)
 	if err != nil {
 		return "", err
 	}
@@ -18,3 +50,9 @@ func VerifyHashedString(originalString, hashedString string) bool {
 
 	return err == nil
 }
+
+// NeedsRehash reports whether the hash was made with a lower cost than HashCost
+func NeedsRehash(hashedString string) bool {
+	cost, err := bcrypt.Cost([]byte(hashedString))
+	return err == nil && cost < HashCost
+}
@@ -3,6 +3,8 @@ package utils
 import (
 	"strings"
 	"testing"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestHashString(t *testing.T) {
@@ -200,4 +202,58 @@ func TestMultipleHashesAreDifferent(t *testing.T) {
 	if !VerifyHashedString(password, hash2) {
 		t.Errorf("Second hash should verify correctly")
 	}
-}
\ No newline at end of file
+}
+func TestHashCostFromEnv(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected int
+	}{
+		{"", bcrypt.DefaultCost},
+		{"12", 12},
+		{"3", bcrypt.DefaultCost},
+		{"32", bcrypt.DefaultCost},
+		{"high", bcrypt.DefaultCost},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("BCRYPT_COST", tt.value)
+		if cost := HashCostFromEnv(); cost != tt.expected {
+			t.Errorf("BCRYPT_COST=%q: expected cost %d, got %d", tt.value, tt.expected, cost)
+		}
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	originalCost := HashCost
+	t.Cleanup(func() { HashCost = originalCost })
+
+	HashCost = bcrypt.MinCost
+	hash, err := HashString("password")
+	if err != nil {
+		t.Fatalf("HashString() error = %v", err)
+	}
+	if NeedsRehash(hash) {
+		t.Error("Hash at the current cost should not need a rehash")
+	}
+
+	HashCost = bcrypt.MinCost + 1
+	if !NeedsRehash(hash) {
+		t.Error("Hash below the current cost should need a rehash")
+	}
+	if NeedsRehash("not a bcrypt hash") {
+		t.Error("Invalid hash should not be reported as needing a rehash")
+	}
+}
+
+func TestValidatePasswordLength(t *testing.T) {
+	if err := ValidatePasswordLength(strings.Repeat("a", MaxPasswordBytes)); err != nil {
+		t.Errorf("Expected %d bytes to be accepted, got %v", MaxPasswordBytes, err)
+	}
+	if err := ValidatePasswordLength(strings.Repeat("a", MaxPasswordBytes+1)); err == nil || err.Error() != "password too long" {
+		t.Errorf("Expected 'password too long', got %v", err)
+	}
+	// The limit is in bytes, not characters
+	if err := ValidatePasswordLength(strings.Repeat("é", 37)); err == nil {
+		t.Error("Expected 74 bytes of multi-byte characters to be rejected")
+	}
+}
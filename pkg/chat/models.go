@@ -6,6 +6,19 @@ import (
 	"time"
 )
 
+// ScrubbedUsername is the reserved username of the placeholder account that
+// scrubbed messages are re-attributed to
+const ScrubbedUsername = "[scrubbed]"
+
+// DeletedUsername is shown in place of the author of messages whose account was deleted
+const DeletedUsername = "[deleted]"
+
+// IsReservedUsername reports whether a username is kept for placeholders and
+// may not be taken by a real account
+func IsReservedUsername(username string) bool {
+	return username == ScrubbedUsername || username == DeletedUsername
+}
+
 type User struct {
 	ID        string `gorm:"primarykey"`
 	CreatedAt time.Time
@@ -14,16 +27,43 @@ type User struct {
 
 	Username string `gorm:"uniqueIndex;not null"`
 	Password string
+	IsAdmin  bool `gorm:"default:false"` // System-wide administrator
+
+	// Placeholder accounts, such as the scrubbed author, have no password and
+	// are found by this flag rather than by their username
+	IsPlaceholder bool `gorm:"default:false"`
+
+	// Server-wide suspension set by a system administrator. A nil
+	// SuspendedUntil on a suspended account means it is permanent.
+	SuspendedAt     *time.Time
+	SuspendedUntil  *time.Time
+	SuspendedReason string
 
 	IPs          []UserIP `gorm:"constraint:OnDelete:SET NULL"`
 	UserChannels []UserChannel
 }
 
+// RefreshToken is one signed-in session of a user, listed by device
 type RefreshToken struct {
 	gorm.Model
-	UserID    string   `gorm:"index;constraint:OnDelete:CASCADE"`
-	TokenHash string `gorm:"unique"`
-	ExpiresAt int64
+	UserID      string   `gorm:"index;constraint:OnDelete:CASCADE"`
+	TokenHash   string `gorm:"unique"` // SHA-256 digest of the token
+	ExpiresAt   int64
+	DeviceLabel string // User-Agent of the client that signed in
+	LastUsedAt  *time.Time
+}
+
+// ApiToken is a long-lived bearer token for programmatic access. Only the
+// SHA-256 hash of the token is stored; the plaintext is shown once on creation.
+type ApiToken struct {
+	gorm.Model
+	UserID     string `gorm:"not null;index"`
+	Label      string `gorm:"not null"`
+	TokenHash  string `gorm:"uniqueIndex;not null"`
+	LastUsedAt *time.Time
+	Revoked    bool `gorm:"default:false"`
+
+	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
 }
 
 type Channel struct {
@@ -32,16 +72,40 @@ type Channel struct {
 	UpdatedAt time.Time
 	DeletedAt gorm.DeletedAt `gorm:"index"`
 
-	Name        string `gorm:"uniqueIndex;not null"`
-	IsVisible   bool
-	Password    *string
-	LoggingDays uint
+	Name          string `gorm:"not null;index:idx_channels_owner_name,priority:2"` // Unique per owner, case-insensitively
+	IsVisible     bool
+	HideOwner     bool `gorm:"default:false"` // Hide the owner in public listings
+	Password      *string
+	LoggingDays   uint
+	MaxMembers    int        `gorm:"default:0"`     // Member cap including the owner; 0 means unlimited
+	AllowPreview  bool       `gorm:"default:false"` // Let non-members read recent history of a public channel
+	LockedAt      *time.Time // Set while the channel is locked to moderators only
+	LockedUntil   *time.Time // nil for a lock that lasts until explicitly lifted
+	LastMessageAt *time.Time `gorm:"index"` // Creation time of the newest message, stored or not; nil until the first
+	LastSeq       int64      `gorm:"not null;default:0"` // Seq of the newest message, stored or not
+	CategoryID    *uint      `gorm:"index"` // Category the channel is listed under; nil when uncategorized
+
+	AnnounceMembership bool `gorm:"default:false"` // Post a system message when members join, leave or are banned
+
+	Description string // What the channel is about; set by the owner
+	Topic       string // Current subject of discussion; set by the owner and moderators
 
-	OwnerID      string
-	Owner        User `gorm:"foreignKey:OwnerID;constraint:OnDelete:CASCADE"`
+	OwnerID      string           `gorm:"index:idx_channels_owner_name,priority:1"`
+	Owner        User             `gorm:"foreignKey:OwnerID;constraint:OnDelete:CASCADE"`
+	Category     *ChannelCategory `gorm:"foreignKey:CategoryID;constraint:OnDelete:SET NULL"`
 	UserChannels []UserChannel
 }
 
+// ChannelCategory groups channels in listings. Global categories have no owner and
+// are created by system administrators; any user can create categories of their own.
+type ChannelCategory struct {
+	gorm.Model
+	Name    string  `gorm:"not null"`
+	OwnerID *string `gorm:"index"` // nil for a global category
+
+	Owner *User `gorm:"foreignKey:OwnerID;constraint:OnDelete:CASCADE"`
+}
+
 type UserIP struct {
 	gorm.Model
 	UserID string `gorm:"not null"`
@@ -52,8 +116,8 @@ type UserIP struct {
 type UserChannel struct {
 	gorm.Model
 
-	UserID    string `gorm:"not null"`
-	ChannelID string `gorm:"not null"`
+	UserID    string `gorm:"not null;index"`
+	ChannelID string `gorm:"not null;index"`
 	RoleID    *uint
 
 	User    User    `gorm:"foreignKey:UserID"`
@@ -63,43 +127,137 @@ type UserChannel struct {
 
 type Role struct {
 	gorm.Model
-	Name string `gorm:"uniqueIndex;not null"`
+	Name     string `gorm:"uniqueIndex;not null"`
+	Priority int    `gorm:"not null;default:0"` // Rank in the role hierarchy; higher outranks lower
+}
+
+// DefaultRoles are the channel roles seeded at startup. Members can only hold these roles.
+var DefaultRoles = []string{"Administrator", "Moderator", "Member", "Guest"}
+
+// RolePriorities ranks the DefaultRoles, highest first
+var RolePriorities = map[string]int{
+	"Administrator": 100,
+	"Moderator":     50,
+	"Member":        10,
+	"Guest":         1,
 }
 
 type UserBan struct {
 	gorm.Model
-	UserID    string `gorm:"not null;index"`
-	ChannelID string `gorm:"not null;index"`
+	// A user holds at most one active ban per channel
+	UserID    string `gorm:"not null;index;uniqueIndex:idx_user_bans_active_user,priority:1,where:is_active = true AND deleted_at IS NULL"`
+	ChannelID string `gorm:"not null;index:idx_user_bans_channel_active,priority:1;uniqueIndex:idx_user_bans_active_user,priority:2"`
 	BannedBy  string `gorm:"not null"` // UserID of the admin who banned
 	Reason    string
 	ExpiresAt *time.Time // nil for permanent bans
-	IsActive  bool       `gorm:"default:true"`
+	IsActive  bool       `gorm:"default:true;index:idx_user_bans_channel_active,priority:2"`
 
 	User      User    `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
 	Channel   Channel `gorm:"foreignKey:ChannelID;constraint:OnDelete:CASCADE"`
 	BannedByUser User `gorm:"foreignKey:BannedBy;constraint:OnDelete:SET NULL"`
 }
 
+// Notification modes for a user's channel preference
+const (
+	NotificationModeAll      = "all"      // deliver every message
+	NotificationModeMentions = "mentions" // deliver mentions only
+	NotificationModeNone     = "none"     // deliver nothing
+)
+
+type ChannelNotificationPref struct {
+	gorm.Model
+	UserID    string `gorm:"not null;uniqueIndex:idx_notification_pref_user_channel"`
+	ChannelID string `gorm:"not null;uniqueIndex:idx_notification_pref_user_channel"`
+	Mode      string `gorm:"not null;default:all"`
+
+	User    User    `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Channel Channel `gorm:"foreignKey:ChannelID;constraint:OnDelete:CASCADE"`
+}
+
+// ChannelReadState records how far a user has read in a channel. Messages
+// created after LastReadAt are unread.
+type ChannelReadState struct {
+	gorm.Model
+	UserID     string    `gorm:"not null;uniqueIndex:idx_read_state_user_channel"`
+	ChannelID  string    `gorm:"not null;uniqueIndex:idx_read_state_user_channel"`
+	LastReadAt time.Time `gorm:"not null"`
+
+	User    User    `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Channel Channel `gorm:"foreignKey:ChannelID;constraint:OnDelete:CASCADE"`
+}
+
+// Message formats tell clients how to render the content; the server never renders it
+const (
+	MessageFormatPlain    = "plain"
+	MessageFormatMarkdown = "markdown"
+)
+
 type Message struct {
-	ID        string `gorm:"primarykey"`
-	CreatedAt time.Time
+	ID        string    `gorm:"primarykey"`
+	CreatedAt time.Time `gorm:"index:idx_messages_channel_created,priority:2"`
 	UpdatedAt time.Time
 	DeletedAt gorm.DeletedAt `gorm:"index"`
 
 	Content   string `gorm:"type:text;not null"`
 	UserID    string `gorm:"not null;index"`
-	ChannelID string `gorm:"not null;index"`
+	ChannelID string `gorm:"not null;index:idx_messages_channel_created,priority:1"` // Serves history queries ordered by CreatedAt
+	IsSystem  bool   `gorm:"default:false"` // Generated by the server rather than typed by UserID
+	Format    string `gorm:"not null;default:plain"` // MessageFormatPlain or MessageFormatMarkdown
+	Seq       int64  `gorm:"not null;default:0"` // Increases with every message of the channel, so clients can restore order
+	EditedAt  *time.Time // nil until the content is edited
 
-	User    User    `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
-	Channel Channel `gorm:"foreignKey:ChannelID;constraint:OnDelete:CASCADE"`
+	User        User         `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Channel     Channel      `gorm:"foreignKey:ChannelID;constraint:OnDelete:CASCADE"`
+	Attachments []Attachment `gorm:"constraint:OnDelete:CASCADE"`
 }
 
-type AuditLog struct {
+// Attachment references a file shared in a message. Files are hosted
+// elsewhere; only their URL and the metadata given by the client are stored.
+type Attachment struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+
+	MessageID string `gorm:"not null;index"`
+	URL       string `gorm:"not null"`
+	MimeType  string
+	Size      int64 // In bytes, as reported by the client
+	Filename  string
+}
+
+// Message report statuses
+const (
+	ReportStatusOpen      = "open"      // awaiting a moderator
+	ReportStatusResolved  = "resolved"  // acted upon
+	ReportStatusDismissed = "dismissed" // no action needed
+)
+
+// MessageReport flags a message for the channel's moderators. Each user holds
+// at most one report per message; reporting it again updates that report.
+type MessageReport struct {
 	gorm.Model
+	MessageID  string `gorm:"not null;uniqueIndex:idx_message_reports_message_reporter,priority:1"`
+	ReporterID string `gorm:"not null;uniqueIndex:idx_message_reports_message_reporter,priority:2"`
+	ChannelID  string `gorm:"not null;index:idx_message_reports_channel_status,priority:1"` // Channel of the message, for moderator listings
+	Reason     string
+	Status     string     `gorm:"not null;default:open;index:idx_message_reports_channel_status,priority:2"`
+	ResolvedBy *string    // Moderator who last set a closed status
+	ResolvedAt *time.Time // nil while open
+
+	Message  Message `gorm:"foreignKey:MessageID;constraint:OnDelete:CASCADE"`
+	Reporter User    `gorm:"foreignKey:ReporterID;constraint:OnDelete:CASCADE"`
+	Channel  Channel `gorm:"foreignKey:ChannelID;constraint:OnDelete:CASCADE"`
+}
+
+type AuditLog struct {
+	ID        uint      `gorm:"primarykey"`
+	CreatedAt time.Time `gorm:"index:idx_audit_logs_channel_created,priority:2"`
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+
 	Action      string `gorm:"not null;index"` // CREATE_CHANNEL, BAN_USER, PROMOTE_USER, etc.
 	ActorID     string `gorm:"not null"`       // Who performed the action
 	TargetID    *string                       // Who was affected (optional)
-	ChannelID   *string                       // Which channel (optional)
+	ChannelID   *string `gorm:"index:idx_audit_logs_channel_created,priority:1"` // Which channel (optional)
 	Description string                        // Human-readable description
 	Metadata    string `gorm:"type:json"`     // Additional data as JSON
 
@@ -108,6 +266,48 @@ type AuditLog struct {
 	Channel *Channel `gorm:"foreignKey:ChannelID;constraint:OnDelete:SET NULL"`
 }
 
+// IsLockedAt reports whether the channel is locked at the given time
+func (c *Channel) IsLockedAt(now time.Time) bool {
+	if c.LockedAt == nil {
+		return false
+	}
+	return c.LockedUntil == nil || now.Before(*c.LockedUntil)
+}
+
+// IsSuspendedAt reports whether the account is suspended at the given time
+func (u *User) IsSuspendedAt(now time.Time) bool {
+	if u.SuspendedAt == nil {
+		return false
+	}
+	return u.SuspendedUntil == nil || now.Before(*u.SuspendedUntil)
+}
+
+// IsModerator reports whether the membership carries a moderating role.
+// The Role association must be loaded.
+func (uc *UserChannel) IsModerator() bool {
+	return uc.Role.Name == "Administrator" || uc.Role.Name == "Moderator"
+}
+
+// IsGuest reports whether the member has read-only access to the channel
+func (uc *UserChannel) IsGuest() bool {
+	return uc.Role.Name == "Guest"
+}
+
+// AfterCreate records the message as the channel's latest activity
+func (m *Message) AfterCreate(tx *gorm.DB) error {
+	return RecordChannelActivity(tx, m.ChannelID, m.CreatedAt)
+}
+
+// RecordChannelActivity moves the channel's last_message_at to at. Messages
+// created out of order, such as imports, never move it backwards. Messages of
+// channels that keep no history are never stored, so their senders call it
+// directly.
+func RecordChannelActivity(tx *gorm.DB, channelID string, at time.Time) error {
+	return tx.Model(&Channel{}).
+		Where("id = ? AND (last_message_at IS NULL OR last_message_at < ?)", channelID, at).
+		UpdateColumn("last_message_at", at).Error
+}
+
 func (u *User) BeforeCreate(tx *gorm.DB) (err error) {
 	u.ID, err = nanoid.New(8)
 	return err
@@ -120,5 +320,28 @@ func (c *Channel) BeforeCreate(tx *gorm.DB) (err error) {
 
 func (m *Message) BeforeCreate(tx *gorm.DB) (err error) {
 	m.ID, err = nanoid.New(10)
+	if err != nil || m.Seq != 0 {
+		return err
+	}
+	m.Seq, err = NextMessageSeq(tx, m.ChannelID)
 	return err
 }
+
+// NextMessageSeq allocates the next sequence number of the channel's messages.
+// The counter lives on the channel row, whose lock is held until the transaction
+// ends, so concurrent writers never share a number. Messages of channels that
+// keep no history draw from the same counter.
+func NextMessageSeq(tx *gorm.DB, channelID string) (int64, error) {
+	var channel Channel
+	err := tx.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&Channel{}).Where("id = ?", channelID).UpdateColumn("last_seq", gorm.Expr("last_seq + 1"))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Select("last_seq").Where("id = ?", channelID).Take(&channel).Error
+	})
+	return channel.LastSeq, err
+}
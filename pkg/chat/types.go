@@ -1,6 +1,9 @@
 package chat
 
 import (
+	"encoding/json"
+	"errors"
+	"strings"
 	"sync"
 
 	"github.com/gorilla/websocket"
@@ -13,6 +16,104 @@ type WSMessage struct {
 	Timestamp int64  `json:"timestamp"`
 }
 
+// WebSocket message types
+const (
+	WSTypeMessage = "message" // MessagePayload: post a message to a channel
+	WSTypeTyping  = "typing"  // TypingPayload: typing indicator in a channel
+	WSTypeError   = "error"   // ErrorPayload: error reply from the server
+)
+
+// ErrUnsupportedMessageType is returned for WebSocket messages with an unknown Type
+var ErrUnsupportedMessageType = errors.New("unsupported message type")
+
+// WebSocketMessage is the envelope of every WebSocket frame. Data holds the
+// payload matching Type.
+type WebSocketMessage struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// Payload is implemented by every typed WebSocket payload
+type Payload interface {
+	Validate() error
+}
+
+type MessagePayload struct {
+	ChannelID string `json:"channel_id"`
+	Content   string `json:"content"`
+}
+
+func (p *MessagePayload) Validate() error {
+	if p.ChannelID == "" {
+		return errors.New("channel_id is required")
+	}
+	if strings.TrimSpace(p.Content) == "" {
+		return errors.New("content is required")
+	}
+	return nil
+}
+
+type TypingPayload struct {
+	ChannelID string `json:"channel_id"`
+}
+
+func (p *TypingPayload) Validate() error {
+	if p.ChannelID == "" {
+		return errors.New("channel_id is required")
+	}
+	return nil
+}
+
+type ErrorPayload struct {
+	Error string `json:"error"`
+}
+
+func (p *ErrorPayload) Validate() error {
+	if p.Error == "" {
+		return errors.New("error is required")
+	}
+	return nil
+}
+
+// DecodeWebSocketMessage decodes a raw frame into the payload type selected by
+// its Type and validates it. Callers type-switch on the returned payload.
+func DecodeWebSocketMessage(raw []byte) (Payload, error) {
+	var envelope WebSocketMessage
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		return nil, errors.New("invalid message format")
+	}
+
+	var payload Payload
+	switch envelope.Type {
+	case WSTypeMessage:
+		payload = &MessagePayload{}
+	case WSTypeTyping:
+		payload = &TypingPayload{}
+	case WSTypeError:
+		payload = &ErrorPayload{}
+	default:
+		return nil, ErrUnsupportedMessageType
+	}
+
+	if len(envelope.Data) == 0 {
+		return nil, errors.New("data is required")
+	}
+	if err := json.Unmarshal(envelope.Data, payload); err != nil {
+		return nil, errors.New("invalid " + envelope.Type + " payload")
+	}
+	if err := payload.Validate(); err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+// NewErrorMessage builds the error reply sent back for a rejected frame
+func NewErrorMessage(err error) WebSocketMessage {
+	data, _ := json.Marshal(ErrorPayload{Error: err.Error()})
+	return WebSocketMessage{Type: WSTypeError, Data: data}
+}
+
 type Client struct {
 	Conn *websocket.Conn
 	User *User
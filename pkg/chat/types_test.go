@@ -0,0 +1,109 @@
+package chat
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeWebSocketMessage(t *testing.T) {
+	tests := []struct {
+		name          string
+		raw           string
+		expected      Payload
+		expectedError string
+	}{
+		{
+			name:     "message",
+			raw:      `{"type":"message","data":{"channel_id":"abc123","content":"hello"}}`,
+			expected: &MessagePayload{ChannelID: "abc123", Content: "hello"},
+		},
+		{
+			name:     "typing",
+			raw:      `{"type":"typing","data":{"channel_id":"abc123"}}`,
+			expected: &TypingPayload{ChannelID: "abc123"},
+		},
+		{
+			name:     "error",
+			raw:      `{"type":"error","data":{"error":"slow down"}}`,
+			expected: &ErrorPayload{Error: "slow down"},
+		},
+		{
+			name:          "message without channel",
+			raw:           `{"type":"message","data":{"content":"hello"}}`,
+			expectedError: "channel_id is required",
+		},
+		{
+			name:          "message with blank content",
+			raw:           `{"type":"message","data":{"channel_id":"abc123","content":"  "}}`,
+			expectedError: "content is required",
+		},
+		{
+			name:          "typing without channel",
+			raw:           `{"type":"typing","data":{}}`,
+			expectedError: "channel_id is required",
+		},
+		{
+			name:          "missing data",
+			raw:           `{"type":"message"}`,
+			expectedError: "data is required",
+		},
+		{
+			name:          "data of the wrong shape",
+			raw:           `{"type":"message","data":"hello"}`,
+			expectedError: "invalid message payload",
+		},
+		{
+			name:          "unknown type",
+			raw:           `{"type":"shout","data":{"content":"hello"}}`,
+			expectedError: "unsupported message type",
+		},
+		{
+			name:          "missing type",
+			raw:           `{"data":{"channel_id":"abc123","content":"hello"}}`,
+			expectedError: "unsupported message type",
+		},
+		{
+			name:          "malformed json",
+			raw:           `{"type":`,
+			expectedError: "invalid message format",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, err := DecodeWebSocketMessage([]byte(tt.raw))
+
+			if tt.expectedError != "" {
+				if err == nil || err.Error() != tt.expectedError {
+					t.Fatalf("Expected error %q, got %v", tt.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(payload, tt.expected) {
+				t.Errorf("Expected %#v, got %#v", tt.expected, payload)
+			}
+		})
+	}
+}
+
+func TestNewErrorMessage(t *testing.T) {
+	raw, err := json.Marshal(NewErrorMessage(ErrUnsupportedMessageType))
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	if string(raw) != `{"type":"error","data":{"error":"unsupported message type"}}` {
+		t.Errorf("Unexpected error reply: %s", raw)
+	}
+
+	payload, err := DecodeWebSocketMessage(raw)
+	if err != nil {
+		t.Fatalf("Error reply should decode: %v", err)
+	}
+	if payload.(*ErrorPayload).Error != "unsupported message type" {
+		t.Errorf("Unexpected payload: %#v", payload)
+	}
+}